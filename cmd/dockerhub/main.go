@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
@@ -8,7 +9,7 @@ import (
 
 func main() {
 
-	results := dockerhub.Search("cimg/")
+	results := dockerhub.Search(context.Background(), "cimg/")
 	if !results.HasNext() {
 		fmt.Println("No images found")
 		panic("No images found")
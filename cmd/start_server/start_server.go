@@ -17,6 +17,8 @@ func main() {
 	schemaRef := flag.String("schema", "", "Location of the schema (optional, uses built-in schema if not provided)")
 	versionRef := flag.Bool("version", false, "display version")
 	stdioRef := flag.Bool("stdio", false, "Use stdio instead of socket to communicate")
+	logFileRef := flag.String("log-file", "", "Write the logs, including the timings of the requests, to this file instead of stderr")
+	clearCacheRef := flag.Bool("clear-cache", false, "Clear the data fetched from CircleCI and Docker Hub kept from previous sessions")
 	flag.Parse()
 
 	// Parameter: version
@@ -26,6 +28,19 @@ func main() {
 		return
 	}
 
+	// Parameter: log-file
+	if *logFileRef != "" {
+		if err := utils.SetLogFile(*logFileRef); err != nil {
+			fmt.Printf("Error while opening the log file \"%s\"\n", *logFileRef)
+			panic(err)
+		}
+	}
+
+	// Parameter: clear-cache
+	if *clearCacheRef {
+		utils.NewDiskCache(utils.GetDiskCacheFSPath()).Invalidate()
+	}
+
 	// Parameter: schema
 	// If no schema is provided via flag or env, the embedded schema will be used.
 	schema := *schemaRef
@@ -71,3 +71,14 @@ type RemoteOrbInfo struct {
 	LatestMinorVersion string
 	LatestPatchVersion string
 }
+
+// The `display` map of an orb, shown on its page of the orb registry
+type OrbDisplay struct {
+	Range protocol.Range
+
+	HomeUrl      string
+	HomeUrlRange protocol.Range
+
+	SourceUrl      string
+	SourceUrlRange protocol.Range
+}
@@ -144,6 +144,7 @@ func (step RestoreCache) GetName() string {
 type StoreArtifacts struct {
 	protocol.Range
 	Path        string
+	PathRange   protocol.Range
 	Destination string
 }
 
@@ -157,7 +158,8 @@ func (step StoreArtifacts) GetName() string {
 
 type StoreTestResults struct {
 	protocol.Range
-	Path string
+	Path      string
+	PathRange protocol.Range
 }
 
 func (step StoreTestResults) GetRange() protocol.Range {
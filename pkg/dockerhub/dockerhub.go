@@ -1,17 +1,27 @@
 package dockerhub
 
-import "net/url"
+import (
+	"context"
+	"net/url"
+)
 
 type DockerHubAPI interface {
 	DoesImageExist(namespace, image string) bool
 	GetImageTags(namespace, image string) ([]string, error)
 	ImageHasTag(namespace, image, tag string) bool
+	GetTagDetails(namespace, image, tag string) (*TagDetails, error)
 }
 
 type dockerHubAPI struct {
 	baseURL url.URL
+	ctx     context.Context
 }
 
 func NewAPI() DockerHubAPI {
-	return &dockerHubAPI{baseURL: baseURL}
+	return NewAPIWithContext(context.Background())
+}
+
+// NewAPIWithContext returns an API whose requests are aborted when ctx is done
+func NewAPIWithContext(ctx context.Context) DockerHubAPI {
+	return &dockerHubAPI{baseURL: baseURL, ctx: ctx}
 }
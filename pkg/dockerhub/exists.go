@@ -23,14 +23,14 @@ func (me *dockerHubAPI) DoesImageExist(namespace, image string) bool {
 		fmt.Sprintf("namespaces/%s/repositories/%s", namespace, image),
 	)
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(me.ctx, "GET", url.String(), nil)
 	req.Header.Set("User-Agent", utils.UserAgent)
 
 	if err != nil {
 		return false
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := utils.HTTPClient().Do(req)
 	if err != nil {
 		return false
 	}
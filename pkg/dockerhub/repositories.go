@@ -1,6 +1,7 @@
 package dockerhub
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -59,15 +60,16 @@ var namespaceRegex = regexp.MustCompile(`^([a-z0-9\-_]+)\/`)
 var tagNameRegex = regexp.MustCompile(`(?:[a-z0-9\-_]+\/)?(?:[a-z0-9\-_]+):(.*)`)
 var imageNameRegex = regexp.MustCompile(`^([a-z0-9\-_]+\/([a-z0-9\-_]+)|[a-z0-9\-_]+).*$`)
 
-func (h *HubNamespace) createSearchCursor(search string) DockerResultsCursor {
+func (h *HubNamespace) createSearchCursor(ctx context.Context, search string) DockerResultsCursor {
 	return &SearchCursor{
+		ctx:   ctx,
 		hub:   h,
 		index: -1,
 		query: search,
 	}
 }
 
-func (h *HubNamespace) loadNext() ([]Repository, error) {
+func (h *HubNamespace) loadNext(ctx context.Context) ([]Repository, error) {
 	hubResponse := HubResponse{}
 	queryURL := h.nextURL
 
@@ -79,14 +81,14 @@ func (h *HubNamespace) loadNext() ([]Repository, error) {
 		return nil, fmt.Errorf("No more to load")
 	}
 
-	req, err := http.NewRequest("GET", queryURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to load next")
 	}
 
 	req.Header.Set("User-Agent", utils.UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := utils.HTTPClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to load next")
 	}
@@ -1,6 +1,9 @@
 package dockerhub
 
+import "context"
+
 type SearchCursor struct {
+	ctx   context.Context
 	hub   *HubNamespace
 	index int
 	query string
@@ -18,7 +21,9 @@ var hubNamespaces = map[string]*HubNamespace{
 	},
 }
 
-func Search(query string) DockerResultsCursor {
+// Search returns the repositories matching the query, the pages of results
+// are fetched with ctx
+func Search(ctx context.Context, query string) DockerResultsCursor {
 	namespace := getQueryNamespace(query)
 	imageName := getQueryImageName(query)
 
@@ -30,7 +35,7 @@ func Search(query string) DockerResultsCursor {
 
 	ns := hubNamespaces[namespace]
 
-	return ns.createSearchCursor(imageName)
+	return ns.createSearchCursor(ctx, imageName)
 }
 
 // --
@@ -47,7 +52,9 @@ func (s *SearchCursor) HasNext() bool {
 	}
 
 	for s.hub.nextURL != "" || !s.hub.hasLoaded {
-		s.hub.loadNext()
+		if _, err := s.hub.loadNext(s.ctx); err != nil {
+			break
+		}
 		searchItems := s.hub.allRepositories[start:]
 
 		_, index = findFirstMatch(
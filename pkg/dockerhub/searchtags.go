@@ -1,6 +1,9 @@
 package dockerhub
 
+import "context"
+
 type TagsSearchCursor struct {
+	ctx          context.Context
 	query        string
 	index        int
 	results      []RepoTag
@@ -13,14 +16,17 @@ type TagsResultsCursor interface {
 	Prev() *RepoTag
 }
 
-func SearchTags(namespace, repo string, query string) (TagsResultsCursor, error) {
-	results, err := fetchTags(namespace, repo, query)
+// SearchTags returns the tags of the repository matching the query, the pages
+// of results are fetched with ctx
+func SearchTags(ctx context.Context, namespace, repo string, query string) (TagsResultsCursor, error) {
+	results, err := fetchTags(ctx, namespace, repo, query)
 
 	if err != nil {
 		return nil, err
 	}
 
 	return &TagsSearchCursor{
+		ctx:          ctx,
 		query:        query,
 		index:        0,
 		results:      results.Results,
@@ -34,7 +40,7 @@ func SearchTags(namespace, repo string, query string) (TagsResultsCursor, error)
 
 func (t *TagsSearchCursor) HasNext() bool {
 	if t.index >= len(t.results)-1 && t.lastResponse.Next != "" {
-		nextPage, err := t.lastResponse.loadNext()
+		nextPage, err := t.lastResponse.loadNext(t.ctx)
 
 		if err != nil {
 			return false
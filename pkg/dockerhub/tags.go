@@ -1,6 +1,7 @@
 package dockerhub
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -19,15 +20,33 @@ type RepoTag struct {
 	Name      string `json:"name"`
 }
 
-func (t *TagResponse) loadNext() (TagResponse, error) {
+// TagDetails are the metadata of a tag, as given when fetching it alone
+type TagDetails struct {
+	Name          string     `json:"name"`
+	Digest        string     `json:"digest"`
+	TagLastPushed string     `json:"tag_last_pushed"`
+	FullSize      int64      `json:"full_size"`
+	Images        []TagImage `json:"images"`
+}
+
+// TagImage is the image of a tag for one platform
+type TagImage struct {
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+	OS           string `json:"os"`
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+}
+
+func (t *TagResponse) loadNext(ctx context.Context) (TagResponse, error) {
 	if t.Next == "" {
 		return TagResponse{}, fmt.Errorf("Failed to fetch more tags: nothing to fetch")
 	}
 
-	return fetchTagsByURL(t.Next)
+	return fetchTagsByURL(ctx, t.Next)
 }
 
-func fetchTags(namespace, repo, name string) (TagResponse, error) {
+func fetchTags(ctx context.Context, namespace, repo, name string) (TagResponse, error) {
 	url := baseURL.JoinPath(
 		fmt.Sprintf("/namespaces/%s/repositories/%s/tags", namespace, repo),
 	)
@@ -43,19 +62,19 @@ func fetchTags(namespace, repo, name string) (TagResponse, error) {
 
 	queryURL := url.String()
 
-	return fetchTagsByURL(queryURL)
+	return fetchTagsByURL(ctx, queryURL)
 }
 
-func fetchTagsByURL(queryURL string) (TagResponse, error) {
+func fetchTagsByURL(ctx context.Context, queryURL string) (TagResponse, error) {
 	tagResponse := TagResponse{}
-	req, err := http.NewRequest("GET", queryURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
 	if err != nil {
 		return tagResponse, fmt.Errorf("Failed to load next")
 	}
 
 	req.Header.Set("User-Agent", utils.UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := utils.HTTPClient().Do(req)
 	if err != nil {
 		return tagResponse, fmt.Errorf("Failed to load next")
 	}
@@ -78,14 +97,14 @@ func (me *dockerHubAPI) GetImageTags(namespace, image string) ([]string, error)
 		fmt.Sprintf("namespaces/%s/repositories/%s/tags", namespace, image),
 	)
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(me.ctx, "GET", url.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("User-Agent", utils.UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := utils.HTTPClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -116,16 +135,45 @@ func (me *dockerHubAPI) ImageHasTag(namespace, image, tag string) bool {
 		fmt.Sprintf("namespaces/%s/repositories/%s/tags/%s", namespace, image, tag),
 	)
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(me.ctx, "GET", url.String(), nil)
 	if err != nil {
 		return false
 	}
 	req.Header.Set("User-Agent", utils.UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := utils.HTTPClient().Do(req)
 	if err != nil {
 		return false
 	}
 
 	return res.StatusCode == 200
 }
+
+func (me *dockerHubAPI) GetTagDetails(namespace, image, tag string) (*TagDetails, error) {
+	url := me.baseURL.JoinPath(
+		fmt.Sprintf("namespaces/%s/repositories/%s/tags/%s", namespace, image, tag),
+	)
+
+	req, err := http.NewRequestWithContext(me.ctx, "GET", url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", utils.UserAgent)
+
+	res, err := utils.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch the tag %s of %s/%s: %s", tag, namespace, image, res.Status)
+	}
+
+	details := &TagDetails{}
+	if err := json.NewDecoder(res.Body).Decode(details); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
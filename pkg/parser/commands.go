@@ -55,6 +55,12 @@ func (doc *YamlDocument) parseSingleCommand(commandNode *sitter.Node) ast.Comman
 	res.Range = doc.NodeToRange(commandNode)
 	res.NameRange = doc.NodeToRange(commandNameNode)
 
+	doc.parseCommandAttributes(&res, blockMappingNode)
+
+	return res
+}
+
+func (doc *YamlDocument) parseCommandAttributes(res *ast.Command, blockMappingNode *sitter.Node) {
 	doc.iterateOnBlockMapping(blockMappingNode, func(child *sitter.Node) {
 		keyNode, valueNode := doc.GetKeyValueNodes(child)
 		keyName := doc.GetNodeText(keyNode)
@@ -73,6 +79,4 @@ func (doc *YamlDocument) parseSingleCommand(commandNode *sitter.Node) ast.Comman
 			res.Parameters = doc.parseParameters(valueNode)
 		}
 	})
-
-	return res
 }
@@ -0,0 +1,333 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+type EnvVariableSourceKind string
+
+const (
+	EnvVariableFromStep        EnvVariableSourceKind = "step"
+	EnvVariableFromJob         EnvVariableSourceKind = "job"
+	EnvVariableFromExecutor    EnvVariableSourceKind = "executor"
+	EnvVariableFromDockerImage EnvVariableSourceKind = "docker image"
+	EnvVariableFromContext     EnvVariableSourceKind = "context"
+	EnvVariableFromProject     EnvVariableSourceKind = "project"
+	EnvVariableFromBuiltIn     EnvVariableSourceKind = "built-in"
+	EnvVariableFromScript      EnvVariableSourceKind = "script"
+	EnvVariableFromShell       EnvVariableSourceKind = "shell"
+)
+
+// An EnvVariableSource is a place an environment variable is set from. Name
+// is the name of the job, executor, image, context or project defining it
+type EnvVariableSource struct {
+	Kind  EnvVariableSourceKind
+	Name  string
+	Range protocol.Range
+}
+
+// An EnvVariableUsage is an expansion of an environment variable in the
+// command of a run step
+type EnvVariableUsage struct {
+	Name  string
+	Range protocol.Range
+}
+
+// The EnvVariableScope lists the sources of the variables available to the
+// run steps of a job or a command
+type EnvVariableScope struct {
+	Sources map[string][]EnvVariableSource
+
+	// Complete is false when some variables may come from a source that is
+	// not known: an orb executor, or contexts and project settings that
+	// could not be fetched
+	Complete bool
+}
+
+func (scope *EnvVariableScope) add(name string, source EnvVariableSource) {
+	if !slices.Contains(scope.Sources[name], source) {
+		scope.Sources[name] = append(scope.Sources[name], source)
+	}
+}
+
+// GetEnvVariableSources returns where a variable used in a run step comes from
+func (scope EnvVariableScope) GetEnvVariableSources(name string, run ast.Run) []EnvVariableSource {
+	sources := []EnvVariableSource{}
+
+	if _, ok := run.Environment[name]; ok {
+		sources = append(sources, EnvVariableSource{Kind: EnvVariableFromStep, Range: run.Range})
+	}
+
+	sources = append(sources, scope.Sources[name]...)
+
+	if _, ok := utils.GetBuiltInEnvVariable(name); ok {
+		sources = append(sources, EnvVariableSource{Kind: EnvVariableFromBuiltIn})
+	}
+
+	if slices.Contains(utils.ShellEnvVariables, name) {
+		sources = append(sources, EnvVariableSource{Kind: EnvVariableFromShell})
+	}
+
+	return sources
+}
+
+// GetJobEnvVariableScope returns the variables available to the steps of a
+// job, with the contexts and project variables loaded in the cache
+func (doc *YamlDocument) GetJobEnvVariableScope(job ast.Job, cache *utils.Cache) EnvVariableScope {
+	scope := EnvVariableScope{Sources: map[string][]EnvVariableSource{}, Complete: true}
+	doc.addJobEnvVariables(&scope, job, cache)
+	return scope
+}
+
+// GetCommandEnvVariableScope returns the variables available to the steps of
+// a command, from all the jobs of the document invoking it. ok is false when
+// the command is not invoked by any job of the document
+func (doc *YamlDocument) GetCommandEnvVariableScope(commandName string, cache *utils.Cache) (scope EnvVariableScope, ok bool) {
+	scope = EnvVariableScope{Sources: map[string][]EnvVariableSource{}, Complete: true}
+
+	for _, job := range doc.Jobs {
+		if slices.Contains(doc.getInvokedCommands(job.Steps, []string{}), commandName) {
+			doc.addJobEnvVariables(&scope, job, cache)
+			ok = true
+		}
+	}
+
+	return scope, ok
+}
+
+func (doc *YamlDocument) addJobEnvVariables(scope *EnvVariableScope, job ast.Job, cache *utils.Cache) {
+	for name := range job.Environment {
+		scope.add(name, EnvVariableSource{Kind: EnvVariableFromJob, Name: job.Name, Range: job.EnvironmentRange})
+	}
+
+	addDockerImages := func(images []ast.DockerImage) {
+		for _, image := range images {
+			for name := range image.Environment {
+				scope.add(name, EnvVariableSource{Kind: EnvVariableFromDockerImage, Name: image.Image.FullPath, Range: image.ImageRange})
+			}
+		}
+	}
+	addDockerImages(job.Docker.Image)
+
+	if job.Executor != "" {
+		if executor, ok := doc.Executors[job.Executor]; ok {
+			envs := executor.GetEnvs()
+			for _, name := range envs.Keys {
+				scope.add(name, EnvVariableSource{Kind: EnvVariableFromExecutor, Name: job.Executor, Range: envs.Range})
+			}
+
+			if docker, ok := executor.(ast.DockerExecutor); ok {
+				addDockerImages(docker.Image)
+			}
+		} else {
+			scope.Complete = false
+		}
+	}
+
+	// Orb commands can export variables for the next steps through $BASH_ENV
+	if doc.invokesExternalCommands(job.Steps, []string{}) {
+		scope.Complete = false
+	}
+
+	for _, step := range doc.getRunSteps(job.Steps, []string{}) {
+		for _, name := range GetScriptAssignedVariables(step) {
+			scope.add(name, EnvVariableSource{Kind: EnvVariableFromScript, Name: job.Name})
+		}
+	}
+
+	var cachedFile *utils.CachedFile
+	if cache != nil {
+		cachedFile = cache.FileCache.GetFile(doc.URI)
+	}
+
+	if cachedFile == nil || cachedFile.Project.Slug == "" {
+		scope.Complete = false
+	} else {
+		for _, name := range cachedFile.EnvVariables {
+			scope.add(name, EnvVariableSource{Kind: EnvVariableFromProject, Name: cachedFile.Project.Name})
+		}
+	}
+
+	if job.Contexts == nil {
+		return
+	}
+
+	for _, context := range *job.Contexts {
+		if cachedFile == nil || cache.ContextCache.GetOrganizationContext(cachedFile.Project.OrganizationId, context) == nil {
+			scope.Complete = false
+		}
+	}
+
+	if cachedFile != nil {
+		for _, env := range utils.GetAllContextEnvVariables(cache, cachedFile.Project.OrganizationId, *job.Contexts) {
+			scope.add(env.Name, EnvVariableSource{Kind: EnvVariableFromContext, Name: env.AssociatedContext})
+		}
+	}
+}
+
+// Returns the local commands invoked by the steps, and by the commands they
+// invoke
+func (doc *YamlDocument) getInvokedCommands(steps []ast.Step, visited []string) []string {
+	for _, step := range steps {
+		named, ok := step.(ast.NamedStep)
+		if !ok || slices.Contains(visited, named.Name) {
+			continue
+		}
+
+		if command, ok := doc.Commands[named.Name]; ok {
+			visited = doc.getInvokedCommands(command.Steps, append(visited, named.Name))
+		}
+	}
+
+	return visited
+}
+
+// Returns the run steps of the given steps and of the local commands they
+// invoke
+func (doc *YamlDocument) getRunSteps(steps []ast.Step, visited []string) []ast.Run {
+	res := []ast.Run{}
+
+	for _, step := range steps {
+		switch step := step.(type) {
+		case ast.Run:
+			res = append(res, step)
+		case ast.NamedStep:
+			if command, ok := doc.Commands[step.Name]; ok && !slices.Contains(visited, step.Name) {
+				res = append(res, doc.getRunSteps(command.Steps, append(visited, step.Name))...)
+			}
+		}
+	}
+
+	return res
+}
+
+func (doc *YamlDocument) invokesExternalCommands(steps []ast.Step, visited []string) bool {
+	for _, step := range steps {
+		named, ok := step.(ast.NamedStep)
+		if !ok || slices.Contains(visited, named.Name) {
+			continue
+		}
+
+		command, ok := doc.Commands[named.Name]
+		if !ok {
+			if doc.IsBuiltIn(named.Name) {
+				continue
+			}
+			return true
+		}
+
+		if doc.invokesExternalCommands(command.Steps, append(visited, named.Name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches assignments, including the ones written in strings such as
+// `echo 'export FOO=bar' >> "$BASH_ENV"`
+var assignmentRegex = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\+?=`)
+
+// GetScriptAssignedVariables returns the variables set by the command of a
+// run step: assignments, loops and `read`
+func GetScriptAssignedVariables(step ast.Run) []string {
+	res := []string{}
+	if step.RawCommand == "" {
+		return res
+	}
+
+	script := GetRunScript(step)
+	for _, match := range assignmentRegex.FindAllStringSubmatch(script.Text, -1) {
+		res = append(res, match[1])
+	}
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script.MaskedText()), "")
+	if err != nil {
+		return res
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.Assign:
+			if node.Name != nil {
+				res = append(res, node.Name.Value)
+			}
+		case *syntax.WordIter:
+			res = append(res, node.Name.Value)
+		case *syntax.CallExpr:
+			if len(node.Args) == 0 {
+				return true
+			}
+
+			switch wordLiteral(node.Args[0]) {
+			case "read", "mapfile", "readarray", "getopts":
+				for _, arg := range node.Args[1:] {
+					if value := wordLiteral(arg); isVariableName(value) {
+						res = append(res, value)
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	return res
+}
+
+// GetEnvVariableUsages returns the variables expanded by the command of a run
+// step. Expansions with a default value, such as `${FOO:-bar}`, are ignored
+func GetEnvVariableUsages(step ast.Run) []EnvVariableUsage {
+	res := []EnvVariableUsage{}
+	if step.RawCommand == "" {
+		return res
+	}
+
+	script := GetRunScript(step)
+	file, err := syntax.NewParser().Parse(strings.NewReader(script.MaskedText()), "")
+	if err != nil {
+		return res
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		param, ok := node.(*syntax.ParamExp)
+		if !ok || param.Param == nil || param.Excl {
+			return true
+		}
+
+		if param.Exp != nil {
+			switch param.Exp.Op {
+			case syntax.DefaultUnset, syntax.DefaultUnsetOrNull,
+				syntax.AlternateUnset, syntax.AlternateUnsetOrNull,
+				syntax.AssignUnset, syntax.AssignUnsetOrNull:
+				return true
+			}
+		}
+
+		start, end := int(param.Param.Pos().Offset()), int(param.Param.End().Offset())
+		name := param.Param.Value
+		if !isVariableName(name) || end > len(script.Text) || script.Text[start:end] != name {
+			return true
+		}
+
+		res = append(res, EnvVariableUsage{
+			Name:  name,
+			Range: script.RangeAt(start, end),
+		})
+		return true
+	})
+
+	return res
+}
+
+var variableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func isVariableName(name string) bool {
+	return variableNameRegex.MatchString(name)
+}
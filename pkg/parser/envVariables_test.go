@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestGetEnvVariableUsages(t *testing.T) {
+	content := `jobs:
+  build:
+    steps:
+      - run: echo "$FOO ${BAR}" ${BAZ:-default} $1 $<< parameters.name >>`
+
+	doc, err := ParseFromContent([]byte(content), testHelpers.GetDefaultLsContext(), uri.File(""), protocol.Position{})
+	assert.NoError(t, err)
+
+	usages := GetEnvVariableUsages(doc.Jobs["build"].Steps[0].(ast.Run))
+	assert.Equal(t, []EnvVariableUsage{
+		{
+			Name: "FOO",
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 3, Character: 20},
+				End:   protocol.Position{Line: 3, Character: 23},
+			},
+		},
+		{
+			Name: "BAR",
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 3, Character: 26},
+				End:   protocol.Position{Line: 3, Character: 29},
+			},
+		},
+	}, usages)
+}
+
+func TestGetScriptAssignedVariables(t *testing.T) {
+	content := `jobs:
+  build:
+    steps:
+      - run: |
+          export FOO=1
+          echo 'export BAR=2' >> "$BASH_ENV"
+          for item in a b; do read -r LINE; done`
+
+	doc, err := ParseFromContent([]byte(content), testHelpers.GetDefaultLsContext(), uri.File(""), protocol.Position{})
+	assert.NoError(t, err)
+
+	assigned := GetScriptAssignedVariables(doc.Jobs["build"].Steps[0].(ast.Run))
+	assert.Subset(t, assigned, []string{"FOO", "BAR", "item", "LINE"})
+}
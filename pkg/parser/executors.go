@@ -47,31 +47,39 @@ func (doc *YamlDocument) parseSingleExecutor(executorNode *sitter.Node) {
 		return
 	}
 
+	doc.Executors[executorName] = doc.parseExecutorDefinition(executorNameNode, blockMappingNode)
+}
+
+func (doc *YamlDocument) parseExecutorDefinition(executorNameNode *sitter.Node, blockMappingNode *sitter.Node) ast.Executor {
+	var executor ast.Executor
+
 	doc.iterateOnBlockMapping(blockMappingNode, func(child *sitter.Node) {
 		keyNode, _ := doc.GetKeyValueNodes(child)
 		keyName := doc.GetNodeText(keyNode)
 
 		switch keyName {
 		case "docker":
-			doc.Executors[executorName] = doc.parseSingleExecutorDocker(executorNameNode, blockMappingNode)
+			executor = doc.parseSingleExecutorDocker(executorNameNode, blockMappingNode)
 		case "machine":
-			doc.Executors[executorName] = doc.parseSingleExecutorMachine(executorNameNode, blockMappingNode)
+			executor = doc.parseSingleExecutorMachine(executorNameNode, blockMappingNode)
 		case "macos":
-			doc.Executors[executorName] = doc.parseSingleExecutorMacOS(executorNameNode, blockMappingNode)
+			executor = doc.parseSingleExecutorMacOS(executorNameNode, blockMappingNode)
 		}
 	})
 
-	// If the executor has not been parsed and set in doc.Executors,
-	// we can assume it is not complete and therefor we set it as uncomplete
-	// and parse what has been set for the autocomplete to suggest the missing fields
-	if _, ok := doc.Executors[executorName]; !ok {
+	// If the executor has not been parsed, we can assume it is not complete
+	// and therefor we set it as uncomplete and parse what has been set for
+	// the autocomplete to suggest the missing fields
+	if executor == nil {
 		baseExecutor := ast.BaseExecutor{
 			UserParameters: make(map[string]ast.Parameter),
 		}
 		doc.parseBaseExecutor(&baseExecutor, executorNameNode, blockMappingNode, func(node *sitter.Node) {}, "dummy")
 		baseExecutor.Uncomplete = true
-		doc.Executors[executorName] = baseExecutor
+		executor = baseExecutor
 	}
+
+	return executor
 }
 
 func (doc *YamlDocument) parseBaseExecutor(base *ast.BaseExecutor, nameNode *sitter.Node, blockMappingNode *sitter.Node, fn func(node *sitter.Node), nameStep string) {
@@ -93,6 +101,8 @@ func (doc *YamlDocument) parseBaseExecutor(base *ast.BaseExecutor, nameNode *sit
 			base.BuiltInParameters.Shell = doc.GetNodeText(valueNode)
 		case "working_directory":
 			base.BuiltInParameters.WorkingDirectory = doc.GetNodeText(valueNode)
+		case "description":
+			base.BuiltInParameters.Description = doc.parseDescription(valueNode)
 		case "environment":
 			base.Environment = doc.parseEnvs(valueNode)
 		case "parameters":
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// A FileReference is a path to a file of the repository written in a step:
+// a script run by a `run` step or the path of `store_test_results` and
+// `store_artifacts`
+type FileReference struct {
+	Path  string
+	Range protocol.Range
+
+	// IsScript is true when the file is executed by the step and therefore
+	// has to be checked in (or at least created by a previous step)
+	IsScript bool
+}
+
+var scriptInterpreters = []string{
+	"bash", "sh", "zsh", "dash", "ksh",
+	"python", "python2", "python3",
+	"node", "ruby", "perl", "php",
+	"pwsh", "powershell",
+	"source", ".",
+}
+
+// Interpreter flags after which the next argument is not a file
+var inlineCodeFlags = []string{"-c", "-e", "-m", "--eval", "-Command"}
+
+var scriptExtensions = []string{".sh", ".bash", ".zsh", ".py", ".js", ".mjs", ".cjs", ".ts", ".rb", ".pl", ".php", ".ps1"}
+
+// GetStepFileReferences returns the files of the repository referenced by a
+// step
+func GetStepFileReferences(step ast.Step) []FileReference {
+	switch step := step.(type) {
+	case ast.Run:
+		return getRunFileReferences(step)
+	case ast.StoreTestResults:
+		if step.Path != "" {
+			return []FileReference{{Path: step.Path, Range: step.PathRange}}
+		}
+	case ast.StoreArtifacts:
+		if step.Path != "" {
+			return []FileReference{{Path: step.Path, Range: step.PathRange}}
+		}
+	}
+
+	return []FileReference{}
+}
+
+func getRunFileReferences(step ast.Run) []FileReference {
+	res := []FileReference{}
+	if step.RawCommand == "" {
+		return res
+	}
+
+	script := GetRunScript(step)
+	file, err := syntax.NewParser().Parse(strings.NewReader(script.MaskedText()), "")
+	if err != nil {
+		return res
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		command := wordLiteral(call.Args[0])
+
+		if slices.Contains(scriptInterpreters, filepath.Base(command)) {
+			for _, arg := range call.Args[1:] {
+				value := wordLiteral(arg)
+				if slices.Contains(inlineCodeFlags, value) {
+					break
+				}
+				if value == "" || strings.HasPrefix(value, "-") {
+					continue
+				}
+
+				if ref, ok := newFileReference(script, arg, value, true); ok {
+					res = append(res, ref)
+				}
+				break
+			}
+			return true
+		}
+
+		if strings.Contains(command, "/") {
+			isScript := slices.Contains(scriptExtensions, filepath.Ext(command))
+			if ref, ok := newFileReference(script, call.Args[0], command, isScript); ok {
+				res = append(res, ref)
+			}
+		}
+
+		return true
+	})
+
+	return res
+}
+
+func newFileReference(script RunScript, word *syntax.Word, value string, isScript bool) (FileReference, bool) {
+	start, end := int(word.Pos().Offset()), int(word.End().Offset())
+
+	// Interpolated parameters are masked in the parsed script
+	if end > len(script.Text) || strings.Contains(script.Text[start:end], "<<") {
+		return FileReference{}, false
+	}
+
+	if filepath.IsAbs(value) || strings.HasPrefix(value, "~") {
+		return FileReference{}, false
+	}
+
+	return FileReference{
+		Path:     value,
+		Range:    script.RangeAt(start, end),
+		IsScript: isScript,
+	}, true
+}
+
+// Returns the value of a word made only of literal parts, or an empty string
+func wordLiteral(word *syntax.Word) string {
+	var builder strings.Builder
+
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			builder.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			builder.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, quotedPart := range part.Parts {
+				lit, ok := quotedPart.(*syntax.Lit)
+				if !ok {
+					return ""
+				}
+				builder.WriteString(lit.Value)
+			}
+		default:
+			return ""
+		}
+	}
+
+	return builder.String()
+}
+
+// GetFileReferenceAt returns the file referenced at the given position in a
+// step of a job or a command
+func (doc *YamlDocument) GetFileReferenceAt(position protocol.Position) (FileReference, bool) {
+	find := func(steps []ast.Step) (FileReference, bool) {
+		for _, step := range steps {
+			for _, ref := range GetStepFileReferences(step) {
+				if utils.PosInRange(ref.Range, position) {
+					return ref, true
+				}
+			}
+		}
+		return FileReference{}, false
+	}
+
+	for _, job := range doc.Jobs {
+		if ref, ok := find(job.Steps); ok {
+			return ref, true
+		}
+	}
+
+	for _, command := range doc.Commands {
+		if ref, ok := find(command.Steps); ok {
+			return ref, true
+		}
+	}
+
+	return FileReference{}, false
+}
+
+// ResolveFileReference returns the path on disk of a referenced file, relative
+// to the root of the repository of the document. ok is false when the file
+// does not exist or resolves outside of the repository, as the definition and
+// the hover open the file
+func (doc *YamlDocument) ResolveFileReference(ref FileReference) (path string, ok bool) {
+	root := doc.GetRepositoryRoot()
+	if root == "" {
+		return "", false
+	}
+
+	path = filepath.Join(root, filepath.FromSlash(ref.Path))
+	if !isWithinDirectory(root, path) {
+		return "", false
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return path, false
+	}
+
+	// The symbolic links of the repository can point anywhere
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", false
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil || !isWithinDirectory(realRoot, realPath) {
+		return "", false
+	}
+
+	return path, true
+}
+
+func isWithinDirectory(directory string, path string) bool {
+	rel, err := filepath.Rel(directory, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// GetRepositoryRoot returns the root of the repository of the document, or an
+// empty string for documents that are not saved on disk
+func (doc *YamlDocument) GetRepositoryRoot() string {
+	if !strings.HasPrefix(string(doc.URI), uri.FileScheme+"://") {
+		return ""
+	}
+
+	return utils.GetRepositoryRoot(doc.URI.Filename())
+}
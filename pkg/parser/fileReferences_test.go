@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestGetStepFileReferences(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		YamlContent string
+		Expected    []FileReference
+	}{
+		{
+			Name: "Script run by an interpreter",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run: python3 -u scripts/build.py --fast`,
+			Expected: []FileReference{
+				{
+					Path: "scripts/build.py",
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 3, Character: 24},
+						End:   protocol.Position{Line: 3, Character: 40},
+					},
+					IsScript: true,
+				},
+			},
+		},
+		{
+			Name: "Executable called by its path",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run: |
+          set -e
+          ./scripts/test.sh`,
+			Expected: []FileReference{
+				{
+					Path: "./scripts/test.sh",
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 5, Character: 10},
+						End:   protocol.Position{Line: 5, Character: 27},
+					},
+					IsScript: true,
+				},
+			},
+		},
+		{
+			Name: "Inline code, absolute paths and parameters are ignored",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run: |
+          python -c "print(1)"
+          /usr/bin/make
+          bash << parameters.script >>`,
+			Expected: []FileReference{},
+		},
+		{
+			Name: "Path of store_test_results",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - store_test_results:
+          path: test-results`,
+			Expected: []FileReference{
+				{
+					Path: "test-results",
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 4, Character: 16},
+						End:   protocol.Position{Line: 4, Character: 28},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Name, func(t *testing.T) {
+			doc, err := ParseFromContent([]byte(tt.YamlContent), testHelpers.GetDefaultLsContext(), uri.File(""), protocol.Position{})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.Expected, GetStepFileReferences(doc.Jobs["build"].Steps[0]))
+		})
+	}
+}
+
+func TestResolveFileReference(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "repository")
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, ".circleci"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "scripts"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "scripts", "build.sh"), []byte("#!/bin/bash\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "outside.sh"), []byte("#!/bin/bash\n"), 0o644))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "outside.sh"), filepath.Join(root, "scripts", "link.sh")))
+
+	doc := YamlDocument{URI: uri.File(filepath.Join(root, ".circleci", "config.yml"))}
+
+	testCases := []struct {
+		Path     string
+		Expected bool
+	}{
+		{Path: "scripts/build.sh", Expected: true},
+		{Path: "./scripts/../scripts/build.sh", Expected: true},
+		{Path: "scripts/missing.sh", Expected: false},
+		{Path: "../outside.sh", Expected: false},
+		{Path: "scripts/../../outside.sh", Expected: false},
+		{Path: "scripts/link.sh", Expected: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Path, func(t *testing.T) {
+			path, ok := doc.ResolveFileReference(FileReference{Path: tt.Path})
+			assert.Equal(t, tt.Expected, ok)
+			if ok {
+				assert.Equal(t, filepath.Join(root, "scripts", "build.sh"), path)
+			}
+		})
+	}
+}
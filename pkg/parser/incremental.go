@@ -0,0 +1,342 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// A document is parsed once per version: the parsed document of the last
+// version of each file of the cache is kept and, on a change, its tree is
+// edited and reparsed incrementally. Only the top-level sections whose content
+// changed are then walked again.
+//
+// The nodes kept in the parsed entities (steps, orbs, anchors) are read with
+// the byte offsets of their tree, so a section is only reused when it starts
+// at the same offset and has the same content as in the previous version.
+
+type parsedSection struct {
+	Name       string
+	StartByte  uint32
+	EndByte    uint32
+	StartPoint sitter.Point
+
+	// The diagnostics added while walking the section
+	Diagnostics []protocol.Diagnostic
+}
+
+func newParsedSection(name string, node *sitter.Node) parsedSection {
+	return parsedSection{
+		Name:       name,
+		StartByte:  node.StartByte(),
+		EndByte:    node.EndByte(),
+		StartPoint: node.StartPoint(),
+	}
+}
+
+type parsedDocument struct {
+	contentHash string
+	hostUrl     string
+	doc         YamlDocument
+}
+
+type parsedDocumentCache struct {
+	mutex     sync.Mutex
+	documents map[protocol.URI]parsedDocument
+}
+
+var parsedDocuments = parsedDocumentCache{documents: make(map[protocol.URI]parsedDocument)}
+
+// Returns the document parsed for the given content of the file. The parsing
+// also depends on the instance of CircleCI used, see
+// addedMachineTrueDeprecatedDiag, and on whether the file is in the source
+// directory of an orb, which depends on the `@orb.yml` on disk
+func (c *parsedDocumentCache) get(URI protocol.URI, contentHash string, context *utils.LsContext) (YamlDocument, bool) {
+	c.mutex.Lock()
+	parsed, ok := c.documents[URI]
+	c.mutex.Unlock()
+
+	if !ok || context == nil ||
+		parsed.contentHash != contentHash ||
+		parsed.doc.Context != context ||
+		parsed.hostUrl != context.Api.HostUrl {
+		return YamlDocument{}, false
+	}
+
+	if _, isOrbSourceFile := GetOrbSourceFile(URI); isOrbSourceFile != (parsed.doc.OrbSourceFile != nil) {
+		return YamlDocument{}, false
+	}
+
+	return parsed.doc, true
+}
+
+func (c *parsedDocumentCache) set(URI protocol.URI, contentHash string, doc YamlDocument) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	hostUrl := ""
+	if doc.Context != nil {
+		hostUrl = doc.Context.Api.HostUrl
+	}
+
+	c.documents[URI] = parsedDocument{
+		contentHash: contentHash,
+		hostUrl:     hostUrl,
+		doc:         doc,
+	}
+}
+
+// UpdateParsedDocument parses the new version of a file of the cache from the
+// document parsed for its previous content, whose hash is given, and the edits
+// made to it. The file is fully parsed when the previous document is unknown
+func UpdateParsedDocument(ctx context.Context, file utils.CachedFile, previousContentHash string, edits []sitter.EditInput, context *utils.LsContext) YamlDocument {
+	content := []byte(file.TextDocument.Text)
+
+	var doc YamlDocument
+	if previous, ok := parsedDocuments.get(file.TextDocument.URI, previousContentHash, context); ok {
+		doc = previous.Reparse(ctx, content, edits)
+	} else {
+		doc, _ = ParseFromContentWithContext(ctx, content, context, file.TextDocument.URI, protocol.Position{})
+	}
+
+	parsedDocuments.set(file.TextDocument.URI, file.ContentHash, doc)
+	return doc
+}
+
+func RemoveParsedDocument(URI protocol.URI) {
+	parsedDocuments.mutex.Lock()
+	defer parsedDocuments.mutex.Unlock()
+	delete(parsedDocuments.documents, URI)
+}
+
+// RemoveParsedDocuments forgets the documents parsed for the files of the
+// directory, such as a workspace folder that is removed
+func RemoveParsedDocuments(dir string) {
+	parsedDocuments.mutex.Lock()
+	defer parsedDocuments.mutex.Unlock()
+
+	for URI := range parsedDocuments.documents {
+		if strings.HasPrefix(string(URI), uri.FileScheme+"://") && isWithinDirectory(dir, URI.Filename()) {
+			delete(parsedDocuments.documents, URI)
+		}
+	}
+}
+
+// ApplyContentChange returns the content modified by the change and the edit
+// to apply to the tree of the content for it to match the new one
+func ApplyContentChange(content []byte, change protocol.TextDocumentContentChangeEvent) ([]byte, sitter.EditInput) {
+	start, end := utils.PosToIndex(change.Range.Start, content), utils.PosToIndex(change.Range.End, content)
+
+	var buf bytes.Buffer
+	buf.Write(content[:start])
+	buf.Write([]byte(change.Text))
+	buf.Write(content[end:])
+	newContent := buf.Bytes()
+
+	newEnd := start + len(change.Text)
+
+	return newContent, sitter.EditInput{
+		StartIndex:  uint32(start),
+		OldEndIndex: uint32(end),
+		NewEndIndex: uint32(newEnd),
+		StartPoint:  indexToPoint(content, start),
+		OldEndPoint: indexToPoint(content, end),
+		NewEndPoint: indexToPoint(newContent, newEnd),
+	}
+}
+
+// The columns of the points of tree-sitter are in bytes
+func indexToPoint(content []byte, index int) sitter.Point {
+	lineStart := bytes.LastIndexByte(content[:index], '\n') + 1
+
+	return sitter.Point{
+		Row:    uint32(bytes.Count(content[:index], []byte{'\n'})),
+		Column: uint32(index - lineStart),
+	}
+}
+
+// Reparse returns the document with the given content, made from the content
+// of doc by the edits. The tree of doc is left untouched as the nodes of doc
+// may still be in use. The timing is logged to the client of ctx
+func (doc *YamlDocument) Reparse(ctx context.Context, content []byte, edits []sitter.EditInput) YamlDocument {
+	defer utils.LogDurationContext(ctx, "document reparsed", time.Now(), "uri", doc.URI, "edits", len(edits))
+
+	if doc.Tree == nil || doc.Offset != (protocol.Position{}) {
+		res, _ := ParseFromContentWithContext(ctx, content, doc.Context, doc.URI, doc.Offset)
+		return res
+	}
+
+	oldTree := doc.Tree.Copy()
+	for _, edit := range edits {
+		oldTree.Edit(edit)
+	}
+
+	res := newYamlDocument(content, doc.Context, ParseTree(content, oldTree))
+	res.setURI(doc.URI, protocol.Position{})
+	res.SchemaLocation = doc.SchemaLocation
+	res.parseSections(doc)
+
+	return res
+}
+
+// The walk of a section depends on the anchors of the whole document and the
+// walk of the workflows on the version
+func (doc *YamlDocument) canReuseSections(previous *YamlDocument) bool {
+	if previous.sections == nil || previous.OrbSourceFile != nil || doc.OrbSourceFile != nil || previous.IsOrb != doc.IsOrb {
+		return false
+	}
+
+	if doc.hasMixedSections() || previous.hasMixedSections() {
+		return false
+	}
+
+	if previous.GetNodeText(previous.getSectionNode("version")) != doc.GetNodeText(doc.getSectionNode("version")) {
+		return false
+	}
+
+	if len(previous.YamlAnchors) != len(doc.YamlAnchors) {
+		return false
+	}
+	for name, anchor := range doc.YamlAnchors {
+		previousAnchor, ok := previous.YamlAnchors[name]
+		if !ok ||
+			previousAnchor.DefinitionRange != anchor.DefinitionRange ||
+			previous.GetNodeText(previousAnchor.ValueNode) != doc.GetNodeText(anchor.ValueNode) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// The merge keys and the duplicated keys mix the content of several sections
+func (doc *YamlDocument) hasMixedSections() bool {
+	blockMappingNode := GetBlockMappingNode(doc.RootNode)
+	keys := map[string]bool{}
+
+	for i := 0; blockMappingNode != nil && uint32(i) < blockMappingNode.ChildCount(); i++ {
+		child := blockMappingNode.Child(i)
+		if child.Type() == "comment" {
+			continue
+		}
+
+		keyNode, _ := doc.GetKeyValueNodes(child)
+		keyName := doc.GetNodeText(keyNode)
+		if keyName == "<<" || keys[keyName] {
+			return true
+		}
+		keys[keyName] = true
+	}
+
+	return false
+}
+
+// Returns the value of the top-level section with the given name
+func (doc *YamlDocument) getSectionNode(name string) *sitter.Node {
+	var res *sitter.Node
+	doc.iterateOnBlockMapping(GetBlockMappingNode(doc.RootNode), func(child *sitter.Node) {
+		keyNode, valueNode := doc.GetKeyValueNodes(child)
+		if res == nil && doc.GetNodeText(keyNode) == name {
+			res = valueNode
+		}
+	})
+
+	return res
+}
+
+func (doc *YamlDocument) getSectionsByStart() map[uint32]parsedSection {
+	sections := map[uint32]parsedSection{}
+	if doc == nil {
+		return sections
+	}
+
+	for _, section := range doc.sections {
+		sections[section.StartByte] = section
+	}
+
+	return sections
+}
+
+func (doc *YamlDocument) isSameSection(section parsedSection, other *YamlDocument, otherSection parsedSection) bool {
+	return section.Name == otherSection.Name &&
+		section.StartByte == otherSection.StartByte &&
+		section.EndByte == otherSection.EndByte &&
+		section.StartPoint == otherSection.StartPoint &&
+		int(section.EndByte) <= len(doc.Content) &&
+		int(otherSection.EndByte) <= len(other.Content) &&
+		bytes.Equal(doc.Content[section.StartByte:section.EndByte], other.Content[otherSection.StartByte:otherSection.EndByte])
+}
+
+// Copies what the walk of the section built in the previous version of the
+// document, along with its diagnostics
+func (doc *YamlDocument) reuseSection(previous *YamlDocument, section parsedSection) {
+	switch section.Name {
+	case "version":
+		doc.Version = previous.Version
+		doc.VersionRange = previous.VersionRange
+
+	case "setup":
+		doc.Setup = previous.Setup
+		doc.SetupRange = previous.SetupRange
+
+	case "orbs":
+		doc.OrbsRange = previous.OrbsRange
+		doc.Orbs = maps.Clone(previous.Orbs)
+		doc.LocalOrbs = slices.Clone(previous.LocalOrbs)
+		doc.LocalOrbInfo = maps.Clone(previous.LocalOrbInfo)
+
+	case "commands":
+		doc.CommandsRange = previous.CommandsRange
+		// The contexts are assigned from the workflows, which may have changed
+		for name, command := range previous.Commands {
+			command.Contexts = &[]string{}
+			doc.Commands[name] = command
+		}
+
+	case "jobs":
+		doc.JobsRange = previous.JobsRange
+		for name, job := range previous.Jobs {
+			job.Contexts = &[]string{}
+			doc.Jobs[name] = job
+		}
+
+	case "job-groups":
+		doc.JobGroupsRange = previous.JobGroupsRange
+		doc.JobGroups = maps.Clone(previous.JobGroups)
+
+	case "workflows":
+		doc.WorkflowRange = previous.WorkflowRange
+		doc.Workflows = maps.Clone(previous.Workflows)
+
+	case "executors":
+		doc.ExecutorsRange = previous.ExecutorsRange
+		doc.Executors = maps.Clone(previous.Executors)
+
+	case "description":
+		doc.Description = previous.Description
+		doc.DescriptionRange = previous.DescriptionRange
+
+	case "display":
+		doc.Display = previous.Display
+
+	case "examples":
+		doc.ExamplesRange = previous.ExamplesRange
+		doc.Examples = maps.Clone(previous.Examples)
+
+	case "parameters":
+		doc.PipelineParametersRange = previous.PipelineParametersRange
+		doc.PipelineParameters = maps.Clone(previous.PipelineParameters)
+	}
+
+	*doc.Diagnostics = append(*doc.Diagnostics, section.Diagnostics...)
+	doc.sections = append(doc.sections, section)
+}
@@ -0,0 +1,262 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+const incrementalConfig = `version: 2.1
+
+orbs:
+  local:
+    commands:
+      hello:
+        steps:
+          - run: echo hello
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:2024.01
+    steps:
+      - checkout
+      - local/hello
+
+workflows:
+  main:
+    jobs:
+      - build:
+          context: org-global
+`
+
+// Returns the change replacing the first occurrence of old by new
+func replaceChange(t *testing.T, content string, old string, new string) protocol.TextDocumentContentChangeEvent {
+	index := strings.Index(content, old)
+	assert.NotEqual(t, -1, index)
+
+	return protocol.TextDocumentContentChangeEvent{
+		Range: protocol.Range{
+			Start: utils.IndexToPos(index, []byte(content)),
+			End:   utils.IndexToPos(index+len(old), []byte(content)),
+		},
+		Text: new,
+	}
+}
+
+func assertSameDocument(t *testing.T, expected YamlDocument, actual YamlDocument) {
+	assert.Equal(t, expected.RootNode.String(), actual.RootNode.String())
+	assert.Equal(t, expected.Version, actual.Version)
+	assert.Equal(t, *expected.Diagnostics, *actual.Diagnostics)
+	assert.Equal(t, expected.OrbsRange, actual.OrbsRange)
+	assert.Equal(t, expected.JobsRange, actual.JobsRange)
+	assert.Equal(t, expected.WorkflowRange, actual.WorkflowRange)
+
+	assert.Len(t, actual.Jobs, len(expected.Jobs))
+	for name, job := range expected.Jobs {
+		assert.Equal(t, job.Range, actual.Jobs[name].Range)
+		assert.Equal(t, *job.Contexts, *actual.Jobs[name].Contexts)
+	}
+
+	assert.Len(t, actual.Workflows, len(expected.Workflows))
+	for name, workflow := range expected.Workflows {
+		assert.Equal(t, workflow.Range, actual.Workflows[name].Range)
+	}
+
+	assert.Len(t, actual.LocalOrbInfo, len(expected.LocalOrbInfo))
+}
+
+func TestApplyContentChange(t *testing.T) {
+	content := []byte("jobs:\n  build:\n    steps: []\n")
+	tree := ParseTree(content, nil)
+
+	changes := []protocol.TextDocumentContentChangeEvent{
+		replaceChange(t, string(content), "build", "test"),
+		{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 3, Character: 0},
+				End:   protocol.Position{Line: 3, Character: 0},
+			},
+			Text: "  lint:\n    steps: []\n",
+		},
+	}
+
+	for _, change := range changes {
+		var edit sitter.EditInput
+		content, edit = ApplyContentChange(content, change)
+		tree.Edit(edit)
+	}
+
+	assert.Equal(t, "jobs:\n  test:\n    steps: []\n  lint:\n    steps: []\n", string(content))
+	assert.Equal(t, ParseTree(content, nil).RootNode().String(), ParseTree(content, tree).RootNode().String())
+}
+
+func TestReparse(t *testing.T) {
+	context := &utils.LsContext{Api: utils.ApiContext{HostUrl: "https://circleci.com"}}
+	URI := uri.File("/tmp/.circleci/config.yml")
+
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		// Whether the orbs, defined before the change, are walked again
+		reusesOrbs bool
+	}{
+		{name: "rename a job", old: "build", new: "test", reusesOrbs: true},
+		{name: "change a context", old: "org-global", new: "deploy", reusesOrbs: true},
+		{name: "add a step", old: "      - local/hello\n", new: "      - local/hello\n      - run: make\n", reusesOrbs: true},
+		{name: "break the yaml", old: "    jobs:\n", new: "    jobs: [\n", reusesOrbs: false},
+		{name: "change the version", old: "2.1", new: "2.0", reusesOrbs: false},
+		{name: "change an orb", old: "echo hello", new: "echo bye", reusesOrbs: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, err := ParseFromContent([]byte(incrementalConfig), context, URI, protocol.Position{})
+			assert.NoError(t, err)
+
+			change := replaceChange(t, incrementalConfig, tt.old, tt.new)
+			content, edit := ApplyContentChange([]byte(incrementalConfig), change)
+
+			doc := previous.Reparse(t.Context(), content, []sitter.EditInput{edit})
+			expected, err := ParseFromContent(content, context, URI, protocol.Position{})
+			assert.NoError(t, err)
+
+			assertSameDocument(t, expected, doc)
+			if tt.reusesOrbs {
+				assert.Same(t, previous.LocalOrbInfo["local"], doc.LocalOrbInfo["local"])
+			} else {
+				assert.NotSame(t, previous.LocalOrbInfo["local"], doc.LocalOrbInfo["local"])
+			}
+		})
+	}
+}
+
+func TestParseFromUriWithCache(t *testing.T) {
+	context := &utils.LsContext{Api: utils.ApiContext{HostUrl: "https://circleci.com"}}
+	cache := utils.CreateCache()
+	URI := uri.File("/tmp/incremental/.circleci/config.yml")
+	defer RemoveParsedDocument(URI)
+
+	file := cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{URI: URI, Version: 1, Text: incrementalConfig},
+	})
+
+	first, err := ParseFromUriWithCache(URI, cache, context)
+	assert.NoError(t, err)
+
+	// The document is parsed once per version, while the diagnostics of each
+	// returned document stay its own
+	*first.Diagnostics = append(*first.Diagnostics, protocol.Diagnostic{Message: "added"})
+	second, err := ParseFromUriWithCache(URI, cache, context)
+	assert.NoError(t, err)
+	assert.Same(t, first.LocalOrbInfo["local"], second.LocalOrbInfo["local"])
+	assert.Empty(t, *second.Diagnostics)
+
+	// A new version is parsed from the previous one
+	change := replaceChange(t, incrementalConfig, "build", "test")
+	content, edit := ApplyContentChange([]byte(incrementalConfig), change)
+	cache.FileCache.UpdateTextDocument(URI, protocol.TextDocumentItem{URI: URI, Version: 2, Text: string(content)})
+	updated := UpdateParsedDocument(t.Context(), *cache.FileCache.GetFile(URI), file.ContentHash, []sitter.EditInput{edit}, context)
+	assert.Same(t, first.LocalOrbInfo["local"], updated.LocalOrbInfo["local"])
+
+	third, err := ParseFromUriWithCache(URI, cache, context)
+	assert.NoError(t, err)
+	assert.Contains(t, third.Jobs, "test")
+	assert.Same(t, updated.LocalOrbInfo["local"], third.LocalOrbInfo["local"])
+
+	// The parsing depends on the instance of CircleCI
+	context.Api.HostUrl = "https://circleci.example.com"
+	fourth, err := ParseFromUriWithCache(URI, cache, context)
+	assert.NoError(t, err)
+	assert.NotSame(t, third.LocalOrbInfo["local"], fourth.LocalOrbInfo["local"])
+}
+
+func TestParseFromUriWithCacheOrbSourceFile(t *testing.T) {
+	context := &utils.LsContext{Api: utils.ApiContext{HostUrl: "https://circleci.com"}}
+	cache := utils.CreateCache()
+	dir := filepath.Join(t.TempDir(), "src")
+	URI := uri.File(filepath.Join(dir, "commands", "greet.yml"))
+	defer RemoveParsedDocument(URI)
+
+	cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{URI: URI, Version: 1, Text: "steps:\n  - run: echo hello\n"},
+	})
+
+	doc, err := ParseFromUriWithCache(URI, cache, context)
+	assert.NoError(t, err)
+	assert.False(t, doc.IsOrb)
+
+	// Creating `@orb.yml` makes the file a command of the orb
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "commands"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "@orb.yml"), []byte("version: 2.1\n"), 0o644))
+	doc, err = ParseFromUriWithCache(URI, cache, context)
+	assert.NoError(t, err)
+	assert.True(t, doc.IsOrb)
+	assert.Equal(t, &OrbSourceFile{Kind: OrbSourceCommands, Name: "greet"}, doc.OrbSourceFile)
+	assert.Contains(t, doc.Commands, "greet")
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "@orb.yml")))
+	doc, err = ParseFromUriWithCache(URI, cache, context)
+	assert.NoError(t, err)
+	assert.False(t, doc.IsOrb)
+	assert.Nil(t, doc.OrbSourceFile)
+}
+
+func TestRemoveParsedDocuments(t *testing.T) {
+	context := &utils.LsContext{Api: utils.ApiContext{HostUrl: "https://circleci.com"}}
+	cache := utils.CreateCache()
+	inside := uri.File("/tmp/removed/.circleci/config.yml")
+	outside := uri.File("/tmp/removed-sibling/.circleci/config.yml")
+	defer RemoveParsedDocument(outside)
+
+	for _, URI := range []protocol.URI{inside, outside} {
+		cache.FileCache.SetFile(utils.CachedFile{
+			TextDocument: protocol.TextDocumentItem{URI: URI, Version: 1, Text: incrementalConfig},
+		})
+		_, err := ParseFromUriWithCache(URI, cache, context)
+		assert.NoError(t, err)
+	}
+
+	RemoveParsedDocuments("/tmp/removed")
+
+	_, ok := parsedDocuments.get(inside, cache.FileCache.GetFile(inside).ContentHash, context)
+	assert.False(t, ok)
+	_, ok = parsedDocuments.get(outside, cache.FileCache.GetFile(outside).ContentHash, context)
+	assert.True(t, ok)
+}
+
+type traceClientMock struct {
+	traces []string
+}
+
+func (c *traceClientMock) LogMessage(protocol.MessageType, string) {}
+
+func (c *traceClientMock) LogTrace(message string, _ string) {
+	c.traces = append(c.traces, message)
+}
+
+func TestParseTracedToTheClient(t *testing.T) {
+	client := &traceClientMock{}
+	clientLog := utils.NewClientLog(client)
+	clientLog.SetTrace(protocol.TraceMessage)
+	ctx := utils.WithClientLog(t.Context(), clientLog)
+	lsContext := &utils.LsContext{Api: utils.ApiContext{HostUrl: "https://circleci.com"}}
+	URI := uri.File("/tmp/incremental/.circleci/config.yml")
+
+	doc, err := ParseFromContentWithContext(ctx, []byte(incrementalConfig), lsContext, URI, protocol.Position{})
+	assert.NoError(t, err)
+
+	change := replaceChange(t, incrementalConfig, "build", "test")
+	content, edit := ApplyContentChange([]byte(incrementalConfig), change)
+	doc.Reparse(ctx, content, []sitter.EditInput{edit})
+
+	assert.Equal(t, []string{"document parsed", "document reparsed"}, client.traces)
+}
@@ -40,7 +40,7 @@ func (doc *YamlDocument) parseJobs(jobsNode *sitter.Node) {
 func (doc *YamlDocument) parseSingleJob(jobNode *sitter.Node) ast.Job {
 	// jobNode is a block_mapping_pair
 	jobNameNode, valueNode := doc.GetKeyValueNodes(jobNode)
-	res := ast.Job{CompletionItem: &[]protocol.CompletionItem{}, Parallelism: -1, Contexts: &[]string{}, Parameters: map[string]ast.Parameter{}}
+	res := newJob()
 
 	if jobNameNode == nil || valueNode == nil {
 		return res
@@ -55,6 +55,16 @@ func (doc *YamlDocument) parseSingleJob(jobNode *sitter.Node) ast.Job {
 	res.Range = doc.NodeToRange(jobNode)
 	res.NameRange = doc.NodeToRange(jobNameNode)
 
+	doc.parseJobAttributes(&res, blockMappingNode)
+
+	return res
+}
+
+func newJob() ast.Job {
+	return ast.Job{CompletionItem: &[]protocol.CompletionItem{}, Parallelism: -1, Contexts: &[]string{}, Parameters: map[string]ast.Parameter{}}
+}
+
+func (doc *YamlDocument) parseJobAttributes(res *ast.Job, blockMappingNode *sitter.Node) {
 	machineNode := &sitter.Node{}
 	machineNodeFound := false
 
@@ -141,9 +151,7 @@ func (doc *YamlDocument) parseSingleJob(jobNode *sitter.Node) ast.Job {
 	if machineNodeFound {
 		doc.addedMachineTrueDeprecatedDiag(machineNode, res.ResourceClass)
 	}
-	doc.jobCompletionItem(res)
-
-	return res
+	doc.jobCompletionItem(*res)
 }
 
 func (doc *YamlDocument) jobCompletionItem(job ast.Job) {
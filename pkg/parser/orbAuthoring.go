@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// File names of the orbs sources: `@orb.yml` is the root of the `src/` layout
+// of the orb development kit, `orb.yml` is a packed orb
+var orbFileNames = []string{"@orb.yml", "@orb.yaml", "orb.yml", "orb.yaml"}
+
+// A `# orb` comment in the header of a file enables the orb authoring mode
+var orbMarkerRegex = regexp.MustCompile(`^#\s*orb\s*$`)
+
+// An OrbExample is an entry of the `examples` of an orb. Usage is the
+// `usage` config parsed as a document of its own, nil if missing
+type OrbExample struct {
+	Name      string
+	NameRange protocol.Range
+	Range     protocol.Range
+
+	Description      string
+	DescriptionRange protocol.Range
+
+	Usage      *YamlDocument
+	UsageRange protocol.Range
+}
+
+// IsOrbAuthoringFile returns true when the file is the source of an orb
+// rather than a pipeline config
+func IsOrbAuthoringFile(URI protocol.URI, content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if orbMarkerRegex.MatchString(line) {
+			return true
+		}
+	}
+
+	if !strings.HasPrefix(string(URI), uri.FileScheme+"://") {
+		return false
+	}
+
+	name := path.Base(URI.Filename())
+	for _, orbFileName := range orbFileNames {
+		if name == orbFileName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (doc *YamlDocument) parseDisplay(displayNode *sitter.Node) {
+	doc.Display = ast.OrbDisplay{Range: doc.NodeToRange(displayNode)}
+
+	doc.iterateOnBlockMapping(GetChildMapping(displayNode), func(child *sitter.Node) {
+		keyNode, valueNode := doc.GetKeyValueNodes(child)
+		if keyNode == nil || valueNode == nil {
+			return
+		}
+
+		switch doc.GetNodeText(keyNode) {
+		case "home_url":
+			doc.Display.HomeUrl = doc.GetNodeText(valueNode)
+			doc.Display.HomeUrlRange = doc.NodeToRange(valueNode)
+		case "source_url":
+			doc.Display.SourceUrl = doc.GetNodeText(valueNode)
+			doc.Display.SourceUrlRange = doc.NodeToRange(valueNode)
+		}
+	})
+}
+
+func (doc *YamlDocument) parseExamples(examplesNode *sitter.Node) {
+	doc.iterateOnBlockMapping(GetChildMapping(examplesNode), func(child *sitter.Node) {
+		keyNode, valueNode := doc.GetKeyValueNodes(child)
+		if keyNode == nil {
+			return
+		}
+
+		example := OrbExample{
+			Name:      doc.GetNodeText(keyNode),
+			NameRange: doc.NodeToRange(keyNode),
+			Range:     doc.NodeToRange(child),
+		}
+
+		doc.parseExampleAttributes(&example, GetChildMapping(valueNode))
+		doc.Examples[example.Name] = example
+	})
+}
+
+func (doc *YamlDocument) parseExampleAttributes(example *OrbExample, blockMappingNode *sitter.Node) {
+	doc.iterateOnBlockMapping(blockMappingNode, func(child *sitter.Node) {
+		keyNode, valueNode := doc.GetKeyValueNodes(child)
+		if keyNode == nil || valueNode == nil {
+			return
+		}
+
+		switch doc.GetNodeText(keyNode) {
+		case "description":
+			example.Description = doc.GetNodeText(valueNode)
+			example.DescriptionRange = doc.NodeToRange(valueNode)
+		case "usage":
+			example.UsageRange = doc.NodeToRange(valueNode)
+			example.Usage = doc.parseExampleUsage(valueNode)
+		}
+	})
+}
+
+// The usage of an example is parsed the same way as local orbs, as a
+// standalone config shifted to its position in the file
+func (doc *YamlDocument) parseExampleUsage(usageNode *sitter.Node) *YamlDocument {
+	if GetChildMapping(usageNode) == nil {
+		return nil
+	}
+
+	usageRange := doc.NodeToRange(usageNode)
+	usageContent := strings.Repeat(" ", int(usageNode.StartPoint().Column)) + doc.GetNodeText(usageNode)
+	usageDoc, err := ParseFromContent([]byte(usageContent), doc.Context, doc.URI, protocol.Position{
+		Line:      usageRange.Start.Line,
+		Character: 0,
+	})
+	if err != nil {
+		return nil
+	}
+
+	return &usageDoc
+}
+
+// GetOrbName guesses the name of the orb written in the document from its
+// path: the repository of `src/@orb.yml` or `dist/orb.yml`, or the file name.
+// The files of a source directory all take the name of their orb
+func (doc *YamlDocument) GetOrbName() string {
+	if doc.OrbSource != nil {
+		return doc.OrbSource.Name
+	}
+
+	if !strings.HasPrefix(string(doc.URI), uri.FileScheme+"://") {
+		return ""
+	}
+
+	filename := doc.URI.Filename()
+	name := path.Base(filename)
+	dir := path.Dir(filename)
+
+	for _, orbFileName := range orbFileNames {
+		if name == orbFileName {
+			if base := path.Base(dir); base == "src" || base == "dist" {
+				dir = path.Dir(dir)
+			}
+			return path.Base(dir)
+		}
+	}
+
+	return strings.TrimSuffix(name, path.Ext(name))
+}
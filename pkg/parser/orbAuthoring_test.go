@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestIsOrbAuthoringFile(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		URI      protocol.URI
+		Content  string
+		Expected bool
+	}{
+		{
+			Name:     "Root of the src layout",
+			URI:      uri.File("/repo/src/@orb.yml"),
+			Content:  "version: 2.1\n",
+			Expected: true,
+		},
+		{
+			Name:     "Packed orb",
+			URI:      uri.File("/repo/dist/orb.yml"),
+			Content:  "version: 2.1\n",
+			Expected: true,
+		},
+		{
+			Name:     "Marker in the header",
+			URI:      uri.File("/repo/my-orb.yml"),
+			Content:  "# Shared steps\n# orb\nversion: 2.1\n",
+			Expected: true,
+		},
+		{
+			Name:     "Marker after the header",
+			URI:      uri.File("/repo/.circleci/config.yml"),
+			Content:  "version: 2.1\n# orb\n",
+			Expected: false,
+		},
+		{
+			Name:     "Pipeline config",
+			URI:      uri.File("/repo/.circleci/config.yml"),
+			Content:  "version: 2.1\n",
+			Expected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expected, IsOrbAuthoringFile(tt.URI, []byte(tt.Content)))
+		})
+	}
+}
+
+func TestParseOrbAuthoringKeys(t *testing.T) {
+	content := `version: 2.1
+description: Greets people
+display:
+  home_url: https://example.com
+  source_url: https://github.com/org/greeter
+examples:
+  simple:
+    description: Say hello
+    usage:
+      version: 2.1
+      orbs:
+        greeter: org/greeter@1.0.0
+      workflows:
+        main:
+          jobs:
+            - greeter/greet
+`
+
+	doc, err := ParseFromContent([]byte(content), testHelpers.GetDefaultLsContext(), uri.File("/greeter/src/@orb.yml"), protocol.Position{})
+	assert.NoError(t, err)
+
+	assert.True(t, doc.IsOrb)
+	assert.Equal(t, "greeter", doc.GetOrbName())
+	assert.Equal(t, "https://github.com/org/greeter", doc.Display.SourceUrl)
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 3, Character: 12},
+		End:   protocol.Position{Line: 3, Character: 31},
+	}, doc.Display.HomeUrlRange)
+
+	example, ok := doc.Examples["simple"]
+	assert.True(t, ok)
+	assert.Equal(t, "Say hello", example.Description)
+	assert.NotNil(t, example.Usage)
+	assert.False(t, example.Usage.IsOrb)
+
+	invocation := example.Usage.Workflows["main"].JobInvocations[0]
+	assert.Equal(t, "greeter/greet", invocation.JobName)
+	assert.Equal(t, protocol.Position{Line: 15, Character: 14}, invocation.JobNameRange.Start)
+}
@@ -0,0 +1,587 @@
+/**
+ * The orb development kit splits the source of an orb in a `src/` directory:
+ *
+ *	src/@orb.yml          version, description, display and imported orbs
+ *	src/commands/*.yml    one command per file, named after the file
+ *	src/executors/*.yml   one executor per file
+ *	src/jobs/*.yml        one job per file
+ *	src/examples/*.yml    one example per file
+ *	src/scripts/*         scripts inlined with `<<include(scripts/file.sh)>>`
+ *
+ * `circleci orb pack` combines these files into a single orb.yml.
+ *
+ * Each file of the directory is parsed on its own: the root mapping of a
+ * command file is parsed as the body of the command, so that the ranges of
+ * the document are the ones of the file.
+ * The entities of all the files are gathered in an OrbSource, which is used
+ * to look up the entities defined in the other files of the directory.
+ */
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+const orbSourceRootFile = "@orb.yml"
+
+const (
+	OrbSourceCommands  = "commands"
+	OrbSourceExamples  = "examples"
+	OrbSourceExecutors = "executors"
+	OrbSourceJobs      = "jobs"
+)
+
+// In the order in which they are packed
+var orbSourceKinds = []string{OrbSourceCommands, OrbSourceExamples, OrbSourceExecutors, OrbSourceJobs}
+
+var orbSourceExtensions = []string{".yml", ".yaml"}
+
+// An OrbSourceFile is a file of a source directory defining a single entity.
+// Kind is the directory of the file and Name is the file name without its
+// extension
+type OrbSourceFile struct {
+	Kind string
+	Name string
+}
+
+// An OrbSource is the orb defined by a source directory
+type OrbSource struct {
+	// The entities of all the files of the directory, URI being the one of
+	// `@orb.yml`
+	ast.OrbParsedAttributes
+
+	Dir      string
+	Examples map[string]OrbExample
+
+	Root  YamlDocument
+	Files []YamlDocument
+}
+
+// An OrbInclude is a `<<include(path)>>` of a file of a source directory, its
+// path is relative to the directory
+type OrbInclude struct {
+	Path  string
+	Range protocol.Range
+}
+
+var orbIncludeRegex = regexp.MustCompile(`<<\s*include\(([^)]*)\)\s*>>`)
+
+// Matches a value made of an include, which is replaced by a block scalar
+// when packing
+var orbIncludeValueRegex = regexp.MustCompile(`(?m)^([ \t]*)(.*?)<<\s*include\(([^)]*)\)\s*>>[ \t]*$`)
+
+// GetOrbSourceDir returns the source directory containing the given file:
+// the directory of `@orb.yml`, or its parent for the files of its sub
+// directories
+func GetOrbSourceDir(URI protocol.URI) (string, bool) {
+	if !strings.HasPrefix(string(URI), uri.FileScheme+"://") {
+		return "", false
+	}
+
+	filename := URI.Filename()
+	dir := filepath.Dir(filename)
+	if filepath.Base(filename) != orbSourceRootFile {
+		if !slices.Contains(orbSourceKinds, filepath.Base(dir)) {
+			return "", false
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, orbSourceRootFile)); err != nil {
+		return "", false
+	}
+
+	return dir, true
+}
+
+// GetOrbSourceFile returns the entity defined by a file of a source
+// directory. ok is false for `@orb.yml` and the files outside of a source
+// directory
+func GetOrbSourceFile(URI protocol.URI) (file OrbSourceFile, ok bool) {
+	if _, ok := GetOrbSourceDir(URI); !ok {
+		return OrbSourceFile{}, false
+	}
+
+	filename := URI.Filename()
+	extension := filepath.Ext(filename)
+	if !slices.Contains(orbSourceExtensions, extension) {
+		return OrbSourceFile{}, false
+	}
+
+	kind := filepath.Base(filepath.Dir(filename))
+	if !slices.Contains(orbSourceKinds, kind) {
+		return OrbSourceFile{}, false
+	}
+
+	return OrbSourceFile{
+		Kind: kind,
+		Name: strings.TrimSuffix(filepath.Base(filename), extension),
+	}, true
+}
+
+// LoadOrbSource parses all the files of the source directory containing the
+// given file. The content of the files opened in the editor is taken from the
+// cache. Returns nil for files outside of a source directory
+func LoadOrbSource(URI protocol.URI, cache *utils.Cache, context *utils.LsContext) *OrbSource {
+	dir, ok := GetOrbSourceDir(URI)
+	if !ok {
+		return nil
+	}
+
+	root, err := parseOrbSourceFile(filepath.Join(dir, orbSourceRootFile), cache, context)
+	if err != nil {
+		return nil
+	}
+
+	src := &OrbSource{
+		OrbParsedAttributes: ast.OrbParsedAttributes{
+			URI:       root.URI,
+			Name:      root.GetOrbName(),
+			Commands:  map[string]ast.Command{},
+			Jobs:      map[string]ast.Job{},
+			Executors: map[string]ast.Executor{},
+		},
+		Dir:      dir,
+		Examples: map[string]OrbExample{},
+		Root:     root,
+		Files:    []YamlDocument{},
+	}
+	src.add(root)
+
+	for _, kind := range orbSourceKinds {
+		entries, err := os.ReadDir(filepath.Join(dir, kind))
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !slices.Contains(orbSourceExtensions, filepath.Ext(entry.Name())) {
+				continue
+			}
+
+			doc, err := parseOrbSourceFile(filepath.Join(dir, kind, entry.Name()), cache, context)
+			if err != nil {
+				continue
+			}
+
+			src.Files = append(src.Files, doc)
+			src.add(doc)
+		}
+	}
+
+	return src
+}
+
+// The sources are loaded once per directory, and dropped as soon as one of
+// their files changes, see InvalidateOrbSources
+type orbSourceCache struct {
+	mutex sync.Mutex
+	// Incremented on each invalidation, a source loaded meanwhile may be
+	// outdated and is not kept
+	generation uint64
+	sources    map[string]cachedOrbSource
+}
+
+type cachedOrbSource struct {
+	context *utils.LsContext
+	src     *OrbSource
+}
+
+var orbSources = orbSourceCache{sources: make(map[string]cachedOrbSource)}
+
+// GetOrbSource returns the source directory containing the given file, see
+// LoadOrbSource. It is only loaded again once one of its files changed
+func GetOrbSource(URI protocol.URI, cache *utils.Cache, context *utils.LsContext) *OrbSource {
+	dir, ok := GetOrbSourceDir(URI)
+	if !ok {
+		return nil
+	}
+
+	orbSources.mutex.Lock()
+	cached, ok := orbSources.sources[dir]
+	generation := orbSources.generation
+	orbSources.mutex.Unlock()
+	if ok && cached.context == context {
+		return cached.src
+	}
+
+	src := LoadOrbSource(URI, cache, context)
+	if src == nil {
+		return nil
+	}
+
+	orbSources.mutex.Lock()
+	defer orbSources.mutex.Unlock()
+	if orbSources.generation == generation {
+		orbSources.sources[dir] = cachedOrbSource{context: context, src: src}
+	}
+
+	return src
+}
+
+// InvalidateOrbSources drops the loaded source directories containing the
+// file, to be called when the file is opened, changed, closed or changes on
+// disk
+func InvalidateOrbSources(URI protocol.URI) {
+	if !strings.HasPrefix(string(URI), uri.FileScheme+"://") {
+		return
+	}
+	filename := URI.Filename()
+
+	orbSources.mutex.Lock()
+	defer orbSources.mutex.Unlock()
+
+	orbSources.generation++
+	for dir := range orbSources.sources {
+		if isWithinDirectory(dir, filename) {
+			delete(orbSources.sources, dir)
+		}
+	}
+}
+
+func parseOrbSourceFile(filename string, cache *utils.Cache, context *utils.LsContext) (YamlDocument, error) {
+	URI := uri.File(filename)
+
+	if cache != nil {
+		if cachedFile := cache.FileCache.GetFile(URI); cachedFile != nil {
+			return ParseFromContent([]byte(cachedFile.TextDocument.Text), context, URI, protocol.Position{})
+		}
+	}
+
+	return ParseFromURI(URI, context)
+}
+
+// The first definition of an entity wins, `@orb.yml` being parsed first
+func (src *OrbSource) add(doc YamlDocument) {
+	for name, command := range doc.Commands {
+		if _, ok := src.Commands[name]; !ok {
+			src.Commands[name] = command
+		}
+	}
+
+	for name, job := range doc.Jobs {
+		if _, ok := src.Jobs[name]; !ok {
+			src.Jobs[name] = job
+		}
+	}
+
+	for name, executor := range doc.Executors {
+		if _, ok := src.Executors[name]; !ok {
+			src.Executors[name] = executor
+		}
+	}
+
+	for name, example := range doc.Examples {
+		if _, ok := src.Examples[name]; !ok {
+			src.Examples[name] = example
+		}
+	}
+}
+
+// Documents returns the parsed files of the directory, `@orb.yml` first
+func (src *OrbSource) Documents() []YamlDocument {
+	return append([]YamlDocument{src.Root}, src.Files...)
+}
+
+// GetDefinitionDocument returns the file defining the command, job or
+// executor of the given kind and name
+func (src *OrbSource) GetDefinitionDocument(kind string, name string) (YamlDocument, bool) {
+	if src == nil {
+		return YamlDocument{}, false
+	}
+
+	for _, doc := range src.Documents() {
+		ok := false
+		switch kind {
+		case OrbSourceCommands:
+			_, ok = doc.Commands[name]
+		case OrbSourceJobs:
+			_, ok = doc.Jobs[name]
+		case OrbSourceExecutors:
+			_, ok = doc.Executors[name]
+		case OrbSourceExamples:
+			_, ok = doc.Examples[name]
+		}
+
+		if ok {
+			return doc, true
+		}
+	}
+
+	return YamlDocument{}, false
+}
+
+// Parses a file of a source directory, whose root mapping is the body of the
+// entity it defines
+func (doc *YamlDocument) parseOrbSourceEntity(blockMappingNode *sitter.Node) {
+	name := doc.OrbSourceFile.Name
+	rng := doc.NodeToRange(doc.RootNode)
+
+	switch doc.OrbSourceFile.Kind {
+	case OrbSourceCommands:
+		command := ast.Command{Name: name, Range: rng, Contexts: &[]string{}, Parameters: map[string]ast.Parameter{}}
+		doc.parseCommandAttributes(&command, blockMappingNode)
+		doc.Commands[name] = command
+		doc.CommandsRange = rng
+
+	case OrbSourceJobs:
+		job := newJob()
+		job.Name = name
+		job.Range = rng
+		doc.parseJobAttributes(&job, blockMappingNode)
+		doc.Jobs[name] = job
+		doc.JobsRange = rng
+
+	case OrbSourceExecutors:
+		doc.Executors[name] = renameExecutor(doc.parseExecutorDefinition(nil, blockMappingNode), name)
+		doc.ExecutorsRange = rng
+
+	case OrbSourceExamples:
+		example := OrbExample{Name: name, Range: rng}
+		doc.parseExampleAttributes(&example, blockMappingNode)
+		doc.Examples[name] = example
+		doc.ExamplesRange = rng
+	}
+}
+
+// Executors defined in their own file are named after the file
+func renameExecutor(executor ast.Executor, name string) ast.Executor {
+	switch executor := executor.(type) {
+	case ast.DockerExecutor:
+		executor.Name = name
+		return executor
+	case ast.MachineExecutor:
+		executor.Name = name
+		return executor
+	case ast.MacOSExecutor:
+		executor.Name = name
+		return executor
+	case ast.BaseExecutor:
+		executor.Name = name
+		return executor
+	}
+
+	return executor
+}
+
+// GetOrbIncludes returns the `<<include(path)>>` of the document
+func (doc *YamlDocument) GetOrbIncludes() []OrbInclude {
+	res := []OrbInclude{}
+
+	for _, match := range orbIncludeRegex.FindAllSubmatchIndex(doc.Content, -1) {
+		start, end := match[2], match[3]
+		value := string(doc.Content[start:end])
+		trimmed := strings.TrimSpace(value)
+		start += strings.Index(value, trimmed)
+		end = start + len(trimmed)
+
+		res = append(res, OrbInclude{
+			Path: trimmed,
+			Range: utils.AddOffsetToRange(protocol.Range{
+				Start: utils.IndexToPos(start, doc.Content),
+				End:   utils.IndexToPos(end, doc.Content),
+			}, doc.Offset),
+		})
+	}
+
+	return res
+}
+
+// GetOrbIncludeAt returns the include whose path is at the given position
+func (doc *YamlDocument) GetOrbIncludeAt(position protocol.Position) (OrbInclude, bool) {
+	for _, include := range doc.GetOrbIncludes() {
+		if utils.PosInRange(include.Range, position) {
+			return include, true
+		}
+	}
+
+	return OrbInclude{}, false
+}
+
+// ErrOrbIncludeOutside is returned for the includes of files outside of the
+// orb source directory, which are not packed with the orb
+var ErrOrbIncludeOutside = errors.New("the included file is outside of the orb source directory")
+
+// ResolveOrbInclude returns the path on disk of an included file. ok is false
+// when the file does not exist, is outside of the source directory or the
+// document is not in a source directory
+func (doc *YamlDocument) ResolveOrbInclude(include OrbInclude) (filename string, ok bool) {
+	if doc.OrbSource == nil || include.Path == "" {
+		return "", false
+	}
+
+	filename, err := resolveOrbIncludePath(doc.OrbSource.Dir, include.Path)
+	return filename, err == nil
+}
+
+// OrbIncludeError returns why the include can't be resolved, nil when it can
+func (doc *YamlDocument) OrbIncludeError(include OrbInclude) error {
+	if doc.OrbSource == nil {
+		return nil
+	}
+
+	_, err := resolveOrbIncludePath(doc.OrbSource.Dir, include.Path)
+	return err
+}
+
+// Returns the path on disk of the file included from the source directory,
+// rejecting the paths and the symbolic links leading out of the directory
+func resolveOrbIncludePath(dir string, includePath string) (string, error) {
+	filename := filepath.Join(dir, filepath.FromSlash(includePath))
+	if includePath == "" || !isWithinDirectory(dir, filename) {
+		return "", ErrOrbIncludeOutside
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return filename, err
+	}
+	if info.IsDir() {
+		return filename, fmt.Errorf("%s is a directory", includePath)
+	}
+
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	realFilename, err := filepath.EvalSymlinks(filename)
+	if err != nil || !isWithinDirectory(realDir, realFilename) {
+		return "", ErrOrbIncludeOutside
+	}
+
+	return filename, nil
+}
+
+// Pack combines the files of the source directory into a single orb, as done
+// by `circleci orb pack`: the files of each sub directory are added under the
+// key of the directory, named after the file, and the includes are replaced
+// by the content of the included files
+func (src *OrbSource) Pack() (string, error) {
+	var builder strings.Builder
+	builder.WriteString(strings.TrimRight(string(src.Root.Content), "\n") + "\n")
+
+	for _, kind := range orbSourceKinds {
+		files := []YamlDocument{}
+		for _, file := range src.Files {
+			if file.OrbSourceFile.Kind == kind {
+				files = append(files, file)
+			}
+		}
+
+		if len(files) == 0 {
+			continue
+		}
+
+		builder.WriteString("\n" + kind + ":\n")
+		for _, file := range files {
+			builder.WriteString("  " + file.OrbSourceFile.Name + ":\n")
+			builder.WriteString(indentLines(string(file.Content), "    "))
+		}
+	}
+
+	return src.inlineIncludes(builder.String())
+}
+
+func (src *OrbSource) inlineIncludes(content string) (string, error) {
+	var builder strings.Builder
+	last := 0
+
+	for _, match := range orbIncludeValueRegex.FindAllStringSubmatchIndex(content, -1) {
+		indent := content[match[2]:match[3]]
+		prefix := content[match[4]:match[5]]
+		includePath := strings.TrimSpace(content[match[6]:match[7]])
+
+		filename, err := resolveOrbIncludePath(src.Dir, includePath)
+		if err != nil {
+			return "", fmt.Errorf("could not include %s: %w", includePath, err)
+		}
+
+		included, err := os.ReadFile(filename)
+		if err != nil {
+			return "", fmt.Errorf("could not include %s: %w", includePath, err)
+		}
+
+		// The content has to be indented deeper than the key, which comes
+		// after the dashes of the sequences the value is in
+		keyIndent := len(indent) + len(prefix) - len(strings.TrimLeft(prefix, "- "))
+
+		builder.WriteString(content[last:match[0]])
+		builder.WriteString(indent + prefix + "|\n")
+		builder.WriteString(strings.TrimSuffix(indentLines(string(included), strings.Repeat(" ", keyIndent+2)), "\n"))
+		last = match[1]
+	}
+
+	builder.WriteString(content[last:])
+	return builder.String(), nil
+}
+
+// UnpackOrb splits a packed orb into the files of a source directory. The
+// keys of the returned map are paths relative to the directory. The comments
+// between the entities are not kept
+func (doc *YamlDocument) UnpackOrb() map[string]string {
+	files := map[string]string{}
+	root := []string{}
+
+	doc.iterateOnBlockMapping(GetBlockMappingNode(doc.RootNode), func(child *sitter.Node) {
+		keyNode, valueNode := doc.GetKeyValueNodes(child)
+		kind := doc.GetNodeText(keyNode)
+
+		if slices.Contains(orbSourceKinds, kind) && GetChildMapping(valueNode) != nil {
+			doc.iterateOnBlockMapping(GetChildMapping(valueNode), func(entity *sitter.Node) {
+				nameNode, bodyNode := doc.GetKeyValueNodes(entity)
+				if nameNode == nil || bodyNode == nil {
+					return
+				}
+
+				files[path.Join(kind, doc.GetNodeText(nameNode)+".yml")] = doc.getDedentedNodeText(bodyNode)
+			})
+			return
+		}
+
+		root = append(root, doc.getDedentedNodeText(child))
+	})
+
+	files[orbSourceRootFile] = strings.Join(root, "")
+	return files
+}
+
+// Returns the text of a node as if it started at the beginning of its line
+func (doc *YamlDocument) getDedentedNodeText(node *sitter.Node) string {
+	column := int(node.StartPoint().Column)
+	lines := strings.Split(doc.GetRawNodeText(node), "\n")
+
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		lines[i] = lines[i][min(column, len(lines[i])-len(trimmed)):]
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+func indentLines(text string, indent string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		} else {
+			lines[i] = ""
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,287 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func createOrbSourceDir(t *testing.T, files map[string]string) string {
+	dir := filepath.Join(t.TempDir(), "greeter", "src")
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return dir
+}
+
+var orbSourceFiles = map[string]string{
+	"@orb.yml": "version: 2.1\ndescription: Greets people\n",
+	"commands/greet.yml": `description: Says hello
+parameters:
+  to:
+    type: string
+steps:
+  - run:
+      name: Greet
+      command: <<include(scripts/greet.sh)>>
+`,
+	"jobs/hello.yml": `executor: default
+steps:
+  - greet:
+      to: world
+`,
+	"executors/default.yml": `docker:
+  - image: cimg/base:stable
+`,
+	"examples/simple.yml": `description: Greets the world
+usage:
+  version: 2.1
+  orbs:
+    greeter: company/greeter@1.0.0
+  workflows:
+    main:
+      jobs:
+        - greeter/hello
+`,
+	"scripts/greet.sh": "#!/bin/bash\necho \"Hello $TO\"\n",
+}
+
+func TestGetOrbSourceFile(t *testing.T) {
+	dir := createOrbSourceDir(t, orbSourceFiles)
+
+	file, ok := GetOrbSourceFile(uri.File(filepath.Join(dir, "commands", "greet.yml")))
+	assert.True(t, ok)
+	assert.Equal(t, OrbSourceFile{Kind: OrbSourceCommands, Name: "greet"}, file)
+
+	_, ok = GetOrbSourceFile(uri.File(filepath.Join(dir, "@orb.yml")))
+	assert.False(t, ok)
+
+	_, ok = GetOrbSourceFile(uri.File(filepath.Join(dir, "scripts", "greet.sh")))
+	assert.False(t, ok)
+
+	_, ok = GetOrbSourceFile(uri.File(filepath.Join(t.TempDir(), "commands", "greet.yml")))
+	assert.False(t, ok)
+}
+
+func TestParseOrbSourceFile(t *testing.T) {
+	dir := createOrbSourceDir(t, orbSourceFiles)
+
+	doc, err := ParseFromURI(uri.File(filepath.Join(dir, "commands", "greet.yml")), testHelpers.GetDefaultLsContext())
+	assert.NoError(t, err)
+	assert.True(t, doc.IsOrb)
+
+	command, ok := doc.Commands["greet"]
+	assert.True(t, ok)
+	assert.Equal(t, "Says hello", command.Description)
+	assert.Contains(t, command.Parameters, "to")
+	assert.Len(t, command.Steps, 1)
+	assert.Equal(t, protocol.Position{Line: 5, Character: 4}, command.Steps[0].GetRange().Start)
+
+	doc, err = ParseFromURI(uri.File(filepath.Join(dir, "executors", "default.yml")), testHelpers.GetDefaultLsContext())
+	assert.NoError(t, err)
+	assert.Equal(t, "default", doc.Executors["default"].GetName())
+}
+
+func TestLoadOrbSource(t *testing.T) {
+	dir := createOrbSourceDir(t, orbSourceFiles)
+	cache := utils.CreateCache()
+
+	// Opened files are read from the cache
+	jobURI := uri.File(filepath.Join(dir, "jobs", "hello.yml"))
+	cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{
+			URI:  jobURI,
+			Text: "description: Says hello\nexecutor: default\nsteps:\n  - greet\n",
+		},
+	})
+
+	src := LoadOrbSource(jobURI, cache, testHelpers.GetDefaultLsContext())
+	assert.NotNil(t, src)
+	assert.Equal(t, "greeter", src.Name)
+	assert.Equal(t, dir, src.Dir)
+	assert.Contains(t, src.Commands, "greet")
+	assert.Contains(t, src.Executors, "default")
+	assert.Contains(t, src.Examples, "simple")
+	assert.Equal(t, "Says hello", src.Jobs["hello"].Description)
+
+	doc, ok := src.GetDefinitionDocument(OrbSourceCommands, "greet")
+	assert.True(t, ok)
+	assert.Equal(t, uri.File(filepath.Join(dir, "commands", "greet.yml")), doc.URI)
+
+	assert.Nil(t, LoadOrbSource(uri.File(filepath.Join(t.TempDir(), "config.yml")), cache, testHelpers.GetDefaultLsContext()))
+}
+
+func TestGetOrbSource(t *testing.T) {
+	dir := createOrbSourceDir(t, orbSourceFiles)
+	cache := utils.CreateCache()
+	context := testHelpers.GetDefaultLsContext()
+	jobURI := uri.File(filepath.Join(dir, "jobs", "hello.yml"))
+
+	src := GetOrbSource(jobURI, cache, context)
+	assert.NotNil(t, src)
+	assert.Same(t, src, GetOrbSource(uri.File(filepath.Join(dir, "@orb.yml")), cache, context))
+
+	// The files of other directories do not drop the source
+	InvalidateOrbSources(uri.File(filepath.Join(t.TempDir(), "commands", "greet.yml")))
+	assert.Same(t, src, GetOrbSource(jobURI, cache, context))
+
+	commandURI := uri.File(filepath.Join(dir, "commands", "wave.yml"))
+	assert.NoError(t, os.WriteFile(commandURI.Filename(), []byte("steps:\n  - run: echo wave\n"), 0o644))
+	InvalidateOrbSources(commandURI)
+
+	reloaded := GetOrbSource(jobURI, cache, context)
+	assert.NotSame(t, src, reloaded)
+	assert.Contains(t, reloaded.Commands, "wave")
+}
+
+func TestOrbIncludes(t *testing.T) {
+	dir := createOrbSourceDir(t, orbSourceFiles)
+	URI := uri.File(filepath.Join(dir, "commands", "greet.yml"))
+
+	doc, err := ParseFromURI(URI, testHelpers.GetDefaultLsContext())
+	assert.NoError(t, err)
+	doc.OrbSource = LoadOrbSource(URI, nil, testHelpers.GetDefaultLsContext())
+
+	includes := doc.GetOrbIncludes()
+	assert.Equal(t, []OrbInclude{
+		{
+			Path: "scripts/greet.sh",
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 7, Character: 25},
+				End:   protocol.Position{Line: 7, Character: 41},
+			},
+		},
+	}, includes)
+
+	path, ok := doc.ResolveOrbInclude(includes[0])
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "scripts", "greet.sh"), path)
+
+	_, ok = doc.ResolveOrbInclude(OrbInclude{Path: "scripts/missing.sh"})
+	assert.False(t, ok)
+	assert.Error(t, doc.OrbIncludeError(OrbInclude{Path: "scripts/missing.sh"}))
+	assert.NotErrorIs(t, doc.OrbIncludeError(OrbInclude{Path: "scripts/missing.sh"}), ErrOrbIncludeOutside)
+
+	// Files outside of the source directory, directly or through a symbolic
+	// link, are not resolved
+	outside := filepath.Join(filepath.Dir(dir), "outside.sh")
+	assert.NoError(t, os.WriteFile(outside, []byte("echo outside\n"), 0o644))
+	assert.NoError(t, os.Symlink(outside, filepath.Join(dir, "scripts", "link.sh")))
+
+	for _, includePath := range []string{"../outside.sh", "scripts/../../outside.sh", "scripts/link.sh"} {
+		_, ok = doc.ResolveOrbInclude(OrbInclude{Path: includePath})
+		assert.False(t, ok, includePath)
+		assert.ErrorIs(t, doc.OrbIncludeError(OrbInclude{Path: includePath}), ErrOrbIncludeOutside, includePath)
+	}
+}
+
+func TestPackOrbSource(t *testing.T) {
+	dir := createOrbSourceDir(t, orbSourceFiles)
+	src := LoadOrbSource(uri.File(filepath.Join(dir, "@orb.yml")), nil, testHelpers.GetDefaultLsContext())
+
+	packed, err := src.Pack()
+	assert.NoError(t, err)
+	assert.Equal(t, `version: 2.1
+description: Greets people
+
+commands:
+  greet:
+    description: Says hello
+    parameters:
+      to:
+        type: string
+    steps:
+      - run:
+          name: Greet
+          command: |
+            #!/bin/bash
+            echo "Hello $TO"
+
+examples:
+  simple:
+    description: Greets the world
+    usage:
+      version: 2.1
+      orbs:
+        greeter: company/greeter@1.0.0
+      workflows:
+        main:
+          jobs:
+            - greeter/hello
+
+executors:
+  default:
+    docker:
+      - image: cimg/base:stable
+
+jobs:
+  hello:
+    executor: default
+    steps:
+      - greet:
+          to: world
+`, packed)
+
+	// The packed orb is a valid orb
+	doc, err := ParseFromContent([]byte(packed), testHelpers.GetDefaultLsContext(), uri.File(""), protocol.Position{})
+	assert.NoError(t, err)
+	assert.Empty(t, *doc.Diagnostics)
+	assert.Contains(t, doc.Commands, "greet")
+	assert.Contains(t, doc.Jobs, "hello")
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "scripts", "greet.sh")))
+	_, err = src.Pack()
+	assert.Error(t, err)
+}
+
+func TestPackOrbSourceIncludeOutside(t *testing.T) {
+	files := map[string]string{}
+	for name, content := range orbSourceFiles {
+		files[name] = content
+	}
+	files["commands/greet.yml"] = "steps:\n  - run: <<include(../outside.sh)>>\n"
+
+	dir := createOrbSourceDir(t, files)
+	assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(dir), "outside.sh"), []byte("echo outside\n"), 0o644))
+	src := LoadOrbSource(uri.File(filepath.Join(dir, "@orb.yml")), nil, testHelpers.GetDefaultLsContext())
+
+	_, err := src.Pack()
+	assert.ErrorIs(t, err, ErrOrbIncludeOutside)
+}
+
+func TestUnpackOrb(t *testing.T) {
+	content := `version: 2.1
+description: Greets people
+
+commands:
+  greet:
+    steps:
+      - run: echo hello
+
+jobs:
+  hello:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - greet
+`
+
+	doc, err := ParseFromContent([]byte(content), testHelpers.GetDefaultLsContext(), uri.File(""), protocol.Position{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"@orb.yml":           "version: 2.1\ndescription: Greets people\n",
+		"commands/greet.yml": "steps:\n  - run: echo hello\n",
+		"jobs/hello.yml":     "docker:\n  - image: cimg/base:stable\nsteps:\n  - greet\n",
+	}, doc.UnpackOrb())
+}
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"context"
 	"strings"
+	"sync"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
@@ -10,6 +12,7 @@ import (
 )
 
 var simpleOrbExistanceCache = make(map[string]bool)
+var simpleOrbExistanceCacheMutex sync.Mutex
 
 func (doc *YamlDocument) GetOrbInfoFromName(name string, cache *utils.Cache) (*ast.OrbInfo, error) {
 	// Searching within local orbs
@@ -28,6 +31,12 @@ func (doc *YamlDocument) GetOrbInfoFromName(name string, cache *utils.Cache) (*a
 }
 
 func (doc *YamlDocument) GetOrFetchOrbInfo(orb ast.Orb, cache *utils.Cache) (*ast.OrbInfo, error) {
+	return doc.GetOrFetchOrbInfoWithContext(context.Background(), orb, cache)
+}
+
+// GetOrFetchOrbInfoWithContext is like GetOrFetchOrbInfo but the fetch of the
+// orb is aborted when ctx is done
+func (doc *YamlDocument) GetOrFetchOrbInfoWithContext(ctx context.Context, orb ast.Orb, cache *utils.Cache) (*ast.OrbInfo, error) {
 	// Searching within local orbs
 	orbInfo, ok := doc.LocalOrbInfo[orb.Name]
 	if ok {
@@ -44,7 +53,7 @@ func (doc *YamlDocument) GetOrFetchOrbInfo(orb ast.Orb, cache *utils.Cache) (*as
 
 	// Trying to fetch if not found
 	var err error
-	orbInfo, err = GetOrbInfo(orbId, cache, doc.Context)
+	orbInfo, err = GetOrbInfoWithContext(ctx, orbId, cache, doc.Context)
 
 	if err != nil {
 		return &ast.OrbInfo{}, err
@@ -53,18 +62,30 @@ func (doc *YamlDocument) GetOrFetchOrbInfo(orb ast.Orb, cache *utils.Cache) (*as
 	return orbInfo, nil
 }
 
-func (doc *YamlDocument) DoesOrbExist(orb ast.Orb, cache *utils.Cache) bool {
+func (doc *YamlDocument) DoesOrbExist(ctx context.Context, orb ast.Orb, cache *utils.Cache) bool {
 	lookup := orb.Url.Name
+
+	simpleOrbExistanceCacheMutex.Lock()
 	exists, inMap := simpleOrbExistanceCache[lookup]
+	simpleOrbExistanceCacheMutex.Unlock()
 
 	if inMap {
 		return exists
 	}
 
-	fetchedOrb, err := GetOrbByName(lookup, doc.Context)
-	simpleOrbExistanceCache[lookup] = err == nil && fetchedOrb.Name != ""
+	fetchedOrb, err := GetOrbByNameWithContext(ctx, lookup, doc.Context)
+	exists = err == nil && fetchedOrb.Name != ""
+
+	// An aborted request says nothing about the orb
+	if ctx.Err() != nil {
+		return true
+	}
+
+	simpleOrbExistanceCacheMutex.Lock()
+	simpleOrbExistanceCache[lookup] = exists
+	simpleOrbExistanceCacheMutex.Unlock()
 
-	return simpleOrbExistanceCache[lookup]
+	return exists
 }
 
 func (doc *YamlDocument) parseOrbs(orbsNode *sitter.Node) {
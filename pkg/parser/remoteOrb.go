@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -37,23 +38,35 @@ type OrbQuery struct {
 	Source string
 }
 
-func GetOrbInfo(orbVersionCode string, cache *utils.Cache, context *utils.LsContext) (*ast.OrbInfo, error) {
+func GetOrbInfo(orbVersionCode string, cache *utils.Cache, lsContext *utils.LsContext) (*ast.OrbInfo, error) {
+	return GetOrbInfoWithContext(context.Background(), orbVersionCode, cache, lsContext)
+}
+
+// GetOrbInfoWithContext is like GetOrbInfo but the fetch of the orb is aborted
+// when ctx is done
+func GetOrbInfoWithContext(ctx context.Context, orbVersionCode string, cache *utils.Cache, lsContext *utils.LsContext) (*ast.OrbInfo, error) {
 	// Returning cache if exists
 	if !cache.OrbCache.HasOrb(orbVersionCode) {
 
-		orb, err := fetchOrbInfo(orbVersionCode, cache, context)
+		orb, err := fetchOrbInfo(ctx, orbVersionCode, cache, lsContext)
 		return orb, err
 	}
 
 	return cache.OrbCache.GetOrb(orbVersionCode), nil
 }
 
-func GetOrbByName(orbName string, context *utils.LsContext) (OrbGQLData, error) {
-	if context.Api.HostUrl == "" {
+func GetOrbByName(orbName string, lsContext *utils.LsContext) (OrbGQLData, error) {
+	return GetOrbByNameWithContext(context.Background(), orbName, lsContext)
+}
+
+// GetOrbByNameWithContext is like GetOrbByName but the request is aborted
+// when ctx is done
+func GetOrbByNameWithContext(ctx context.Context, orbName string, lsContext *utils.LsContext) (OrbGQLData, error) {
+	if lsContext.Api.HostUrl == "" {
 		return OrbGQLData{}, errors.New("host URL not defined")
 	}
 
-	client := utils.NewClient(context.Api.HostUrl, "graphql-unstable", context.Api.Token, false)
+	client := utils.NewClient(lsContext.Api.HostUrl, "graphql-unstable", lsContext.Api.Token, false)
 	query := `
 		query($orbName: String!) {
 			orb(name: $orbName) {
@@ -65,11 +78,11 @@ func GetOrbByName(orbName string, context *utils.LsContext) (OrbGQLData, error)
 
 	request := utils.NewRequest(query)
 	request.SetToken(client.Token)
-	request.SetUserId(context.UserIdForTelemetry)
+	request.SetUserId(lsContext.UserIdForTelemetry)
 	request.Var("orbName", orbName)
 
 	var response OrbByNameResponse
-	err := client.Run(request, &response)
+	err := client.RunWithContext(ctx, request, &response)
 
 	if err != nil {
 		return OrbGQLData{}, err
@@ -82,14 +95,18 @@ func GetOrbByName(orbName string, context *utils.LsContext) (OrbGQLData, error)
 	return response.Orb, nil
 }
 
-func ParseRemoteOrbs(orbs map[string]ast.Orb, cache *utils.Cache, context *utils.LsContext) {
+func ParseRemoteOrbs(ctx context.Context, orbs map[string]ast.Orb, cache *utils.Cache, lsContext *utils.LsContext) {
 	for _, orb := range orbs {
+		if ctx.Err() != nil {
+			return
+		}
+
 		if orb.Url.IsLocal {
 			continue
 		}
 
 		if orb.Url.Version != "volatile" && checkIfRemoteOrbAlreadyExistsInFSCache(orb.Url.GetOrbID()) {
-			err := addAlreadyExistingRemoteOrbsToFSCache(orb, cache, context)
+			err := addAlreadyExistingRemoteOrbsToFSCache(ctx, orb, cache, lsContext)
 
 			// If no error, we continue
 			// Otherwise, we fetch again orb info
@@ -98,18 +115,18 @@ func ParseRemoteOrbs(orbs map[string]ast.Orb, cache *utils.Cache, context *utils
 			}
 		}
 
-		fetchOrbInfo(orb.Url.GetOrbID(), cache, context)
+		fetchOrbInfo(ctx, orb.Url.GetOrbID(), cache, lsContext)
 	}
 }
 
-func fetchOrbInfo(orbVersionCode string, cache *utils.Cache, context *utils.LsContext) (*ast.OrbInfo, error) {
-	orbQuery, err := GetRemoteOrb(orbVersionCode, context.Api.Token, context.Api.HostUrl, context.UserIdForTelemetry)
+func fetchOrbInfo(ctx context.Context, orbVersionCode string, cache *utils.Cache, lsContext *utils.LsContext) (*ast.OrbInfo, error) {
+	orbQuery, err := GetRemoteOrb(ctx, orbVersionCode, lsContext.Api.Token, lsContext.Api.HostUrl, lsContext.UserIdForTelemetry)
 
 	if err != nil {
 		return &ast.OrbInfo{}, err
 	}
 
-	parsedOrbSource, err := ParseFromContent([]byte(orbQuery.Source), context, uri.File(""), protocol.Position{})
+	parsedOrbSource, err := ParseFromContent([]byte(orbQuery.Source), lsContext, uri.File(""), protocol.Position{})
 
 	if err != nil {
 		return &ast.OrbInfo{}, err
@@ -198,7 +215,7 @@ func GetVersionInfo(
 	return latest, latestMinor, latestPatch
 }
 
-func GetRemoteOrb(orbId string, token string, hostUrl, userId string) (OrbQuery, error) {
+func GetRemoteOrb(ctx context.Context, orbId string, token string, hostUrl, userId string) (OrbQuery, error) {
 	if hostUrl == "" {
 		return OrbQuery{}, errors.New("host URL not defined")
 	}
@@ -224,7 +241,7 @@ func GetRemoteOrb(orbId string, token string, hostUrl, userId string) (OrbQuery,
 	request.Var("orbVersionRef", orbId)
 
 	var response OrbResponse
-	err := client.Run(request, &response)
+	err := client.RunWithContext(ctx, request, &response)
 
 	if response.OrbVersion.Id == "" {
 		return response.OrbVersion, fmt.Errorf("could not find orb %s", orbId)
@@ -233,7 +250,7 @@ func GetRemoteOrb(orbId string, token string, hostUrl, userId string) (OrbQuery,
 	return response.OrbVersion, err
 }
 
-func GetOrbVersions(orbId string, token string, hostUrl, userId string) ([]struct{ Version string }, error) {
+func GetOrbVersions(ctx context.Context, orbId string, token string, hostUrl, userId string) ([]struct{ Version string }, error) {
 	if hostUrl == "" {
 		emptyList := make([]struct{ Version string }, 0)
 		return emptyList, fmt.Errorf("host URL not defined")
@@ -252,7 +269,7 @@ func GetOrbVersions(orbId string, token string, hostUrl, userId string) ([]struc
 	request.Var("orbVersionRef", orbId)
 
 	var response OrbResponse
-	err := client.Run(request, &response)
+	err := client.RunWithContext(ctx, request, &response)
 
 	if response.OrbVersion.Id == "" {
 		emptyList := make([]struct{ Version string }, 0)
@@ -267,7 +284,7 @@ func writeRemoteOrbSourceInFSCache(orbYaml string, source string) (string, error
 	_, err := os.Stat(filePath)
 
 	if errors.Is(err, os.ErrNotExist) {
-		fmt.Fprintln(os.Stderr, "Writing remote orb source in cache:", filePath)
+		utils.Logger.Debug("writing remote orb source in cache", "path", filePath)
 
 		err = os.WriteFile(filePath, []byte(source), 0644)
 		return filePath, err
@@ -284,12 +301,12 @@ func checkIfRemoteOrbAlreadyExistsInFSCache(orbYaml string) bool {
 	return err == nil
 }
 
-func addAlreadyExistingRemoteOrbsToFSCache(orb ast.Orb, cache *utils.Cache, context *utils.LsContext) error {
+func addAlreadyExistingRemoteOrbsToFSCache(ctx context.Context, orb ast.Orb, cache *utils.Cache, lsContext *utils.LsContext) error {
 	filePath := utils.GetOrbCacheFSPath(orb.Url.GetOrbID())
 
 	content, err := os.ReadFile(filePath)
 
-	AddOrbToCacheWithContent(orb, uri.File(filePath), content, context, cache)
+	AddOrbToCacheWithContent(ctx, orb, uri.File(filePath), content, lsContext, cache)
 
 	if err != nil {
 		return err
@@ -298,14 +315,14 @@ func addAlreadyExistingRemoteOrbsToFSCache(orb ast.Orb, cache *utils.Cache, cont
 	return nil
 }
 
-func AddOrbToCacheWithContent(orb ast.Orb, uri protocol.URI, content []byte, context *utils.LsContext, cache *utils.Cache) error {
-	parsedOrbSource, err := ParseFromContent(content, context, uri, protocol.Position{})
+func AddOrbToCacheWithContent(ctx context.Context, orb ast.Orb, uri protocol.URI, content []byte, context *utils.LsContext, cache *utils.Cache) error {
+	parsedOrbSource, err := ParseFromContentWithContext(ctx, content, context, uri, protocol.Position{})
 
 	if err != nil {
 		return err
 	}
 
-	versions, err := GetOrbVersions(orb.Url.GetOrbID(), context.Api.Token, context.Api.HostUrl, context.UserIdForTelemetry)
+	versions, err := GetOrbVersions(ctx, orb.Url.GetOrbID(), context.Api.Token, context.Api.HostUrl, context.UserIdForTelemetry)
 
 	if err != nil {
 		return nil
@@ -0,0 +1,319 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"go.lsp.dev/protocol"
+)
+
+// RunScript is the shell script of a `run` step once decoded from its YAML
+// scalar, along with the document position of every byte of the script so
+// that anything found in the script can be reported at the right place
+type RunScript struct {
+	Text      string
+	positions []protocol.Position
+}
+
+// The `<< ... >>` of CircleCI: parameters, pipeline values, conditional
+// sections such as `<<# parameters.x >>` and `<<include(path)>>`. The content
+// must have a dot or parentheses not to be mistaken with a here-document such
+// as `cat <<EOF >>log`
+var interpolationRegex = regexp.MustCompile(`<<\s*[#^/]?\s*(\w[\w-]*\.[\w.-]+|\w+\([^)]*\))\s*>>`)
+
+// GetRunScript decodes the command of a run step, whether it is written as a
+// plain, quoted or block scalar
+func GetRunScript(step ast.Run) RunScript {
+	raw := step.RawCommand
+
+	rawPositions := make([]protocol.Position, len(raw)+1)
+	line, character := step.CommandRange.Start.Line, step.CommandRange.Start.Character
+	for i := 0; i < len(raw); i++ {
+		rawPositions[i] = protocol.Position{Line: line, Character: character}
+		if raw[i] == '\n' {
+			line++
+			character = 0
+		} else {
+			character++
+		}
+	}
+	rawPositions[len(raw)] = protocol.Position{Line: line, Character: character}
+
+	decoder := scriptDecoder{raw: raw, rawPositions: rawPositions}
+
+	switch {
+	case strings.HasPrefix(raw, "|"), strings.HasPrefix(raw, ">"):
+		decoder.decodeBlockScalar(raw[0] == '>')
+	case strings.HasPrefix(raw, "\""):
+		decoder.decodeQuotedScalar('"')
+	case strings.HasPrefix(raw, "'"):
+		decoder.decodeQuotedScalar('\'')
+	default:
+		decoder.decodeFoldedLines(0, len(raw))
+	}
+
+	return RunScript{
+		Text:      decoder.text.String(),
+		positions: decoder.positions,
+	}
+}
+
+// MaskedText returns the script where every `<< ... >>` interpolation is
+// replaced by a shell word of the same length, so that shell tools do not
+// mistake them for here-documents while offsets stay the same
+func (script RunScript) MaskedText() string {
+	return interpolationRegex.ReplaceAllStringFunc(script.Text, func(match string) string {
+		return strings.Repeat("_", len(match))
+	})
+}
+
+// IsInclude returns true when the whole script is an `<<include(path)>>` of
+// an orb source, the script being the one of the included file
+func (script RunScript) IsInclude() bool {
+	text := strings.TrimSpace(script.Text)
+	match := orbIncludeRegex.FindStringIndex(text)
+	return match != nil && match[0] == 0 && match[1] == len(text)
+}
+
+// PositionAt returns the document position of the given byte offset of the
+// script
+func (script RunScript) PositionAt(offset int) protocol.Position {
+	if len(script.positions) == 0 {
+		return protocol.Position{}
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset >= len(script.positions) {
+		last := script.positions[len(script.positions)-1]
+		last.Character++
+		return last
+	}
+
+	return script.positions[offset]
+}
+
+// RangeAt returns the document range covering the script bytes from start
+// (inclusive) to end (exclusive)
+func (script RunScript) RangeAt(start int, end int) protocol.Range {
+	if end <= start {
+		end = start + 1
+	}
+
+	endPosition := script.PositionAt(end - 1)
+	endPosition.Character++
+
+	return protocol.Range{
+		Start: script.PositionAt(start),
+		End:   endPosition,
+	}
+}
+
+// OffsetAt returns the byte offset of the script located at the given
+// document position, or -1 if the position is not inside the script
+func (script RunScript) OffsetAt(position protocol.Position) int {
+	for i, pos := range script.positions {
+		if pos == position {
+			return i
+		}
+	}
+	return -1
+}
+
+// OffsetOf converts a 1-based line and column of the script, as reported by
+// shell tools, to a byte offset
+func (script RunScript) OffsetOf(line int, column int) int {
+	offset := 0
+	for currentLine := 1; currentLine < line; currentLine++ {
+		next := strings.IndexByte(script.Text[offset:], '\n')
+		if next == -1 {
+			return len(script.Text)
+		}
+		offset += next + 1
+	}
+
+	return min(offset+column-1, len(script.Text))
+}
+
+type scriptDecoder struct {
+	raw          string
+	rawPositions []protocol.Position
+
+	text      strings.Builder
+	positions []protocol.Position
+}
+
+func (decoder *scriptDecoder) emit(b byte, rawIndex int) {
+	decoder.text.WriteByte(b)
+	decoder.positions = append(decoder.positions, decoder.rawPositions[rawIndex])
+}
+
+func (decoder *scriptDecoder) decodeBlockScalar(folded bool) {
+	headerEnd := strings.IndexByte(decoder.raw, '\n')
+	if headerEnd == -1 {
+		return
+	}
+
+	type blockLine struct {
+		start int
+		end   int
+	}
+
+	lines := []blockLine{}
+	start := headerEnd + 1
+	for start <= len(decoder.raw) {
+		end := strings.IndexByte(decoder.raw[start:], '\n')
+		if end == -1 {
+			lines = append(lines, blockLine{start, len(decoder.raw)})
+			break
+		}
+		lines = append(lines, blockLine{start, start + end})
+		start += end + 1
+	}
+
+	indent := -1
+	for _, line := range lines {
+		content := decoder.raw[line.start:line.end]
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		indent = len(content) - len(strings.TrimLeft(content, " "))
+		break
+	}
+
+	if indent == -1 {
+		return
+	}
+
+	pendingEmptyLines := 0
+	hasContent := false
+	previousIsMoreIndented := false
+	for _, line := range lines {
+		content := decoder.raw[line.start:line.end]
+		if strings.TrimSpace(content) == "" {
+			if hasContent {
+				pendingEmptyLines++
+			}
+			continue
+		}
+
+		isMoreIndented := len(content) > indent && (content[indent] == ' ' || content[indent] == '\t')
+
+		if hasContent {
+			// The line break ending the previous line is attached to the last
+			// byte of that line
+			if !folded || isMoreIndented || previousIsMoreIndented {
+				pendingEmptyLines++
+			}
+
+			if pendingEmptyLines == 0 {
+				decoder.emit(' ', line.start-1)
+			}
+			for range pendingEmptyLines {
+				decoder.emit('\n', line.start-1)
+			}
+		}
+
+		for j := line.start + indent; j < line.end; j++ {
+			decoder.emit(decoder.raw[j], j)
+		}
+
+		hasContent = true
+		pendingEmptyLines = 0
+		previousIsMoreIndented = isMoreIndented
+	}
+}
+
+func (decoder *scriptDecoder) decodeQuotedScalar(quote byte) {
+	end := len(decoder.raw)
+	if end > 1 && decoder.raw[end-1] == quote {
+		end--
+	}
+
+	for i := 1; i < end; i++ {
+		c := decoder.raw[i]
+
+		switch {
+		case quote == '\'' && c == '\'' && i+1 < end && decoder.raw[i+1] == '\'':
+			decoder.emit('\'', i)
+			i++
+
+		case quote == '"' && c == '\\' && i+1 < end:
+			escaped := decoder.raw[i+1]
+			switch escaped {
+			case 'n':
+				decoder.emit('\n', i)
+			case 't':
+				decoder.emit('\t', i)
+			case '\n':
+				// Escaped line break: the line continues without any space
+				i++
+				for i+1 < end && (decoder.raw[i+1] == ' ' || decoder.raw[i+1] == '\t') {
+					i++
+				}
+				continue
+			default:
+				decoder.emit(escaped, i)
+			}
+			i++
+
+		case c == '\n':
+			i = decoder.foldLineBreak(i, end)
+
+		default:
+			decoder.emit(c, i)
+		}
+	}
+}
+
+func (decoder *scriptDecoder) decodeFoldedLines(start int, end int) {
+	for i := start; i < end; i++ {
+		if decoder.raw[i] == '\n' {
+			i = decoder.foldLineBreak(i, end)
+			continue
+		}
+		decoder.emit(decoder.raw[i], i)
+	}
+}
+
+// foldLineBreak handles a line break inside a flow scalar: it becomes a space,
+// or a newline for each following empty line. Returns the index of the last
+// consumed byte
+func (decoder *scriptDecoder) foldLineBreak(index int, end int) int {
+	// Trailing spaces before the line break are not part of the content
+	text := decoder.text.String()
+	trimmed := strings.TrimRight(text, " \t")
+	if len(trimmed) != len(text) {
+		decoder.text.Reset()
+		decoder.text.WriteString(trimmed)
+		decoder.positions = decoder.positions[:len(trimmed)]
+	}
+
+	emptyLines := 0
+	i := index
+	for i+1 < end {
+		next := decoder.raw[i+1]
+		if next == ' ' || next == '\t' {
+			i++
+			continue
+		}
+		if next == '\n' {
+			emptyLines++
+			i++
+			continue
+		}
+		break
+	}
+
+	if emptyLines == 0 {
+		decoder.emit(' ', index)
+	}
+	for range emptyLines {
+		decoder.emit('\n', index)
+	}
+
+	return i
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestGetRunScript(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		YamlContent  string
+		ExpectedText string
+		// Offset of the script and the document position it should map to
+		Offset           int
+		ExpectedPosition protocol.Position
+	}{
+		{
+			Name: "Plain scalar",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run: echo "hello"`,
+			ExpectedText:     `echo "hello"`,
+			Offset:           5,
+			ExpectedPosition: protocol.Position{Line: 3, Character: 18},
+		},
+		{
+			Name: "Double quoted scalar with escapes",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run: "echo \"a\" b"`,
+			ExpectedText:     `echo "a" b`,
+			Offset:           9,
+			ExpectedPosition: protocol.Position{Line: 3, Character: 25},
+		},
+		{
+			Name: "Single quoted scalar",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run:
+          command: 'echo ''a'' b'`,
+			ExpectedText:     `echo 'a' b`,
+			Offset:           9,
+			ExpectedPosition: protocol.Position{Line: 4, Character: 31},
+		},
+		{
+			Name: "Literal block scalar",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run:
+          command: |
+            echo "a"
+
+            echo "b"
+`,
+			ExpectedText:     "echo \"a\"\n\necho \"b\"",
+			Offset:           10,
+			ExpectedPosition: protocol.Position{Line: 7, Character: 12},
+		},
+		{
+			Name: "Folded block scalar",
+			YamlContent: `jobs:
+  build:
+    steps:
+      - run: >
+          echo
+          "a"
+`,
+			ExpectedText:     `echo "a"`,
+			Offset:           5,
+			ExpectedPosition: protocol.Position{Line: 5, Character: 10},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Name, func(t *testing.T) {
+			doc, err := ParseFromContent([]byte(tt.YamlContent), testHelpers.GetDefaultLsContext(), uri.File(""), protocol.Position{})
+			assert.NoError(t, err)
+
+			run, ok := doc.Jobs["build"].Steps[0].(ast.Run)
+			assert.True(t, ok)
+
+			script := GetRunScript(run)
+			assert.Equal(t, tt.ExpectedText, script.Text)
+			assert.Equal(t, tt.ExpectedPosition, script.PositionAt(tt.Offset))
+			assert.Equal(t, tt.Offset, script.OffsetAt(tt.ExpectedPosition))
+		})
+	}
+}
+
+func TestRunScriptMaskedText(t *testing.T) {
+	script := RunScript{Text: "cat <<EOF > << parameters.file >>\nEOF"}
+
+	assert.Equal(t, "cat <<EOF > _____________________\nEOF", script.MaskedText())
+
+	mask := func(text string) string { return strings.Repeat("_", len(text)) }
+	script = RunScript{Text: "echo <<# parameters.debug >>-v<</ parameters.debug >> <<include(scripts/a.sh)>> << pipeline.git.branch >>"}
+	assert.Equal(t,
+		"echo "+mask("<<# parameters.debug >>")+"-v"+mask("<</ parameters.debug >>")+" "+mask("<<include(scripts/a.sh)>>")+" "+mask("<< pipeline.git.branch >>"),
+		script.MaskedText())
+
+	// Here-documents are kept
+	script = RunScript{Text: "cat <<EOF >>log\nEOF"}
+	assert.Equal(t, script.Text, script.MaskedText())
+}
+
+func TestRunScriptIsInclude(t *testing.T) {
+	assert.True(t, RunScript{Text: "<<include(scripts/install.sh)>>"}.IsInclude())
+	assert.True(t, RunScript{Text: "<< include(scripts/install.sh) >>\n"}.IsInclude())
+	assert.False(t, RunScript{Text: "bash <<include(scripts/install.sh)>>"}.IsInclude())
+	assert.False(t, RunScript{Text: "echo hello"}.IsInclude())
+}
@@ -275,7 +275,7 @@ func (doc *YamlDocument) parseRunStep(runNode *sitter.Node) ast.Run {
 				res.When = doc.GetNodeText(valueNode)
 				res.WhenRange = doc.NodeToRange(valueNode)
 			case "environment":
-				res.Environment = doc.parseDictionary(valueNode)
+				res.Environment = doc.parseDictionary(GetChildMapping(valueNode))
 			case "max_auto_reruns":
 				res.MaxAutoReruns = doc.GetNodeText(valueNode)
 			case "auto_rerun_delay":
@@ -392,6 +392,7 @@ func (doc *YamlDocument) parseStoreArtifactsStep(storeArtifactsNode *sitter.Node
 		switch keyName {
 		case "path":
 			res.Path = doc.GetNodeText(valueNode)
+			res.PathRange = doc.NodeToRange(valueNode)
 		case "destination":
 			res.Destination = doc.GetNodeText(valueNode)
 		}
@@ -411,6 +412,7 @@ func (doc *YamlDocument) parseStoreTestResultsStep(storeTestResultsNode *sitter.
 		switch keyName {
 		case "path":
 			res.Path = doc.GetNodeText(valueNode)
+			res.PathRange = doc.NodeToRange(valueNode)
 		}
 	})
 	return res
@@ -5,7 +5,11 @@ import (
 	"testing"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
 	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
 )
 
 const YamlFile = `
@@ -187,3 +191,26 @@ func TestYamlDocument_parseSteps(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRunStepEnvironment(t *testing.T) {
+	yaml := `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:2024.01
+    steps:
+      - run:
+          environment:
+            FIRST: a
+            SECOND: b
+          command: echo "$FIRST $SECOND"
+`
+
+	doc, err := ParseFromContent([]byte(yaml), testHelpers.GetDefaultLsContext(), uri.File(""), protocol.Position{})
+	assert.NoError(t, err)
+
+	run, ok := doc.Jobs["build"].Steps[0].(ast.Run)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"FIRST": "a", "SECOND": "b"}, run.Environment)
+}
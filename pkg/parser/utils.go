@@ -13,12 +13,17 @@ import (
 )
 
 func GetRootNode(content []byte) *sitter.Node {
+	return ParseTree(content, nil).RootNode()
+}
+
+// ParseTree parses the content, reusing the parts of oldTree that did not
+// change when it is given. oldTree must have been edited beforehand to match
+// the content, see sitter.Tree.Edit
+func ParseTree(content []byte, oldTree *sitter.Tree) *sitter.Tree {
 	parser := sitter.NewParser()
 	parser.SetLanguage(ymlgrammar.GetLanguage())
 
-	tree := parser.Parse(nil, content)
-
-	return tree.RootNode()
+	return parser.Parse(oldTree, content)
 }
 
 func GetChildOfType(node *sitter.Node, typeName string) *sitter.Node {
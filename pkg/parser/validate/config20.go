@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// ValidateConfig20 runs the basic checks of the 2.0 configs: the schema and
+// most of the validators only know about 2.1. The use of the 2.1 features is
+// reported, the migration to 2.1 gives access to the full validation
+func (val Validate) ValidateConfig20() {
+	sections := []struct {
+		Name  string
+		Range protocol.Range
+	}{
+		{"orbs", val.Doc.OrbsRange},
+		{"commands", val.Doc.CommandsRange},
+		{"executors", val.Doc.ExecutorsRange},
+		{"parameters", val.Doc.PipelineParametersRange},
+	}
+	for _, section := range sections {
+		if !utils.IsDefaultRange(section.Range) {
+			val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+				section.Range,
+				fmt.Sprintf("`%s` requires version 2.1", section.Name)))
+		}
+	}
+
+	for _, job := range val.Doc.Jobs {
+		val.validateJob20(job)
+	}
+
+	for _, workflow := range val.Doc.Workflows {
+		for _, jobInvocation := range workflow.JobInvocations {
+			if jobInvocation.Type != "approval" && !val.Doc.DoesJobExist(jobInvocation.JobName) {
+				val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+					jobInvocation.JobInvocationRange,
+					fmt.Sprintf("Cannot find declaration for job \"%s\"", jobInvocation.JobName)))
+			}
+		}
+	}
+}
+
+func (val Validate) validateJob20(job ast.Job) {
+	if !utils.IsDefaultRange(job.ParametersRange) {
+		val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(job.ParametersRange, "Job parameters require version 2.1"))
+	}
+
+	if !utils.IsDefaultRange(job.ExecutorRange) {
+		val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(job.ExecutorRange, "`executor` requires version 2.1"))
+	} else if utils.IsDefaultRange(job.DockerRange) && utils.IsDefaultRange(job.MachineRange) && utils.IsDefaultRange(job.MacOSRange) {
+		val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(job.NameRange, "Missing executor: `docker`, `machine` or `macos`"))
+	}
+
+	if utils.IsDefaultRange(job.StepsRange) {
+		val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(job.NameRange, "Missing `steps`"))
+	}
+
+	for _, step := range job.Steps {
+		if step, ok := step.(ast.NamedStep); ok && step.Name != "" && !val.Doc.IsBuiltIn(step.Name) {
+			val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+				step.Range,
+				fmt.Sprintf("Unknown step \"%s\", commands require version 2.1", step.Name)))
+		}
+	}
+}
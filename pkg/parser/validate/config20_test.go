@@ -0,0 +1,97 @@
+package validate
+
+import (
+	"testing"
+
+	"go.lsp.dev/protocol"
+)
+
+func TestValidateConfig20(t *testing.T) {
+	testCases := []ValidateTestCase{
+		{
+			Name: "Valid 2.0 config",
+			YamlContent: `version: 2
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+
+workflows:
+  version: 2
+  main:
+    jobs:
+      - build`,
+			OnlyErrors:  true,
+			Diagnostics: []protocol.Diagnostic{},
+		},
+		{
+			Name: "2.1 features and undeclared jobs",
+			YamlContent: `version: 2
+
+commands:
+  greet:
+    steps:
+      - run: echo hello
+
+jobs:
+  build:
+    steps:
+      - greet
+
+workflows:
+  version: 2
+  main:
+    jobs:
+      - build
+      - deploy`,
+			OnlyErrors: true,
+			Diagnostics: []protocol.Diagnostic{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 3, Character: 2},
+						End:   protocol.Position{Line: 5, Character: 23},
+					},
+					Severity: protocol.DiagnosticSeverityError,
+					Source:   "cci-language-server",
+					Message:  "`commands` requires version 2.1",
+					Data:     []protocol.CodeAction{},
+				},
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 8, Character: 2},
+						End:   protocol.Position{Line: 8, Character: 7},
+					},
+					Severity: protocol.DiagnosticSeverityError,
+					Source:   "cci-language-server",
+					Message:  "Missing executor: `docker`, `machine` or `macos`",
+					Data:     []protocol.CodeAction{},
+				},
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 10, Character: 8},
+						End:   protocol.Position{Line: 10, Character: 13},
+					},
+					Severity: protocol.DiagnosticSeverityError,
+					Source:   "cci-language-server",
+					Message:  "Unknown step \"greet\", commands require version 2.1",
+					Data:     []protocol.CodeAction{},
+				},
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 17, Character: 6},
+						End:   protocol.Position{Line: 17, Character: 14},
+					},
+					Severity: protocol.DiagnosticSeverityError,
+					Source:   "cci-language-server",
+					Message:  "Cannot find declaration for job \"deploy\"",
+					Data:     []protocol.CodeAction{},
+				},
+			},
+		},
+	}
+
+	CheckYamlErrors(t, testCases)
+}
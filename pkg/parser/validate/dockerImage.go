@@ -35,6 +35,12 @@ func DoesDockerImageExists(img *ast.DockerImage, cache *utils.DockerCache, api d
 		cachedDockerImage = cache.Get(img.Image.FullPath)
 	}
 
+	if cachedDockerImage.Stale {
+		cache.Revalidate(img.Image.FullPath, func() bool {
+			return api.DoesImageExist(img.Image.Namespace, img.Image.Name)
+		})
+	}
+
 	return cachedDockerImage.Exists
 }
 
@@ -107,8 +113,23 @@ func GetImageTagInfo(img *ast.DockerImage, cache *utils.DockerTagsCache, api doc
 	tagInfo := cache.Get(img.Image.Namespace, img.Image.Name)
 
 	if tagInfo != nil {
+		if tagInfo.Stale {
+			cache.Revalidate(img.Image.Namespace, img.Image.Name, func() *utils.CachedDockerTags {
+				return fetchImageTagInfo(img, api)
+			})
+		}
 		return tagInfo
 	}
+
+	tagInfo = fetchImageTagInfo(img, api)
+	if tagInfo == nil {
+		return nil
+	}
+	cache.Add(img.Image.Namespace, img.Image.Name, *tagInfo)
+	return tagInfo
+}
+
+func fetchImageTagInfo(img *ast.DockerImage, api dockerhub.DockerHubAPI) *utils.CachedDockerTags {
 	tags, err := api.GetImageTags(img.Image.Namespace, img.Image.Name)
 	if err != nil {
 		return nil
@@ -119,14 +140,10 @@ func GetImageTagInfo(img *ast.DockerImage, cache *utils.DockerTagsCache, api doc
 		tagsForCache[tag] = true
 	}
 
-	recommended := chooseTagToRecommend(tags)
-
-	tagInfo = &utils.CachedDockerTags{
+	return &utils.CachedDockerTags{
 		CheckedTags: tagsForCache,
-		Recommended: recommended,
+		Recommended: chooseTagToRecommend(tags),
 	}
-	cache.Add(img.Image.Namespace, img.Image.Name, *tagInfo)
-	return tagInfo
 }
 
 func chooseTagToRecommend(allTags []string) string {
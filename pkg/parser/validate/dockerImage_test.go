@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
@@ -45,6 +46,13 @@ func (me DockerHubMock) ImageHasTag(namespace, image, tag string) bool {
 	return !me.NoTag
 }
 
+func (me DockerHubMock) GetTagDetails(namespace, image, tag string) (*dockerhub.TagDetails, error) {
+	if !me.ImageHasTag(namespace, image, tag) {
+		return nil, fmt.Errorf("no tag %s", tag)
+	}
+	return &dockerhub.TagDetails{Name: tag}, nil
+}
+
 func TestValidateDockerImage(t *testing.T) {
 	testCases := []struct {
 		Name        string
@@ -0,0 +1,56 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+)
+
+// ValidateEnvVariables reports the environment variables used in run steps
+// that are not set by the configuration, the contexts, the project settings
+// or CircleCI. Nothing is reported for the steps whose variables are not all
+// known, such as without a token or outside of a followed project
+func (val Validate) ValidateEnvVariables() {
+	if val.Context == nil || val.Context.Api.Token == "" {
+		return
+	}
+
+	for _, job := range val.Doc.Jobs {
+		val.validateStepsEnvVariables(job.Steps, val.Doc.GetJobEnvVariableScope(job, val.Cache))
+	}
+
+	for name, command := range val.Doc.Commands {
+		scope, ok := val.Doc.GetCommandEnvVariableScope(name, val.Cache)
+		if !ok {
+			// The variables depend on the jobs invoking the command
+			continue
+		}
+		val.validateStepsEnvVariables(command.Steps, scope)
+	}
+}
+
+func (val Validate) validateStepsEnvVariables(steps []ast.Step, scope parser.EnvVariableScope) {
+	if !scope.Complete {
+		return
+	}
+
+	for _, step := range steps {
+		run, ok := step.(ast.Run)
+		if !ok {
+			continue
+		}
+
+		for _, usage := range parser.GetEnvVariableUsages(run) {
+			if len(scope.GetEnvVariableSources(usage.Name, run)) > 0 {
+				continue
+			}
+
+			val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+				usage.Range,
+				fmt.Sprintf("Environment variable `%s` is not defined", usage.Name),
+			))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+func TestEnvVariablesValidation(t *testing.T) {
+	yaml := `version: 2.1
+
+executors:
+  go:
+    docker:
+      - image: cimg/go:1.22
+        environment:
+          GOFLAGS: -mod=mod
+    environment:
+      EXECUTOR_VAR: a
+
+commands:
+  greet:
+    steps:
+      - run: echo "$GREETING $UNKNOWN_IN_COMMAND"
+  unused:
+    steps:
+      - run: echo "$ANYTHING"
+
+jobs:
+  build:
+    executor: go
+    environment:
+      GREETING: hello
+    steps:
+      - greet
+      - run:
+          environment:
+            STEP_VAR: a
+          command: |
+            echo 'export EXPORTED=1' >> "$BASH_ENV"
+            for file in *.go; do echo "$file"; done
+            echo "$STEP_VAR $GOFLAGS $EXECUTOR_VAR $PROJECT_TOKEN $CIRCLE_SHA1 $HOME"
+            echo "${OPTIONAL:-default} $EXPORTED"
+            echo "$MISSING"
+`
+
+	val := CreateValidateFromYAML(yaml)
+	val.Context.Api.Token = "XXXXXXXXXXXX"
+	val.Cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{URI: val.Doc.URI},
+		Project:      utils.Project{Slug: "gh/org/project", Name: "project"},
+		EnvVariables: []string{"PROJECT_TOKEN"},
+	})
+	val.ValidateEnvVariables()
+
+	messages := []string{}
+	for _, diagnostic := range *val.Diagnostics {
+		assert.Equal(t, protocol.DiagnosticSeverityWarning, diagnostic.Severity)
+		messages = append(messages, diagnostic.Message)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"Environment variable `UNKNOWN_IN_COMMAND` is not defined",
+		"Environment variable `MISSING` is not defined",
+	}, messages)
+}
+
+func TestEnvVariablesValidationWithIncompleteScope(t *testing.T) {
+	yaml := `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:2024.01
+    steps:
+      - run: echo "$PROJECT_TOKEN"
+`
+
+	testCases := []struct {
+		Name    string
+		Token   string
+		Project utils.Project
+	}{
+		{Name: "Without project", Token: "XXXXXXXXXXXX"},
+		{Name: "Without token", Project: utils.Project{Slug: "gh/org/project", Name: "project"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Name, func(t *testing.T) {
+			val := CreateValidateFromYAML(yaml)
+			val.Context.Api.Token = tt.Token
+			val.Cache.FileCache.SetFile(utils.CachedFile{
+				TextDocument: protocol.TextDocumentItem{URI: val.Doc.URI},
+				Project:      tt.Project,
+			})
+			val.ValidateEnvVariables()
+
+			assert.Empty(t, *val.Diagnostics)
+		})
+	}
+}
@@ -322,7 +322,7 @@ func (val Validate) validateExecutorNamespace(resourceClass string, resourceClas
 	request.SetUserId(val.Context.UserIdForTelemetry)
 
 	var response RegistryNamespace
-	err := client.Run(request, &response)
+	err := client.RunWithContext(val.getCtx(), request, &response)
 	if err != nil {
 		return
 	}
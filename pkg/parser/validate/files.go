@@ -0,0 +1,70 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+)
+
+// ValidateFileReferences warns about scripts run by the steps that can not be
+// found in the repository of the config file
+func (val Validate) ValidateFileReferences() {
+	if val.Doc.GetRepositoryRoot() == "" {
+		return
+	}
+
+	for _, job := range val.Doc.Jobs {
+		if !isDefaultWorkingDirectory(job.WorkingDirectory) {
+			continue
+		}
+		val.validateStepsFileReferences(job.Steps)
+	}
+
+	for _, command := range val.Doc.Commands {
+		val.validateStepsFileReferences(command.Steps)
+	}
+}
+
+func (val Validate) validateStepsFileReferences(steps []ast.Step) {
+	for i, step := range steps {
+		if run, ok := step.(ast.Run); ok && !isDefaultWorkingDirectory(run.WorkingDirectory) {
+			continue
+		}
+
+		for _, ref := range parser.GetStepFileReferences(step) {
+			if !ref.IsScript || isCreatedByPreviousSteps(ref.Path, steps[:i]) {
+				continue
+			}
+
+			if _, ok := val.Doc.ResolveFileReference(ref); !ok {
+				val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+					ref.Range,
+					fmt.Sprintf("Script `%s` does not exist in the repository", ref.Path),
+				))
+			}
+		}
+	}
+}
+
+func isDefaultWorkingDirectory(workingDirectory string) bool {
+	switch strings.TrimSuffix(workingDirectory, "/") {
+	case "", ".", "~/project", "/home/circleci/project":
+		return true
+	}
+	return false
+}
+
+// Scripts can be downloaded or generated by the steps before the one running
+// them
+func isCreatedByPreviousSteps(path string, steps []ast.Step) bool {
+	for _, step := range steps {
+		run, ok := step.(ast.Run)
+		if ok && strings.Contains(run.Command, strings.TrimPrefix(path, "./")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestFileReferencesValidation(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, ".circleci"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "scripts"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "scripts", "build.sh"), []byte("#!/bin/bash\n"), 0o644))
+
+	yaml := `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:2024.01
+    steps:
+      - checkout
+      - run: ./scripts/build.sh
+      - run: bash scripts/missing.sh
+      - run: curl -o scripts/fetched.sh https://example.com
+      - run: sh scripts/fetched.sh
+  other:
+    docker:
+      - image: cimg/base:2024.01
+    working_directory: ~/project/app
+    steps:
+      - run: ./scripts/elsewhere.sh
+`
+
+	context := testHelpers.GetDefaultLsContext()
+	doc, err := parser.ParseFromContent([]byte(yaml), context, uri.File(filepath.Join(root, ".circleci", "config.yml")), protocol.Position{})
+	assert.NoError(t, err)
+
+	val := Validate{
+		APIs:        ValidateAPIs{DockerHub: DockerHubMock{}},
+		Diagnostics: &[]protocol.Diagnostic{},
+		Cache:       utils.CreateCache(),
+		Doc:         doc,
+		Context:     context,
+	}
+	val.ValidateFileReferences()
+
+	assert.Len(t, *val.Diagnostics, 1)
+	diagnostic := (*val.Diagnostics)[0]
+	assert.Equal(t, "Script `scripts/missing.sh` does not exist in the repository", diagnostic.Message)
+	assert.Equal(t, protocol.Range{
+		Start: protocol.Position{Line: 9, Character: 18},
+		End:   protocol.Position{Line: 9, Character: 36},
+	}, diagnostic.Range)
+}
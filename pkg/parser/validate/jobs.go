@@ -33,7 +33,7 @@ func (val Validate) validateSingleJob(job ast.Job) {
 	}
 
 	// Local orbs do not need unused checks because those checks collides with the overall YAML unused checks
-	if !val.IsLocalOrb {
+	if !val.isPartOfOrb() {
 		val.checkAndReportUnusedJob(job)
 	}
 
@@ -92,7 +92,7 @@ func (val Validate) validateSingleJob(job ast.Job) {
 		} else if !val.Doc.DoesExecutorExist(job.Executor) {
 			val.validateExecutorReference(job.Executor, job.ExecutorRange)
 		} else {
-			executor := val.Doc.Executors[job.Executor]
+			executor, _ := val.Doc.GetExecutor(job.Executor)
 			val.validateParametersValue(
 				job.ExecutorParameters,
 				executor.GetName(),
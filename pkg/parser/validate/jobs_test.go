@@ -28,7 +28,7 @@ func validateYAML(t *testing.T, yamlData string) *[]protocol.Diagnostic {
 	assert.NoError(t, err, "invalid YAML data")
 
 	val := Validate{
-		APIs:        ValidateAPIs{DockerHubMock{}},
+		APIs:        ValidateAPIs{DockerHub: DockerHubMock{}},
 		Context:     ctx,
 		Doc:         doc,
 		Diagnostics: &[]protocol.Diagnostic{},
@@ -357,7 +357,7 @@ func TestResourceClass(t *testing.T) {
 			assert.Contains(t, doc.Jobs, "test")
 
 			val := Validate{
-				APIs:        ValidateAPIs{DockerHubMock{}},
+				APIs:        ValidateAPIs{DockerHub: DockerHubMock{}},
 				Context:     ctx,
 				Doc:         doc,
 				Diagnostics: &[]protocol.Diagnostic{},
@@ -451,7 +451,7 @@ func TestRetention(t *testing.T) {
 			assert.Contains(t, doc.Jobs, "test")
 
 			val := Validate{
-				APIs:        ValidateAPIs{DockerHubMock{}},
+				APIs:        ValidateAPIs{DockerHub: DockerHubMock{}},
 				Context:     ctx,
 				Doc:         doc,
 				Diagnostics: &[]protocol.Diagnostic{},
@@ -544,7 +544,7 @@ func TestJobTypeValidation(t *testing.T) {
 			assert.NoError(t, err, "invalid YAML data")
 
 			val := Validate{
-				APIs:        ValidateAPIs{DockerHubMock{}},
+				APIs:        ValidateAPIs{DockerHub: DockerHubMock{}},
 				Context:     ctx,
 				Doc:         doc,
 				Diagnostics: &[]protocol.Diagnostic{},
@@ -5,7 +5,6 @@ import (
 	"strings"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
-	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 	"go.lsp.dev/protocol"
@@ -35,7 +34,7 @@ func (val Validate) validateSingleOrb(orb ast.Orb) {
 		return
 	}
 
-	if !orb.Url.IsLocal && !val.Doc.DoesOrbExist(orb, val.Cache) {
+	if !orb.Url.IsLocal && !val.Doc.DoesOrbExist(val.getCtx(), orb, val.Cache) {
 		message := fmt.Sprintf("Orb %s does not exist or is private.", orb.Url.Name)
 
 		if val.Context.IsCciExtension && val.Context.Api.Token == "" {
@@ -52,7 +51,7 @@ func (val Validate) validateSingleOrb(orb ast.Orb) {
 		return
 	}
 
-	orbVersion, err := val.Doc.GetOrFetchOrbInfo(orb, val.Cache)
+	orbVersion, err := val.Doc.GetOrFetchOrbInfoWithContext(val.getCtx(), orb, val.Cache)
 
 	if err != nil {
 		if strings.HasPrefix(err.Error(), "could not find orb") {
@@ -219,7 +218,7 @@ func (val Validate) doesOrbExecutorExist(executorName string, executorRange prot
 		return false, err
 	}
 
-	remoteOrb, err := parser.GetOrbInfo(orb.Url.GetOrbID(), val.Cache, val.Context)
+	remoteOrb, err := parser.GetOrbInfoWithContext(val.getCtx(), orb.Url.GetOrbID(), val.Cache, val.Context)
 	if err != nil {
 		val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
 			executorRange,
@@ -235,7 +234,7 @@ func (val Validate) doesOrbExecutorExist(executorName string, executorRange prot
 func (val Validate) ValidateLocalOrbs() {
 	for _, orb := range val.Doc.Orbs {
 		if orb.Url.IsLocal {
-			orbInfo, err := val.Doc.GetOrFetchOrbInfo(orb, val.Cache)
+			orbInfo, err := val.Doc.GetOrFetchOrbInfoWithContext(val.getCtx(), orb, val.Cache)
 
 			if err != nil {
 				continue
@@ -243,13 +242,15 @@ func (val Validate) ValidateLocalOrbs() {
 
 			validateStruct := Validate{
 				APIs: ValidateAPIs{
-					DockerHub: dockerhub.NewAPI(),
+					DockerHub:   val.APIs.DockerHub,
+					ShellLinter: val.APIs.ShellLinter,
 				},
 				Doc:         val.Doc.FromOrbParsedAttributesToYamlDocument(orbInfo.OrbParsedAttributes),
 				Diagnostics: val.Diagnostics,
 				Cache:       val.Cache,
 				Context:     val.Context,
 				IsLocalOrb:  true,
+				Ctx:         val.Ctx,
 			}
 			validateStruct.Validate()
 
@@ -0,0 +1,243 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// ValidateOrbAuthoring runs the checks specific to the source of an orb: its
+// metadata shown in the orb registry and its examples
+func (val Validate) ValidateOrbAuthoring() {
+	// The metadata of a source directory are in `@orb.yml`
+	if val.Doc.OrbSourceFile == nil {
+		val.validateOrbMetadata()
+	}
+
+	for _, example := range val.Doc.Examples {
+		val.validateOrbExample(example)
+	}
+
+	val.validateOrbIncludes()
+}
+
+func (val Validate) validateOrbMetadata() {
+	if strings.TrimSpace(val.Doc.Description) == "" {
+		rng := val.Doc.DescriptionRange
+		if rng == (protocol.Range{}) {
+			rng = val.Doc.VersionRange
+		}
+		val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+			rng,
+			"Orbs should have a `description`, it is shown in the orb registry",
+		))
+	}
+
+	val.validateOrbDisplay()
+}
+
+func (val Validate) validateOrbDisplay() {
+	display := val.Doc.Display
+
+	if display.SourceUrl == "" {
+		rng := display.Range
+		if rng == (protocol.Range{}) {
+			rng = val.Doc.VersionRange
+		}
+		val.addDiagnostic(utils.CreateInformationDiagnosticFromRange(
+			rng,
+			"Set `display.source_url` to link the orb registry page to the source code of the orb",
+		))
+	} else {
+		val.validateDisplayUrl("source_url", display.SourceUrl, display.SourceUrlRange)
+	}
+
+	if display.HomeUrl != "" {
+		val.validateDisplayUrl("home_url", display.HomeUrl, display.HomeUrlRange)
+	}
+}
+
+func (val Validate) validateDisplayUrl(key string, value string, rng protocol.Range) {
+	value = strings.Trim(value, `"'`)
+
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+			rng,
+			fmt.Sprintf("`%s` must be an absolute http(s) URL", key),
+		))
+	}
+}
+
+func (val Validate) validateOrbExample(example parser.OrbExample) {
+	if strings.TrimSpace(example.Description) == "" {
+		val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+			example.NameRange,
+			fmt.Sprintf("Example `%s` has no `description`", example.Name),
+		))
+	}
+
+	if example.Usage == nil {
+		rng := example.UsageRange
+		message := "The `usage` of an example must be a config using the orb"
+		if rng == (protocol.Range{}) {
+			rng = example.NameRange
+			message = fmt.Sprintf("Example `%s` has no `usage`", example.Name)
+		}
+		val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(rng, message))
+		return
+	}
+
+	usage := *example.Usage
+	for _, diagnostic := range *usage.Diagnostics {
+		val.addDiagnostic(diagnostic)
+	}
+
+	if usage.Version != 2.1 {
+		rng := usage.VersionRange
+		if rng == (protocol.Range{}) {
+			rng = example.UsageRange
+		}
+		val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(rng, "The `usage` of an example must be a config of version 2.1"))
+	}
+
+	alias, ok := val.getExampleOrbAlias(usage)
+	if !ok {
+		return
+	}
+
+	for _, workflow := range usage.Workflows {
+		for _, invocation := range workflow.JobInvocations {
+			name, ok := strings.CutPrefix(invocation.JobName, alias+"/")
+			if !ok {
+				continue
+			}
+
+			job, ok := val.Doc.GetJob(name)
+			if !ok {
+				val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+					invocation.JobNameRange,
+					fmt.Sprintf("Job `%s` is not defined in this orb", name),
+				))
+				continue
+			}
+
+			val.validateExampleParameters(invocation.Parameters, job.Parameters, invocation.JobNameRange)
+			val.validateExampleSteps(alias, invocation.PreSteps)
+			val.validateExampleSteps(alias, invocation.PostSteps)
+		}
+	}
+
+	for _, job := range usage.Jobs {
+		val.validateExampleSteps(alias, job.Steps)
+
+		if name, ok := strings.CutPrefix(job.Executor, alias+"/"); ok {
+			if _, ok := val.Doc.GetExecutor(name); !ok {
+				val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+					job.ExecutorRange,
+					fmt.Sprintf("Executor `%s` is not defined in this orb", name),
+				))
+			}
+		}
+	}
+
+	for _, command := range usage.Commands {
+		val.validateExampleSteps(alias, command.Steps)
+	}
+}
+
+// Returns the name under which the example imports the orb being written:
+// the only orb it imports, or the one with the same name as the file
+func (val Validate) getExampleOrbAlias(usage parser.YamlDocument) (string, bool) {
+	if len(usage.Orbs) == 1 {
+		for alias := range usage.Orbs {
+			return alias, true
+		}
+	}
+
+	orbName := val.Doc.GetOrbName()
+	for alias, orb := range usage.Orbs {
+		if orbName != "" && !orb.Url.IsLocal {
+			if _, name, _ := strings.Cut(orb.Url.Name, "/"); name == orbName {
+				return alias, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (val Validate) validateExampleSteps(alias string, steps []ast.Step) {
+	for _, step := range steps {
+		namedStep, ok := step.(ast.NamedStep)
+		if !ok {
+			continue
+		}
+
+		name, ok := strings.CutPrefix(namedStep.Name, alias+"/")
+		if !ok {
+			continue
+		}
+
+		command, ok := val.Doc.GetCommand(name)
+		if !ok {
+			val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+				namedStep.Range,
+				fmt.Sprintf("Command `%s` is not defined in this orb", name),
+			))
+			continue
+		}
+
+		val.validateExampleParameters(namedStep.Parameters, command.Parameters, namedStep.Range)
+	}
+}
+
+func (val Validate) validateExampleParameters(values map[string]ast.ParameterValue, definitions map[string]ast.Parameter, rng protocol.Range) {
+	for name, value := range values {
+		if _, ok := definitions[name]; !ok {
+			val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+				value.Range,
+				fmt.Sprintf("Parameter `%s` is not defined", name),
+			))
+		}
+	}
+
+	for name, definition := range definitions {
+		if _, ok := values[name]; !ok && !definition.IsOptional() {
+			val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+				rng,
+				fmt.Sprintf("Missing required parameter `%s`", name),
+			))
+		}
+	}
+}
+
+// The includes are resolved relatively to the source directory
+func (val Validate) validateOrbIncludes() {
+	if val.Doc.OrbSource == nil {
+		return
+	}
+
+	for _, include := range val.Doc.GetOrbIncludes() {
+		err := val.Doc.OrbIncludeError(include)
+		switch {
+		case err == nil:
+		case errors.Is(err, parser.ErrOrbIncludeOutside):
+			val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+				include.Range,
+				fmt.Sprintf("File `%s` is outside of the orb source directory, it can't be included", include.Path),
+			))
+		default:
+			val.addDiagnostic(utils.CreateErrorDiagnosticFromRange(
+				include.Range,
+				fmt.Sprintf("File `%s` does not exist in the orb source directory", include.Path),
+			))
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func createOrbValidateFromYAML(yaml string) Validate {
+	context := testHelpers.GetDefaultLsContext()
+	context.Api.Token = ""
+	doc, _ := parser.ParseFromContent([]byte(yaml), context, uri.File("/greeter/src/@orb.yml"), protocol.Position{})
+
+	return Validate{
+		APIs:        ValidateAPIs{DockerHub: DockerHubMock{}},
+		Diagnostics: &[]protocol.Diagnostic{},
+		Cache:       utils.CreateCache(),
+		Doc:         doc,
+		Context:     context,
+	}
+}
+
+func TestOrbAuthoringValidation(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		YamlContent      string
+		ExpectedMessages []string
+	}{
+		{
+			Name: "Valid orb",
+			YamlContent: `version: 2.1
+description: Greets people
+display:
+  home_url: https://example.com
+  source_url: https://github.com/org/greeter
+commands:
+  greet:
+    parameters:
+      to:
+        type: string
+    steps:
+      - run: echo "hello << parameters.to >>"
+jobs:
+  greet:
+    docker:
+      - image: cimg/base:2024.01
+    steps:
+      - greet:
+          to: world
+examples:
+  simple:
+    description: Say hello
+    usage:
+      version: 2.1
+      orbs:
+        greeter: org/greeter@1.0.0
+      workflows:
+        main:
+          jobs:
+            - greeter/greet
+`,
+		},
+		{
+			Name: "Missing metadata",
+			YamlContent: `version: 2.1
+display:
+  home_url: example.com
+commands:
+  greet:
+    steps:
+      - run: echo hello
+`,
+			ExpectedMessages: []string{
+				"Orbs should have a `description`, it is shown in the orb registry",
+				"Set `display.source_url` to link the orb registry page to the source code of the orb",
+				"`home_url` must be an absolute http(s) URL",
+			},
+		},
+		{
+			Name: "Invalid examples",
+			YamlContent: `version: 2.1
+description: Greets people
+display:
+  source_url: https://github.com/org/greeter
+commands:
+  greet:
+    parameters:
+      to:
+        type: string
+    steps:
+      - run: echo "hello << parameters.to >>"
+examples:
+  no_usage:
+    description: Nothing
+  wrong:
+    usage:
+      version: 2.1
+      orbs:
+        greeter: org/greeter@1.0.0
+      jobs:
+        build:
+          docker:
+            - image: cimg/base:2024.01
+          steps:
+            - greeter/greet:
+                from: me
+            - greeter/wave
+      workflows:
+        main:
+          jobs:
+            - greeter/deploy
+`,
+			ExpectedMessages: []string{
+				"Example `no_usage` has no `usage`",
+				"Example `wrong` has no `description`",
+				"Parameter `from` is not defined",
+				"Missing required parameter `to`",
+				"Command `wave` is not defined in this orb",
+				"Job `deploy` is not defined in this orb",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Name, func(t *testing.T) {
+			val := createOrbValidateFromYAML(tt.YamlContent)
+			val.ValidateOrbAuthoring()
+
+			messages := []string{}
+			for _, diagnostic := range *val.Diagnostics {
+				messages = append(messages, diagnostic.Message)
+			}
+
+			assert.ElementsMatch(t, tt.ExpectedMessages, messages)
+		})
+	}
+}
+
+func TestOrbsHaveNoUnusedEntities(t *testing.T) {
+	val := createOrbValidateFromYAML(`version: 2.1
+description: Greets people
+commands:
+  greet:
+    steps:
+      - run: echo hello
+`)
+	val.ValidateCommands()
+
+	assert.Empty(t, *val.Diagnostics)
+}
+
+func TestOrbSourceValidation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "greeter", "src")
+	files := map[string]string{
+		"@orb.yml": "version: 2.1\ndescription: Greets people\n",
+		"commands/greet.yml": `parameters:
+  to:
+    type: string
+steps:
+  - run: <<include(scripts/greet.sh)>>
+  - run: <<include(scripts/missing.sh)>>
+  - run: <<include(../outside.sh)>>
+`,
+		"jobs/hello.yml": `executor: default
+steps:
+  - greet:
+      to: world
+  - wave
+`,
+		"executors/default.yml": "docker:\n  - image: cimg/base:2024.01\n",
+		"examples/simple.yml": `description: Greets the world
+usage:
+  version: 2.1
+  orbs:
+    greeter: org/greeter@1.0.0
+  workflows:
+    main:
+      jobs:
+        - greeter/hello
+        - greeter/wave
+`,
+		"scripts/greet.sh": "echo hello\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	createValidate := func(name string) Validate {
+		context := testHelpers.GetDefaultLsContext()
+		context.Api.Token = ""
+		URI := uri.File(filepath.Join(dir, filepath.FromSlash(name)))
+		doc, err := parser.ParseFromURI(URI, context)
+		assert.NoError(t, err)
+		doc.OrbSource = parser.LoadOrbSource(URI, nil, context)
+
+		return Validate{
+			APIs:        ValidateAPIs{DockerHub: DockerHubMock{}},
+			Diagnostics: &[]protocol.Diagnostic{},
+			Cache:       utils.CreateCache(),
+			Doc:         doc,
+			Context:     context,
+		}
+	}
+
+	getMessages := func(val Validate) []string {
+		messages := []string{}
+		for _, diagnostic := range *val.Diagnostics {
+			messages = append(messages, diagnostic.Message)
+		}
+		return messages
+	}
+
+	val := createValidate("jobs/hello.yml")
+	val.ValidateJobs()
+	assert.ElementsMatch(t, []string{"Cannot find declaration for step wave"}, getMessages(val))
+
+	val = createValidate("commands/greet.yml")
+	val.ValidateOrbAuthoring()
+	assert.ElementsMatch(t, []string{
+		"File `scripts/missing.sh` does not exist in the orb source directory",
+		"File `../outside.sh` is outside of the orb source directory, it can't be included",
+	}, getMessages(val))
+
+	val = createValidate("examples/simple.yml")
+	val.ValidateOrbAuthoring()
+	assert.ElementsMatch(t, []string{"Job `wave` is not defined in this orb"}, getMessages(val))
+}
@@ -0,0 +1,297 @@
+package validate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+type secretPattern struct {
+	Description string
+	Regex       *regexp.Regexp
+	EnvVariable string
+}
+
+// Credentials that are recognizable wherever they are written
+var secretPatterns = []secretPattern{
+	{
+		Description: "AWS access key ID",
+		Regex:       regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		EnvVariable: "AWS_ACCESS_KEY_ID",
+	},
+	{
+		Description: "GitHub token",
+		Regex:       regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`),
+		EnvVariable: "GITHUB_TOKEN",
+	},
+	{
+		Description: "private key",
+		Regex:       regexp.MustCompile(`-----BEGIN[A-Z ]* PRIVATE KEY( BLOCK)?-----`),
+		EnvVariable: "PRIVATE_KEY",
+	},
+}
+
+// Keys of the `auth` and `aws_auth` maps of the Docker images that should
+// only be given through environment variables
+var credentialKeys = map[string]string{
+	"password":              "DOCKERHUB_PASSWORD",
+	"aws_secret_access_key": "AWS_SECRET_ACCESS_KEY",
+}
+
+const (
+	minSecretLength  = 20
+	minSecretEntropy = 4.0
+)
+
+// ValidateSecrets warns about credentials written in clear in the config file
+func (val Validate) ValidateSecrets() {
+	if val.Doc.RootNode == nil {
+		return
+	}
+
+	val.findSecrets(val.Doc.RootNode, []string{})
+}
+
+func (val Validate) findSecrets(node *sitter.Node, path []string) {
+	if node.Type() == "block_mapping_pair" || node.Type() == "flow_pair" {
+		keyNode, valueNode := val.Doc.GetKeyValueNodes(node)
+		if keyNode == nil || valueNode == nil {
+			return
+		}
+
+		key := val.Doc.GetNodeText(keyNode)
+		if scalar := getScalarNode(valueNode); scalar != nil {
+			val.checkScalarValue(node, scalar, key, path)
+			return
+		}
+
+		val.findSecrets(valueNode, append(path, key))
+		return
+	}
+
+	if scalar := getScalarNode(node); scalar != nil {
+		val.checkScalar(scalar, nil)
+		return
+	}
+
+	for i := 0; i < int(node.ChildCount()); i++ {
+		val.findSecrets(node.Child(i), path)
+	}
+}
+
+// Checks a value of a mapping, whose meaning depends on its key
+func (val Validate) checkScalarValue(pair *sitter.Node, scalar *sitter.Node, key string, path []string) {
+	parent := ""
+	if len(path) > 0 {
+		parent = path[len(path)-1]
+	}
+
+	// The values of `environment` are not expanded by CircleCI, a secret can
+	// only be removed to be set in a context or in the project settings
+	environmentPair := pair
+	if parent != "environment" {
+		environmentPair = nil
+	}
+
+	if val.checkScalar(scalar, environmentPair) {
+		return
+	}
+
+	value := strings.TrimSpace(val.Doc.GetNodeText(scalar))
+	if isInterpolated(value) {
+		return
+	}
+
+	switch parent {
+	case "auth", "aws_auth":
+		envVariable, ok := credentialKeys[key]
+		if !ok || value == "" {
+			return
+		}
+
+		val.addSecretDiagnostic(
+			val.Doc.NodeToRange(scalar),
+			fmt.Sprintf("Possible hard-coded secret: `%s` should be set with an environment variable", key),
+			envVariable,
+		)
+
+	case "environment":
+		if !isHighEntropy(value) {
+			return
+		}
+
+		val.addDiagnostic(utils.CreateDiagnosticFromRange(
+			val.Doc.NodeToRange(scalar),
+			protocol.DiagnosticSeverityWarning,
+			fmt.Sprintf("Possible hard-coded secret: the value of `%s` looks like a credential", key),
+			[]protocol.CodeAction{val.createRemoveEnvironmentPairAction(pair, key)},
+		))
+	}
+}
+
+// Looks for known credential formats in a scalar, returns true when one is
+// found. environmentPair is the pair of the scalar when it is the value of an
+// environment variable
+func (val Validate) checkScalar(scalar *sitter.Node, environmentPair *sitter.Node) bool {
+	text := val.Doc.GetNodeText(scalar)
+	found := false
+
+	for _, pattern := range secretPatterns {
+		for _, match := range pattern.Regex.FindAllStringIndex(text, -1) {
+			found = true
+			rng := val.rangeInScalar(scalar, text, match[0], match[1])
+
+			// A private key spans over several lines, the whole value has
+			// to be replaced
+			replacedRange := rng
+			if pattern.EnvVariable == "PRIVATE_KEY" {
+				replacedRange = val.Doc.NodeToRange(scalar)
+			}
+
+			action := val.createReplaceWithEnvVariableAction(replacedRange, pattern.EnvVariable)
+			if environmentPair != nil {
+				keyNode, _ := val.Doc.GetKeyValueNodes(environmentPair)
+				action = val.createRemoveEnvironmentPairAction(environmentPair, val.Doc.GetNodeText(keyNode))
+			}
+
+			val.addDiagnostic(utils.CreateDiagnosticFromRange(
+				rng,
+				protocol.DiagnosticSeverityWarning,
+				fmt.Sprintf("Possible hard-coded secret: %s", pattern.Description),
+				[]protocol.CodeAction{action},
+			))
+		}
+	}
+
+	return found
+}
+
+func (val Validate) addSecretDiagnostic(rng protocol.Range, message string, envVariable string) {
+	val.addDiagnostic(utils.CreateDiagnosticFromRange(
+		rng,
+		protocol.DiagnosticSeverityWarning,
+		message,
+		[]protocol.CodeAction{
+			val.createReplaceWithEnvVariableAction(rng, envVariable),
+		},
+	))
+}
+
+func (val Validate) createReplaceWithEnvVariableAction(rng protocol.Range, envVariable string) protocol.CodeAction {
+	return utils.CreateCodeActionTextEdit(
+		fmt.Sprintf("Replace with `$%s`", envVariable),
+		val.Doc.URI,
+		[]protocol.TextEdit{
+			{
+				Range:   rng,
+				NewText: "$" + envVariable,
+			},
+		},
+		true,
+	)
+}
+
+// Removes the environment variable, for it to be set in a context or in the
+// project settings where it is kept secret
+func (val Validate) createRemoveEnvironmentPairAction(pair *sitter.Node, key string) protocol.CodeAction {
+	rng := val.Doc.NodeToRange(pair)
+
+	if pair.Type() == "block_mapping_pair" {
+		// The whole lines of the pair
+		endLine := rng.End.Line + 1
+		if rng.End.Character == 0 && rng.End.Line > rng.Start.Line {
+			endLine = rng.End.Line
+		}
+		rng = protocol.Range{
+			Start: protocol.Position{Line: rng.Start.Line},
+			End:   protocol.Position{Line: endLine},
+		}
+	} else if next := pair.NextSibling(); next != nil && next.Type() == "," {
+		// The pair of a flow mapping with the comma separating it from the
+		// next one
+		if following := next.NextSibling(); following != nil {
+			rng.End = val.Doc.NodeToRange(following).Start
+		}
+	} else if previous := pair.PrevSibling(); previous != nil && previous.Type() == "," {
+		rng.Start = val.Doc.NodeToRange(previous).Start
+	}
+
+	return utils.CreateCodeActionTextEdit(
+		fmt.Sprintf("Remove `%s` to set it in a context or in the project settings", key),
+		val.Doc.URI,
+		[]protocol.TextEdit{{Range: rng, NewText: ""}},
+		true,
+	)
+}
+
+// Returns the range of text[start:end], text being the content of the scalar
+func (val Validate) rangeInScalar(scalar *sitter.Node, text string, start int, end int) protocol.Range {
+	toPosition := func(offset int) protocol.Position {
+		line := scalar.StartPoint().Row
+		character := scalar.StartPoint().Column
+
+		before := text[:offset]
+		if idx := strings.LastIndex(before, "\n"); idx != -1 {
+			line += uint32(strings.Count(before, "\n"))
+			character = uint32(len(before) - idx - 1)
+		} else {
+			character += uint32(len(before))
+		}
+
+		return protocol.Position{Line: line, Character: character}
+	}
+
+	return utils.AddOffsetToRange(protocol.Range{
+		Start: toPosition(start),
+		End:   toPosition(end),
+	}, val.Doc.Offset)
+}
+
+func getScalarNode(node *sitter.Node) *sitter.Node {
+	if node == nil {
+		return nil
+	}
+
+	switch node.Type() {
+	case "plain_scalar", "double_quote_scalar", "single_quote_scalar", "block_scalar":
+		return node
+	case "flow_node", "block_node":
+		if node.ChildCount() == 1 {
+			return getScalarNode(node.Child(0))
+		}
+	}
+
+	return nil
+}
+
+func isInterpolated(value string) bool {
+	return strings.Contains(value, "$") || strings.Contains(value, "<<")
+}
+
+// Returns true for long values without spaces with a high Shannon entropy,
+// such as API tokens
+func isHighEntropy(value string) bool {
+	value = strings.Trim(value, `"'`)
+	if len(value) < minSecretLength || strings.ContainsAny(value, " \t\n") || strings.Contains(value, "://") {
+		return false
+	}
+
+	frequencies := map[rune]float64{}
+	for _, char := range value {
+		frequencies[char]++
+	}
+
+	entropy := 0.0
+	length := float64(len([]rune(value)))
+	for _, count := range frequencies {
+		probability := count / length
+		entropy -= probability * math.Log2(probability)
+	}
+
+	return entropy >= minSecretEntropy
+}
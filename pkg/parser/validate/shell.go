@@ -0,0 +1,136 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/shellcheck"
+	"go.lsp.dev/protocol"
+)
+
+// ValidateShellScripts lints the command of every run step with the shell
+// linter, if any, and reports the findings at their position in the YAML. The
+// run steps of the `pre-steps` and `post-steps` of the workflows, and the ones
+// given as value or default of a `steps` parameter are linted as well
+func (val Validate) ValidateShellScripts() {
+	if val.APIs.ShellLinter == nil {
+		return
+	}
+
+	for _, job := range val.Doc.Jobs {
+		val.lintRunSteps(job.Steps, job.Shell)
+		val.lintStepsParametersDefaults(job.Parameters, job.Shell)
+	}
+
+	for _, command := range val.Doc.Commands {
+		val.lintRunSteps(command.Steps, "")
+		val.lintStepsParametersDefaults(command.Parameters, "")
+	}
+
+	for _, workflow := range val.Doc.Workflows {
+		for _, jobInvocation := range workflow.JobInvocations {
+			shell := ""
+			if job, ok := val.Doc.Jobs[jobInvocation.JobName]; ok {
+				shell = job.Shell
+			}
+
+			val.lintRunSteps(jobInvocation.PreSteps, shell)
+			val.lintRunSteps(jobInvocation.PostSteps, shell)
+			for _, value := range jobInvocation.Parameters {
+				val.lintRunSteps(stepsOfParameterValue(value), shell)
+			}
+		}
+	}
+}
+
+func (val Validate) lintStepsParametersDefaults(parameters map[string]ast.Parameter, defaultShell string) {
+	for _, parameter := range parameters {
+		if stepsParameter, ok := parameter.(ast.StepsParameter); ok && stepsParameter.HasDefault {
+			val.lintRunSteps(stepsOfParameterValue(stepsParameter.Default), defaultShell)
+		}
+	}
+}
+
+func (val Validate) lintRunSteps(steps []ast.Step, defaultShell string) {
+	for _, step := range steps {
+		if namedStep, ok := step.(ast.NamedStep); ok {
+			for _, value := range namedStep.Parameters {
+				val.lintRunSteps(stepsOfParameterValue(value), defaultShell)
+			}
+			continue
+		}
+
+		run, ok := step.(ast.Run)
+		if !ok || run.RawCommand == "" {
+			continue
+		}
+
+		shell := run.Shell
+		if shell == "" {
+			shell = defaultShell
+		}
+
+		script := parser.GetRunScript(run)
+		if script.IsInclude() {
+			// The script is the content of the included file, which is not
+			// part of the document
+			continue
+		}
+
+		for _, finding := range val.APIs.ShellLinter.Lint(script.MaskedText(), shell) {
+			val.addDiagnostic(findingToDiagnostic(script, finding))
+		}
+	}
+}
+
+// Returns the steps of a list of steps given as value of a parameter
+func stepsOfParameterValue(value ast.ParameterValue) []ast.Step {
+	values, ok := value.Value.([]ast.ParameterValue)
+	if !ok {
+		return nil
+	}
+
+	res := []ast.Step{}
+	for _, value := range values {
+		if steps, ok := value.Value.([]ast.Step); ok && value.Type == "steps" {
+			res = append(res, steps...)
+		}
+	}
+	return res
+}
+
+func findingToDiagnostic(script parser.RunScript, finding shellcheck.Finding) protocol.Diagnostic {
+	diagnostic := protocol.Diagnostic{
+		Range: script.RangeAt(
+			script.OffsetOf(finding.Line, finding.Column),
+			script.OffsetOf(finding.EndLine, finding.EndColumn),
+		),
+		Severity: findingSeverity(finding.Severity),
+		Source:   "cci-language-server",
+		Message:  finding.Message,
+	}
+
+	if finding.Code != "" {
+		diagnostic.Message = fmt.Sprintf("%s: %s", finding.Code, finding.Message)
+		diagnostic.Code = finding.Code
+		diagnostic.CodeDescription = &protocol.CodeDescription{
+			Href: protocol.URI("https://www.shellcheck.net/wiki/" + finding.Code),
+		}
+	}
+
+	return diagnostic
+}
+
+func findingSeverity(severity shellcheck.Severity) protocol.DiagnosticSeverity {
+	switch severity {
+	case shellcheck.SeverityError:
+		return protocol.DiagnosticSeverityError
+	case shellcheck.SeverityWarning:
+		return protocol.DiagnosticSeverityWarning
+	case shellcheck.SeverityInfo:
+		return protocol.DiagnosticSeverityInformation
+	default:
+		return protocol.DiagnosticSeverityHint
+	}
+}
@@ -0,0 +1,180 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/shellcheck"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+func TestShellScriptsValidation(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		YamlContent   string
+		ExpectedRange *protocol.Range
+	}{
+		{
+			Name: "Valid script with parameters",
+			YamlContent: `version: 2.1
+
+commands:
+  greet:
+    parameters:
+      to:
+        type: string
+    steps:
+      - run: |
+          cat <<EOF > << parameters.to >>
+          hello
+          EOF
+`,
+		},
+		{
+			Name: "Syntax error in a block scalar",
+			YamlContent: `version: 2.1
+
+commands:
+  greet:
+    steps:
+      - run:
+          name: Greet
+          command: |
+            echo "hello"
+            if true; then
+              echo "world"
+`,
+			ExpectedRange: &protocol.Range{
+				Start: protocol.Position{Line: 9, Character: 12},
+				End:   protocol.Position{Line: 9, Character: 13},
+			},
+		},
+		{
+			Name: "Syntax error in a plain scalar",
+			YamlContent: `version: 2.1
+
+commands:
+  greet:
+    steps:
+      - run: echo "hello
+`,
+			ExpectedRange: &protocol.Range{
+				Start: protocol.Position{Line: 5, Character: 18},
+				End:   protocol.Position{Line: 5, Character: 19},
+			},
+		},
+		{
+			Name: "Syntax error in the pre-steps of a workflow",
+			YamlContent: `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+
+workflows:
+  main:
+    jobs:
+      - build:
+          pre-steps:
+            - run: echo "hello
+`,
+			ExpectedRange: &protocol.Range{
+				Start: protocol.Position{Line: 14, Character: 24},
+				End:   protocol.Position{Line: 14, Character: 25},
+			},
+		},
+		{
+			Name: "Syntax error in the value of a steps parameter",
+			YamlContent: `version: 2.1
+
+commands:
+  wrap:
+    parameters:
+      inner:
+        type: steps
+    steps:
+      - steps: << parameters.inner >>
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - wrap:
+          inner:
+            - run: echo "hello
+`,
+			ExpectedRange: &protocol.Range{
+				Start: protocol.Position{Line: 17, Character: 24},
+				End:   protocol.Position{Line: 17, Character: 25},
+			},
+		},
+		{
+			Name: "Syntax error in the default of a steps parameter",
+			YamlContent: `version: 2.1
+
+commands:
+  wrap:
+    parameters:
+      inner:
+        type: steps
+        default:
+          - run: echo "hello
+    steps:
+      - steps: << parameters.inner >>
+`,
+			ExpectedRange: &protocol.Range{
+				Start: protocol.Position{Line: 8, Character: 22},
+				End:   protocol.Position{Line: 8, Character: 23},
+			},
+		},
+		{
+			Name: "Script included from an orb source",
+			YamlContent: `version: 2.1
+
+commands:
+  install:
+    steps:
+      - run:
+          name: Install
+          command: <<include(scripts/install.sh)>>
+      - run: |
+          echo <<include(scripts/version.txt)>>
+`,
+		},
+		{
+			Name: "Non POSIX shells are ignored",
+			YamlContent: `version: 2.1
+
+commands:
+  greet:
+    steps:
+      - run:
+          shell: powershell.exe
+          command: if ($true) { Write-Host "hello" }
+`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Name, func(t *testing.T) {
+			val := CreateValidateFromYAML(tt.YamlContent)
+			val.APIs.ShellLinter = shellcheck.NewLinter()
+			val.ValidateShellScripts()
+
+			diags := getErrorDiagnostic(val.Diagnostics)
+
+			if tt.ExpectedRange == nil {
+				assert.Len(t, diags, 0)
+				return
+			}
+
+			assert.Len(t, diags, 1)
+			assert.Equal(t, *tt.ExpectedRange, diags[0].Range)
+			assert.Contains(t, diags[0].Message, "Shell syntax error")
+		})
+	}
+}
@@ -194,10 +194,10 @@ func (val Validate) validateNamedStep(step ast.NamedStep, usableParams map[strin
 }
 
 func (val Validate) validateStepSteps(step ast.Steps, name string) {
-	if !val.Doc.DoesCommandExist(name) {
+	command, ok := val.Doc.GetCommand(name)
+	if !ok {
 		return
 	}
-	command := val.Doc.Commands[name]
 	parameter, ok := command.Parameters[step.Name]
 	if !ok {
 		return
@@ -1,15 +1,20 @@
 package validate
 
 import (
+	"context"
+	"time"
+
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/shellcheck"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 
 	"go.lsp.dev/protocol"
 )
 
 type ValidateAPIs struct {
-	DockerHub dockerhub.DockerHubAPI
+	DockerHub   dockerhub.DockerHubAPI
+	ShellLinter shellcheck.ShellLinter
 }
 
 type Validate struct {
@@ -19,20 +24,66 @@ type Validate struct {
 	Cache       *utils.Cache
 	Context     *utils.LsContext
 	IsLocalOrb  bool
+
+	// Aborts the requests made during the validation, a newer version of the
+	// document makes the result useless
+	Ctx context.Context
 }
 
 func (val *Validate) Validate() {
-	if !val.IsLocalOrb {
-		val.CheckIfParamsExist()
-		val.ValidateAnchors()
-		val.ValidateJobGroups()
-		val.ValidateWorkflows()
-		val.ValidateOrbs()
-		val.CheckNames()
-		val.ValidatePipelineParameters()
-		val.ValidateLocalOrbs()
+	defer utils.LogDurationContext(val.getCtx(), "document validated", time.Now(), "uri", val.Doc.URI)
+
+	if val.Doc.Version != 0 && val.Doc.Version < 2.1 {
+		val.timed("ValidateConfig20", val.ValidateConfig20)
+		val.timed("ValidateAnchors", val.ValidateAnchors)
+		return
 	}
-	val.ValidateJobs()
-	val.ValidateCommands()
-	val.ValidateExecutors()
+
+	if val.Doc.IsOrb {
+		// Orbs have no workflows nor pipeline parameters
+		val.timed("CheckIfParamsExist", val.CheckIfParamsExist)
+		val.timed("ValidateAnchors", val.ValidateAnchors)
+		val.timed("ValidateOrbs", val.ValidateOrbs)
+		val.timed("CheckNames", val.CheckNames)
+		val.timed("ValidateLocalOrbs", val.ValidateLocalOrbs)
+		val.timed("ValidateOrbAuthoring", val.ValidateOrbAuthoring)
+	} else if !val.IsLocalOrb {
+		val.timed("CheckIfParamsExist", val.CheckIfParamsExist)
+		val.timed("ValidateAnchors", val.ValidateAnchors)
+		val.timed("ValidateJobGroups", val.ValidateJobGroups)
+		val.timed("ValidateWorkflows", val.ValidateWorkflows)
+		val.timed("ValidateWorkspaces", val.ValidateWorkspaces)
+		val.timed("ValidateOrbs", val.ValidateOrbs)
+		val.timed("CheckNames", val.CheckNames)
+		val.timed("ValidatePipelineParameters", val.ValidatePipelineParameters)
+		val.timed("ValidateLocalOrbs", val.ValidateLocalOrbs)
+	}
+	val.timed("ValidateJobs", val.ValidateJobs)
+	val.timed("ValidateCommands", val.ValidateCommands)
+	val.timed("ValidateExecutors", val.ValidateExecutors)
+	val.timed("ValidateShellScripts", val.ValidateShellScripts)
+	val.timed("ValidateFileReferences", val.ValidateFileReferences)
+	if !val.Doc.IsOrb {
+		// The variables of an orb are set by the projects using it
+		val.timed("ValidateEnvVariables", val.ValidateEnvVariables)
+	}
+	val.timed("ValidateSecrets", val.ValidateSecrets)
+}
+
+// Runs the validator, logging the time it took
+func (val *Validate) timed(name string, validator func()) {
+	defer utils.LogDurationContext(val.getCtx(), "validator run", time.Now(), "validator", name, "uri", val.Doc.URI)
+	validator()
+}
+
+func (val Validate) getCtx() context.Context {
+	if val.Ctx == nil {
+		return context.Background()
+	}
+	return val.Ctx
+}
+
+// Jobs and commands of orbs are meant to be used by other configs
+func (val Validate) isPartOfOrb() bool {
+	return val.IsLocalOrb || val.Doc.IsOrb
 }
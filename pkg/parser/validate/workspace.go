@@ -0,0 +1,146 @@
+package validate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// ValidateWorkspaces checks the data flow between `persist_to_workspace` and
+// `attach_workspace` steps along the DAG of every workflow.
+//
+// A job may be invoked from several workflows: a step is only reported when
+// it is useless in every one of them.
+func (val Validate) ValidateWorkspaces() {
+	attachSatisfied := map[protocol.Range]bool{}
+	persistConsumed := map[protocol.Range]bool{}
+
+	for _, workflowName := range slices.Sorted(maps.Keys(val.Doc.Workflows)) {
+		workflow := val.Doc.Workflows[workflowName]
+		usages := map[string]parser.WorkspaceUsage{}
+		for _, invocation := range workflow.JobInvocations {
+			usages[invocation.StepName] = val.Doc.GetWorkspaceUsage(invocation)
+		}
+
+		for _, invocation := range workflow.JobInvocations {
+			usage := usages[invocation.StepName]
+
+			if len(usage.Attaches) > 0 {
+				satisfied := false
+				for _, upstream := range parser.GetUpstreamInvocations(workflow.JobInvocations, invocation.StepName) {
+					upstreamUsage := usages[upstream.StepName]
+					if !upstreamUsage.Complete || len(upstreamUsage.Persists) > 0 {
+						satisfied = true
+						break
+					}
+				}
+
+				for _, attach := range usage.Attaches {
+					attachSatisfied[attach.Range] = attachSatisfied[attach.Range] || satisfied
+				}
+			}
+
+			if len(usage.Persists) > 0 {
+				consumed := false
+				for _, downstream := range parser.GetDownstreamInvocations(workflow.JobInvocations, workflow.JobsDAG, invocation.StepName) {
+					downstreamUsage := usages[downstream.StepName]
+					if !downstreamUsage.Complete || len(downstreamUsage.Attaches) > 0 {
+						consumed = true
+						break
+					}
+				}
+
+				for _, persist := range usage.Persists {
+					persistConsumed[persist.Range] = persistConsumed[persist.Range] || consumed
+				}
+			}
+		}
+
+		val.checkConcurrentPersistedPaths(workflow, usages)
+	}
+
+	for _, rng := range sortedRanges(attachSatisfied) {
+		if !attachSatisfied[rng] {
+			val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+				rng,
+				"Workspace is attached but no upstream job persists anything to it",
+			))
+		}
+	}
+
+	for _, rng := range sortedRanges(persistConsumed) {
+		if !persistConsumed[rng] {
+			val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+				rng,
+				"Persisted paths are never attached by a downstream job",
+			))
+		}
+	}
+}
+
+// The diagnostics are reported in the order of the document, the maps being
+// iterated in a random order
+func sortedRanges(ranges map[protocol.Range]bool) []protocol.Range {
+	return slices.SortedFunc(maps.Keys(ranges), func(a, b protocol.Range) int {
+		if a.Start.Line != b.Start.Line {
+			return int(a.Start.Line) - int(b.Start.Line)
+		}
+		return int(a.Start.Character) - int(b.Start.Character)
+	})
+}
+
+// Jobs that are not ordered by `requires` run concurrently; persisting the
+// same files from two of them makes any downstream `attach_workspace` fail
+func (val Validate) checkConcurrentPersistedPaths(workflow ast.Workflow, usages map[string]parser.WorkspaceUsage) {
+	for i, invocation := range workflow.JobInvocations {
+		usage := usages[invocation.StepName]
+		if len(usage.Persists) == 0 {
+			continue
+		}
+
+		ordered := map[string]bool{}
+		for _, upstream := range parser.GetUpstreamInvocations(workflow.JobInvocations, invocation.StepName) {
+			ordered[upstream.StepName] = true
+		}
+		for _, downstream := range parser.GetDownstreamInvocations(workflow.JobInvocations, workflow.JobsDAG, invocation.StepName) {
+			ordered[downstream.StepName] = true
+		}
+
+		for _, sibling := range workflow.JobInvocations[i+1:] {
+			if ordered[sibling.StepName] || sibling.StepName == invocation.StepName {
+				continue
+			}
+
+			for _, persist := range usage.Persists {
+				for _, siblingPersist := range usages[sibling.StepName].Persists {
+					val.reportPersistClash(persist, invocation, siblingPersist, sibling)
+				}
+			}
+		}
+	}
+}
+
+func (val Validate) reportPersistClash(persist ast.PersistToWorkspace, invocation ast.JobInvocation, siblingPersist ast.PersistToWorkspace, sibling ast.JobInvocation) {
+	for _, persistedPath := range parser.GetPersistedPaths(persist) {
+		for _, siblingPath := range parser.GetPersistedPaths(siblingPersist) {
+			if !parser.DoPersistedPathsOverlap(persistedPath, siblingPath) {
+				continue
+			}
+
+			val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+				persist.Range,
+				fmt.Sprintf("Path `%s` is also persisted by the concurrent job `%s`", persistedPath, sibling.StepName),
+			))
+			val.addDiagnostic(utils.CreateWarningDiagnosticFromRange(
+				siblingPersist.Range,
+				fmt.Sprintf("Path `%s` is also persisted by the concurrent job `%s`", siblingPath, invocation.StepName),
+			))
+			return
+		}
+	}
+}
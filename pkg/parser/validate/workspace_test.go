@@ -0,0 +1,255 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+func TestWorkspaceValidation(t *testing.T) {
+	testCases := []ValidateTestCase{
+		{
+			Name: "Attached workspace persisted by an upstream job",
+			YamlContent: `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - persist_to_workspace:
+          root: .
+          paths:
+            - dist
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: .
+
+workflows:
+  main:
+    jobs:
+      - build
+      - deploy:
+          requires:
+            - build`,
+		},
+		{
+			Name: "Attached workspace without any upstream persist",
+			YamlContent: `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: .
+
+workflows:
+  main:
+    jobs:
+      - build
+      - deploy:
+          requires:
+            - build`,
+			Diagnostics: []protocol.Diagnostic{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 12, Character: 8},
+						End:   protocol.Position{Line: 12, Character: 24},
+					},
+					Severity: protocol.DiagnosticSeverityWarning,
+					Source:   "cci-language-server",
+					Message:  "Workspace is attached but no upstream job persists anything to it",
+					Data:     []protocol.CodeAction{},
+				},
+			},
+		},
+		{
+			Name: "Persisted paths without any downstream attach",
+			YamlContent: `version: 2.1
+
+commands:
+  save:
+    steps:
+      - persist_to_workspace:
+          root: .
+          paths:
+            - dist
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - save
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+
+workflows:
+  main:
+    jobs:
+      - build
+      - deploy:
+          requires:
+            - build`,
+			Diagnostics: []protocol.Diagnostic{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 5, Character: 8},
+						End:   protocol.Position{Line: 5, Character: 28},
+					},
+					Severity: protocol.DiagnosticSeverityWarning,
+					Source:   "cci-language-server",
+					Message:  "Persisted paths are never attached by a downstream job",
+					Data:     []protocol.CodeAction{},
+				},
+			},
+		},
+		{
+			Name: "Workspace steps hidden behind steps parameters are not reported",
+			YamlContent: `version: 2.1
+
+jobs:
+  build:
+    parameters:
+      extra-steps:
+        type: steps
+        default: []
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - steps: << parameters.extra-steps >>
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: .
+
+workflows:
+  main:
+    jobs:
+      - build
+      - deploy:
+          requires:
+            - build`,
+		},
+		{
+			Name: "Concurrent jobs persisting the same path",
+			YamlContent: `version: 2.1
+
+jobs:
+  build-a:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - persist_to_workspace:
+          root: .
+          paths:
+            - dist
+  build-b:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - persist_to_workspace:
+          root: .
+          paths:
+            - dist/b
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: .
+
+workflows:
+  main:
+    jobs:
+      - build-a
+      - build-b
+      - deploy:
+          requires:
+            - build-a
+            - build-b`,
+			Diagnostics: []protocol.Diagnostic{
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 7, Character: 8},
+						End:   protocol.Position{Line: 7, Character: 28},
+					},
+					Severity: protocol.DiagnosticSeverityWarning,
+					Source:   "cci-language-server",
+					Message:  "Path `dist` is also persisted by the concurrent job `build-b`",
+					Data:     []protocol.CodeAction{},
+				},
+				{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 15, Character: 8},
+						End:   protocol.Position{Line: 15, Character: 28},
+					},
+					Severity: protocol.DiagnosticSeverityWarning,
+					Source:   "cci-language-server",
+					Message:  "Path `dist/b` is also persisted by the concurrent job `build-a`",
+					Data:     []protocol.CodeAction{},
+				},
+			},
+		},
+	}
+
+	CheckYamlErrors(t, testCases)
+}
+
+func TestWorkspaceValidationOrder(t *testing.T) {
+	yaml := `version: 2.1
+
+jobs:
+  first:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: .
+  second:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: .
+  third:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: .
+
+workflows:
+  main:
+    jobs:
+      - first
+      - second
+      - third`
+
+	for range 10 {
+		val := CreateValidateFromYAML(yaml)
+		val.ValidateWorkspaces()
+
+		lines := []uint32{}
+		for _, diagnostic := range *val.Diagnostics {
+			lines = append(lines, diagnostic.Range.Start.Line)
+		}
+		assert.Equal(t, []uint32{7, 13, 19}, lines)
+	}
+}
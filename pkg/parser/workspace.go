@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"path"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+)
+
+// WorkspaceUsage describes how a job invocation interacts with the workflow
+// workspace through `persist_to_workspace` and `attach_workspace` steps
+type WorkspaceUsage struct {
+	Persists []ast.PersistToWorkspace
+	Attaches []ast.AttachWorkspace
+
+	// Complete is false when some steps of the job could not be resolved
+	// (orb jobs or commands, steps parameters, ...): the job might then use
+	// the workspace in ways that are not visible here.
+	Complete bool
+}
+
+// GetWorkspaceUsage collects the workspace steps of a job invocation,
+// including its pre-steps, post-steps and the steps of the local commands it
+// calls
+func (doc *YamlDocument) GetWorkspaceUsage(invocation ast.JobInvocation) WorkspaceUsage {
+	usage := WorkspaceUsage{Complete: true}
+
+	job, ok := doc.Jobs[invocation.JobName]
+	if !ok {
+		// Approval jobs never run any step
+		usage.Complete = invocation.Type == "approval"
+		return usage
+	}
+
+	visitedCommands := map[string]bool{}
+	doc.collectWorkspaceSteps(invocation.PreSteps, &usage, visitedCommands)
+	doc.collectWorkspaceSteps(job.Steps, &usage, visitedCommands)
+	doc.collectWorkspaceSteps(invocation.PostSteps, &usage, visitedCommands)
+
+	return usage
+}
+
+func (doc *YamlDocument) collectWorkspaceSteps(steps []ast.Step, usage *WorkspaceUsage, visitedCommands map[string]bool) {
+	for _, step := range steps {
+		switch step := step.(type) {
+		case ast.PersistToWorkspace:
+			usage.Persists = append(usage.Persists, step)
+		case ast.AttachWorkspace:
+			usage.Attaches = append(usage.Attaches, step)
+		case ast.Steps:
+			usage.Complete = false
+		case ast.NamedStep:
+			if doc.IsBuiltIn(step.Name) {
+				continue
+			}
+
+			command, ok := doc.Commands[step.Name]
+			if !ok {
+				usage.Complete = false
+				continue
+			}
+
+			if visitedCommands[step.Name] {
+				continue
+			}
+			visitedCommands[step.Name] = true
+			doc.collectWorkspaceSteps(command.Steps, usage, visitedCommands)
+		}
+	}
+}
+
+// GetPersistedPaths returns the workspace paths persisted by the given step,
+// relative to the workspace root
+func GetPersistedPaths(step ast.PersistToWorkspace) []string {
+	res := []string{}
+	for _, persistedPath := range step.Paths {
+		if strings.HasPrefix(persistedPath, "/") && step.Root != "" {
+			relative := strings.TrimPrefix(persistedPath, strings.TrimSuffix(step.Root, "/"))
+			if relative != persistedPath {
+				persistedPath = strings.TrimPrefix(relative, "/")
+			}
+		}
+		res = append(res, path.Clean(persistedPath))
+	}
+	return res
+}
+
+// DoPersistedPathsOverlap reports whether two workspace paths, as returned by
+// GetPersistedPaths, refer to the same files
+func DoPersistedPathsOverlap(a string, b string) bool {
+	// Parameterized paths can not be compared before the config is compiled
+	if strings.Contains(a, "<<") || strings.Contains(b, "<<") {
+		return false
+	}
+
+	if a == b || a == "." || b == "." {
+		return true
+	}
+
+	return strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// GetUpstreamInvocations returns every invocation that has to run before the
+// given one, following `requires` transitively
+func GetUpstreamInvocations(invocations []ast.JobInvocation, stepName string) []ast.JobInvocation {
+	byName := map[string]ast.JobInvocation{}
+	for _, invocation := range invocations {
+		byName[invocation.StepName] = invocation
+	}
+
+	res := []ast.JobInvocation{}
+	visited := map[string]bool{stepName: true}
+	queue := []string{stepName}
+
+	for len(queue) > 0 {
+		current, ok := byName[queue[0]]
+		queue = queue[1:]
+		if !ok {
+			continue
+		}
+
+		for _, require := range current.Requires {
+			if visited[require.Name] {
+				continue
+			}
+			visited[require.Name] = true
+
+			if upstream, ok := byName[require.Name]; ok {
+				res = append(res, upstream)
+				queue = append(queue, require.Name)
+			}
+		}
+	}
+
+	return res
+}
+
+// GetDownstreamInvocations returns every invocation that runs after the given
+// one, following the workflow DAG transitively
+func GetDownstreamInvocations(invocations []ast.JobInvocation, dag map[string][]string, stepName string) []ast.JobInvocation {
+	byName := map[string]ast.JobInvocation{}
+	for _, invocation := range invocations {
+		byName[invocation.StepName] = invocation
+	}
+
+	res := []ast.JobInvocation{}
+	visited := map[string]bool{stepName: true}
+	queue := []string{stepName}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, child := range dag[current] {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+
+			if downstream, ok := byName[child]; ok {
+				res = append(res, downstream)
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// Directories never holding CircleCI files, skipped when searching a workspace
+var ignoredWorkspaceDirs = []string{"node_modules", "vendor"}
+
+// IsWorkspaceFile returns true when the file is validated by the server
+// without being opened: the configs of a `.circleci` directory and the orbs,
+// either packed or in the source directory layout
+func IsWorkspaceFile(filename string) bool {
+	if !slices.Contains(orbSourceExtensions, filepath.Ext(filename)) {
+		return false
+	}
+
+	if slices.Contains(strings.Split(filepath.ToSlash(filepath.Dir(filename)), "/"), ".circleci") {
+		return true
+	}
+
+	if slices.Contains(orbFileNames, filepath.Base(filename)) {
+		return true
+	}
+
+	_, ok := GetOrbSourceFile(uri.File(filename))
+	return ok
+}
+
+// FindWorkspaceFiles lists the files of the directory validated by the server,
+// see IsWorkspaceFile
+func FindWorkspaceFiles(dir string) []protocol.URI {
+	files := []protocol.URI{}
+
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != dir && ((strings.HasPrefix(name, ".") && name != ".circleci") || slices.Contains(ignoredWorkspaceDirs, name)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if IsWorkspaceFile(path) {
+			files = append(files, uri.File(path))
+		}
+		return nil
+	})
+
+	return files
+}
@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestFindWorkspaceFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		".circleci/config.yml",
+		".circleci/continue/deploy.yaml",
+		".circleci/README.md",
+		".github/workflows/ci.yml",
+		"node_modules/orb/orb.yml",
+		"docker-compose.yml",
+		"orb.yml",
+		"greeter/src/@orb.yml",
+		"greeter/src/commands/greet.yml",
+		"greeter/src/scripts/greet.sh",
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte("version: 2.1\n"), 0o644))
+	}
+
+	assert.Equal(t, []protocol.URI{
+		uri.File(filepath.Join(dir, ".circleci", "config.yml")),
+		uri.File(filepath.Join(dir, ".circleci", "continue", "deploy.yaml")),
+		uri.File(filepath.Join(dir, "greeter", "src", "@orb.yml")),
+		uri.File(filepath.Join(dir, "greeter", "src", "commands", "greet.yml")),
+		uri.File(filepath.Join(dir, "orb.yml")),
+	}, FindWorkspaceFiles(dir))
+}
@@ -1,12 +1,14 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
@@ -15,12 +17,15 @@ import (
 )
 
 func ParseFile(content []byte, context *utils.LsContext) YamlDocument {
-	rootNode := GetRootNode(content)
+	return newYamlDocument(content, context, ParseTree(content, nil))
+}
 
+func newYamlDocument(content []byte, context *utils.LsContext, tree *sitter.Tree) YamlDocument {
 	doc := YamlDocument{
 		Content:            content,
 		Context:            context,
-		RootNode:           rootNode,
+		Tree:               tree,
+		RootNode:           tree.RootNode(),
 		Commands:           make(map[string]ast.Command),
 		Orbs:               make(map[string]ast.Orb),
 		Jobs:               make(map[string]ast.Job),
@@ -31,6 +36,7 @@ func ParseFile(content []byte, context *utils.LsContext) YamlDocument {
 		Diagnostics:        &[]protocol.Diagnostic{},
 
 		LocalOrbInfo: make(map[string]*ast.OrbInfo),
+		Examples:     make(map[string]OrbExample),
 	}
 
 	return doc
@@ -41,95 +47,139 @@ func (doc *YamlDocument) ParseYAML(context *utils.LsContext, offset protocol.Pos
 		return
 	}
 	doc.Offset = offset
+	doc.parseSections(nil)
+}
+
+// Walks the top-level sections of the document. The sections of the previous
+// version of the document that did not change are reused instead of being
+// walked again, see incremental.go
+func (doc *YamlDocument) parseSections(previous *YamlDocument) {
 	blockMappingNode := GetBlockMappingNode(doc.RootNode)
 	doc.YamlAnchors = ParseYamlAnchors(doc)
 
 	doc.SuppressionInfo = ParseSuppressionComments(doc)
 
+	if doc.OrbSourceFile != nil {
+		doc.parseOrbSourceEntity(blockMappingNode)
+		doc.assignContexts()
+		return
+	}
+
+	if previous != nil && !doc.canReuseSections(previous) {
+		previous = nil
+	}
+	previousSections := previous.getSectionsByStart()
+	doc.sections = []parsedSection{}
+
 	doc.iterateOnBlockMapping(blockMappingNode, func(child *sitter.Node) {
 		keyNode, valueNode := doc.GetKeyValueNodes(child)
 		keyName := doc.GetNodeText(keyNode)
 
-		switch keyName {
-		case "version":
-			if valueNode != nil {
-				doc.parseVersion(valueNode)
-			}
+		section := newParsedSection(keyName, child)
+		if previousSection, ok := previousSections[section.StartByte]; ok && previous.isSameSection(previousSection, doc, section) {
+			doc.reuseSection(previous, previousSection)
+			return
+		}
 
-			doc.VersionRange = doc.NodeToRange(child)
+		diagnosticsCount := len(*doc.Diagnostics)
+		doc.parseSection(keyName, child, valueNode)
+		section.Diagnostics = slices.Clone((*doc.Diagnostics)[diagnosticsCount:])
+		doc.sections = append(doc.sections, section)
+	})
 
-		case "setup":
-			text := strings.TrimSpace(doc.GetNodeText(valueNode))
-			if len(text) != 0 && text != "false" {
-				doc.Setup = true
-			}
-			doc.SetupRange = doc.NodeToRange(child)
-
-		case "orbs":
-			if valueNode != nil {
-				doc.OrbsRange = doc.NodeToRange(valueNode)
-				doc.parseOrbs(valueNode)
-			} else {
-				doc.OrbsRange = doc.NodeToRange(child)
-			}
+	doc.assignContexts()
+}
 
-		case "commands":
-			if valueNode != nil {
-				doc.CommandsRange = doc.NodeToRange(valueNode)
-				doc.parseCommands(valueNode)
-			} else {
-				doc.CommandsRange = doc.NodeToRange(child)
-			}
+func (doc *YamlDocument) parseSection(keyName string, child *sitter.Node, valueNode *sitter.Node) {
+	switch keyName {
+	case "version":
+		if valueNode != nil {
+			doc.parseVersion(valueNode)
+		}
 
-		case "jobs":
-			if valueNode == nil {
-				break
-			}
+		doc.VersionRange = doc.NodeToRange(child)
+
+	case "setup":
+		text := strings.TrimSpace(doc.GetNodeText(valueNode))
+		if len(text) != 0 && text != "false" {
+			doc.Setup = true
+		}
+		doc.SetupRange = doc.NodeToRange(child)
+
+	case "orbs":
+		if valueNode != nil {
+			doc.OrbsRange = doc.NodeToRange(valueNode)
+			doc.parseOrbs(valueNode)
+		} else {
+			doc.OrbsRange = doc.NodeToRange(child)
+		}
 
-			doc.JobsRange = doc.NodeToRange(valueNode)
-			doc.parseJobs(valueNode)
+	case "commands":
+		if valueNode != nil {
+			doc.CommandsRange = doc.NodeToRange(valueNode)
+			doc.parseCommands(valueNode)
+		} else {
+			doc.CommandsRange = doc.NodeToRange(child)
+		}
 
-		case "job-groups":
-			if valueNode == nil {
-				break
-			}
-			doc.JobGroupsRange = doc.NodeToRange(valueNode)
-			doc.parseJobGroups(valueNode)
+	case "jobs":
+		if valueNode == nil {
+			break
+		}
 
-		case "workflows":
-			if valueNode == nil {
-				break
-			}
+		doc.JobsRange = doc.NodeToRange(valueNode)
+		doc.parseJobs(valueNode)
 
-			doc.WorkflowRange = doc.NodeToRange(valueNode)
-			doc.parseWorkflows(valueNode)
+	case "job-groups":
+		if valueNode == nil {
+			break
+		}
+		doc.JobGroupsRange = doc.NodeToRange(valueNode)
+		doc.parseJobGroups(valueNode)
 
-		case "executors":
-			if valueNode != nil {
-				doc.ExecutorsRange = doc.NodeToRange(valueNode)
-				doc.parseExecutors(valueNode)
-			} else {
-				doc.ExecutorsRange = doc.NodeToRange(child)
-			}
+	case "workflows":
+		if valueNode == nil {
+			break
+		}
 
-		case "description":
-			if valueNode == nil {
-				break
-			}
+		doc.WorkflowRange = doc.NodeToRange(valueNode)
+		doc.parseWorkflows(valueNode)
+
+	case "executors":
+		if valueNode != nil {
+			doc.ExecutorsRange = doc.NodeToRange(valueNode)
+			doc.parseExecutors(valueNode)
+		} else {
+			doc.ExecutorsRange = doc.NodeToRange(child)
+		}
 
-			doc.Description = doc.GetNodeText(valueNode)
+	case "description":
+		if valueNode == nil {
+			break
+		}
 
-		case "parameters":
-			if valueNode != nil {
-				doc.PipelineParametersRange = doc.NodeToRange(valueNode)
-				doc.PipelineParameters = doc.parseParameters(valueNode)
-			} else {
-				doc.PipelineParametersRange = doc.NodeToRange(child)
-			}
+		doc.Description = doc.GetNodeText(valueNode)
+		doc.DescriptionRange = doc.NodeToRange(child)
+
+	case "display":
+		if valueNode != nil {
+			doc.parseDisplay(valueNode)
 		}
-	})
 
-	doc.assignContexts()
+	case "examples":
+		if valueNode != nil {
+			doc.ExamplesRange = doc.NodeToRange(valueNode)
+			doc.parseExamples(valueNode)
+		}
+
+	case "parameters":
+		if valueNode != nil {
+			doc.PipelineParametersRange = doc.NodeToRange(valueNode)
+			doc.PipelineParameters = doc.parseParameters(valueNode)
+		} else {
+			doc.PipelineParametersRange = doc.NodeToRange(child)
+		}
+	}
 }
 
 func (doc *YamlDocument) ValidateYAML() {
@@ -162,29 +212,69 @@ func ParseFromURI(URI protocol.URI, context *utils.LsContext) (YamlDocument, err
 
 var CacheMissingError = errors.New("file not found in cache")
 
-func ParseFromUriWithCache(URI protocol.URI, cache *utils.Cache, context *utils.LsContext) (YamlDocument, error) {
+func ParseFromUriWithCache(URI protocol.URI, cache *utils.Cache, lsContext *utils.LsContext) (YamlDocument, error) {
+	return ParseFromUriWithCacheWithContext(context.Background(), URI, cache, lsContext)
+}
+
+// ParseFromUriWithCacheWithContext is like ParseFromUriWithCache, the timing of
+// the parse being logged to the client of ctx
+func ParseFromUriWithCacheWithContext(ctx context.Context, URI protocol.URI, cache *utils.Cache, context *utils.LsContext) (YamlDocument, error) {
 	cachedFile := cache.FileCache.GetFile(URI)
 
 	if cachedFile == nil {
 		return YamlDocument{}, fmt.Errorf("%w: %s", CacheMissingError, URI.Filename())
 	}
 
-	content := []byte(cachedFile.TextDocument.Text)
+	doc, ok := parsedDocuments.get(URI, cachedFile.ContentHash, context)
+	if !ok {
+		content := []byte(cachedFile.TextDocument.Text)
+
+		var err error
+		doc, err = ParseFromContentWithContext(ctx, content, context, URI, protocol.Position{})
+		if err != nil {
+			return doc, err
+		}
+		parsedDocuments.set(URI, cachedFile.ContentHash, doc)
+	}
 
-	doc, err := ParseFromContent(content, context, URI, protocol.Position{})
+	// The cached document is shared by every request on this version of the
+	// file while the diagnostics are appended to by the validation
+	diagnostics := slices.Clone(*doc.Diagnostics)
+	doc.Diagnostics = &diagnostics
+	doc.OrbSource = GetOrbSource(URI, cache, context)
 
-	return doc, err
+	return doc, nil
 }
 
-func ParseFromContent(content []byte, context *utils.LsContext, URI protocol.URI, offset protocol.Position) (YamlDocument, error) {
-	doc := ParseFile([]byte(content), context)
-	doc.URI = URI
+func ParseFromContent(content []byte, lsContext *utils.LsContext, URI protocol.URI, offset protocol.Position) (YamlDocument, error) {
+	return ParseFromContentWithContext(context.Background(), content, lsContext, URI, offset)
+}
+
+// ParseFromContentWithContext is like ParseFromContent, the timing of the parse
+// being logged to the client of ctx
+func ParseFromContentWithContext(ctx context.Context, content []byte, context *utils.LsContext, URI protocol.URI, offset protocol.Position) (YamlDocument, error) {
+	defer utils.LogDurationContext(ctx, "document parsed", time.Now(), "uri", URI)
 
+	doc := ParseFile([]byte(content), context)
+	doc.setURI(URI, offset)
 	doc.ParseYAML(context, offset)
 
 	return doc, nil
 }
 
+func (doc *YamlDocument) setURI(URI protocol.URI, offset protocol.Position) {
+	doc.URI = URI
+	// Documents parsed at an offset are parts of another file (local orbs,
+	// examples of orbs), they are never orbs on their own
+	doc.IsOrb = offset == (protocol.Position{}) && IsOrbAuthoringFile(URI, doc.Content)
+	if offset == (protocol.Position{}) {
+		if file, ok := GetOrbSourceFile(URI); ok {
+			doc.IsOrb = true
+			doc.OrbSourceFile = &file
+		}
+	}
+}
+
 type YamlAnchor struct {
 	Name            string
 	DefinitionRange protocol.Range
@@ -194,9 +284,11 @@ type YamlAnchor struct {
 
 type YamlDocument struct {
 	Content        []byte
+	Tree           *sitter.Tree
 	RootNode       *sitter.Node
 	Version        float32
 	Description    string
+	IsOrb          bool
 	URI            protocol.URI
 	Diagnostics    *[]protocol.Diagnostic
 	Context        *utils.LsContext
@@ -222,6 +314,16 @@ type YamlDocument struct {
 	WorkflowRange           protocol.Range
 	PipelineParametersRange protocol.Range
 	VersionRange            protocol.Range
+	DescriptionRange        protocol.Range
+
+	// Orb authoring
+	Display       ast.OrbDisplay
+	Examples      map[string]OrbExample
+	ExamplesRange protocol.Range
+
+	// Source directory of orbs, see orbSource.go
+	OrbSource     *OrbSource
+	OrbSourceFile *OrbSourceFile
 
 	LocalOrbInfo map[string]*ast.OrbInfo
 
@@ -229,6 +331,10 @@ type YamlDocument struct {
 	Offset       protocol.Position
 
 	SuppressionInfo *SuppressionInfo
+
+	// The top-level sections walked to build the document, in their order in
+	// the file
+	sections []parsedSection
 }
 
 func (doc *YamlDocument) IsBuiltIn(commandName string) bool {
@@ -348,7 +454,7 @@ func (doc *YamlDocument) IsAlias(commandName string) bool {
 }
 
 func (doc *YamlDocument) DoesJobExist(jobName string) bool {
-	_, ok := doc.Jobs[jobName]
+	_, ok := doc.GetJob(jobName)
 	return ok
 }
 
@@ -372,15 +478,59 @@ func (doc *YamlDocument) FindJobGroupContainingJob(jobName string) (string, bool
 }
 
 func (doc *YamlDocument) DoesCommandExist(commandName string) bool {
-	_, ok := doc.Commands[commandName]
+	_, ok := doc.GetCommand(commandName)
 	return ok
 }
 
 func (doc *YamlDocument) DoesExecutorExist(executorName string) bool {
-	_, ok := doc.Executors[executorName]
+	_, ok := doc.GetExecutor(executorName)
 	return ok
 }
 
+// GetCommand returns a command of the document or, for the files of the
+// source directory of an orb, of the other files of the directory
+func (doc *YamlDocument) GetCommand(commandName string) (ast.Command, bool) {
+	if command, ok := doc.Commands[commandName]; ok {
+		return command, true
+	}
+
+	if doc.OrbSource != nil {
+		command, ok := doc.OrbSource.Commands[commandName]
+		return command, ok
+	}
+
+	return ast.Command{}, false
+}
+
+// GetJob returns a job of the document or of its orb source directory
+func (doc *YamlDocument) GetJob(jobName string) (ast.Job, bool) {
+	if job, ok := doc.Jobs[jobName]; ok {
+		return job, true
+	}
+
+	if doc.OrbSource != nil {
+		job, ok := doc.OrbSource.Jobs[jobName]
+		return job, ok
+	}
+
+	return ast.Job{}, false
+}
+
+// GetExecutor returns an executor of the document or of its orb source
+// directory
+func (doc *YamlDocument) GetExecutor(executorName string) (ast.Executor, bool) {
+	if executor, ok := doc.Executors[executorName]; ok {
+		return executor, true
+	}
+
+	if doc.OrbSource != nil {
+		executor, ok := doc.OrbSource.Executors[executorName]
+		return executor, ok
+	}
+
+	return nil, false
+}
+
 func (doc *YamlDocument) DoesWorkflowExist(workflowName string) bool {
 	_, ok := doc.Workflows[workflowName]
 	return ok
@@ -607,11 +757,11 @@ func (doc *YamlDocument) GetExecutorDefinedAtPosition(position protocol.Position
 func (doc *YamlDocument) GetDefinedParams(entityName string, cache *utils.Cache) map[string]ast.Parameter {
 	var definedParams map[string]ast.Parameter
 
-	if command, ok := doc.Commands[entityName]; ok {
+	if command, ok := doc.GetCommand(entityName); ok {
 		definedParams = command.Parameters
 	}
 
-	if job, ok := doc.Jobs[entityName]; ok {
+	if job, ok := doc.GetJob(entityName); ok {
 		definedParams = job.Parameters
 	}
 
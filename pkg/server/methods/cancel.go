@@ -0,0 +1,103 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+)
+
+// PendingRequests keeps the cancel function of the requests being handled so
+// that they can be cancelled by a `$/cancelRequest` notification
+type PendingRequests struct {
+	mu      sync.Mutex
+	cancels map[jsonrpc2.ID]context.CancelFunc
+}
+
+func NewPendingRequests() *PendingRequests {
+	return &PendingRequests{
+		cancels: make(map[jsonrpc2.ID]context.CancelFunc),
+	}
+}
+
+func (pending *PendingRequests) add(id jsonrpc2.ID, cancel context.CancelFunc) {
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+
+	pending.cancels[id] = cancel
+}
+
+func (pending *PendingRequests) remove(id jsonrpc2.ID) {
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+
+	delete(pending.cancels, id)
+}
+
+func (pending *PendingRequests) cancel(id jsonrpc2.ID) {
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+
+	if cancel, ok := pending.cancels[id]; ok {
+		cancel()
+		delete(pending.cancels, id)
+	}
+}
+
+func (methods *Methods) CancelRequest(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := protocol.CancelParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	switch id := params.ID.(type) {
+	case float64:
+		methods.PendingRequests.cancel(jsonrpc2.NewNumberID(int32(id)))
+	case string:
+		methods.PendingRequests.cancel(jsonrpc2.NewStringID(id))
+	}
+
+	return reply(methods.Ctx, nil, nil)
+}
+
+type cancellableResult struct {
+	result interface{}
+	err    error
+}
+
+// replyCancellable handles the request in the background so that the next
+// messages, and thus `$/cancelRequest`, are read while it runs. A cancelled
+// request is answered with the RequestCancelled error and its result dropped
+func (methods *Methods) replyCancellable(reply jsonrpc2.Replier, req jsonrpc2.Request, handle func(ctx context.Context) (interface{}, error)) error {
+	call, ok := req.(*jsonrpc2.Call)
+	if !ok {
+		res, err := handle(methods.Ctx)
+		return reply(methods.Ctx, res, err)
+	}
+
+	ctx, cancel := context.WithCancel(methods.Ctx)
+	methods.PendingRequests.add(call.ID(), cancel)
+
+	results := make(chan cancellableResult, 1)
+	go func() {
+		res, err := handle(ctx)
+		results <- cancellableResult{result: res, err: err}
+	}()
+
+	go func() {
+		defer methods.PendingRequests.remove(call.ID())
+		defer cancel()
+
+		select {
+		case <-ctx.Done():
+			reply(methods.Ctx, nil, protocol.ErrRequestCancelled)
+		case res := <-results:
+			reply(methods.Ctx, res.result, res.err)
+		}
+	}()
+
+	return nil
+}
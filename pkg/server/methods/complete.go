@@ -1,6 +1,7 @@
 package methods
 
 import (
+	"context"
 	"fmt"
 
 	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
@@ -19,7 +20,6 @@ func (methods *Methods) Complete(reply jsonrpc2.Replier, req jsonrpc2.Request) e
 		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
 	}
 
-	res, err := languageservice.Complete(params, methods.Cache, methods.LsContext)
 	go (func() {
 		methods.SendTelemetryEvent(TelemetryEvent{
 			Action: "autocompleted",
@@ -31,13 +31,12 @@ func (methods *Methods) Complete(reply jsonrpc2.Replier, req jsonrpc2.Request) e
 		})
 	})()
 
-	if err != nil {
-		return reply(
-			methods.Ctx,
-			nil,
-			err,
-		)
-	}
+	return methods.replyCancellable(reply, req, func(ctx context.Context) (interface{}, error) {
+		res, err := languageservice.CompleteWithContext(ctx, params, methods.Cache, methods.LsContext)
+		if err != nil {
+			return nil, err
+		}
 
-	return reply(methods.Ctx, res, nil)
+		return res, nil
+	})
 }
@@ -0,0 +1,23 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
+	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+)
+
+func (methods *Methods) CompletionResolve(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	item := protocol.CompletionItem{}
+	if err := json.Unmarshal(req.Params(), &item); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	return methods.replyCancellable(reply, req, func(ctx context.Context) (interface{}, error) {
+		return languageservice.ResolveCompletionItem(item, methods.Cache, methods.LsContext, dockerhub.NewAPIWithContext(ctx)), nil
+	})
+}
@@ -1,12 +1,15 @@
 package methods
 
 import (
+	"context"
+
 	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
 	"go.lsp.dev/protocol"
 )
 
-func (methods *Methods) Diagnostics(textDocument protocol.TextDocumentItem) protocol.PublishDiagnosticsParams {
-	diagnostic, _ := languageservice.DiagnosticFile(
+func (methods *Methods) Diagnostics(ctx context.Context, textDocument protocol.TextDocumentItem) protocol.PublishDiagnosticsParams {
+	diagnostic, _ := languageservice.DiagnosticFileWithContext(
+		ctx,
 		textDocument.URI,
 		methods.Cache,
 		methods.LsContext,
@@ -1,8 +1,6 @@
 package methods
 
 import (
-	"log"
-
 	"go.lsp.dev/protocol"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
@@ -11,8 +9,7 @@ import (
 func (methods *Methods) getAllEnvVariables(textDocument protocol.TextDocumentItem) {
 	cachedFile := methods.Cache.FileCache.GetFile(textDocument.URI)
 	if cachedFile.Project.Slug == "" {
-		projectSlug := utils.GetProjectSlug(textDocument.URI.Filename())
-		project, err := utils.GetProjectId(projectSlug, methods.LsContext)
+		project, err := methods.getProject(textDocument.URI)
 		if err != nil {
 			return
 		}
@@ -20,16 +17,36 @@ func (methods *Methods) getAllEnvVariables(textDocument protocol.TextDocumentIte
 		methods.updateProjectEnvVariables(cachedFile)
 	}
 
-	err := utils.GetAllContext(methods.LsContext, cachedFile.Project.OrganizationId, methods.Cache)
+	organizationId := cachedFile.Project.OrganizationId
+
+	// The contexts kept on the disk are used at once, and fetched again in
+	// the background once stale
+	found, fresh := utils.LoadStoredContexts(methods.LsContext, organizationId, methods.Cache)
+	if found {
+		methods.Cache.ContextCache.MarkOrganizationContextListLoaded(organizationId)
+	}
+
+	switch {
+	case found && fresh:
+	case found:
+		go methods.fetchContexts(organizationId)
+	default:
+		methods.fetchContexts(organizationId)
+	}
+}
+
+func (methods *Methods) fetchContexts(organizationId string) {
+	err := utils.GetAllContext(methods.Ctx, methods.LsContext, organizationId, methods.Cache)
 	if err != nil {
-		log.Printf("error getting contexts: %s", err)
+		utils.Logger.WarnContext(methods.Ctx, "could not get the contexts", "organization", organizationId, "error", err)
 		return
 	}
-	methods.Cache.ContextCache.MarkOrganizationContextListLoaded(cachedFile.Project.OrganizationId)
+	methods.Cache.ContextCache.MarkOrganizationContextListLoaded(organizationId)
 
-	if err := utils.GetAllContextWithEnvVars(methods.LsContext, cachedFile.Project.OrganizationId, methods.Cache); err != nil {
-		log.Printf("error getting context environment variables: %s", err)
+	if err := utils.GetAllContextWithEnvVars(methods.Ctx, methods.LsContext, organizationId, methods.Cache); err != nil {
+		utils.Logger.WarnContext(methods.Ctx, "could not get the environment variables of the contexts", "organization", organizationId, "error", err)
 	}
+	utils.StoreContexts(methods.LsContext, organizationId, methods.Cache)
 }
 
 func (methods *Methods) updateProjectsEnvVariables() {
@@ -43,6 +60,6 @@ func (methods *Methods) updateProjectEnvVariables(file *utils.CachedFile) {
 	cachedFile.EnvVariables = []string{}
 	methods.Cache.FileCache.SetFile(*cachedFile)
 	if methods.LsContext.Api.Token != "" {
-		utils.GetAllProjectEnvVariables(methods.LsContext, methods.Cache, cachedFile)
+		utils.GetAllProjectEnvVariables(methods.Ctx, methods.LsContext, methods.Cache, cachedFile)
 	}
 }
@@ -1,7 +1,10 @@
 package methods
 
 import (
+	"slices"
+
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services/refactor"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 	"github.com/rollbar/rollbar-go"
 	"github.com/segmentio/encoding/json"
@@ -35,6 +38,18 @@ func (methods *Methods) ExecuteCommand(reply jsonrpc2.Replier, req jsonrpc2.Requ
 		methods.setHostUrl(param)
 		methods.updateAllCachedFiles()
 
+	case "invalidateCache":
+		kinds := []utils.DiskCacheKind{}
+		for _, argument := range arguments {
+			kind, ok := argument.(string)
+			if !ok || !slices.Contains(utils.DiskCacheKinds, utils.DiskCacheKind(kind)) {
+				return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "invalid method parameter: kind"))
+			}
+			kinds = append(kinds, utils.DiskCacheKind(kind))
+		}
+		methods.Cache.Invalidate(kinds...)
+		methods.updateAllCachedFiles()
+
 	case "setUserId":
 		param, ok := arguments[0].(string)
 		if !ok {
@@ -52,7 +67,7 @@ func (methods *Methods) ExecuteCommand(reply jsonrpc2.Replier, req jsonrpc2.Requ
 			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "invalid method parameter: fileURI"))
 		}
 
-		parsedFile, err := parser.ParseFromContent([]byte(content), methods.LsContext, uri.File(fileUri), protocol.Position{})
+		parsedFile, err := parser.ParseFromContentWithContext(methods.Ctx, []byte(content), methods.LsContext, uri.File(fileUri), protocol.Position{})
 		if err != nil {
 			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InternalError, "unable to parse file"))
 		}
@@ -61,6 +76,55 @@ func (methods *Methods) ExecuteCommand(reply jsonrpc2.Replier, req jsonrpc2.Requ
 
 		return reply(methods.Ctx, workflows, nil)
 
+	case "packOrb":
+		fileUri, ok := arguments[0].(string)
+		if !ok {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "invalid method parameter: fileURI"))
+		}
+
+		orbSource := parser.LoadOrbSource(protocol.URI(fileUri), methods.Cache, methods.LsContext)
+		if orbSource == nil {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "file is not in an orb source directory"))
+		}
+
+		packedOrb, err := orbSource.Pack()
+		if err != nil {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InternalError, err.Error()))
+		}
+
+		return reply(methods.Ctx, packedOrb, nil)
+
+	case "unpackOrb":
+		fileUri, ok := arguments[0].(string)
+		if !ok {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "invalid method parameter: fileURI"))
+		}
+
+		parsedFile, err := parser.ParseFromUriWithCacheWithContext(methods.Ctx, protocol.URI(fileUri), methods.Cache, methods.LsContext)
+		if err != nil {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InternalError, "unable to parse file"))
+		}
+
+		return reply(methods.Ctx, parsedFile.UnpackOrb(), nil)
+
+	case "migrateTo21":
+		fileUri, ok := arguments[0].(string)
+		if !ok {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "invalid method parameter: fileURI"))
+		}
+
+		parsedFile, err := parser.ParseFromUriWithCacheWithContext(methods.Ctx, protocol.URI(fileUri), methods.Cache, methods.LsContext)
+		if err != nil {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InternalError, "unable to parse file"))
+		}
+
+		edit, ok := refactor.MigrateTo21(&parsedFile)
+		if !ok {
+			return reply(methods.Ctx, nil, jsonrpc2.NewError(jsonrpc2.InvalidParams, "file is not a 2.0 config"))
+		}
+
+		return reply(methods.Ctx, edit, nil)
+
 	case "setRollbarInformation":
 		parameters, ok := arguments[0].(map[string]interface{})
 		if !ok {
@@ -100,7 +164,7 @@ func (methods *Methods) setToken(token string) {
 	methods.LsContext.Api.Token = token
 	filesCache := methods.Cache.FileCache.GetFiles()
 	for _, file := range filesCache {
-		go methods.notificationMethods(file.TextDocument)
+		methods.DiagnosticScheduler.Run(file.TextDocument.URI, methods.runDiagnostics(file.TextDocument))
 	}
 
 	methods.updateProjectsEnvVariables()
@@ -119,7 +183,7 @@ func (methods *Methods) setHostUrl(hostUrl string) {
 
 	filesCache := methods.Cache.FileCache.GetFiles()
 	for _, file := range filesCache {
-		go methods.notificationMethods(file.TextDocument)
+		methods.DiagnosticScheduler.Run(file.TextDocument.URI, methods.runDiagnostics(file.TextDocument))
 	}
 
 	methods.updateProjectsEnvVariables()
@@ -0,0 +1,26 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+)
+
+func (methods *Methods) FoldingRange(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := protocol.FoldingRangeParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	return methods.replyCancellable(reply, req, func(_ context.Context) (interface{}, error) {
+		res, err := languageservice.FoldingRanges(params, methods.Cache, methods.LsContext)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
+}
@@ -1,6 +1,7 @@
 package methods
 
 import (
+	"context"
 	"fmt"
 
 	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
@@ -15,10 +16,12 @@ func (methods *Methods) Hover(reply jsonrpc2.Replier, req jsonrpc2.Request) erro
 		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
 	}
 
-	res, err := languageservice.Hover(params, methods.Cache, methods.LsContext)
-	if err != nil {
-		return reply(methods.Ctx, nil, nil)
-	}
+	return methods.replyCancellable(reply, req, func(_ context.Context) (interface{}, error) {
+		res, err := languageservice.Hover(params, methods.Cache, methods.LsContext)
+		if err != nil {
+			return nil, nil
+		}
 
-	return reply(methods.Ctx, res, nil)
+		return res, nil
+	})
 }
@@ -2,6 +2,7 @@ package methods
 
 import (
 	"fmt"
+	"path"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 	"github.com/segmentio/encoding/json"
@@ -16,6 +17,29 @@ type SemanticTokensOptions struct {
 	Full             bool                          `json:"full,omitempty"`
 }
 
+// The capabilities of the server that go.lsp.dev/protocol does not know about
+type ServerCapabilities struct {
+	protocol.ServerCapabilities
+	DiagnosticProvider *DiagnosticOptions `json:"diagnosticProvider,omitempty"`
+}
+
+type InitializeResult struct {
+	Capabilities ServerCapabilities   `json:"capabilities"`
+	ServerInfo   *protocol.ServerInfo `json:"serverInfo,omitempty"`
+}
+
+// The capabilities of the client that go.lsp.dev/protocol does not know about
+type ClientCapabilities struct {
+	TextDocument struct {
+		Diagnostic *struct{} `json:"diagnostic"`
+	} `json:"textDocument"`
+	Workspace struct {
+		Diagnostics *struct {
+			RefreshSupport bool `json:"refreshSupport"`
+		} `json:"diagnostics"`
+	} `json:"workspace"`
+}
+
 var TokenTypes = []protocol.SemanticTokenTypes{
 	protocol.SemanticTokenKeyword,
 	protocol.SemanticTokenNamespace,
@@ -35,6 +59,39 @@ func (methods *Methods) Initialize(reply jsonrpc2.Replier, req jsonrpc2.Request)
 		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
 	}
 
+	methods.ClientLog.SetTrace(params.Trace)
+
+	capabilities := struct {
+		Capabilities ClientCapabilities `json:"capabilities"`
+	}{}
+	if err := json.Unmarshal(req.Params(), &capabilities); err == nil {
+		methods.LsContext.UsePullDiagnostics = capabilities.Capabilities.TextDocument.Diagnostic != nil
+		methods.LsContext.CanRefreshDiagnostics = capabilities.Capabilities.Workspace.Diagnostics != nil &&
+			capabilities.Capabilities.Workspace.Diagnostics.RefreshSupport
+	}
+
+	if workspace := params.Capabilities.Workspace; workspace != nil && workspace.DidChangeWatchedFiles != nil {
+		methods.LsContext.CanWatchFiles = workspace.DidChangeWatchedFiles.DynamicRegistration
+	}
+
+	folders := params.WorkspaceFolders
+	if len(folders) == 0 && params.RootURI != "" {
+		folders = []protocol.WorkspaceFolder{{
+			URI:  string(params.RootURI),
+			Name: path.Base(params.RootURI.Filename()),
+		}}
+	}
+	// The project slugs are read from the git configs right away, as the
+	// documents opened after the initialization need them
+	for _, folder := range folders {
+		methods.addWorkspaceFolder(folder)
+	}
+	go (func() {
+		for _, folder := range folders {
+			methods.indexWorkspaceFolder(folder)
+		}
+	})()
+
 	if params.InitializationOptions != nil {
 		isCciExtension, ok := params.InitializationOptions.(map[string]interface{})["isCciExtension"]
 		if ok && isCciExtension == true {
@@ -49,52 +106,77 @@ func (methods *Methods) Initialize(reply jsonrpc2.Replier, req jsonrpc2.Request)
 		}
 	}
 
-	v := protocol.InitializeResult{
-		Capabilities: protocol.ServerCapabilities{
-			RenameProvider: false,
-			TextDocumentSync: protocol.TextDocumentSyncOptions{
-				OpenClose: true,
-				Change:    protocol.TextDocumentSyncKindIncremental,
-			},
-			SemanticTokensProvider: SemanticTokensOptions{
-				Legend: protocol.SemanticTokensLegend{
-					TokenTypes:     TokenTypes,
-					TokenModifiers: TokenModifiers,
-				},
-				Full:  true,
-				Range: false,
-			},
-			DefinitionProvider: protocol.DefinitionOptions{
-				WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{
-					WorkDoneProgress: true,
+	v := InitializeResult{
+		Capabilities: ServerCapabilities{
+			ServerCapabilities: protocol.ServerCapabilities{
+				RenameProvider: false,
+				TextDocumentSync: protocol.TextDocumentSyncOptions{
+					OpenClose: true,
+					Change:    protocol.TextDocumentSyncKindIncremental,
 				},
-			},
-			ReferencesProvider: protocol.ReferenceOptions{
-				WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{
-					WorkDoneProgress: true,
+				SemanticTokensProvider: SemanticTokensOptions{
+					Legend: protocol.SemanticTokensLegend{
+						TokenTypes:     TokenTypes,
+						TokenModifiers: TokenModifiers,
+					},
+					Full:  true,
+					Range: false,
 				},
-			},
-			CompletionProvider: &protocol.CompletionOptions{
-				ResolveProvider: false,
-				// TriggerCharacters: []string{":"},
-			},
-			HoverProvider: &protocol.HoverOptions{
-				WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{
-					WorkDoneProgress: true,
+				DefinitionProvider: protocol.DefinitionOptions{
+					WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{
+						WorkDoneProgress: true,
+					},
 				},
-			},
-			ExecuteCommandProvider: &protocol.ExecuteCommandOptions{
-				Commands: []string{"setToken"},
-			},
-			CodeActionProvider: &protocol.CodeActionRegistrationOptions{
-				CodeActionOptions: protocol.CodeActionOptions{
-					CodeActionKinds: []protocol.CodeActionKind{
-						"quickfix",
+				ReferencesProvider: protocol.ReferenceOptions{
+					WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{
+						WorkDoneProgress: true,
 					},
+				},
+				CompletionProvider: &protocol.CompletionOptions{
 					ResolveProvider: true,
+					// TriggerCharacters: []string{":"},
 				},
+				HoverProvider: &protocol.HoverOptions{
+					WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{
+						WorkDoneProgress: true,
+					},
+				},
+				CallHierarchyProvider: true,
+				SignatureHelpProvider: &protocol.SignatureHelpOptions{
+					TriggerCharacters:   []string{":", "\n"},
+					RetriggerCharacters: []string{" "},
+				},
+				ExecuteCommandProvider: &protocol.ExecuteCommandOptions{
+					Commands: []string{"setToken"},
+				},
+				CodeActionProvider: &protocol.CodeActionRegistrationOptions{
+					CodeActionOptions: protocol.CodeActionOptions{
+						CodeActionKinds: []protocol.CodeActionKind{
+							"quickfix",
+							protocol.RefactorExtract,
+							protocol.RefactorInline,
+							protocol.RefactorRewrite,
+						},
+						ResolveProvider: true,
+					},
+				},
+				DocumentSymbolProvider:    true,
+				WorkspaceSymbolProvider:   true,
+				DocumentHighlightProvider: true,
+				FoldingRangeProvider:      true,
+				SelectionRangeProvider:    true,
+				Workspace: &protocol.ServerCapabilitiesWorkspace{
+					WorkspaceFolders: &protocol.ServerCapabilitiesWorkspaceFolders{
+						Supported:           true,
+						ChangeNotifications: true,
+					},
+				},
+			},
+			DiagnosticProvider: &DiagnosticOptions{
+				Identifier:            "circleci",
+				InterFileDependencies: true,
+				WorkspaceDiagnostics:  true,
 			},
-			DocumentSymbolProvider: true,
 		},
 		ServerInfo: &protocol.ServerInfo{
 			Name:    "circleci-language-server",
@@ -0,0 +1,45 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+)
+
+// Sends the logs of the server to the client, see utils/log.go
+type logClient struct {
+	ctx  context.Context
+	conn jsonrpc2.Conn
+}
+
+func NewLogClient(ctx context.Context, conn jsonrpc2.Conn) utils.LogClient {
+	return logClient{ctx: ctx, conn: conn}
+}
+
+func (client logClient) LogMessage(messageType protocol.MessageType, message string) {
+	client.conn.Notify(client.ctx, protocol.MethodWindowLogMessage, protocol.LogMessageParams{
+		Type:    messageType,
+		Message: message,
+	})
+}
+
+func (client logClient) LogTrace(message string, verbose string) {
+	client.conn.Notify(client.ctx, protocol.MethodLogTrace, protocol.LogTraceParams{
+		Message: message,
+		Verbose: protocol.TraceValue(verbose),
+	})
+}
+
+func (methods *Methods) SetTrace(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := protocol.SetTraceParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	methods.ClientLog.SetTrace(params.Value)
+	return reply(methods.Ctx, nil, nil)
+}
@@ -13,4 +13,8 @@ type Methods struct {
 	Cache          *utils.Cache
 	LsContext      *utils.LsContext
 	SchemaLocation string
+	ClientLog      *utils.ClientLog
+
+	DiagnosticScheduler *DiagnosticScheduler
+	PendingRequests     *PendingRequests
 }
@@ -0,0 +1,230 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/bep/debounce"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// The pull diagnostics were introduced by the version 3.17 of the protocol,
+// they are not part of go.lsp.dev/protocol
+
+const (
+	MethodTextDocumentDiagnostic     = "textDocument/diagnostic"
+	MethodWorkspaceDiagnostic        = "workspace/diagnostic"
+	MethodWorkspaceDiagnosticRefresh = "workspace/diagnostic/refresh"
+)
+
+type DocumentDiagnosticReportKind string
+
+const (
+	DocumentDiagnosticReportKindFull      DocumentDiagnosticReportKind = "full"
+	DocumentDiagnosticReportKindUnchanged DocumentDiagnosticReportKind = "unchanged"
+)
+
+type DiagnosticOptions struct {
+	Identifier            string `json:"identifier,omitempty"`
+	InterFileDependencies bool   `json:"interFileDependencies"`
+	WorkspaceDiagnostics  bool   `json:"workspaceDiagnostics"`
+}
+
+type DocumentDiagnosticParams struct {
+	TextDocument     protocol.TextDocumentIdentifier `json:"textDocument"`
+	Identifier       string                          `json:"identifier,omitempty"`
+	PreviousResultID string                          `json:"previousResultId,omitempty"`
+}
+
+type PreviousResultID struct {
+	URI   protocol.DocumentURI `json:"uri"`
+	Value string               `json:"value"`
+}
+
+type WorkspaceDiagnosticParams struct {
+	Identifier        string             `json:"identifier,omitempty"`
+	PreviousResultIds []PreviousResultID `json:"previousResultIds"`
+}
+
+// DocumentDiagnosticReport is either a full report, with its Items, or an
+// unchanged one telling the client to keep the diagnostics of ResultID. Items
+// is a pointer as a full report always has items, even if there are none
+type DocumentDiagnosticReport struct {
+	Kind     DocumentDiagnosticReportKind `json:"kind"`
+	ResultID string                       `json:"resultId,omitempty"`
+	Items    *[]protocol.Diagnostic       `json:"items,omitempty"`
+}
+
+type WorkspaceDocumentDiagnosticReport struct {
+	DocumentDiagnosticReport
+	URI     protocol.DocumentURI `json:"uri"`
+	Version *int32               `json:"version"`
+}
+
+type WorkspaceDiagnosticReport struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items"`
+}
+
+func (methods *Methods) DocumentDiagnostic(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := DocumentDiagnosticParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	return methods.replyCancellable(reply, req, func(ctx context.Context) (interface{}, error) {
+		report, _, err := methods.documentDiagnosticReport(ctx, params.TextDocument.URI, params.PreviousResultID)
+		if err != nil {
+			return nil, err
+		}
+
+		return report, nil
+	})
+}
+
+func (methods *Methods) WorkspaceDiagnostic(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := WorkspaceDiagnosticParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	previousResultIds := map[protocol.DocumentURI]string{}
+	for _, previous := range params.PreviousResultIds {
+		previousResultIds[previous.URI] = previous.Value
+	}
+
+	return methods.replyCancellable(reply, req, func(ctx context.Context) (interface{}, error) {
+		res := WorkspaceDiagnosticReport{Items: []WorkspaceDocumentDiagnosticReport{}}
+
+		for _, documentURI := range methods.getWorkspaceDocuments() {
+			report, version, err := methods.documentDiagnosticReport(ctx, documentURI, previousResultIds[documentURI])
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				continue
+			}
+
+			res.Items = append(res.Items, WorkspaceDocumentDiagnosticReport{
+				DocumentDiagnosticReport: report,
+				URI:                      documentURI,
+				Version:                  version,
+			})
+		}
+
+		return res, nil
+	})
+}
+
+// Returns the diagnostics of the document, or an unchanged report when the
+// result ID did not change since the previous pull. Open documents are
+// validated from the cache, the others from the disk and have no version
+func (methods *Methods) documentDiagnosticReport(ctx context.Context, documentURI protocol.DocumentURI, previousResultID string) (DocumentDiagnosticReport, *int32, error) {
+	var content []byte
+	var version *int32
+	var contentHash string
+
+	if file := methods.Cache.FileCache.GetFile(documentURI); file != nil {
+		content = []byte(file.TextDocument.Text)
+		fileVersion := file.TextDocument.Version
+		version = &fileVersion
+		contentHash = file.ContentHash
+	} else {
+		var err error
+		content, err = os.ReadFile(uri.URI(documentURI).Filename())
+		if err != nil {
+			return DocumentDiagnosticReport{}, nil, err
+		}
+		contentHash = utils.HashContent(string(content))
+	}
+
+	resultID := methods.getDiagnosticResultID(contentHash)
+	if resultID == previousResultID {
+		return DocumentDiagnosticReport{
+			Kind:     DocumentDiagnosticReportKindUnchanged,
+			ResultID: resultID,
+		}, version, nil
+	}
+
+	diagnostics, err := languageservice.DiagnosticContentWithContext(
+		ctx,
+		documentURI,
+		content,
+		methods.Cache,
+		methods.LsContext,
+		methods.SchemaLocation,
+	)
+	if err != nil {
+		return DocumentDiagnosticReport{}, nil, err
+	}
+	if diagnostics == nil {
+		diagnostics = []protocol.Diagnostic{}
+	}
+
+	return DocumentDiagnosticReport{
+		Kind:     DocumentDiagnosticReportKindFull,
+		ResultID: resultID,
+		Items:    &diagnostics,
+	}, version, nil
+}
+
+// The diagnostics of a content change with the instance of CircleCI used and
+// whether the user is logged in, as private orbs and contexts become available,
+// and with the data fetched or found for the documents, see Cache.Generation
+func (methods *Methods) getDiagnosticResultID(contentHash string) string {
+	return utils.HashContent(fmt.Sprintf(
+		"%s\n%s\n%s\n%s\n%d",
+		contentHash,
+		methods.LsContext.Api.HostUrl,
+		methods.LsContext.Api.Token,
+		methods.SchemaLocation,
+		methods.Cache.Generation(),
+	))
+}
+
+// Returns the open documents and the CircleCI files indexed in the workspace
+// folders
+func (methods *Methods) getWorkspaceDocuments() []protocol.DocumentURI {
+	documents := []protocol.DocumentURI{}
+	seen := map[protocol.DocumentURI]bool{}
+
+	add := func(documentURI protocol.DocumentURI) {
+		if !seen[documentURI] {
+			seen[documentURI] = true
+			documents = append(documents, documentURI)
+		}
+	}
+
+	for documentURI := range methods.Cache.FileCache.GetFiles() {
+		add(documentURI)
+	}
+
+	for _, documentURI := range methods.Cache.WorkspaceCache.GetIndexedFiles() {
+		add(documentURI)
+	}
+
+	return documents
+}
+
+// RefreshDiagnosticsOnCacheChanges asks the client to pull the diagnostics
+// again once the data fetched for the documents changed, as the orbs are only
+// fetched after the documents are first validated
+func (methods *Methods) RefreshDiagnosticsOnCacheChanges() {
+	refresh := debounce.New(DiagnosticsDelay)
+	methods.Cache.OnChange(func() {
+		refresh(methods.refreshDiagnostics)
+	})
+}
+
+func (methods *Methods) refreshDiagnostics() {
+	if !methods.LsContext.UsePullDiagnostics || !methods.LsContext.CanRefreshDiagnostics {
+		return
+	}
+
+	go methods.Conn.Call(methods.Ctx, MethodWorkspaceDiagnosticRefresh, nil, nil)
+}
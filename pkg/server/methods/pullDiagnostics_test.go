@@ -0,0 +1,103 @@
+package methods
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+const undefinedJobConfig = `version: 2.1
+
+workflows:
+  main:
+    jobs:
+      - build
+`
+
+func TestDocumentDiagnosticReport(t *testing.T) {
+	dir := t.TempDir()
+	closedPath := filepath.Join(dir, ".circleci", "config.yml")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(closedPath), 0o755))
+	assert.NoError(t, os.WriteFile(closedPath, []byte(undefinedJobConfig), 0o644))
+
+	methods := Methods{
+		Ctx:       context.Background(),
+		Cache:     utils.CreateCache(),
+		LsContext: testHelpers.GetDefaultLsContext(),
+	}
+
+	// Closed files are read from the disk
+	closedURI := uri.File(closedPath)
+	report, version, err := methods.documentDiagnosticReport(context.Background(), closedURI, "")
+	assert.NoError(t, err)
+	assert.Nil(t, version)
+	assert.Equal(t, DocumentDiagnosticReportKindFull, report.Kind)
+	assert.NotEmpty(t, *report.Items)
+
+	unchanged, _, err := methods.documentDiagnosticReport(context.Background(), closedURI, report.ResultID)
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentDiagnosticReport{
+		Kind:     DocumentDiagnosticReportKindUnchanged,
+		ResultID: report.ResultID,
+	}, unchanged)
+
+	// Open files are read from the cache
+	methods.Cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{
+			URI:     closedURI,
+			Version: 3,
+			Text:    "version: 2.1\n",
+		},
+	})
+	opened, version, err := methods.documentDiagnosticReport(context.Background(), closedURI, report.ResultID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), *version)
+	assert.Equal(t, DocumentDiagnosticReportKindFull, opened.Kind)
+	assert.NotEqual(t, report.ResultID, opened.ResultID)
+	assert.Empty(t, *opened.Items)
+
+	// Logging in changes the diagnostics of a same content
+	methods.LsContext.Api.Token = ""
+	loggedOut, _, err := methods.documentDiagnosticReport(context.Background(), closedURI, opened.ResultID)
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentDiagnosticReportKindFull, loggedOut.Kind)
+
+	// As does an orb fetched in the meantime
+	methods.Cache.OrbCache.SetOrb(&ast.OrbInfo{Source: "version: 2.1\n"}, "circleci/node@5.0.0")
+	withOrb, _, err := methods.documentDiagnosticReport(context.Background(), closedURI, loggedOut.ResultID)
+	assert.NoError(t, err)
+	assert.Equal(t, DocumentDiagnosticReportKindFull, withOrb.Kind)
+	assert.NotEqual(t, loggedOut.ResultID, withOrb.ResultID)
+}
+
+func TestGetWorkspaceDocuments(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".circleci", "config.yml")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(configPath), 0o755))
+	assert.NoError(t, os.WriteFile(configPath, []byte(undefinedJobConfig), 0o644))
+
+	methods := Methods{
+		Ctx:       context.Background(),
+		Cache:     utils.CreateCache(),
+		LsContext: testHelpers.GetDefaultLsContext(),
+	}
+	methods.addWorkspaceFolder(protocol.WorkspaceFolder{URI: string(uri.File(dir)), Name: "project"})
+
+	openURI := uri.File(filepath.Join(t.TempDir(), "config.yml"))
+	methods.Cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{URI: openURI, Text: undefinedJobConfig},
+	})
+	methods.Cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{URI: uri.File(configPath), Text: undefinedJobConfig},
+	})
+
+	assert.ElementsMatch(t, []protocol.DocumentURI{openURI, uri.File(configPath)}, methods.getWorkspaceDocuments())
+}
@@ -1,6 +1,7 @@
 package methods
 
 import (
+	"context"
 	"fmt"
 
 	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
@@ -15,9 +16,11 @@ func (methods *Methods) References(reply jsonrpc2.Replier, req jsonrpc2.Request)
 		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
 	}
 
-	res, err := languageservice.References(params, methods.Cache, methods.LsContext)
-	if err != nil {
-		return reply(methods.Ctx, nil, err)
-	}
-	return reply(methods.Ctx, res, nil)
+	return methods.replyCancellable(reply, req, func(_ context.Context) (interface{}, error) {
+		res, err := languageservice.References(params, methods.Cache, methods.LsContext)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
 }
@@ -1,6 +1,7 @@
 package methods
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -22,13 +23,12 @@ type ResourceClassResponse struct {
 }
 
 func (methods *Methods) SetResourceClassOfFile(params protocol.DidOpenTextDocumentParams) {
-	resourceClasses := getResourceClassOfOrg(params.TextDocument.URI, methods.LsContext)
+	resourceClasses := getResourceClassOfOrg(methods.Ctx, methods.getProjectSlug(params.TextDocument.URI), methods.LsContext)
 
 	methods.Cache.ResourceClassCache.SetResourceClassForFile(params.TextDocument.URI, &resourceClasses)
 }
 
-func getResourceClassOfOrg(textDocumentUri protocol.URI, context *utils.LsContext) []string {
-	projectSlug := utils.GetProjectSlug(textDocumentUri.Filename())
+func getResourceClassOfOrg(ctx context.Context, projectSlug string, context *utils.LsContext) []string {
 	org := utils.GetProjectOrg(projectSlug)
 
 	if org == "" {
@@ -42,12 +42,12 @@ func getResourceClassOfOrg(textDocumentUri protocol.URI, context *utils.LsContex
 	hostUrl.Host = "runner." + hostUrl.Host
 	url := fmt.Sprintf("%s/api/v3/runner/resource?namespace=%s", hostUrl, org)
 
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	req.Header.Add("Circle-Token", context.Api.Token)
 	req.Header.Set("User-Agent", utils.UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := utils.HTTPClient().Do(req)
 
 	if err != nil || res.StatusCode != 200 {
 		return []string{}
@@ -0,0 +1,99 @@
+package methods
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.lsp.dev/protocol"
+)
+
+// DiagnosticScheduler runs the diagnostics of each document independently:
+// scheduling a run for a document cancels the pending or in-flight run of the
+// same document only, so that the diagnostics of an outdated version are
+// neither computed in full nor published
+type DiagnosticScheduler struct {
+	ctx   context.Context
+	delay time.Duration
+
+	mu   sync.Mutex
+	runs map[protocol.DocumentURI]*diagnosticRun
+
+	// Starts the timer of a run, time.AfterFunc unless faked by the tests
+	afterFunc func(delay time.Duration, fn func()) diagnosticTimer
+}
+
+type diagnosticTimer interface {
+	Stop() bool
+}
+
+type diagnosticRun struct {
+	timer  diagnosticTimer
+	cancel context.CancelFunc
+}
+
+func NewDiagnosticScheduler(ctx context.Context, delay time.Duration) *DiagnosticScheduler {
+	return &DiagnosticScheduler{
+		ctx:   ctx,
+		delay: delay,
+		runs:  make(map[protocol.DocumentURI]*diagnosticRun),
+		afterFunc: func(delay time.Duration, fn func()) diagnosticTimer {
+			return time.AfterFunc(delay, fn)
+		},
+	}
+}
+
+// Schedule runs fn once no other run has been scheduled for the document
+// during the delay of the scheduler
+func (scheduler *DiagnosticScheduler) Schedule(uri protocol.DocumentURI, fn func(ctx context.Context)) {
+	scheduler.schedule(uri, scheduler.delay, fn)
+}
+
+// Run runs fn right away, cancelling the previous run of the document
+func (scheduler *DiagnosticScheduler) Run(uri protocol.DocumentURI, fn func(ctx context.Context)) {
+	scheduler.schedule(uri, 0, fn)
+}
+
+// Cancel stops the pending or in-flight run of the document, if any
+func (scheduler *DiagnosticScheduler) Cancel(uri protocol.DocumentURI) {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	if run, ok := scheduler.runs[uri]; ok {
+		run.stop()
+		delete(scheduler.runs, uri)
+	}
+}
+
+func (scheduler *DiagnosticScheduler) schedule(uri protocol.DocumentURI, delay time.Duration, fn func(ctx context.Context)) {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	if previous, ok := scheduler.runs[uri]; ok {
+		previous.stop()
+	}
+
+	ctx, cancel := context.WithCancel(scheduler.ctx)
+	run := &diagnosticRun{cancel: cancel}
+	run.timer = scheduler.afterFunc(delay, func() {
+		defer scheduler.done(uri, run)
+		fn(ctx)
+	})
+
+	scheduler.runs[uri] = run
+}
+
+func (scheduler *DiagnosticScheduler) done(uri protocol.DocumentURI, run *diagnosticRun) {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	run.cancel()
+	if scheduler.runs[uri] == run {
+		delete(scheduler.runs, uri)
+	}
+}
+
+func (run *diagnosticRun) stop() {
+	run.timer.Stop()
+	run.cancel()
+}
@@ -0,0 +1,138 @@
+package methods
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+func TestDiagnosticSchedulerKeepsDocumentsIndependent(t *testing.T) {
+	scheduler := NewDiagnosticScheduler(context.Background(), 10*time.Millisecond)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ran := []protocol.DocumentURI{}
+
+	for _, uri := range []protocol.DocumentURI{"file:///a.yml", "file:///b.yml"} {
+		uri := uri
+		wg.Add(1)
+		scheduler.Schedule(uri, func(ctx context.Context) {
+			defer wg.Done()
+			mu.Lock()
+			ran = append(ran, uri)
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+	assert.ElementsMatch(t, []protocol.DocumentURI{"file:///a.yml", "file:///b.yml"}, ran)
+}
+
+func TestDiagnosticSchedulerCancelsOutdatedRuns(t *testing.T) {
+	scheduler := NewDiagnosticScheduler(context.Background(), 10*time.Millisecond)
+	uri := protocol.DocumentURI("file:///a.yml")
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	scheduler.Run(uri, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+	<-started
+
+	// A pending run is replaced before it starts
+	scheduler.Schedule(uri, func(ctx context.Context) {
+		t.Error("outdated run should not start")
+	})
+
+	done := make(chan struct{})
+	scheduler.Schedule(uri, func(ctx context.Context) {
+		assert.NoError(t, ctx.Err())
+		close(done)
+	})
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("in-flight run was not cancelled")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("latest run did not run")
+	}
+}
+
+// Timers only firing when asked to
+type fakeTimers struct {
+	mu     sync.Mutex
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	fn      func()
+	stopped bool
+}
+
+func (timers *fakeTimers) afterFunc(delay time.Duration, fn func()) diagnosticTimer {
+	timers.mu.Lock()
+	defer timers.mu.Unlock()
+
+	timer := &fakeTimer{fn: fn}
+	timers.timers = append(timers.timers, timer)
+	return timer
+}
+
+func (timers *fakeTimers) fire() {
+	timers.mu.Lock()
+	pending := []*fakeTimer{}
+	for _, timer := range timers.timers {
+		if !timer.stopped {
+			timer.stopped = true
+			pending = append(pending, timer)
+		}
+	}
+	timers.mu.Unlock()
+
+	for _, timer := range pending {
+		timer.fn()
+	}
+}
+
+func (timer *fakeTimer) Stop() bool {
+	wasPending := !timer.stopped
+	timer.stopped = true
+	return wasPending
+}
+
+func TestDiagnosticSchedulerCancel(t *testing.T) {
+	scheduler := NewDiagnosticScheduler(context.Background(), 10*time.Millisecond)
+	timers := &fakeTimers{}
+	scheduler.afterFunc = timers.afterFunc
+	uri := protocol.DocumentURI("file:///a.yml")
+
+	started := false
+	scheduler.Schedule(uri, func(ctx context.Context) {
+		started = true
+	})
+	scheduler.Cancel(uri)
+	timers.fire()
+
+	assert.False(t, started, "cancelled run should not start")
+	assert.Empty(t, scheduler.runs)
+
+	// The next run of the document is not affected
+	scheduler.Schedule(uri, func(ctx context.Context) {
+		started = true
+		assert.NoError(t, ctx.Err())
+	})
+	timers.fire()
+
+	assert.True(t, started)
+}
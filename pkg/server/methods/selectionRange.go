@@ -0,0 +1,35 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+)
+
+// The selection ranges are not part of go.lsp.dev/protocol
+
+const MethodTextDocumentSelectionRange = "textDocument/selectionRange"
+
+type SelectionRangeParams struct {
+	TextDocument protocol.TextDocumentIdentifier `json:"textDocument"`
+	Positions    []protocol.Position             `json:"positions"`
+}
+
+func (methods *Methods) SelectionRange(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := SelectionRangeParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	return methods.replyCancellable(reply, req, func(_ context.Context) (interface{}, error) {
+		res, err := languageservice.SelectionRanges(params.TextDocument.URI, params.Positions, methods.Cache, methods.LsContext)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
+}
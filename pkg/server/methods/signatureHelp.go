@@ -0,0 +1,27 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+)
+
+func (methods *Methods) SignatureHelp(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := protocol.SignatureHelpParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	return methods.replyCancellable(reply, req, func(_ context.Context) (interface{}, error) {
+		res, err := languageservice.SignatureHelpFromParams(params, methods.Cache, methods.LsContext)
+		if err != nil || res == nil {
+			return nil, nil
+		}
+
+		return res, nil
+	})
+}
@@ -1,7 +1,7 @@
 package methods
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"path"
 	"strings"
@@ -11,6 +11,7 @@ import (
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 	"github.com/bep/debounce"
 	"github.com/segmentio/encoding/json"
+	sitter "github.com/smacker/go-tree-sitter"
 	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
 )
@@ -32,10 +33,11 @@ func (methods *Methods) DidOpen(reply jsonrpc2.Replier, req jsonrpc2.Request) er
 	}
 
 	methods.setChangeInFileCache(params.TextDocument)
-	methods.parsingMethods(params.TextDocument)
+	parser.InvalidateOrbSources(params.TextDocument.URI)
+	methods.parsingMethods(methods.Ctx, params.TextDocument)
 	methods.updateOrbFile([]byte(params.TextDocument.Text), params.TextDocument.URI)
+	methods.DiagnosticScheduler.Run(params.TextDocument.URI, methods.runDiagnostics(params.TextDocument))
 	go (func() {
-		methods.notificationMethods(params.TextDocument)
 		methods.SetResourceClassOfFile(params)
 		methods.SendTelemetryEvent(TelemetryEvent{
 			Action: "opened_file",
@@ -57,30 +59,38 @@ func (methods *Methods) updateAllCachedFiles() {
 		files := methods.Cache.FileCache.GetFiles()
 
 		for _, file := range files {
-			go methods.notificationMethods(file.TextDocument)
+			methods.DiagnosticScheduler.Run(file.TextDocument.URI, methods.runDiagnostics(file.TextDocument))
 		}
+
+		methods.refreshDiagnostics()
 	})
 }
 
-var debounceInnerChange = debounce.New(1000 * time.Millisecond)
+// Delay after the last change of a document before it is parsed and validated
+const DiagnosticsDelay = 1000 * time.Millisecond
 
 func (methods *Methods) DidChange(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
 	params := protocol.DidChangeTextDocumentParams{}
 	if err := json.Unmarshal(req.Params(), &params); err != nil {
 		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
 	}
-	newText := methods.applyIncrementalChanges(params.TextDocument.URI, params.ContentChanges)
+	previousContentHash := methods.Cache.FileCache.GetFile(params.TextDocument.URI).ContentHash
+	newText, edits := methods.applyIncrementalChanges(params.TextDocument.URI, params.ContentChanges)
 	textDocument := protocol.TextDocumentItem{
 		URI:     params.TextDocument.URI,
 		Text:    newText,
 		Version: params.TextDocument.Version,
 	}
 	methods.setChangeInFileCache(textDocument)
+	parser.InvalidateOrbSources(textDocument.URI)
+	if file := methods.Cache.FileCache.GetFile(textDocument.URI); file != nil {
+		parser.UpdateParsedDocument(methods.Ctx, *file, previousContentHash, edits, methods.LsContext)
+	}
 	methods.updateOrbFile([]byte(newText), params.TextDocument.URI)
 
-	debounceInnerChange(func() {
-		methods.parsingMethods(textDocument)
-		go methods.notificationMethods(textDocument)
+	methods.DiagnosticScheduler.Schedule(textDocument.URI, func(ctx context.Context) {
+		methods.parsingMethods(ctx, textDocument)
+		methods.notificationMethods(ctx, textDocument)
 	})
 	return reply(methods.Ctx, nil, nil)
 }
@@ -91,9 +101,14 @@ func (methods *Methods) DidClose(reply jsonrpc2.Replier, req jsonrpc2.Request) e
 		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
 	}
 
+	// The other files of its orb source read it from the disk from now on
+	parser.InvalidateOrbSources(params.TextDocument.URI)
+	parser.RemoveParsedDocument(params.TextDocument.URI)
+
 	// removed due to a bug in remote orbs
 	isOrb, _ := methods.isOrb(params.TextDocument.URI)
 	if isOrb {
+		methods.DiagnosticScheduler.Cancel(params.TextDocument.URI)
 		methods.Cache.FileCache.RemoveFile(params.TextDocument.URI)
 		defer methods.Conn.Notify(
 			methods.Ctx,
@@ -108,13 +123,29 @@ func (methods *Methods) DidClose(reply jsonrpc2.Replier, req jsonrpc2.Request) e
 	return reply(methods.Ctx, nil, nil)
 }
 
-func (methods *Methods) notificationMethods(textDocument protocol.TextDocumentItem) {
+func (methods *Methods) runDiagnostics(textDocument protocol.TextDocumentItem) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		methods.notificationMethods(ctx, textDocument)
+	}
+}
+
+func (methods *Methods) notificationMethods(ctx context.Context, textDocument protocol.TextDocumentItem) {
 	isOrb, _ := methods.isOrb(textDocument.URI)
 	if methods.LsContext.Api.Token != "" && !isOrb {
 		methods.getAllEnvVariables(textDocument)
 	}
 
-	diagnostics := methods.Diagnostics(textDocument)
+	// The client requests the diagnostics itself
+	if methods.LsContext.UsePullDiagnostics {
+		return
+	}
+
+	diagnostics := methods.Diagnostics(ctx, textDocument)
+
+	// A newer version of the document has been scheduled in the meantime
+	if ctx.Err() != nil {
+		return
+	}
 
 	original := methods.Cache.FileCache.GetFile(textDocument.URI)
 
@@ -139,49 +170,58 @@ func (methods *Methods) notificationMethods(textDocument protocol.TextDocumentIt
 
 }
 
-func (methods *Methods) parsingMethods(textDocument protocol.TextDocumentItem) {
-	parsedFile, err := parser.ParseFromUriWithCache(textDocument.URI, methods.Cache, methods.LsContext)
+func (methods *Methods) parsingMethods(ctx context.Context, textDocument protocol.TextDocumentItem) {
+	parsedFile, err := parser.ParseFromUriWithCacheWithContext(ctx, textDocument.URI, methods.Cache, methods.LsContext)
 
 	if err != nil {
 		return
 	}
 
-	parser.ParseRemoteOrbs(parsedFile.Orbs, methods.Cache, methods.LsContext)
+	parser.ParseRemoteOrbs(ctx, parsedFile.Orbs, methods.Cache, methods.LsContext)
 }
 
-func (methods *Methods) applyIncrementalChanges(uri protocol.URI, changes []protocol.TextDocumentContentChangeEvent) string {
+// Returns the new text of the document along with the edits made to its
+// previous text, see parser.UpdateParsedDocument
+func (methods *Methods) applyIncrementalChanges(uri protocol.URI, changes []protocol.TextDocumentContentChangeEvent) (string, []sitter.EditInput) {
 	file := methods.Cache.FileCache.GetFile(uri)
 	content := []byte(file.TextDocument.Text)
+	edits := make([]sitter.EditInput, 0, len(changes))
 
 	for _, change := range changes {
-		start, end := utils.PosToIndex(change.Range.Start, content), utils.PosToIndex(change.Range.End, content)
-
-		var buf bytes.Buffer
-		buf.Write(content[:start])
-		buf.Write([]byte(change.Text))
-		buf.Write(content[end:])
-		content = buf.Bytes()
+		var edit sitter.EditInput
+		content, edit = parser.ApplyContentChange(content, change)
+		edits = append(edits, edit)
 	}
 
-	return string(content)
+	return string(content), edits
 }
 
 func (methods *Methods) updateOrbFile(content []byte, uri protocol.URI) {
 	isOrb, orbId := methods.isOrb(uri)
-	if isOrb {
-		parsedOrbSource, err := parser.ParseFromContent([]byte(content), methods.LsContext, uri, protocol.Position{})
+	if isOrb && orbId != "" {
+		parsedOrbSource, err := parser.ParseFromContentWithContext(methods.Ctx, []byte(content), methods.LsContext, uri, protocol.Position{})
 		if err == nil {
 			methods.Cache.OrbCache.UpdateOrbParsedAttributes(orbId, parsedOrbSource.ToOrbParsedAttributes())
 		}
 	}
 }
 
+// Returns whether the document is an orb rather than a pipeline config: either
+// the file an orb of the cache has been downloaded to, along with the ID of the
+// orb, or the source of an orb being written, see parser.IsOrbAuthoringFile
 func (methods *Methods) isOrb(uri protocol.URI) (bool, string) {
 	namespace := path.Base((path.Dir(uri.Filename())))
 	orb := path.Base(uri.Filename())
-	orbId := strings.TrimRight(path.Join(namespace, orb), ".yml")
+	orbId := strings.TrimSuffix(path.Join(namespace, orb), ".yml")
+
+	if methods.Cache.OrbCache.HasOrb(orbId) {
+		return true, orbId
+	}
 
-	isOrb := methods.Cache.OrbCache.HasOrb(orbId)
+	content := []byte{}
+	if file := methods.Cache.FileCache.GetFile(uri); file != nil {
+		content = []byte(file.TextDocument.Text)
+	}
 
-	return isOrb, orbId
+	return parser.IsOrbAuthoringFile(uri, content), ""
 }
@@ -0,0 +1,67 @@
+package methods
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestIsOrb(t *testing.T) {
+	methods := Methods{
+		Ctx:       context.Background(),
+		Cache:     utils.CreateCache(),
+		LsContext: testHelpers.GetDefaultLsContext(),
+	}
+	methods.Cache.OrbCache.SetOrb(&ast.OrbInfo{}, "circleci/node@5.0.0")
+
+	testCases := []struct {
+		Name          string
+		URI           protocol.URI
+		Content       string
+		ExpectedIsOrb bool
+		ExpectedOrbID string
+	}{
+		{
+			Name:          "Orb of the cache",
+			URI:           uri.File("/cache/circleci/node@5.0.0.yml"),
+			Content:       "version: 2.1\n",
+			ExpectedIsOrb: true,
+			ExpectedOrbID: "circleci/node@5.0.0",
+		},
+		{
+			Name:          "Orb development kit source",
+			URI:           uri.File("/project/src/@orb.yml"),
+			Content:       "version: 2.1\n",
+			ExpectedIsOrb: true,
+		},
+		{
+			Name:          "Orb marker",
+			URI:           uri.File("/project/orbs/tools.yml"),
+			Content:       "# orb\nversion: 2.1\n",
+			ExpectedIsOrb: true,
+		},
+		{
+			Name:    "Pipeline config",
+			URI:     uri.File("/project/.circleci/config.yml"),
+			Content: "version: 2.1\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Name, func(t *testing.T) {
+			methods.Cache.FileCache.SetFile(utils.CachedFile{
+				TextDocument: protocol.TextDocumentItem{URI: tt.URI, Text: tt.Content},
+			})
+
+			isOrb, orbID := methods.isOrb(tt.URI)
+			assert.Equal(t, tt.ExpectedIsOrb, isOrb)
+			assert.Equal(t, tt.ExpectedOrbID, orbID)
+		})
+	}
+}
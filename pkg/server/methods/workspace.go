@@ -0,0 +1,158 @@
+package methods
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// The files whose changes on disk are reported by the client: the configs,
+// the orbs and the git configs holding the remotes the projects are built from
+var workspaceFileWatchers = []protocol.FileSystemWatcher{
+	{GlobPattern: "**/.circleci/**/*.{yml,yaml}"},
+	{GlobPattern: "**/{@orb,orb}.{yml,yaml}"},
+	{GlobPattern: "**/src/{commands,examples,executors,jobs}/*.{yml,yaml}"},
+	{GlobPattern: "**/.git/config"},
+}
+
+func (methods *Methods) Initialized(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	if methods.LsContext.CanWatchFiles {
+		go methods.Conn.Call(methods.Ctx, protocol.MethodClientRegisterCapability, protocol.RegistrationParams{
+			Registrations: []protocol.Registration{
+				{
+					ID:     "circleci-workspace-files",
+					Method: protocol.MethodWorkspaceDidChangeWatchedFiles,
+					RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{
+						Watchers: workspaceFileWatchers,
+					},
+				},
+			},
+		}, nil)
+	}
+
+	return reply(methods.Ctx, nil, nil)
+}
+
+func (methods *Methods) DidChangeWorkspaceFolders(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := protocol.DidChangeWorkspaceFoldersParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	for _, folder := range params.Event.Removed {
+		methods.Cache.WorkspaceCache.RemoveFolder(protocol.URI(folder.URI))
+		parser.RemoveParsedDocuments(uri.URI(folder.URI).Filename())
+	}
+
+	for _, folder := range params.Event.Added {
+		methods.addWorkspaceFolder(folder)
+	}
+	go (func() {
+		for _, folder := range params.Event.Added {
+			methods.indexWorkspaceFolder(folder)
+		}
+		methods.refreshDiagnostics()
+	})()
+
+	return reply(methods.Ctx, nil, nil)
+}
+
+func (methods *Methods) DidChangeWatchedFiles(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := protocol.DidChangeWatchedFilesParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	hasChanged := false
+	for _, change := range params.Changes {
+		filename := change.URI.Filename()
+
+		if filepath.Base(filename) == "config" && filepath.Base(filepath.Dir(filename)) == ".git" {
+			if folder, ok := methods.Cache.WorkspaceCache.GetFolderOfFile(change.URI); ok {
+				methods.Cache.WorkspaceCache.SetFolderProjectSlug(
+					protocol.URI(folder.URI),
+					utils.GetFolderProjectSlug(folder.Dir()),
+				)
+			}
+			continue
+		}
+
+		parser.InvalidateOrbSources(change.URI)
+		if !parser.IsWorkspaceFile(filename) {
+			continue
+		}
+
+		if change.Type == protocol.FileChangeTypeDeleted {
+			methods.Cache.WorkspaceCache.UnindexFile(change.URI)
+			parser.RemoveParsedDocument(change.URI)
+		} else {
+			methods.Cache.WorkspaceCache.IndexFile(change.URI)
+		}
+		hasChanged = true
+	}
+
+	// The open documents may use the changed files, as the other files of the
+	// source of an orb
+	if hasChanged {
+		methods.updateAllCachedFiles()
+	}
+
+	return reply(methods.Ctx, nil, nil)
+}
+
+// Adds the folder to the workspace and finds the project it belongs to
+func (methods *Methods) addWorkspaceFolder(folder protocol.WorkspaceFolder) {
+	methods.Cache.WorkspaceCache.SetFolder(folder)
+	methods.Cache.WorkspaceCache.SetFolderProjectSlug(
+		protocol.URI(folder.URI),
+		utils.GetFolderProjectSlug(uri.URI(folder.URI).Filename()),
+	)
+}
+
+// Indexes the CircleCI files of the folder, which walks the whole folder
+func (methods *Methods) indexWorkspaceFolder(folder protocol.WorkspaceFolder) {
+	methods.Cache.WorkspaceCache.SetFolderFiles(
+		protocol.URI(folder.URI),
+		parser.FindWorkspaceFiles(uri.URI(folder.URI).Filename()),
+	)
+}
+
+// Returns the slug of the project of the file: the one of its workspace folder
+// or, for files outside of the workspace, the one of its repository
+func (methods *Methods) getProjectSlug(documentURI protocol.URI) string {
+	if folder, ok := methods.Cache.WorkspaceCache.GetFolderOfFile(documentURI); ok {
+		return folder.ProjectSlug
+	}
+
+	return utils.GetProjectSlug(documentURI.Filename())
+}
+
+// Returns the project of the file, it is fetched once per workspace folder
+func (methods *Methods) getProject(documentURI protocol.URI) (utils.Project, error) {
+	folder, ok := methods.Cache.WorkspaceCache.GetFolderOfFile(documentURI)
+	if !ok {
+		return utils.GetProjectId(methods.Ctx, utils.GetProjectSlug(documentURI.Filename()), methods.LsContext)
+	}
+
+	if folder.Project != nil {
+		return *folder.Project, nil
+	}
+
+	if folder.ProjectSlug == "" {
+		return utils.Project{}, fmt.Errorf("no project found for the folder %s", folder.Name)
+	}
+
+	project, err := utils.GetProjectId(methods.Ctx, folder.ProjectSlug, methods.LsContext)
+	if err != nil {
+		return utils.Project{}, err
+	}
+	methods.Cache.WorkspaceCache.SetFolderProject(protocol.URI(folder.URI), project)
+
+	return project, nil
+}
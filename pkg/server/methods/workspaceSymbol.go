@@ -0,0 +1,22 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	languageservice "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+)
+
+func (methods *Methods) WorkspaceSymbols(reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	params := protocol.WorkspaceSymbolParams{}
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(methods.Ctx, nil, fmt.Errorf("%s: %w", jsonrpc2.ErrParse, err))
+	}
+
+	return methods.replyCancellable(reply, req, func(ctx context.Context) (interface{}, error) {
+		return languageservice.WorkspaceSymbols(ctx, params, methods.getWorkspaceDocuments(), methods.Cache, methods.LsContext)
+	})
+}
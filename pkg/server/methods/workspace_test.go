@@ -0,0 +1,40 @@
+package methods
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestAddWorkspaceFolderProjectSlug(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"git@github.com:CircleCI-Public/circleci-yaml-language-server.git"},
+	})
+	assert.NoError(t, err)
+
+	methods := Methods{
+		Ctx:       context.Background(),
+		Cache:     utils.CreateCache(),
+		LsContext: testHelpers.GetDefaultLsContext(),
+	}
+
+	// The slug is known before the folder is indexed
+	methods.addWorkspaceFolder(protocol.WorkspaceFolder{URI: string(uri.File(dir)), Name: "project"})
+	assert.Equal(
+		t,
+		"gh/CircleCI-Public/circleci-yaml-language-server",
+		methods.getProjectSlug(uri.File(filepath.Join(dir, ".circleci", "config.yml"))),
+	)
+}
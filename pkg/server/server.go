@@ -23,10 +23,15 @@ type JSONRPCServer struct {
 	cache          *utils.Cache
 	lsContext      *utils.LsContext
 	SchemaLocation string
+
+	// Shared by the connections, for their changes not to overwrite each other
+	diskCache *utils.DiskCache
+
+	diagnosticsDelay time.Duration
 }
 
 func (server JSONRPCServer) commandHandler(_ context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
-	fmt.Fprintln(os.Stderr, "Called method: "+req.Method())
+	reply = timedReplier(server.ctx, reply, req)
 
 	defer func() {
 		err := recover()
@@ -42,6 +47,15 @@ func (server JSONRPCServer) commandHandler(_ context.Context, reply jsonrpc2.Rep
 	case protocol.MethodInitialize:
 		return server.methods.Initialize(reply, req)
 
+	case protocol.MethodInitialized:
+		return server.methods.Initialized(reply, req)
+
+	case protocol.MethodWorkspaceDidChangeWorkspaceFolders:
+		return server.methods.DidChangeWorkspaceFolders(reply, req)
+
+	case protocol.MethodWorkspaceDidChangeWatchedFiles:
+		return server.methods.DidChangeWatchedFiles(reply, req)
+
 	case protocol.MethodWorkspaceExecuteCommand:
 		return server.methods.ExecuteCommand(reply, req)
 
@@ -57,6 +71,9 @@ func (server JSONRPCServer) commandHandler(_ context.Context, reply jsonrpc2.Rep
 	case protocol.MethodTextDocumentHover:
 		return server.methods.Hover(reply, req)
 
+	case protocol.MethodTextDocumentSignatureHelp:
+		return server.methods.SignatureHelp(reply, req)
+
 	case protocol.MethodSemanticTokensFull:
 		return server.methods.SemanticTokens(reply, req)
 
@@ -66,19 +83,56 @@ func (server JSONRPCServer) commandHandler(_ context.Context, reply jsonrpc2.Rep
 	case protocol.MethodTextDocumentReferences:
 		return server.methods.References(reply, req)
 
+	case protocol.MethodTextDocumentPrepareCallHierarchy:
+		return server.methods.PrepareCallHierarchy(reply, req)
+
+	case protocol.MethodCallHierarchyIncomingCalls:
+		return server.methods.IncomingCalls(reply, req)
+
+	case protocol.MethodCallHierarchyOutgoingCalls:
+		return server.methods.OutgoingCalls(reply, req)
+
 	case protocol.MethodTextDocumentCompletion:
 		return server.methods.Complete(reply, req)
 
+	case protocol.MethodCompletionItemResolve:
+		return server.methods.CompletionResolve(reply, req)
+
 	case protocol.MethodTextDocumentCodeAction:
 		return server.methods.CodeAction(reply, req)
 
+	case methods.MethodTextDocumentDiagnostic:
+		return server.methods.DocumentDiagnostic(reply, req)
+
+	case methods.MethodWorkspaceDiagnostic:
+		return server.methods.WorkspaceDiagnostic(reply, req)
+
+	case protocol.MethodSetTrace:
+		return server.methods.SetTrace(reply, req)
+
+	case protocol.MethodCancelRequest:
+		return server.methods.CancelRequest(reply, req)
+
 	case protocol.MethodShutdown:
 		return reply(server.ctx, nil, nil)
 
 	case protocol.MethodTextDocumentDocumentSymbol:
 		return server.methods.DocumentSymbols(reply, req)
 
+	case protocol.MethodWorkspaceSymbol:
+		return server.methods.WorkspaceSymbols(reply, req)
+
+	case protocol.MethodTextDocumentDocumentHighlight:
+		return server.methods.DocumentHighlight(reply, req)
+
+	case protocol.MethodTextDocumentFoldingRange:
+		return server.methods.FoldingRange(reply, req)
+
+	case methods.MethodTextDocumentSelectionRange:
+		return server.methods.SelectionRange(reply, req)
+
 	case protocol.MethodExit:
+		server.diskCache.Flush()
 		os.Exit(0)
 		return nil
 
@@ -87,26 +141,60 @@ func (server JSONRPCServer) commandHandler(_ context.Context, reply jsonrpc2.Rep
 	}
 }
 
+// Logs the time taken to answer the request, the handlers of the requests
+// answered in the background return before replying
+func timedReplier(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) jsonrpc2.Replier {
+	start := time.Now()
+	utils.Logger.DebugContext(ctx, "request received", "method", req.Method())
+
+	return func(replyCtx context.Context, result interface{}, err error) error {
+		attrs := []any{"method", req.Method()}
+		if call, ok := req.(*jsonrpc2.Call); ok {
+			attrs = append(attrs, "id", call.ID())
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		utils.LogDurationContext(ctx, "request handled", start, attrs...)
+
+		return reply(replyCtx, result, err)
+	}
+}
+
 func (server JSONRPCServer) ServeStream(_ context.Context, conn jsonrpc2.Conn) error {
-	defer rollbar.Close()
-	fmt.Fprintln(os.Stderr, "New client connection")
+	utils.Logger.Info("new client connection")
+
+	// The logs of the requests of this connection are sent to its client only
+	clientLog := utils.NewClientLog(methods.NewLogClient(server.ctx, conn))
+	server.ctx = utils.WithClientLog(server.ctx, clientLog)
 
 	server.conn = conn
 	server.cache = utils.CreateCache()
+	server.cache.SetDiskCache(server.diskCache)
 	server.methods = methods.Methods{
 		Ctx:            server.ctx,
 		Conn:           server.conn,
 		Cache:          server.cache,
 		LsContext:      server.lsContext,
 		SchemaLocation: server.SchemaLocation,
+		ClientLog:      clientLog,
+
+		DiagnosticScheduler: methods.NewDiagnosticScheduler(server.ctx, server.diagnosticsDelay),
+		PendingRequests:     methods.NewPendingRequests(),
 	}
+	server.methods.RefreshDiagnosticsOnCacheChanges()
+
 	conn.Go(server.ctx, server.commandHandler)
 	<-conn.Done()
+	server.diskCache.Flush()
 
 	return conn.Err()
 }
 
 func StartServer(port int, host string, schemaLocation string) {
+	// Closed once for all the connections, closing it twice panics
+	defer rollbar.Close()
+
 	ctx := context.Background()
 	server := getJsonRpcServer(ctx, schemaLocation)
 
@@ -156,6 +244,8 @@ type StdioReadWriteCloser struct {
 func (s *StdioReadWriteCloser) Close() error { return nil }
 
 func StartServerStdio(schemaLocation string) {
+	defer rollbar.Close()
+
 	ctx := context.Background()
 
 	stdioStream := jsonrpc2.NewStream(&StdioReadWriteCloser{os.Stdin, os.Stdout})
@@ -177,6 +267,8 @@ func getJsonRpcServer(ctx context.Context, schemaLocation string) JSONRPCServer
 			},
 			IsCciExtension: false,
 		},
-		SchemaLocation: schemaLocation,
+		SchemaLocation:   schemaLocation,
+		diskCache:        utils.NewDiskCache(utils.GetDiskCacheFSPath()),
+		diagnosticsDelay: methods.DiagnosticsDelay,
 	}
 }
@@ -0,0 +1,483 @@
+package languageserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers/fakeapi"
+	"github.com/adrg/xdg"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/jsonrpc2"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// The transcripts of testdata/transcripts are sessions replayed against the
+// server, through JSON-RPC, whose responses are compared with the golden file
+// of the same name. Run the tests with -update to write the golden files.
+//
+// A transcript is a list of commands, one per line. Lines starting with # are
+// comments. The text of a command is either given inline, as JSON, or on the
+// lines following it, up to the end tag of a heredoc:
+//
+//	open .circleci/config.yml <<EOF
+//	version: 2.1
+//	EOF
+//
+// The commands are:
+//
+//	initialize [params]            request initialize, in the workspace root by default
+//	open <path> <<EOF              notify didOpen with the text, followed by a newline
+//	change <path> <range> <text>   notify didChange, the range is line:char-line:char
+//	close <path>                   notify didClose
+//	completion <path> <line:char>  request the completion at the position
+//	hover <path> <line:char>       request the hover at the position
+//	request <method> <params>      request any method
+//	notify <method> <params>       notify any method
+//	wait <method> [path]           wait for the server to notify or call the method
+//
+// Paths are relative to the workspace root and positions are zero based, as in
+// LSP. ${root} is replaced by the URI of the workspace root in the params, and
+// in the responses written to the golden files.
+
+var heredocTag = regexp.MustCompile(`^<<[A-Z]+$`)
+
+// The number of arguments of the commands, before their inline text
+var transcriptArgs = map[string]int{
+	"initialize": 0,
+	"open":       1,
+	"change":     2,
+	"close":      1,
+	"completion": 2,
+	"hover":      2,
+	"request":    1,
+	"notify":     1,
+	"wait":       2,
+}
+
+var update = flag.Bool("update", false, "write the golden files of the transcripts")
+
+const transcriptTimeout = 10 * time.Second
+
+func TestTranscripts(t *testing.T) {
+	transcripts, err := filepath.Glob(filepath.Join("testdata", "transcripts", "*.txt"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, transcripts)
+
+	for _, path := range transcripts {
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile(path)
+			assert.NoError(t, err)
+
+			commands, err := parseTranscript(string(content))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			output := newTranscriptSession(t).run(commands)
+
+			goldenPath := strings.TrimSuffix(path, ".txt") + ".golden"
+			if *update {
+				assert.NoError(t, os.WriteFile(goldenPath, []byte(output), 0o644))
+				return
+			}
+
+			golden, err := os.ReadFile(goldenPath)
+			assert.NoError(t, err, "run the tests with -update to write the golden file")
+			assert.Equal(t, string(golden), output)
+		})
+	}
+}
+
+type transcriptCommand struct {
+	line int
+	name string
+	args []string
+	// The inline JSON or the heredoc
+	text string
+}
+
+func parseTranscript(content string) ([]transcriptCommand, error) {
+	lines := strings.Split(content, "\n")
+	commands := []transcriptCommand{}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		command := transcriptCommand{line: i + 1}
+
+		if fields := strings.Fields(line); heredocTag.MatchString(fields[len(fields)-1]) {
+			tag := strings.TrimPrefix(fields[len(fields)-1], "<<")
+			body := []string{}
+			for i++; i < len(lines) && lines[i] != tag; i++ {
+				body = append(body, lines[i])
+			}
+			if i == len(lines) {
+				return nil, fmt.Errorf("line %d: heredoc not terminated by %s", command.line, tag)
+			}
+
+			line = strings.Join(fields[:len(fields)-1], " ")
+			command.text = strings.Join(body, "\n")
+		}
+
+		// The inline text is what follows the arguments
+		command.name, line = cutField(line)
+		for j := 0; j < transcriptArgs[command.name] && line != ""; j++ {
+			var arg string
+			arg, line = cutField(line)
+			command.args = append(command.args, arg)
+		}
+		if line != "" {
+			command.text = line
+		}
+
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+type transcriptMessage struct {
+	method string
+	params json.RawMessage
+}
+
+type transcriptSession struct {
+	t       *testing.T
+	ctx     context.Context
+	conn    jsonrpc2.Conn
+	root    string
+	rootURI string
+
+	versions map[string]int32
+
+	mutex    sync.Mutex
+	received []transcriptMessage
+	notify   chan struct{}
+
+	output strings.Builder
+}
+
+// Starts the server in process, connected to the session through a pipe. The
+// APIs it reaches are served by a fakeapi server and its caches are kept in a
+// temporary directory
+func newTranscriptSession(t *testing.T) *transcriptSession {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	api := fakeapi.Install(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	root := t.TempDir()
+	session := &transcriptSession{
+		t:        t,
+		ctx:      ctx,
+		root:     root,
+		rootURI:  string(uri.File(root)),
+		versions: make(map[string]int32),
+		notify:   make(chan struct{}, 1),
+	}
+
+	server := JSONRPCServer{
+		ctx:              ctx,
+		lsContext:        api.LsContext(),
+		diagnosticsDelay: 10 * time.Millisecond,
+	}
+
+	serverPipe, clientPipe := net.Pipe()
+	go server.ServeStream(ctx, jsonrpc2.NewConn(jsonrpc2.NewStream(serverPipe)))
+
+	session.conn = jsonrpc2.NewConn(jsonrpc2.NewStream(clientPipe))
+	session.conn.Go(ctx, session.handle)
+	t.Cleanup(func() { session.conn.Close() })
+
+	return session
+}
+
+// Keeps what the server sends, its calls are answered with null
+func (session *transcriptSession) handle(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+	session.mutex.Lock()
+	session.received = append(session.received, transcriptMessage{method: req.Method(), params: req.Params()})
+	session.mutex.Unlock()
+
+	select {
+	case session.notify <- struct{}{}:
+	default:
+	}
+
+	if _, ok := req.(*jsonrpc2.Call); ok {
+		return reply(ctx, nil, nil)
+	}
+	return nil
+}
+
+func (session *transcriptSession) run(commands []transcriptCommand) string {
+	for _, command := range commands {
+		if err := session.runCommand(command); err != nil {
+			session.t.Fatalf("line %d: %s: %s", command.line, command.name, err)
+		}
+	}
+
+	return session.output.String()
+}
+
+func (session *transcriptSession) runCommand(command transcriptCommand) error {
+	switch command.name {
+	case "initialize":
+		params := command.text
+		if params == "" {
+			params = `{"processId": null, "rootUri": "${root}", "capabilities": {}}`
+		}
+		return session.request(command, protocol.MethodInitialize, params)
+
+	case "open":
+		if len(command.args) != 1 {
+			return errors.New("expected a path")
+		}
+		session.versions[command.args[0]] = 1
+		return session.notifyServer(protocol.MethodTextDocumentDidOpen, protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{
+				URI:        session.uri(command.args[0]),
+				LanguageID: "yaml",
+				Version:    1,
+				Text:       command.text + "\n",
+			},
+		})
+
+	case "change":
+		if len(command.args) != 2 {
+			return errors.New("expected a path and a range")
+		}
+		rng, err := parseRange(command.args[1])
+		if err != nil {
+			return err
+		}
+		text := command.text
+		if strings.HasPrefix(text, `"`) {
+			if err := json.Unmarshal([]byte(text), &text); err != nil {
+				return err
+			}
+		}
+
+		session.versions[command.args[0]]++
+		return session.notifyServer(protocol.MethodTextDocumentDidChange, protocol.DidChangeTextDocumentParams{
+			TextDocument: protocol.VersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: session.uri(command.args[0])},
+				Version:                session.versions[command.args[0]],
+			},
+			ContentChanges: []protocol.TextDocumentContentChangeEvent{{Range: rng, Text: text}},
+		})
+
+	case "close":
+		if len(command.args) != 1 {
+			return errors.New("expected a path")
+		}
+		return session.notifyServer(protocol.MethodTextDocumentDidClose, protocol.DidCloseTextDocumentParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: session.uri(command.args[0])},
+		})
+
+	case "completion", "hover":
+		if len(command.args) != 2 {
+			return errors.New("expected a path and a position")
+		}
+		position, err := parsePosition(command.args[1])
+		if err != nil {
+			return err
+		}
+		params, _ := json.Marshal(protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: session.uri(command.args[0])},
+			Position:     position,
+		})
+		method := map[string]string{
+			"completion": protocol.MethodTextDocumentCompletion,
+			"hover":      protocol.MethodTextDocumentHover,
+		}[command.name]
+		return session.request(command, method, string(params))
+
+	case "request":
+		if len(command.args) != 1 {
+			return errors.New("expected a method")
+		}
+		return session.request(command, command.args[0], command.text)
+
+	case "notify":
+		if len(command.args) != 1 {
+			return errors.New("expected a method")
+		}
+		return session.notifyServer(command.args[0], session.expand(command.text))
+
+	case "wait":
+		if len(command.args) < 1 || len(command.args) > 2 {
+			return errors.New("expected a method and an optional path")
+		}
+		uri := ""
+		if len(command.args) == 2 {
+			uri = string(session.uri(command.args[1]))
+		}
+		message, err := session.wait(command.args[0], uri)
+		if err != nil {
+			return err
+		}
+		session.write(command, message.params, nil)
+		return nil
+
+	default:
+		return errors.New("unknown command")
+	}
+}
+
+func (session *transcriptSession) uri(path string) protocol.DocumentURI {
+	return uri.File(filepath.Join(session.root, path))
+}
+
+// Replaces ${root} in the params, which are sent as is
+func (session *transcriptSession) expand(params string) json.RawMessage {
+	if params == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(strings.ReplaceAll(params, "${root}", session.rootURI))
+}
+
+func (session *transcriptSession) request(command transcriptCommand, method string, params string) error {
+	ctx, cancel := context.WithTimeout(session.ctx, transcriptTimeout)
+	defer cancel()
+
+	var result json.RawMessage
+	_, err := session.conn.Call(ctx, method, session.expand(params), &result)
+
+	var rpcErr *jsonrpc2.Error
+	if err != nil && !errors.As(err, &rpcErr) {
+		return err
+	}
+
+	session.write(command, result, rpcErr)
+	return nil
+}
+
+func (session *transcriptSession) notifyServer(method string, params interface{}) error {
+	return session.conn.Notify(session.ctx, method, params)
+}
+
+// Returns the first message of the method received, optionally about the
+// document. The message and those received before it are then forgotten
+func (session *transcriptSession) wait(method string, uri string) (transcriptMessage, error) {
+	timeout := time.After(transcriptTimeout)
+
+	for {
+		session.mutex.Lock()
+		for i, message := range session.received {
+			if message.method == method && (uri == "" || messageURI(message) == uri) {
+				session.received = session.received[i+1:]
+				session.mutex.Unlock()
+				return message, nil
+			}
+		}
+		session.mutex.Unlock()
+
+		select {
+		case <-session.notify:
+		case <-timeout:
+			return transcriptMessage{}, fmt.Errorf("no %s received", method)
+		}
+	}
+}
+
+func messageURI(message transcriptMessage) string {
+	params := struct {
+		URI          string `json:"uri"`
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+	}{}
+	json.Unmarshal(message.params, &params)
+
+	if params.URI != "" {
+		return params.URI
+	}
+	return params.TextDocument.URI
+}
+
+// Writes the command and the response, indented, in the output
+func (session *transcriptSession) write(command transcriptCommand, result json.RawMessage, rpcErr *jsonrpc2.Error) {
+	fmt.Fprintf(&session.output, "> %s\n", strings.Join(append([]string{command.name}, command.args...), " "))
+
+	if rpcErr != nil {
+		fmt.Fprintf(&session.output, "error %d: %s\n\n", rpcErr.Code, rpcErr.Message)
+		return
+	}
+
+	var indented bytes.Buffer
+	if len(result) == 0 || json.Indent(&indented, result, "", "  ") != nil {
+		indented.Reset()
+		indented.WriteString("null")
+	}
+
+	text := strings.ReplaceAll(indented.String(), session.rootURI, "${root}")
+	text = strings.ReplaceAll(text, session.root, "${root}")
+	fmt.Fprintf(&session.output, "%s\n\n", text)
+}
+
+// Returns the first field of the line and the remaining of the line
+func cutField(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	field, rest, _ := strings.Cut(line, " ")
+	return field, strings.TrimSpace(rest)
+}
+
+func parsePosition(value string) (protocol.Position, error) {
+	line, character, ok := strings.Cut(value, ":")
+	if !ok {
+		return protocol.Position{}, fmt.Errorf("invalid position %q, expected line:char", value)
+	}
+
+	l, err := strconv.ParseUint(line, 10, 32)
+	if err != nil {
+		return protocol.Position{}, err
+	}
+	c, err := strconv.ParseUint(character, 10, 32)
+	if err != nil {
+		return protocol.Position{}, err
+	}
+
+	return protocol.Position{Line: uint32(l), Character: uint32(c)}, nil
+}
+
+func parseRange(value string) (protocol.Range, error) {
+	start, end, ok := strings.Cut(value, "-")
+	if !ok {
+		return protocol.Range{}, fmt.Errorf("invalid range %q, expected line:char-line:char", value)
+	}
+
+	startPosition, err := parsePosition(start)
+	if err != nil {
+		return protocol.Range{}, err
+	}
+	endPosition, err := parsePosition(end)
+	if err != nil {
+		return protocol.Range{}, err
+	}
+
+	return protocol.Range{Start: startPosition, End: endPosition}, nil
+}
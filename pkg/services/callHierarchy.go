@@ -0,0 +1,417 @@
+package languageservice
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// Kinds of the items of the call hierarchy
+const (
+	callHierarchyCommand  = "command"
+	callHierarchyJob      = "job"
+	callHierarchyWorkflow = "workflow"
+	callHierarchyJobGroup = "jobGroup"
+)
+
+// Kept in the Data of the items: the calls are searched in the document the
+// hierarchy has been prepared in, the items of the orbs being in other files
+type callHierarchyData struct {
+	Kind string               `json:"kind"`
+	Name string               `json:"name"`
+	URI  protocol.DocumentURI `json:"uri"`
+}
+
+type callHierarchyHandler struct {
+	Doc   yamlparser.YamlDocument
+	Cache *utils.Cache
+}
+
+// A command or job invoked, with the ranges of its invocations
+type call struct {
+	Name   string
+	Ranges []protocol.Range
+}
+
+func PrepareCallHierarchy(params protocol.CallHierarchyPrepareParams, cache *utils.Cache, context *utils.LsContext) ([]protocol.CallHierarchyItem, error) {
+	doc, err := yamlparser.ParseFromUriWithCache(params.TextDocument.URI, cache, context)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := callHierarchyHandler{Doc: doc, Cache: cache}
+	kind, name := ch.nameAt(params.Position)
+	if name == "" {
+		return nil, nil
+	}
+
+	item, ok := ch.item(kind, name)
+	if !ok {
+		return nil, nil
+	}
+	return []protocol.CallHierarchyItem{item}, nil
+}
+
+// IncomingCalls returns the commands and jobs using a command, the workflows
+// and job groups invoking a job and the workflows invoking a job group
+func IncomingCalls(params protocol.CallHierarchyIncomingCallsParams, cache *utils.Cache, context *utils.LsContext) ([]protocol.CallHierarchyIncomingCall, error) {
+	ch, data, err := callHierarchyHandlerOf(params.Item, cache, context)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []protocol.CallHierarchyIncomingCall{}
+	addCaller := func(kind string, name string, ranges []protocol.Range) {
+		if len(ranges) == 0 {
+			return
+		}
+		if from, ok := ch.item(kind, name); ok {
+			res = append(res, protocol.CallHierarchyIncomingCall{From: from, FromRanges: ranges})
+		}
+	}
+
+	switch data.Kind {
+	case callHierarchyCommand:
+		for _, doc := range ch.documents() {
+			for _, name := range slices.Sorted(maps.Keys(doc.Commands)) {
+				command := doc.Commands[name]
+				addCaller(callHierarchyCommand, name, rangesOf(ch.callsOfSteps(command.Steps, command.Parameters), data.Name))
+			}
+			for _, name := range slices.Sorted(maps.Keys(doc.Jobs)) {
+				job := doc.Jobs[name]
+				addCaller(callHierarchyJob, name, rangesOf(ch.callsOfSteps(job.Steps, job.Parameters), data.Name))
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(ch.Doc.Workflows)) {
+			addCaller(callHierarchyWorkflow, name, rangesOf(ch.callsOfWorkflow(ch.Doc.Workflows[name].JobInvocations), data.Name))
+		}
+
+	case callHierarchyJob, callHierarchyJobGroup:
+		for _, name := range slices.Sorted(maps.Keys(ch.Doc.Workflows)) {
+			addCaller(callHierarchyWorkflow, name, rangesOf(invocationsOf(ch.Doc.Workflows[name].JobInvocations), data.Name))
+		}
+		if data.Kind == callHierarchyJob {
+			for _, name := range slices.Sorted(maps.Keys(ch.Doc.JobGroups)) {
+				addCaller(callHierarchyJobGroup, name, rangesOf(invocationsOf(ch.Doc.JobGroups[name].JobInvocations), data.Name))
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// OutgoingCalls returns the commands invoked by a command or a job, including
+// through the `steps` parameters, and the jobs, job groups and commands
+// invoked by a workflow or a job group
+func OutgoingCalls(params protocol.CallHierarchyOutgoingCallsParams, cache *utils.Cache, context *utils.LsContext) ([]protocol.CallHierarchyOutgoingCall, error) {
+	ch, data, err := callHierarchyHandlerOf(params.Item, cache, context)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := []call{}
+	// The calls made within an orb refer to its own commands
+	orbName := ""
+	switch data.Kind {
+	case callHierarchyCommand:
+		if command, ok := ch.Doc.GetCommand(data.Name); ok {
+			calls = ch.callsOfSteps(command.Steps, command.Parameters)
+		} else if orbInfo, entityName, ok := orbInfoOf(ch.Doc, data.Name, cache); ok {
+			command := orbInfo.Commands[entityName]
+			calls = ch.callsOfSteps(command.Steps, command.Parameters)
+			orbName, _, _ = strings.Cut(data.Name, "/")
+		}
+	case callHierarchyJob:
+		if job, ok := ch.Doc.GetJob(data.Name); ok {
+			calls = ch.callsOfSteps(job.Steps, job.Parameters)
+		} else if orbInfo, entityName, ok := orbInfoOf(ch.Doc, data.Name, cache); ok {
+			job := orbInfo.Jobs[entityName]
+			calls = ch.callsOfSteps(job.Steps, job.Parameters)
+			orbName, _, _ = strings.Cut(data.Name, "/")
+		}
+	case callHierarchyWorkflow:
+		if workflow, ok := ch.Doc.Workflows[data.Name]; ok {
+			calls = append(invocationsOf(workflow.JobInvocations), ch.callsOfWorkflow(workflow.JobInvocations)...)
+		}
+	case callHierarchyJobGroup:
+		if jobGroup, ok := ch.Doc.JobGroups[data.Name]; ok {
+			calls = invocationsOf(jobGroup.JobInvocations)
+		}
+	}
+
+	res := []protocol.CallHierarchyOutgoingCall{}
+	for _, call := range calls {
+		name := call.Name
+		if orbName != "" {
+			name = orbName + "/" + name
+		}
+
+		kind := ch.kindOf(name)
+		if data.Kind == callHierarchyWorkflow || data.Kind == callHierarchyJobGroup {
+			if _, ok := ch.Doc.JobGroups[name]; ok {
+				kind = callHierarchyJobGroup
+			}
+		}
+
+		if to, ok := ch.item(kind, name); ok {
+			res = append(res, protocol.CallHierarchyOutgoingCall{To: to, FromRanges: call.Ranges})
+		}
+	}
+
+	return res, nil
+}
+
+func callHierarchyHandlerOf(item protocol.CallHierarchyItem, cache *utils.Cache, context *utils.LsContext) (callHierarchyHandler, callHierarchyData, error) {
+	data := callHierarchyData{}
+	content, err := json.Marshal(item.Data)
+	if err == nil {
+		err = json.Unmarshal(content, &data)
+	}
+	if err != nil || data.URI == "" {
+		// Items from another server, the document of the item is used instead
+		data = callHierarchyData{URI: item.URI, Name: item.Name, Kind: data.Kind}
+	}
+
+	doc, err := yamlparser.ParseFromUriWithCache(data.URI, cache, context)
+	if err != nil {
+		return callHierarchyHandler{}, data, err
+	}
+
+	return callHierarchyHandler{Doc: doc, Cache: cache}, data, nil
+}
+
+// Returns the kind and the name of the command, job, workflow or job group
+// defined or invoked at the position
+func (ch callHierarchyHandler) nameAt(pos protocol.Position) (string, string) {
+	for _, command := range ch.Doc.Commands {
+		if utils.PosInRange(command.NameRange, pos) {
+			return callHierarchyCommand, command.Name
+		}
+	}
+	for _, job := range ch.Doc.Jobs {
+		if utils.PosInRange(job.NameRange, pos) {
+			return callHierarchyJob, job.Name
+		}
+	}
+	for _, workflow := range ch.Doc.Workflows {
+		if utils.PosInRange(workflow.NameRange, pos) {
+			return callHierarchyWorkflow, workflow.Name
+		}
+		if name := invocationAtPos(workflow.JobInvocations, pos); name != "" {
+			if _, ok := ch.Doc.JobGroups[name]; ok {
+				return callHierarchyJobGroup, name
+			}
+			return callHierarchyJob, name
+		}
+	}
+	for _, jobGroup := range ch.Doc.JobGroups {
+		if utils.PosInRange(jobGroup.NameRange, pos) {
+			return callHierarchyJobGroup, jobGroup.Name
+		}
+		if name := invocationAtPos(jobGroup.JobInvocations, pos); name != "" {
+			return callHierarchyJob, name
+		}
+	}
+
+	steps := []StepRangeAndName{}
+	for _, command := range ch.Doc.Commands {
+		steps = append(steps, ch.stepsOf(command.Steps, command.Parameters)...)
+	}
+	for _, job := range ch.Doc.Jobs {
+		steps = append(steps, ch.stepsOf(job.Steps, job.Parameters)...)
+	}
+	for _, workflow := range ch.Doc.Workflows {
+		for _, jobInvocation := range workflow.JobInvocations {
+			steps = append(steps, getStepsOfCommandOrJob(jobInvocation.PreSteps, stepsParameterChecker(ch.Doc, ch.Cache))...)
+			steps = append(steps, getStepsOfCommandOrJob(jobInvocation.PostSteps, stepsParameterChecker(ch.Doc, ch.Cache))...)
+		}
+	}
+	for _, step := range steps {
+		if utils.PosInRange(step.Range, pos) {
+			return ch.kindOf(step.Name), step.Name
+		}
+	}
+
+	return "", ""
+}
+
+func invocationAtPos(jobInvocations []ast.JobInvocation, pos protocol.Position) string {
+	for _, jobInvocation := range jobInvocations {
+		if utils.PosInRange(jobInvocation.JobNameRange, pos) {
+			return jobInvocation.JobName
+		}
+	}
+	return ""
+}
+
+// Returns whether the name is the one of a command or of a job, of the
+// document or of an orb
+func (ch callHierarchyHandler) kindOf(name string) string {
+	if _, ok := ch.Doc.GetCommand(name); ok {
+		return callHierarchyCommand
+	}
+	if _, ok := ch.Doc.GetJob(name); ok {
+		return callHierarchyJob
+	}
+
+	if orbInfo, entityName, ok := orbInfoOf(ch.Doc, name, ch.Cache); ok {
+		if _, ok := orbInfo.Commands[entityName]; ok {
+			return callHierarchyCommand
+		}
+		if _, ok := orbInfo.Jobs[entityName]; ok {
+			return callHierarchyJob
+		}
+	}
+
+	return ""
+}
+
+func (ch callHierarchyHandler) item(kind string, name string) (protocol.CallHierarchyItem, bool) {
+	newItem := func(symbolKind protocol.SymbolKind, detail string, documentURI protocol.DocumentURI, rng protocol.Range, nameRange protocol.Range) (protocol.CallHierarchyItem, bool) {
+		return protocol.CallHierarchyItem{
+			Name:           name,
+			Kind:           symbolKind,
+			Detail:         detail,
+			URI:            documentURI,
+			Range:          rng,
+			SelectionRange: nameRange,
+			Data:           callHierarchyData{Kind: kind, Name: name, URI: ch.Doc.URI},
+		}, true
+	}
+
+	switch kind {
+	case callHierarchyCommand:
+		for _, doc := range ch.documents() {
+			if command, ok := doc.Commands[name]; ok {
+				return newItem(protocol.SymbolKindFunction, "command", doc.URI, command.Range, command.NameRange)
+			}
+		}
+		if orbInfo, entityName, ok := orbInfoOf(ch.Doc, name, ch.Cache); ok {
+			if command, ok := orbInfo.Commands[entityName]; ok && ch.orbURI(orbInfo) != "" {
+				return newItem(protocol.SymbolKindFunction, "orb command", ch.orbURI(orbInfo), command.Range, command.NameRange)
+			}
+		}
+	case callHierarchyJob:
+		for _, doc := range ch.documents() {
+			if job, ok := doc.Jobs[name]; ok {
+				return newItem(protocol.SymbolKindClass, "job", doc.URI, job.Range, job.NameRange)
+			}
+		}
+		if orbInfo, entityName, ok := orbInfoOf(ch.Doc, name, ch.Cache); ok {
+			if job, ok := orbInfo.Jobs[entityName]; ok && ch.orbURI(orbInfo) != "" {
+				return newItem(protocol.SymbolKindClass, "orb job", ch.orbURI(orbInfo), job.Range, job.NameRange)
+			}
+		}
+	case callHierarchyWorkflow:
+		if workflow, ok := ch.Doc.Workflows[name]; ok {
+			return newItem(protocol.SymbolKindModule, "workflow", ch.Doc.URI, workflow.Range, workflow.NameRange)
+		}
+	case callHierarchyJobGroup:
+		if jobGroup, ok := ch.Doc.JobGroups[name]; ok {
+			return newItem(protocol.SymbolKindNamespace, "job group", ch.Doc.URI, jobGroup.Range, jobGroup.NameRange)
+		}
+	}
+
+	return protocol.CallHierarchyItem{}, false
+}
+
+// Returns the file defining the entities of the orb, the one of the document
+// for the local orbs
+func (ch callHierarchyHandler) orbURI(orbInfo *ast.OrbInfo) protocol.DocumentURI {
+	if orbInfo.IsLocal {
+		return ch.Doc.URI
+	}
+	if orbInfo.RemoteInfo.FilePath == "" {
+		return ""
+	}
+	return uri.New(orbInfo.RemoteInfo.FilePath)
+}
+
+// Returns the document and, for the files of an orb source directory, the
+// other files of the directory
+func (ch callHierarchyHandler) documents() []yamlparser.YamlDocument {
+	res := []yamlparser.YamlDocument{ch.Doc}
+	if ch.Doc.OrbSource == nil {
+		return res
+	}
+
+	for _, doc := range ch.Doc.OrbSource.Documents() {
+		if doc.URI != ch.Doc.URI {
+			res = append(res, doc)
+		}
+	}
+	return res
+}
+
+// Returns the named steps of a command or a job, including the default
+// values of its `steps` parameters
+func (ch callHierarchyHandler) stepsOf(steps []ast.Step, parameters map[string]ast.Parameter) []StepRangeAndName {
+	isStepsParameter := stepsParameterChecker(ch.Doc, ch.Cache)
+	res := getStepsOfCommandOrJob(steps, isStepsParameter)
+	for _, name := range slices.Sorted(maps.Keys(parameters)) {
+		if parameter, ok := parameters[name].(ast.StepsParameter); ok && parameter.HasDefault {
+			res = append(res, getStepsOfCommandOrJob(getStepsOfParameterValue(parameter.Default, false), isStepsParameter)...)
+		}
+	}
+	return res
+}
+
+func (ch callHierarchyHandler) callsOfSteps(steps []ast.Step, parameters map[string]ast.Parameter) []call {
+	return groupCalls(ch.stepsOf(steps, parameters))
+}
+
+// Returns the commands invoked in the `pre-steps` and `post-steps` of the jobs
+func (ch callHierarchyHandler) callsOfWorkflow(jobInvocations []ast.JobInvocation) []call {
+	steps := []StepRangeAndName{}
+	for _, jobInvocation := range jobInvocations {
+		steps = append(steps, getStepsOfCommandOrJob(jobInvocation.PreSteps, stepsParameterChecker(ch.Doc, ch.Cache))...)
+		steps = append(steps, getStepsOfCommandOrJob(jobInvocation.PostSteps, stepsParameterChecker(ch.Doc, ch.Cache))...)
+	}
+	return groupCalls(steps)
+}
+
+func invocationsOf(jobInvocations []ast.JobInvocation) []call {
+	steps := []StepRangeAndName{}
+	for _, jobInvocation := range jobInvocations {
+		if jobInvocation.Type != "approval" {
+			steps = append(steps, StepRangeAndName{Name: jobInvocation.JobName, Range: jobInvocation.JobNameRange})
+		}
+	}
+	return groupCalls(steps)
+}
+
+// Groups the invocations by name, in the order of their first invocation
+func groupCalls(steps []StepRangeAndName) []call {
+	res := []call{}
+	indexes := map[string]int{}
+	for _, step := range steps {
+		if step.Name == "" {
+			continue
+		}
+
+		index, ok := indexes[step.Name]
+		if !ok {
+			index = len(res)
+			indexes[step.Name] = index
+			res = append(res, call{Name: step.Name})
+		}
+		res[index].Ranges = append(res[index].Ranges, step.Range)
+	}
+	return res
+}
+
+func rangesOf(calls []call, name string) []protocol.Range {
+	for _, call := range calls {
+		if call.Name == name {
+			return call.Ranges
+		}
+	}
+	return nil
+}
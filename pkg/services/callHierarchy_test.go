@@ -0,0 +1,200 @@
+package languageservice
+
+import (
+	"os"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+const callHierarchyFile = "./testdata/callHierarchy.yml"
+
+// Returns a cache holding the files, as opened in the editor
+func cacheWithFiles(t *testing.T, filePaths ...string) *utils.Cache {
+	t.Helper()
+	cache := utils.CreateCache()
+
+	for _, filePath := range filePaths {
+		content, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		cache.FileCache.SetFile(utils.CachedFile{
+			TextDocument: protocol.TextDocumentItem{URI: uri.File(filePath), Text: string(content)},
+		})
+	}
+
+	return cache
+}
+
+func prepareCallHierarchyAt(t *testing.T, cache *utils.Cache, position protocol.Position) protocol.CallHierarchyItem {
+	t.Helper()
+
+	items, err := PrepareCallHierarchy(protocol.CallHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri.File(callHierarchyFile)},
+			Position:     position,
+		},
+	}, cache, testHelpers.GetDefaultLsContext())
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	return items[0]
+}
+
+func TestPrepareCallHierarchy(t *testing.T) {
+	tests := []struct {
+		name           string
+		position       protocol.Position
+		wantName       string
+		wantDetail     string
+		selectionRange protocol.Range
+	}{
+		{
+			name:       "Definition of a command",
+			position:   protocol.Position{Line: 10, Character: 3},
+			wantName:   "setup",
+			wantDetail: "command",
+			selectionRange: protocol.Range{
+				Start: protocol.Position{Line: 10, Character: 2},
+				End:   protocol.Position{Line: 10, Character: 7},
+			},
+		},
+		{
+			name:       "Command invoked in the steps given to a command",
+			position:   protocol.Position{Line: 38, Character: 14},
+			wantName:   "install",
+			wantDetail: "command",
+		},
+		{
+			name:       "Job invoked in a workflow",
+			position:   protocol.Position{Line: 54, Character: 10},
+			wantName:   "build",
+			wantDetail: "job",
+		},
+		{
+			name:       "Job group invoked in a workflow",
+			position:   protocol.Position{Line: 55, Character: 10},
+			wantName:   "release",
+			wantDetail: "job group",
+		},
+		{
+			name:       "Definition of a workflow",
+			position:   protocol.Position{Line: 52, Character: 3},
+			wantName:   "main",
+			wantDetail: "workflow",
+		},
+	}
+
+	cache := cacheWithFiles(t, callHierarchyFile)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := prepareCallHierarchyAt(t, cache, tt.position)
+			assert.Equal(t, tt.wantName, item.Name)
+			assert.Equal(t, tt.wantDetail, item.Detail)
+			if tt.selectionRange != (protocol.Range{}) {
+				assert.Equal(t, tt.selectionRange, item.SelectionRange)
+			}
+		})
+	}
+}
+
+func TestIncomingCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		position protocol.Position
+		want     map[string][]protocol.Range
+	}{
+		{
+			name:     "Command",
+			position: protocol.Position{Line: 10, Character: 3},
+			want: map[string][]protocol.Range{
+				"command install": {{Start: protocol.Position{Line: 15, Character: 8}, End: protocol.Position{Line: 15, Character: 13}}},
+				"job deploy":      {{Start: protocol.Position{Line: 44, Character: 8}, End: protocol.Position{Line: 44, Character: 13}}},
+			},
+		},
+		{
+			name:     "Through the steps given to a command and the pre-steps of a job",
+			position: protocol.Position{Line: 13, Character: 3},
+			want: map[string][]protocol.Range{
+				"job build":     {{Start: protocol.Position{Line: 38, Character: 14}, End: protocol.Position{Line: 38, Character: 21}}},
+				"workflow main": {{Start: protocol.Position{Line: 60, Character: 14}, End: protocol.Position{Line: 60, Character: 21}}},
+			},
+		},
+		{
+			name:     "Job invoked by a job group and a workflow",
+			position: protocol.Position{Line: 40, Character: 3},
+			want: map[string][]protocol.Range{
+				"job group release": {{Start: protocol.Position{Line: 49, Character: 8}, End: protocol.Position{Line: 49, Character: 14}}},
+				"workflow main":     {{Start: protocol.Position{Line: 58, Character: 8}, End: protocol.Position{Line: 58, Character: 14}}},
+			},
+		},
+		{
+			name:     "Job",
+			position: protocol.Position{Line: 27, Character: 3},
+			want: map[string][]protocol.Range{
+				"workflow main": {{Start: protocol.Position{Line: 54, Character: 8}, End: protocol.Position{Line: 54, Character: 13}}},
+			},
+		},
+	}
+
+	cache := cacheWithFiles(t, callHierarchyFile)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := prepareCallHierarchyAt(t, cache, tt.position)
+			calls, err := IncomingCalls(protocol.CallHierarchyIncomingCallsParams{Item: item}, cache, testHelpers.GetDefaultLsContext())
+			assert.Nil(t, err)
+
+			got := map[string][]protocol.Range{}
+			for _, call := range calls {
+				got[call.From.Detail+" "+call.From.Name] = call.FromRanges
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOutgoingCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		position protocol.Position
+		want     []string
+	}{
+		{
+			name:     "Through the steps given to a command and the default of a steps parameter",
+			position: protocol.Position{Line: 27, Character: 3},
+			want:     []string{"command with-cache", "command install", "orb command tools/lint"},
+		},
+		{
+			name:     "Command",
+			position: protocol.Position{Line: 13, Character: 3},
+			want:     []string{"command setup"},
+		},
+		{
+			name:     "Workflow",
+			position: protocol.Position{Line: 52, Character: 3},
+			want:     []string{"job build", "job group release", "job deploy", "command install"},
+		},
+		{
+			name:     "Job group",
+			position: protocol.Position{Line: 47, Character: 3},
+			want:     []string{"job deploy"},
+		},
+	}
+
+	cache := cacheWithFiles(t, callHierarchyFile)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := prepareCallHierarchyAt(t, cache, tt.position)
+			calls, err := OutgoingCalls(protocol.CallHierarchyOutgoingCallsParams{Item: item}, cache, testHelpers.GetDefaultLsContext())
+			assert.Nil(t, err)
+
+			got := []string{}
+			for _, call := range calls {
+				got = append(got, call.To.Detail+" "+call.To.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
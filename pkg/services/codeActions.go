@@ -0,0 +1,34 @@
+package languageservice
+
+import (
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services/refactor"
+	utils "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// RefactorCodeActions returns the refactorings available for the range of the
+// code action request, the quick fixes come with the diagnostics
+func RefactorCodeActions(params protocol.CodeActionParams, cache *utils.Cache, context *utils.LsContext) []protocol.CodeAction {
+	doc, err := yamlparser.ParseFromUriWithCache(params.TextDocument.URI, cache, context)
+	if err != nil {
+		return nil
+	}
+
+	res := []protocol.CodeAction{}
+	if refactor.IsKindRequested(params.Context.Only, protocol.RefactorExtract) {
+		res = append(res, refactor.ExtractCommand(&doc, params.Range)...)
+		res = append(res, refactor.ExtractExecutor(&doc, params.Range)...)
+	}
+
+	if refactor.IsKindRequested(params.Context.Only, protocol.RefactorInline) {
+		res = append(res, refactor.InlineExecutor(&doc, params.Range)...)
+		res = append(res, refactor.InlineCommand(&doc, params.Range)...)
+	}
+
+	if refactor.IsKindRequested(params.Context.Only, protocol.RefactorRewrite) {
+		res = append(res, refactor.ConvertToMatrix(&doc, params.Range)...)
+	}
+
+	return res
+}
@@ -1,6 +1,7 @@
 package languageservice
 
 import (
+	"context"
 	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services/complete"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
@@ -8,8 +9,14 @@ import (
 	"go.lsp.dev/protocol"
 )
 
-func Complete(params protocol.CompletionParams, cache *utils.Cache, context *utils.LsContext) (protocol.CompletionList, error) {
-	yamlDocument, err := yamlparser.ParseFromUriWithCache(params.TextDocument.URI, cache, context)
+func Complete(params protocol.CompletionParams, cache *utils.Cache, lsContext *utils.LsContext) (protocol.CompletionList, error) {
+	return CompleteWithContext(context.Background(), params, cache, lsContext)
+}
+
+// CompleteWithContext is like Complete but the requests made for the
+// completion are aborted once ctx is done
+func CompleteWithContext(ctx context.Context, params protocol.CompletionParams, cache *utils.Cache, context *utils.LsContext) (protocol.CompletionList, error) {
+	yamlDocument, err := yamlparser.ParseFromUriWithCacheWithContext(ctx, params.TextDocument.URI, cache, context)
 
 	if err != nil {
 		return protocol.CompletionList{}, err
@@ -28,6 +35,7 @@ func Complete(params protocol.CompletionParams, cache *utils.Cache, context *uti
 		Cache:   cache,
 		Items:   []protocol.CompletionItem{},
 		Context: context,
+		Ctx:     ctx,
 	}
 	completionHandler.GetCompletionItems()
 
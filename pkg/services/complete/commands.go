@@ -48,7 +48,7 @@ func findCommand(pos protocol.Position, doc yamlparser.YamlDocument) (ast.Comman
 
 func (ch *CompletionHandler) userDefinedCommands() {
 	for _, cmd := range ch.Doc.Commands {
-		ch.addCompletionItem(cmd.Name)
+		ch.addResolvableCompletionItem(cmd.Name, ch.resolveData(ResolveCommand, cmd.Name))
 	}
 }
 
@@ -60,9 +60,10 @@ func (ch *CompletionHandler) orbCommands(nodeToComplete *sitter.Node) []protocol
 				cmdName = fmt.Sprintf("%s/%s", orb.Name, cmdName)
 
 				if nodeToComplete == nil {
-					ch.addCompletionItem(cmdName)
+					ch.addResolvableCompletionItem(cmdName, ch.resolveData(ResolveCommand, cmdName))
 				} else {
 					ch.addReplaceTextCompletionItem(nodeToComplete, cmdName)
+					ch.Items[len(ch.Items)-1].Data = ch.resolveData(ResolveCommand, cmdName)
 				}
 			}
 		}
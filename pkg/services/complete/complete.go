@@ -1,6 +1,7 @@
 package complete
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
@@ -20,6 +21,17 @@ type CompletionHandler struct {
 	Items   []protocol.CompletionItem
 	Cache   *utils.Cache
 	Context *utils.LsContext
+
+	// The context of the request, the requests made for the completion are
+	// aborted once it is done
+	Ctx context.Context
+}
+
+func (ch *CompletionHandler) getCtx() context.Context {
+	if ch.Ctx == nil {
+		return context.Background()
+	}
+	return ch.Ctx
 }
 
 func (ch *CompletionHandler) GetCompletionItems() {
@@ -38,6 +50,11 @@ func (ch *CompletionHandler) GetCompletionItems() {
 		ch.DocTag = doc.Tag
 		ch.DocDiff = doc.Diff
 
+		ch.completeOrbAuthoring()
+		if len(ch.Items) > 0 {
+			break
+		}
+
 		if ch.Doc.IsYamlAliasPosition(ch.Params.Position) {
 			ch.completeAnchors()
 		} else if utils.PosInRange(ch.Doc.WorkflowRange, ch.Params.Position) {
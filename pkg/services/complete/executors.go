@@ -78,7 +78,7 @@ func (ch *CompletionHandler) completeDockerExecutor(executor ast.DockerExecutor)
 
 			if theImg.Tag == "" && completionString[len(completionString)-1:] != ":" {
 				// Search for repositories
-				results := dockerhub.Search(completionString)
+				results := dockerhub.Search(ch.getCtx(), completionString)
 				i := 0
 
 				for i < 5 && results.HasNext() {
@@ -95,7 +95,7 @@ func (ch *CompletionHandler) completeDockerExecutor(executor ast.DockerExecutor)
 				}
 			} else {
 				// Search for tags instead
-				results, err := dockerhub.SearchTags(img.Image.Namespace, img.Image.Name, theImg.Tag)
+				results, err := dockerhub.SearchTags(ch.getCtx(), img.Image.Namespace, img.Image.Name, theImg.Tag)
 				if err != nil {
 					return
 				}
@@ -140,7 +140,7 @@ func (ch *CompletionHandler) completeMachineExecutor(executor ast.MachineExecuto
 
 	if utils.PosInRange(executor.ImageRange, ch.Params.Position) {
 		for _, img := range images {
-			ch.addCompletionItem(img)
+			ch.addResolvableCompletionItem(img, ch.resolveData(ResolveMachineImage, img))
 		}
 		return
 	}
@@ -151,7 +151,7 @@ func (ch *CompletionHandler) completeMachineExecutor(executor ast.MachineExecuto
 
 		if utils.PosInRange(extendedRange, ch.Params.Position) {
 			for _, img := range images {
-				ch.addCompletionItem(img)
+				ch.addResolvableCompletionItem(img, ch.resolveData(ResolveMachineImage, img))
 			}
 
 			return
@@ -240,6 +240,14 @@ func (ch *CompletionHandler) addDockerImageCompletion(node *sitter.Node, namespa
 		},
 
 		Command: command,
+
+		Data: ResolveData{
+			Kind:      ResolveDockerImage,
+			URI:       ch.Doc.URI,
+			Namespace: namespace,
+			Image:     name,
+			Tag:       tag,
+		},
 	})
 }
 
@@ -47,6 +47,6 @@ func (ch *CompletionHandler) addJobsAndOrbsCompletion() {
 
 func (ch *CompletionHandler) addJobsCompletion() {
 	for _, job := range ch.Doc.Jobs {
-		ch.addCompletionItem(job.Name)
+		ch.addResolvableCompletionItem(job.Name, ch.resolveData(ResolveJob, job.Name))
 	}
 }
@@ -48,7 +48,7 @@ func (ch *CompletionHandler) orbsJobs() {
 		if orbInfo != nil {
 			for jobName := range orbInfo.Jobs {
 				jobName = fmt.Sprintf("%s/%s", orb.Name, jobName)
-				ch.addCompletionItem(jobName)
+				ch.addResolvableCompletionItem(jobName, ch.resolveData(ResolveJob, jobName))
 			}
 		}
 	}
@@ -56,19 +56,20 @@ func (ch *CompletionHandler) orbsJobs() {
 
 func (ch *CompletionHandler) userDefinedJobs() {
 	for _, job := range ch.Doc.Jobs {
-		ch.addCompletionItem(job.Name)
+		ch.addResolvableCompletionItem(job.Name, ch.resolveData(ResolveJob, job.Name))
 	}
 }
 
 func (ch *CompletionHandler) addExecutorsCompletion() {
 	for _, executor := range ch.Doc.Executors {
-		ch.addCompletionItem(executor.GetName())
+		ch.addResolvableCompletionItem(executor.GetName(), ch.resolveData(ResolveExecutor, executor.GetName()))
 	}
 
 	for _, orb := range ch.Doc.Orbs {
 		executor := ch.getOrbExecutors(orb)
 		for _, executor := range executor {
-			ch.addCompletionItem(fmt.Sprintf("%s/%s", orb.Name, executor.GetName()))
+			name := fmt.Sprintf("%s/%s", orb.Name, executor.GetName())
+			ch.addResolvableCompletionItem(name, ch.resolveData(ResolveExecutor, name))
 		}
 	}
 }
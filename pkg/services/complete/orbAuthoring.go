@@ -0,0 +1,80 @@
+package complete
+
+import (
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// Keys that only exist at the root of an orb
+var orbRootKeys = []string{"description", "display", "examples"}
+
+// completeOrbAuthoring completes the keys that only exist in orbs
+func (ch *CompletionHandler) completeOrbAuthoring() {
+	if !ch.Doc.IsOrb {
+		return
+	}
+
+	if utils.PosInRange(ch.Doc.Display.Range, ch.Params.Position) {
+		if ch.Doc.Display.HomeUrl == "" {
+			ch.addCompletionItemField("home_url")
+		}
+		if ch.Doc.Display.SourceUrl == "" {
+			ch.addCompletionItemField("source_url")
+		}
+		return
+	}
+
+	if utils.PosInRange(ch.Doc.ExamplesRange, ch.Params.Position) {
+		for _, example := range ch.Doc.Examples {
+			if !utils.PosInRange(example.Range, ch.Params.Position) ||
+				ch.Params.Position.Line == example.NameRange.Start.Line {
+				continue
+			}
+
+			if example.Description == "" {
+				ch.addCompletionItemField("description")
+			}
+			if example.Usage == nil {
+				ch.addCompletionItemFieldWithNewLine("usage")
+			}
+			ch.addCompletionItemFieldWithNewLine("result")
+		}
+		return
+	}
+
+	if ch.isAtRootKey() {
+		for _, key := range orbRootKeys {
+			if !ch.hasRootKey(key) {
+				ch.addCompletionItemFieldWithNewLine(key)
+			}
+		}
+	}
+}
+
+// Returns true when the line being written is a key of the root mapping
+func (ch *CompletionHandler) isAtRootKey() bool {
+	lines := strings.Split(string(ch.Doc.Content), "\n")
+	if int(ch.Params.Position.Line) >= len(lines) {
+		return false
+	}
+
+	line := lines[ch.Params.Position.Line]
+	prefix := line[:min(int(ch.Params.Position.Character), len(line))]
+
+	return !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") &&
+		!strings.ContainsAny(prefix, ":#-")
+}
+
+func (ch *CompletionHandler) hasRootKey(key string) bool {
+	switch key {
+	case "description":
+		return ch.Doc.DescriptionRange != protocol.Range{}
+	case "display":
+		return ch.Doc.Display.Range != protocol.Range{}
+	case "examples":
+		return ch.Doc.ExamplesRange != protocol.Range{}
+	}
+	return false
+}
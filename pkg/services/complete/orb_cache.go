@@ -1,6 +1,7 @@
 package complete
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -51,7 +52,7 @@ type RequestConfig struct {
 	Response interface{}
 }
 
-func (cache *OrbCache) request(config RequestConfig) (err error) {
+func (cache *OrbCache) request(ctx context.Context, config RequestConfig) (err error) {
 	client := utils.NewClient(
 		config.HostUrl,
 		"graphql-unstable",
@@ -65,12 +66,12 @@ func (cache *OrbCache) request(config RequestConfig) (err error) {
 		request.Var(key, value)
 	}
 
-	err = client.Run(request, config.Response)
+	err = client.RunWithContext(ctx, request, config.Response)
 
 	return
 }
 
-func (cache *OrbCache) GetOrbsOfRegistry(registry, hostUrl, token, userId string) (*NamespaceOrbResponse, error) {
+func (cache *OrbCache) GetOrbsOfRegistry(ctx context.Context, registry, hostUrl, token, userId string) (*NamespaceOrbResponse, error) {
 	cache.mutex.Lock()
 	defer cache.mutex.Unlock()
 
@@ -100,7 +101,7 @@ func (cache *OrbCache) GetOrbsOfRegistry(registry, hostUrl, token, userId string
 		}
 	`
 	var response NamespaceOrbResponse
-	err := cache.request(RequestConfig{
+	err := cache.request(ctx, RequestConfig{
 		HostUrl: hostUrl,
 		Token:   token,
 		UserId:  userId,
@@ -127,7 +128,7 @@ func (cache *OrbCache) GetOrbsOfRegistry(registry, hostUrl, token, userId string
 	return &response, nil
 }
 
-func (cache *OrbCache) GetVersionsOfOrb(orbName, hostUrl, token, userId string) (*OrbGQLData, error) {
+func (cache *OrbCache) GetVersionsOfOrb(ctx context.Context, orbName, hostUrl, token, userId string) (*OrbGQLData, error) {
 	cache.mutex.Lock()
 	defer cache.mutex.Unlock()
 
@@ -149,7 +150,7 @@ func (cache *OrbCache) GetVersionsOfOrb(orbName, hostUrl, token, userId string)
 		}
 	`
 	response := map[string]OrbGQLData{}
-	err := cache.request(RequestConfig{
+	err := cache.request(ctx, RequestConfig{
 		HostUrl: hostUrl,
 		Token:   token,
 		UserId:  userId,
@@ -1,6 +1,7 @@
 package complete
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -89,7 +90,7 @@ func (ch *CompletionHandler) completeOrbVersion(node *sitter.Node) {
 func (ch *CompletionHandler) getOrbVersionCompletions(name, hostUrl, token, userId string) ([]string, error) {
 	orbName := strings.TrimSuffix(name, "@")
 
-	orbData, err := orbCache.GetVersionsOfOrb(orbName, hostUrl, token, userId)
+	orbData, err := orbCache.GetVersionsOfOrb(ch.getCtx(), orbName, hostUrl, token, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +104,7 @@ func (ch *CompletionHandler) getOrbVersionCompletions(name, hostUrl, token, user
 
 func (ch *CompletionHandler) completeOrbName(node *sitter.Node) {
 	completions, err := getOrbNameCompletions(
+		ch.getCtx(),
 		ch.Doc.GetNodeText(node),
 		ch.Doc.Context.Api.HostUrl,
 		ch.Doc.Context.Api.Token,
@@ -117,11 +119,11 @@ func (ch *CompletionHandler) completeOrbName(node *sitter.Node) {
 	}
 }
 
-func getOrbNameCompletions(name, hostUrl, token, userId string) ([]string, error) {
+func getOrbNameCompletions(ctx context.Context, name, hostUrl, token, userId string) ([]string, error) {
 	parts := strings.Split(name, "/")
 	registry := parts[0]
 
-	response, err := orbCache.GetOrbsOfRegistry(registry, hostUrl, token, userId)
+	response, err := orbCache.GetOrbsOfRegistry(ctx, registry, hostUrl, token, userId)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,60 @@
+package complete
+
+import (
+	"github.com/segmentio/encoding/json"
+	"go.lsp.dev/protocol"
+)
+
+// The kinds of the completion items documented by completionItem/resolve
+const (
+	ResolveCommand      = "command"
+	ResolveJob          = "job"
+	ResolveExecutor     = "executor"
+	ResolveDockerImage  = "dockerImage"
+	ResolveMachineImage = "machineImage"
+)
+
+// ResolveData is kept in the Data of the completion items whose documentation
+// is costly to build, so that it is only built for the item highlighted by the
+// user, through completionItem/resolve
+type ResolveData struct {
+	Kind string               `json:"kind"`
+	URI  protocol.DocumentURI `json:"uri"`
+	// Name of the command, job, executor or machine image
+	Name string `json:"name,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+	Image     string `json:"image,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+}
+
+// GetResolveData returns the data of an item sent back by the client, false
+// when the item has nothing to resolve
+func GetResolveData(item protocol.CompletionItem) (ResolveData, bool) {
+	if item.Data == nil {
+		return ResolveData{}, false
+	}
+
+	// The data has been decoded as a map along with the rest of the item
+	content, err := json.Marshal(item.Data)
+	if err != nil {
+		return ResolveData{}, false
+	}
+
+	data := ResolveData{}
+	if err := json.Unmarshal(content, &data); err != nil || data.Kind == "" {
+		return ResolveData{}, false
+	}
+	return data, true
+}
+
+func (ch *CompletionHandler) resolveData(kind string, name string) ResolveData {
+	return ResolveData{Kind: kind, URI: ch.Doc.URI, Name: name}
+}
+
+func (ch *CompletionHandler) addResolvableCompletionItem(label string, data ResolveData) {
+	ch.Items = append(ch.Items, protocol.CompletionItem{
+		Label: label,
+		Data:  data,
+	})
+}
@@ -130,35 +130,7 @@ func (ch *CompletionHandler) addCompleteEnvVariables(contexts []string, paramete
 		}
 	}
 
-	for _, env := range BUILT_IN_ENV {
-		ch.addCompletionItemWithDetail(env, "Built-in environment variable", "C")
+	for _, env := range utils.BuiltInEnvVariables {
+		ch.addCompletionItemWithDetail(env.Name, "Built-in environment variable", "C")
 	}
 }
-
-var BUILT_IN_ENV = []string{
-	"CI",
-	"CIRCLECI",
-	"CIRCLE_BRANCH",
-	"CIRCLE_BUILD_NUM",
-	"CIRCLE_BUILD_URL",
-	"CIRCLE_JOB",
-	"CIRCLE_NODE_INDEX",
-	"CIRCLE_NODE_TOTAL",
-	"CIRCLE_OIDC_TOKEN",
-	"CIRCLE_PR_NUMBER",
-	"CIRCLE_PR_REPONAME",
-	"CIRCLE_PR_USERNAME",
-	"CIRCLE_PREVIOUS_BUILD_NUM",
-	"CIRCLE_PROJECT_REPONAME",
-	"CIRCLE_PROJECT_USERNAME",
-	"CIRCLE_PULL_REQUEST",
-	"CIRCLE_PULL_REQUESTS",
-	"CIRCLE_REPOSITORY_URL",
-	"CIRCLE_SHA1",
-	"CIRCLE_TAG",
-	"CIRCLE_USERNAME",
-	"CIRCLE_WORKFLOW_ID",
-	"CIRCLE_WORKFLOW_JOB_ID",
-	"CIRCLE_WORKFLOW_WORKSPACE_ID",
-	"CIRCLE_WORKING_DIRECTORY",
-}
@@ -42,9 +42,9 @@ func TestComplete(t *testing.T) {
 	}
 
 	builtInEnvsComplete := []protocol.CompletionItem{}
-	for _, env := range complete.BUILT_IN_ENV {
+	for _, env := range utils.BuiltInEnvVariables {
 		builtInEnvsComplete = append(builtInEnvsComplete, protocol.CompletionItem{
-			Label:    env,
+			Label:    env.Name,
 			Detail:   "Built-in environment variable",
 			SortText: "C",
 		})
@@ -158,17 +158,11 @@ func TestComplete(t *testing.T) {
 			},
 			want: []protocol.CompletionItem{
 				// User defined commands
-				{
-					Label: "dummyCommand",
-				},
+				resolvableCompletionItem(complete.ResolveCommand, "dummyCommand"),
 				// Itself (it can be called from itself)
-				{
-					Label: "terraform-init-plan",
-				},
+				resolvableCompletionItem(complete.ResolveJob, "terraform-init-plan"),
 				// User defined job
-				{
-					Label: "dummyJob",
-				},
+				resolvableCompletionItem(complete.ResolveJob, "dummyJob"),
 				// Built-in steps
 				{
 					Label: "run",
@@ -206,9 +200,7 @@ func TestComplete(t *testing.T) {
 				{
 					Label: "when",
 				},
-				{
-					Label: "superOrb/supermethod",
-				},
+				resolvableCompletionItem(complete.ResolveJob, "superOrb/supermethod"),
 			},
 		},
 		{
@@ -244,7 +236,7 @@ func TestComplete(t *testing.T) {
 					Character: 19,
 				},
 			},
-			want: createCompletionItemForLabels(utils.MachineImages(context, cache)),
+			want: resolvableCompletionItems(complete.ResolveMachineImage, utils.MachineImages(context, cache)),
 		},
 		{
 			name: "Completion for resource class",
@@ -267,15 +259,9 @@ func TestComplete(t *testing.T) {
 				},
 			},
 			want: []protocol.CompletionItem{
-				{
-					Label: "machineExec",
-				},
-				{
-					Label: "resourceClass",
-				},
-				{
-					Label: "superOrb/default",
-				},
+				resolvableCompletionItem(complete.ResolveExecutor, "machineExec"),
+				resolvableCompletionItem(complete.ResolveExecutor, "resourceClass"),
+				resolvableCompletionItem(complete.ResolveExecutor, "superOrb/default"),
 			},
 		},
 		{
@@ -364,3 +350,23 @@ func createCompletionItemForLabels(labels []string) []protocol.CompletionItem {
 	}
 	return completeItems
 }
+
+// The items of the autocomplete1.yml test file documented on resolve
+func resolvableCompletionItem(kind string, label string) protocol.CompletionItem {
+	return protocol.CompletionItem{
+		Label: label,
+		Data: complete.ResolveData{
+			Kind: kind,
+			URI:  uri.File("./testdata/autocomplete1.yml"),
+			Name: label,
+		},
+	}
+}
+
+func resolvableCompletionItems(kind string, labels []string) []protocol.CompletionItem {
+	items := make([]protocol.CompletionItem, len(labels))
+	for i, label := range labels {
+		items[i] = resolvableCompletionItem(kind, label)
+	}
+	return items
+}
@@ -0,0 +1,233 @@
+package languageservice
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services/complete"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+const machineImagesURL = "https://circleci.com/developer/machine/image/"
+
+// ResolveCompletionItem fills in the documentation of the item highlighted by
+// the user: the parameters of the commands, jobs and executors, the metadata
+// of the Docker tags and the resource classes and release notes of the
+// machine images. The items without resolve data are returned as is
+func ResolveCompletionItem(item protocol.CompletionItem, cache *utils.Cache, context *utils.LsContext, api dockerhub.DockerHubAPI) protocol.CompletionItem {
+	data, ok := complete.GetResolveData(item)
+	if !ok || item.Documentation != nil {
+		return item
+	}
+
+	documentation := ""
+	switch data.Kind {
+	case complete.ResolveCommand, complete.ResolveJob, complete.ResolveExecutor:
+		doc, err := yamlparser.ParseFromUriWithCache(data.URI, cache, context)
+		if err != nil {
+			return item
+		}
+		documentation = definitionDocumentation(doc, data.Kind, data.Name, cache)
+	case complete.ResolveDockerImage:
+		documentation = dockerTagDocumentation(data, api)
+	case complete.ResolveMachineImage:
+		documentation = machineImageDocumentation(data.Name, context, cache)
+	}
+
+	if documentation != "" {
+		item.Documentation = protocol.MarkupContent{Kind: protocol.Markdown, Value: documentation}
+	}
+	return item
+}
+
+// Returns the description of the command, job or executor of the document or
+// of an orb, followed by the table of its parameters
+func definitionDocumentation(doc yamlparser.YamlDocument, kind string, name string, cache *utils.Cache) string {
+	description, parameters, ok := definitionOf(doc, kind, name, cache)
+	if !ok {
+		return ""
+	}
+
+	sections := []string{}
+	if description != "" {
+		sections = append(sections, description)
+	}
+	if len(parameters) > 0 {
+		sections = append(sections, parametersTable(parameters))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func definitionOf(doc yamlparser.YamlDocument, kind string, name string, cache *utils.Cache) (string, map[string]ast.Parameter, bool) {
+	switch kind {
+	case complete.ResolveCommand:
+		if command, ok := doc.GetCommand(name); ok {
+			return command.Description, command.Parameters, true
+		}
+		if orbInfo, entityName, ok := orbInfoOf(doc, name, cache); ok {
+			if command, ok := orbInfo.Commands[entityName]; ok {
+				return command.Description, command.Parameters, true
+			}
+		}
+	case complete.ResolveJob:
+		if job, ok := doc.GetJob(name); ok {
+			return job.Description, job.Parameters, true
+		}
+		if orbInfo, entityName, ok := orbInfoOf(doc, name, cache); ok {
+			if job, ok := orbInfo.Jobs[entityName]; ok {
+				return job.Description, job.Parameters, true
+			}
+		}
+	case complete.ResolveExecutor:
+		if executor, ok := doc.GetExecutor(name); ok {
+			return executorDescription(executor), executor.GetParameters(), true
+		}
+		if orbInfo, entityName, ok := orbInfoOf(doc, name, cache); ok {
+			if executor, ok := orbInfo.Executors[entityName]; ok {
+				return executorDescription(executor), executor.GetParameters(), true
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+func parametersTable(parametersByName map[string]ast.Parameter) string {
+	lines := []string{
+		"| Parameter | Type | Default | Description |",
+		"| --- | --- | --- | --- |",
+	}
+
+	for _, parameter := range sortedParameters(parametersByName) {
+		defaultCell := "**Required**"
+		if parameter.IsOptional() {
+			defaultCell = ""
+			if value, ok := defaultValue(parameter); ok {
+				defaultCell = "`" + value + "`"
+			}
+		}
+
+		parameterType := parameter.GetType()
+		if enum, ok := parameter.(ast.EnumParameter); ok && len(enum.Enum) > 0 {
+			parameterType += ": " + strings.Join(enum.Enum, ", ")
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"| `%s` | %s | %s | %s |",
+			parameter.GetName(),
+			tableCell(parameterType),
+			tableCell(defaultCell),
+			tableCell(parameter.GetDescription()),
+		))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// The cells of the Markdown tables hold a single line without pipes
+func tableCell(content string) string {
+	content = strings.ReplaceAll(strings.TrimSpace(content), "|", "\\|")
+	return strings.Join(strings.Fields(content), " ")
+}
+
+func dockerTagDocumentation(data complete.ResolveData, api dockerhub.DockerHubAPI) string {
+	if data.Image == "" || data.Tag == "" {
+		return ""
+	}
+
+	details, err := api.GetTagDetails(data.Namespace, data.Image, data.Tag)
+	if err != nil {
+		utils.Logger.Debug("unable to fetch the docker tag", "namespace", data.Namespace, "image", data.Image, "tag", data.Tag, "error", err)
+		return ""
+	}
+
+	lines := []string{}
+	if details.Digest != "" {
+		lines = append(lines, "- Digest: `"+details.Digest+"`")
+	}
+	if pushed, err := time.Parse(time.RFC3339, details.TagLastPushed); err == nil {
+		lines = append(lines, "- Pushed: "+pushed.UTC().Format("2006-01-02"))
+	}
+	if details.FullSize > 0 {
+		lines = append(lines, "- Compressed size: "+humanSize(details.FullSize))
+	}
+
+	platforms := []string{}
+	for _, image := range details.Images {
+		platform := image.OS + "/" + image.Architecture
+		if image.Variant != "" {
+			platform += "/" + image.Variant
+		}
+		if image.Architecture != "" && !slices.Contains(platforms, platform) {
+			platforms = append(platforms, platform)
+		}
+	}
+	if len(platforms) > 0 {
+		lines = append(lines, "- Platforms: `"+strings.Join(platforms, "`, `")+"`")
+	}
+
+	repository := "r/" + data.Namespace + "/" + data.Image
+	if data.Namespace == "library" {
+		repository = "_/" + data.Image
+	}
+	link := fmt.Sprintf("[Docker Hub](https://hub.docker.com/%s/tags?name=%s)", repository, data.Tag)
+	if len(lines) == 0 {
+		return link
+	}
+	return strings.Join(lines, "\n") + "\n\n" + link
+}
+
+func humanSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// Returns the resource classes offering the machine image, whether it is
+// deprecated and the link to its release notes
+func machineImageDocumentation(image string, context *utils.LsContext, cache *utils.Cache) string {
+	name, tag, _ := strings.Cut(image, ":")
+	if name == "" {
+		return ""
+	}
+
+	sections := []string{}
+	switch tag {
+	case "current":
+		sections = append(sections, "`current` is the latest stable release of the image.")
+	case "edge":
+		sections = append(sections, "`edge` is the release of the image being tested before becoming `current`.")
+	}
+
+	if slices.Contains(utils.DeprecatedMachineImages(context, cache), image) {
+		sections = append(sections, "**Deprecated**, this image will be removed.")
+	}
+
+	resourceClasses := []string{}
+	for _, pair := range utils.MachinePairs(context, cache) {
+		if slices.Contains(pair.Images, image) {
+			resourceClasses = append(resourceClasses, pair.ResourceClass)
+		}
+	}
+	if len(resourceClasses) > 0 {
+		slices.Sort(resourceClasses)
+		sections = append(sections, "Resource classes: `"+strings.Join(resourceClasses, "`, `")+"`")
+	}
+
+	sections = append(sections, fmt.Sprintf("[Release notes](%s%s)", machineImagesURL, name))
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,137 @@
+package languageservice
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services/complete"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+type tagDetailsMock map[string]dockerhub.TagDetails
+
+func (m tagDetailsMock) DoesImageExist(namespace, image string) bool { return true }
+
+func (m tagDetailsMock) GetImageTags(namespace, image string) ([]string, error) {
+	return nil, nil
+}
+
+func (m tagDetailsMock) ImageHasTag(namespace, image, tag string) bool {
+	_, ok := m[namespace+"/"+image+":"+tag]
+	return ok
+}
+
+func (m tagDetailsMock) GetTagDetails(namespace, image, tag string) (*dockerhub.TagDetails, error) {
+	details, ok := m[namespace+"/"+image+":"+tag]
+	if !ok {
+		return nil, fmt.Errorf("no tag %s", tag)
+	}
+	return &details, nil
+}
+
+func resolveItem(t *testing.T, cache *utils.Cache, api dockerhub.DockerHubAPI, data complete.ResolveData) string {
+	item := ResolveCompletionItem(
+		protocol.CompletionItem{Label: data.Name, Data: data},
+		cache,
+		testHelpers.GetDefaultLsContext(),
+		api,
+	)
+	if item.Documentation == nil {
+		return ""
+	}
+	return item.Documentation.(protocol.MarkupContent).Value
+}
+
+func TestResolveDefinitions(t *testing.T) {
+	cache := utils.CreateCache()
+	configURI := uri.File("config.yml")
+	cache.FileCache.SetFile(utils.CachedFile{
+		TextDocument: protocol.TextDocumentItem{URI: configURI, Text: signatureHelpConfig},
+	})
+
+	assert.Equal(t,
+		"Installs the dependencies\n\n"+
+			"| Parameter | Type | Default | Description |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| `node-version` | string | **Required** |  |\n"+
+			"| `version` | enum: v1, v2 | `v1` | Version of the lock file |",
+		resolveItem(t, cache, nil, complete.ResolveData{Kind: complete.ResolveCommand, URI: configURI, Name: "install"}))
+
+	assert.Equal(t,
+		"| Parameter | Type | Default | Description |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| `strict` | boolean | `false` |  |",
+		resolveItem(t, cache, nil, complete.ResolveData{Kind: complete.ResolveCommand, URI: configURI, Name: "tools/lint"}))
+
+	assert.Equal(t,
+		"Node.js image\n\n"+
+			"| Parameter | Type | Default | Description |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| `tag` | string | `\"lts\"` |  |",
+		resolveItem(t, cache, nil, complete.ResolveData{Kind: complete.ResolveExecutor, URI: configURI, Name: "node"}))
+
+	assert.Equal(t, "", resolveItem(t, cache, nil, complete.ResolveData{Kind: complete.ResolveJob, URI: configURI, Name: "build"}))
+	assert.Equal(t, "", resolveItem(t, cache, nil, complete.ResolveData{Kind: complete.ResolveJob, URI: configURI, Name: "unknown"}))
+}
+
+func TestResolveDockerTag(t *testing.T) {
+	api := tagDetailsMock{
+		"cimg/node:20.1": {
+			Name:          "20.1",
+			Digest:        "sha256:abc",
+			TagLastPushed: "2024-03-05T10:20:30.123456Z",
+			FullSize:      412_345_678,
+			Images: []dockerhub.TagImage{
+				{OS: "linux", Architecture: "amd64"},
+				{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			},
+		},
+		"library/node:latest": {Name: "latest"},
+	}
+
+	assert.Equal(t,
+		"- Digest: `sha256:abc`\n"+
+			"- Pushed: 2024-03-05\n"+
+			"- Compressed size: 412.3 MB\n"+
+			"- Platforms: `linux/amd64`, `linux/arm64/v8`\n\n"+
+			"[Docker Hub](https://hub.docker.com/r/cimg/node/tags?name=20.1)",
+		resolveItem(t, utils.CreateCache(), api, complete.ResolveData{Kind: complete.ResolveDockerImage, Namespace: "cimg", Image: "node", Tag: "20.1"}))
+
+	assert.Equal(t,
+		"[Docker Hub](https://hub.docker.com/_/node/tags?name=latest)",
+		resolveItem(t, utils.CreateCache(), api, complete.ResolveData{Kind: complete.ResolveDockerImage, Namespace: "library", Image: "node", Tag: "latest"}))
+
+	assert.Equal(t, "", resolveItem(t, utils.CreateCache(), api, complete.ResolveData{Kind: complete.ResolveDockerImage, Namespace: "cimg", Image: "node", Tag: "18.0"}))
+}
+
+func TestResolveMachineImage(t *testing.T) {
+	cache := utils.CreateCache()
+	cache.MachineOfferingsCache.Set(&utils.Offerings{
+		Linux: map[string][]string{
+			"medium": {"ubuntu-2404:current", "ubuntu-2204:2023.07.1"},
+			"large":  {"ubuntu-2404:current"},
+		},
+		Deprecated: map[string][]string{"linux": {"ubuntu-2004:2023.07.1"}},
+	})
+
+	assert.Equal(t,
+		"`current` is the latest stable release of the image.\n\n"+
+			"Resource classes: `large`, `medium`\n\n"+
+			"[Release notes](https://circleci.com/developer/machine/image/ubuntu-2404)",
+		resolveItem(t, cache, nil, complete.ResolveData{Kind: complete.ResolveMachineImage, Name: "ubuntu-2404:current"}))
+
+	assert.Equal(t,
+		"**Deprecated**, this image will be removed.\n\n"+
+			"[Release notes](https://circleci.com/developer/machine/image/ubuntu-2004)",
+		resolveItem(t, cache, nil, complete.ResolveData{Kind: complete.ResolveMachineImage, Name: "ubuntu-2004:2023.07.1"}))
+}
+
+func TestResolveWithoutData(t *testing.T) {
+	item := protocol.CompletionItem{Label: "checkout"}
+	assert.Equal(t, item, ResolveCompletionItem(item, utils.CreateCache(), testHelpers.GetDefaultLsContext(), nil))
+}
@@ -1,9 +1,6 @@
 package definition
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
 	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
@@ -26,6 +23,10 @@ func (def DefinitionStruct) Definition() ([]protocol.Location, error) {
 		return definition, nil
 	}
 
+	if definition := def.searchForOrbIncludeDefinition(); len(definition) > 0 {
+		return definition, nil
+	}
+
 	var res []protocol.Location
 	var err error = nil
 
@@ -59,7 +60,7 @@ func (def DefinitionStruct) Definition() ([]protocol.Location, error) {
 	}
 
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "error occurred during definition:", err)
+		utils.Logger.Warn("error occurred during definition", "error", err)
 	}
 	return res, nil
 }
@@ -2,8 +2,10 @@ package definition
 
 import (
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
 )
 
 func (def DefinitionStruct) searchForJobs() []protocol.Location {
@@ -22,6 +24,12 @@ func (def DefinitionStruct) searchForJobs() []protocol.Location {
 		}
 
 		if utils.PosInRange(job.ExecutorRange, def.Params.Position) {
+			if _, ok := def.Doc.Executors[job.Executor]; !ok {
+				if loc, ok := def.getOrbSourceLocation(parser.OrbSourceExecutors, job.Executor); ok {
+					return loc
+				}
+			}
+
 			return []protocol.Location{
 				{
 					URI:   def.Params.TextDocument.URI,
@@ -40,6 +48,10 @@ func (def DefinitionStruct) searchForJobs() []protocol.Location {
 
 func (def DefinitionStruct) getStepDefinition(steps []ast.Step) []protocol.Location {
 	for _, commandStep := range steps {
+		if res := def.getFileReferenceDefinition(commandStep); len(res) > 0 {
+			return res
+		}
+
 		switch step := commandStep.(type) {
 		case ast.NamedStep:
 			if utils.PosInRange(step.Range, def.Params.Position) {
@@ -57,3 +69,22 @@ func (def DefinitionStruct) getStepDefinition(steps []ast.Step) []protocol.Locat
 	}
 	return []protocol.Location{}
 }
+
+func (def DefinitionStruct) getFileReferenceDefinition(step ast.Step) []protocol.Location {
+	for _, ref := range parser.GetStepFileReferences(step) {
+		if !utils.PosInRange(ref.Range, def.Params.Position) {
+			continue
+		}
+
+		if path, ok := def.Doc.ResolveFileReference(ref); ok {
+			return []protocol.Location{
+				{
+					URI:   uri.File(path),
+					Range: protocol.Range{},
+				},
+			}
+		}
+	}
+
+	return []protocol.Location{}
+}
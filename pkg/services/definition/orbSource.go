@@ -0,0 +1,90 @@
+package definition
+
+import (
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func (def DefinitionStruct) searchForOrbIncludeDefinition() []protocol.Location {
+	include, ok := def.Doc.GetOrbIncludeAt(def.Params.Position)
+	if !ok {
+		return []protocol.Location{}
+	}
+
+	filename, ok := def.Doc.ResolveOrbInclude(include)
+	if !ok {
+		return []protocol.Location{}
+	}
+
+	return []protocol.Location{
+		{
+			URI:   uri.File(filename),
+			Range: protocol.Range{},
+		},
+	}
+}
+
+// Returns the location of an entity defined in another file of the source
+// directory of the orb
+func (def DefinitionStruct) getOrbSourceLocation(kind string, name string) ([]protocol.Location, bool) {
+	doc, ok := def.Doc.OrbSource.GetDefinitionDocument(kind, name)
+	if !ok || doc.URI == def.Doc.URI {
+		return []protocol.Location{}, false
+	}
+
+	var rng protocol.Range
+	switch kind {
+	case parser.OrbSourceCommands:
+		rng = doc.Commands[name].Range
+	case parser.OrbSourceJobs:
+		rng = doc.Jobs[name].Range
+	case parser.OrbSourceExecutors:
+		rng = getExecutorRange(doc.Executors[name])
+	}
+
+	return []protocol.Location{
+		{
+			URI:   doc.URI,
+			Range: rng,
+		},
+	}, true
+}
+
+func (def DefinitionStruct) getOrbSourceParamLocation(name string, paramName string, includeCommands bool) ([]protocol.Location, bool) {
+	kinds := []string{parser.OrbSourceJobs}
+	if includeCommands {
+		kinds = append(kinds, parser.OrbSourceCommands)
+	}
+
+	for _, kind := range kinds {
+		doc, ok := def.Doc.OrbSource.GetDefinitionDocument(kind, name)
+		if !ok || doc.URI == def.Doc.URI {
+			continue
+		}
+
+		params := doc.Jobs[name].Parameters
+		if kind == parser.OrbSourceCommands {
+			params = doc.Commands[name].Parameters
+		}
+
+		if param, ok := params[paramName]; ok {
+			return []protocol.Location{
+				{
+					URI:   doc.URI,
+					Range: param.GetRange(),
+				},
+			}, true
+		}
+	}
+
+	return []protocol.Location{}, false
+}
+
+func getExecutorRange(executor ast.Executor) protocol.Range {
+	if executor == nil {
+		return protocol.Range{}
+	}
+	return executor.GetRange()
+}
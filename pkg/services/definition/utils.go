@@ -40,6 +40,14 @@ func (def DefinitionStruct) getCommandOrJobLocation(name string, includeCommands
 		}, nil
 	}
 
+	if loc, ok := def.getOrbSourceLocation(yamlparser.OrbSourceJobs, name); ok {
+		return loc, nil
+	}
+
+	if loc, ok := def.getOrbSourceLocation(yamlparser.OrbSourceCommands, name); ok && includeCommands {
+		return loc, nil
+	}
+
 	if orb, err := def.getOrbLocation(name, true); err == nil {
 		return orb, nil
 	}
@@ -70,6 +78,10 @@ func (def DefinitionStruct) getCommandOrJobParamLocation(name string, paramName
 		}
 	}
 
+	if loc, ok := def.getOrbSourceParamLocation(name, paramName, includeCommands); ok {
+		return loc, nil
+	}
+
 	if orb, err := def.getOrbParamLocation(name, paramName); err == nil {
 		return orb, nil
 	}
@@ -1,11 +1,14 @@
 package languageservice
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
 	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser/validate"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services/refactor"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/shellcheck"
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 
 	schema "github.com/CircleCI-Public/circleci-yaml-language-server"
@@ -13,6 +16,10 @@ import (
 	"go.lsp.dev/uri"
 )
 
+// Shared between every diagnostic run so that the scripts that did not change
+// are not linted again
+var shellLinter = shellcheck.NewLinter()
+
 type DiagnosticType struct {
 	diagnostics  *[]protocol.Diagnostic
 	yamlDocument yamlparser.YamlDocument
@@ -29,15 +36,35 @@ func Diagnostic(params protocol.PublishDiagnosticsParams, cache *utils.Cache, co
 	return diagnosticParams
 }
 
-func DiagnosticFile(uri protocol.URI, cache *utils.Cache, context *utils.LsContext, schemaLocation string) ([]protocol.Diagnostic, error) {
-	yamlDocument, err := yamlparser.ParseFromUriWithCache(uri, cache, context)
+func DiagnosticFile(uri protocol.URI, cache *utils.Cache, lsContext *utils.LsContext, schemaLocation string) ([]protocol.Diagnostic, error) {
+	return DiagnosticFileWithContext(context.Background(), uri, cache, lsContext, schemaLocation)
+}
+
+// DiagnosticFileWithContext is like DiagnosticFile but stops as soon as ctx is
+// done, returning its error
+func DiagnosticFileWithContext(ctx context.Context, uri protocol.URI, cache *utils.Cache, context *utils.LsContext, schemaLocation string) ([]protocol.Diagnostic, error) {
+	yamlDocument, err := yamlparser.ParseFromUriWithCacheWithContext(ctx, uri, cache, context)
 	yamlDocument.SchemaLocation = schemaLocation
 
 	if err != nil {
 		return []protocol.Diagnostic{}, err
 	}
 
-	return DiagnosticYAML(yamlDocument, cache, context)
+	return DiagnosticYAMLWithContext(ctx, yamlDocument, cache, context)
+}
+
+// DiagnosticContentWithContext computes the diagnostics of a document that is
+// not in the cache, such as the closed files of the workspace
+func DiagnosticContentWithContext(ctx context.Context, uri protocol.URI, content []byte, cache *utils.Cache, lsContext *utils.LsContext, schemaLocation string) ([]protocol.Diagnostic, error) {
+	yamlDocument, err := yamlparser.ParseFromContentWithContext(ctx, content, lsContext, uri, protocol.Position{})
+	yamlDocument.SchemaLocation = schemaLocation
+
+	if err != nil {
+		return []protocol.Diagnostic{}, err
+	}
+	yamlDocument.OrbSource = yamlparser.GetOrbSource(uri, cache, lsContext)
+
+	return DiagnosticYAMLWithContext(ctx, yamlDocument, cache, lsContext)
 }
 
 func DiagnosticString(content string, cache *utils.Cache, context *utils.LsContext, schemaLocation string) ([]protocol.Diagnostic, error) {
@@ -51,11 +78,16 @@ func DiagnosticString(content string, cache *utils.Cache, context *utils.LsConte
 	return DiagnosticYAML(yamlDocument, cache, context)
 }
 
-func DiagnosticYAML(yamlDocument yamlparser.YamlDocument, cache *utils.Cache, context *utils.LsContext) ([]protocol.Diagnostic, error) {
-	if yamlDocument.Version != 0 && yamlDocument.Version < 2.1 {
-		// TODO: Handle error
-		return []protocol.Diagnostic{}, nil
-	}
+func DiagnosticYAML(yamlDocument yamlparser.YamlDocument, cache *utils.Cache, lsContext *utils.LsContext) ([]protocol.Diagnostic, error) {
+	return DiagnosticYAMLWithContext(context.Background(), yamlDocument, cache, lsContext)
+}
+
+// DiagnosticYAMLWithContext is like DiagnosticYAML but the requests made by
+// the validation are aborted when ctx is done
+func DiagnosticYAMLWithContext(ctx context.Context, yamlDocument yamlparser.YamlDocument, cache *utils.Cache, context *utils.LsContext) ([]protocol.Diagnostic, error) {
+	// The schema only describes 2.1, the 2.0 configs get a basic validation and
+	// are offered a migration
+	isConfig20 := yamlDocument.Version != 0 && yamlDocument.Version < 2.1
 
 	diag := DiagnosticType{
 		diagnostics:  &[]protocol.Diagnostic{},
@@ -65,37 +97,63 @@ func DiagnosticYAML(yamlDocument yamlparser.YamlDocument, cache *utils.Cache, co
 	yamlDocument.ValidateYAML()
 	diag.addDiagnostics(*yamlDocument.Diagnostics)
 
-	validator := yamlparser.JSONSchemaValidator{
-		Doc: yamlDocument,
-	}
-
 	var err error
-	if yamlDocument.SchemaLocation != "" {
-		err = validator.LoadJsonSchema(yamlDocument.SchemaLocation)
-	} else {
-		err = validator.LoadJsonSchemaFromBytes(schema.EmbeddedSchemaJSON)
-	}
 
-	if err != nil {
-		return []protocol.Diagnostic{}, err
-	}
+	// The files of an orb source directory only hold a part of a config
+	if yamlDocument.OrbSourceFile == nil && !isConfig20 {
+		validator := yamlparser.JSONSchemaValidator{
+			Doc: yamlDocument,
+		}
+
+		if yamlDocument.SchemaLocation != "" {
+			err = validator.LoadJsonSchema(yamlDocument.SchemaLocation)
+		} else {
+			err = validator.LoadJsonSchemaFromBytes(schema.EmbeddedSchemaJSON)
+		}
 
-	diag.addDiagnostics(
-		validator.ValidateWithJSONSchema(diag.yamlDocument.RootNode, diag.yamlDocument.Content),
-	)
+		if err != nil {
+			return []protocol.Diagnostic{}, err
+		}
+
+		diag.addDiagnostics(
+			validator.ValidateWithJSONSchema(diag.yamlDocument.RootNode, diag.yamlDocument.Content),
+		)
+	}
 
 	validateStruct := validate.Validate{
 		APIs: validate.ValidateAPIs{
-			DockerHub: dockerhub.NewAPI(),
+			DockerHub:   dockerhub.NewAPIWithContext(ctx),
+			ShellLinter: shellLinter,
 		},
 		Doc:         diag.yamlDocument,
 		Diagnostics: &[]protocol.Diagnostic{},
 		Cache:       cache,
 		Context:     context,
+		Ctx:         ctx,
 	}
 	validateStruct.Validate()
+
+	// The validation of an outdated document is incomplete
+	if ctx.Err() != nil {
+		return []protocol.Diagnostic{}, ctx.Err()
+	}
 	diag.addDiagnostics(*validateStruct.Diagnostics)
 
+	if isConfig20 {
+		if edit, ok := refactor.MigrateTo21(&diag.yamlDocument); ok {
+			diag.addDiagnostics([]protocol.Diagnostic{
+				utils.CreateDiagnosticFromRange(
+					yamlDocument.VersionRange,
+					protocol.DiagnosticSeverityInformation,
+					"Version 2.0 is only partially validated, migrate to 2.1 to get the full validation",
+					[]protocol.CodeAction{
+						utils.CreateCodeActionTextEdit("Migrate to version 2.1", yamlDocument.URI, edit.Changes[yamlDocument.URI], false),
+					},
+				),
+			})
+		}
+	}
+
 	*diag.diagnostics = deduplicateDiagnosticsByRange(*diag.diagnostics)
 
 	// after ALL diagnostics are added, filter out the ones that the user wishes to suppress via cci-ignore comments
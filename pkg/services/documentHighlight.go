@@ -0,0 +1,265 @@
+package languageservice
+
+import (
+	"slices"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// A command, job or executor with the parameters it defines
+type parametersScope struct {
+	Range      protocol.Range
+	Parameters map[string]ast.Parameter
+}
+
+// DocumentHighlights returns the occurrences, within the document, of the
+// anchor, parameter, job, job group or command at the position. Definitions
+// are write highlights and usages read highlights
+func DocumentHighlights(params protocol.DocumentHighlightParams, cache *utils.Cache, context *utils.LsContext) ([]protocol.DocumentHighlight, error) {
+	doc, err := yamlparser.ParseFromUriWithCache(params.TextDocument.URI, cache, context)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := params.Position
+	if highlights, ok := anchorHighlights(doc, pos); ok {
+		return highlights, nil
+	}
+	if highlights, ok := parameterHighlights(doc, pos); ok {
+		return highlights, nil
+	}
+
+	ch := callHierarchyHandler{Doc: doc, Cache: cache}
+	kind, name := ch.nameAt(pos)
+	if name == "" {
+		kind, name = requiredNameAt(doc, pos)
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	highlights := []protocol.DocumentHighlight{}
+	switch kind {
+	case callHierarchyJob, callHierarchyJobGroup:
+		if job, ok := doc.Jobs[name]; ok && kind == callHierarchyJob {
+			highlights = append(highlights, writeHighlight(job.NameRange))
+		}
+		if jobGroup, ok := doc.JobGroups[name]; ok && kind == callHierarchyJobGroup {
+			highlights = append(highlights, writeHighlight(jobGroup.NameRange))
+		}
+		for _, workflow := range doc.Workflows {
+			highlights = append(highlights, invocationHighlights(workflow.JobInvocations, name)...)
+		}
+		for _, jobGroup := range doc.JobGroups {
+			highlights = append(highlights, invocationHighlights(jobGroup.JobInvocations, name)...)
+		}
+
+	case callHierarchyCommand, "":
+		// Unknown names are steps invoking a command of an orb not fetched yet
+		if command, ok := doc.Commands[name]; ok {
+			highlights = append(highlights, writeHighlight(command.NameRange))
+		}
+
+		steps := []StepRangeAndName{}
+		for _, command := range doc.Commands {
+			steps = append(steps, ch.stepsOf(command.Steps, command.Parameters)...)
+		}
+		for _, job := range doc.Jobs {
+			steps = append(steps, ch.stepsOf(job.Steps, job.Parameters)...)
+		}
+		for _, workflow := range doc.Workflows {
+			for _, jobInvocation := range workflow.JobInvocations {
+				steps = append(steps, getStepsOfCommandOrJob(jobInvocation.PreSteps, stepsParameterChecker(doc, cache))...)
+				steps = append(steps, getStepsOfCommandOrJob(jobInvocation.PostSteps, stepsParameterChecker(doc, cache))...)
+			}
+		}
+		for _, step := range steps {
+			if step.Name == name {
+				highlights = append(highlights, readHighlight(step.Range))
+			}
+		}
+
+	default:
+		return nil, nil
+	}
+
+	return sortedHighlights(highlights), nil
+}
+
+func anchorHighlights(doc yamlparser.YamlDocument, pos protocol.Position) ([]protocol.DocumentHighlight, bool) {
+	for _, anchor := range doc.YamlAnchors {
+		found := utils.PosInRange(anchor.DefinitionRange, pos)
+		for _, aliasRange := range *anchor.References {
+			found = found || utils.PosInRange(aliasRange, pos)
+		}
+		if !found {
+			continue
+		}
+
+		highlights := []protocol.DocumentHighlight{writeHighlight(anchor.DefinitionRange)}
+		for _, aliasRange := range *anchor.References {
+			highlights = append(highlights, readHighlight(aliasRange))
+		}
+		return sortedHighlights(highlights), true
+	}
+
+	return nil, false
+}
+
+// Returns the definition and the usages of the parameter defined or used at
+// the position: within the command, job or executor defining it or, for the
+// pipeline parameters, within the whole document
+func parameterHighlights(doc yamlparser.YamlDocument, pos protocol.Position) ([]protocol.DocumentHighlight, bool) {
+	documentScope := parametersScope{Range: doc.NodeToRange(doc.RootNode), Parameters: doc.PipelineParameters}
+	scopes := parametersScopes(doc)
+
+	name, isPipelineParameter := utils.GetParamNameUsedAtPos(doc.Content, pos)
+	scope, found := parametersScope{}, false
+	switch {
+	case name != "" && isPipelineParameter:
+		scope, found = documentScope, true
+	case name != "":
+		for _, candidate := range scopes {
+			if utils.PosInRange(candidate.Range, pos) {
+				scope, found = candidate, true
+			}
+		}
+	default:
+		for _, candidate := range append(scopes, documentScope) {
+			if name = utils.GetParamNameDefinedAtPos(candidate.Parameters, pos); name != "" {
+				scope, found = candidate, true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	highlights := []protocol.DocumentHighlight{}
+	if parameter, ok := scope.Parameters[name]; ok {
+		highlights = append(highlights, writeHighlight(parameter.GetNameRange()))
+	}
+
+	usages, err := utils.GetReferencesOfParamInRange(doc.Content, name, scope.Range)
+	if err != nil {
+		return nil, false
+	}
+	for _, usage := range usages {
+		highlights = append(highlights, readHighlight(protocol.Range{
+			Start: utils.IndexToPos(usage[0], doc.Content),
+			End:   utils.IndexToPos(usage[1], doc.Content),
+		}))
+	}
+
+	return sortedHighlights(highlights), true
+}
+
+// Returns the commands, jobs and executors of the document and of its local
+// orbs
+func parametersScopes(doc yamlparser.YamlDocument) []parametersScope {
+	scopes := []parametersScope{}
+	addScopes := func(commands map[string]ast.Command, jobs map[string]ast.Job, executors map[string]ast.Executor) {
+		for _, command := range commands {
+			scopes = append(scopes, parametersScope{Range: command.Range, Parameters: command.Parameters})
+		}
+		for _, job := range jobs {
+			scopes = append(scopes, parametersScope{Range: job.Range, Parameters: job.Parameters})
+		}
+		for _, executor := range executors {
+			scopes = append(scopes, parametersScope{Range: executor.GetRange(), Parameters: executor.GetParameters()})
+		}
+	}
+
+	addScopes(doc.Commands, doc.Jobs, doc.Executors)
+	for _, orbInfo := range doc.LocalOrbInfo {
+		addScopes(orbInfo.Commands, orbInfo.Jobs, orbInfo.Executors)
+	}
+	return scopes
+}
+
+// Returns the invocations of the job or job group among the invocations of a
+// workflow or of a job group, with the `requires` naming them
+func invocationHighlights(jobInvocations []ast.JobInvocation, name string) []protocol.DocumentHighlight {
+	highlights := []protocol.DocumentHighlight{}
+	stepNames := map[string]bool{}
+	for _, jobInvocation := range jobInvocations {
+		if jobInvocation.JobName == name {
+			highlights = append(highlights, readHighlight(jobInvocation.JobNameRange))
+			stepNames[jobInvocation.StepName] = true
+		}
+	}
+
+	for _, jobInvocation := range jobInvocations {
+		for _, require := range jobInvocation.Requires {
+			if stepNames[require.Name] {
+				highlights = append(highlights, readHighlight(require.Range))
+			}
+		}
+	}
+	return highlights
+}
+
+// Returns the job or job group invoked under the name required at the
+// position, the requires naming the invocations of their own workflow or job
+// group
+func requiredNameAt(doc yamlparser.YamlDocument, pos protocol.Position) (string, string) {
+	invocationsLists := [][]ast.JobInvocation{}
+	for _, workflow := range doc.Workflows {
+		invocationsLists = append(invocationsLists, workflow.JobInvocations)
+	}
+	for _, jobGroup := range doc.JobGroups {
+		invocationsLists = append(invocationsLists, jobGroup.JobInvocations)
+	}
+
+	for _, jobInvocations := range invocationsLists {
+		for _, jobInvocation := range jobInvocations {
+			for _, require := range jobInvocation.Requires {
+				if !utils.PosInRange(require.Range, pos) {
+					continue
+				}
+
+				for _, required := range jobInvocations {
+					if required.StepName != require.Name {
+						continue
+					}
+					if _, ok := doc.JobGroups[required.JobName]; ok {
+						return callHierarchyJobGroup, required.JobName
+					}
+					return callHierarchyJob, required.JobName
+				}
+			}
+		}
+	}
+
+	return "", ""
+}
+
+func readHighlight(rng protocol.Range) protocol.DocumentHighlight {
+	return protocol.DocumentHighlight{Range: rng, Kind: protocol.DocumentHighlightKindRead}
+}
+
+func writeHighlight(rng protocol.Range) protocol.DocumentHighlight {
+	return protocol.DocumentHighlight{Range: rng, Kind: protocol.DocumentHighlightKindWrite}
+}
+
+// Sorts the highlights by position, dropping the duplicates
+func sortedHighlights(highlights []protocol.DocumentHighlight) []protocol.DocumentHighlight {
+	slices.SortFunc(highlights, func(a, b protocol.DocumentHighlight) int {
+		return comparePositions(a.Range.Start, b.Range.Start)
+	})
+	return slices.CompactFunc(highlights, func(a, b protocol.DocumentHighlight) bool {
+		return a.Range == b.Range
+	})
+}
+
+// Unlike utils.ComparePosition, returns a negative number when a is before b
+func comparePositions(a, b protocol.Position) int {
+	if a.Line != b.Line {
+		return int(a.Line) - int(b.Line)
+	}
+	return int(a.Character) - int(b.Character)
+}
@@ -0,0 +1,79 @@
+package languageservice
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+const editorFeaturesFile = "./testdata/editorFeatures.yml"
+
+func rangeOf(startLine, startCharacter, endLine, endCharacter uint32) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: startLine, Character: startCharacter},
+		End:   protocol.Position{Line: endLine, Character: endCharacter},
+	}
+}
+
+func TestDocumentHighlights(t *testing.T) {
+	defaultsAnchor := []protocol.DocumentHighlight{
+		writeHighlight(rangeOf(7, 10, 7, 19)),
+		readHighlight(rangeOf(27, 8, 27, 17)),
+		readHighlight(rangeOf(33, 8, 33, 17)),
+	}
+	whoParameter := []protocol.DocumentHighlight{
+		writeHighlight(rangeOf(14, 6, 14, 9)),
+		readHighlight(rangeOf(18, 18, 18, 38)),
+		readHighlight(rangeOf(22, 17, 22, 37)),
+	}
+	envParameter := []protocol.DocumentHighlight{
+		writeHighlight(rangeOf(3, 2, 3, 5)),
+		readHighlight(rangeOf(23, 17, 23, 46)),
+	}
+	greetCommand := []protocol.DocumentHighlight{
+		writeHighlight(rangeOf(12, 2, 12, 7)),
+		readHighlight(rangeOf(29, 8, 29, 13)),
+		readHighlight(rangeOf(30, 8, 30, 13)),
+	}
+	buildJob := []protocol.DocumentHighlight{
+		writeHighlight(rangeOf(26, 2, 26, 7)),
+		readHighlight(rangeOf(40, 8, 40, 13)),
+		readHighlight(rangeOf(43, 14, 43, 19)),
+	}
+
+	testCases := []struct {
+		name     string
+		position protocol.Position
+		expected []protocol.DocumentHighlight
+	}{
+		{name: "anchor definition", position: protocol.Position{Line: 7, Character: 12}, expected: defaultsAnchor},
+		{name: "anchor alias", position: protocol.Position{Line: 27, Character: 10}, expected: defaultsAnchor},
+		{name: "parameter definition", position: protocol.Position{Line: 14, Character: 7}, expected: whoParameter},
+		{name: "parameter usage", position: protocol.Position{Line: 18, Character: 33}, expected: whoParameter},
+		{name: "pipeline parameter definition", position: protocol.Position{Line: 3, Character: 3}, expected: envParameter},
+		{name: "pipeline parameter usage", position: protocol.Position{Line: 23, Character: 35}, expected: envParameter},
+		{name: "command definition", position: protocol.Position{Line: 12, Character: 4}, expected: greetCommand},
+		{name: "command invocation", position: protocol.Position{Line: 29, Character: 9}, expected: greetCommand},
+		{name: "job definition", position: protocol.Position{Line: 26, Character: 4}, expected: buildJob},
+		{name: "job invocation", position: protocol.Position{Line: 40, Character: 9}, expected: buildJob},
+		{name: "required job", position: protocol.Position{Line: 43, Character: 16}, expected: buildJob},
+		{name: "workflow", position: protocol.Position{Line: 38, Character: 3}, expected: nil},
+	}
+
+	cache := cacheWithFiles(t, editorFeaturesFile)
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			highlights, err := DocumentHighlights(protocol.DocumentHighlightParams{
+				TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+					TextDocument: protocol.TextDocumentIdentifier{URI: uri.File(editorFeaturesFile)},
+					Position:     tt.position,
+				},
+			}, cache, testHelpers.GetDefaultLsContext())
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, highlights)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package languageservice
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+// FoldingRanges returns the regions of the top level keys, jobs, commands,
+// executors, workflows, job groups, steps and block scalars of the document.
+// The top level keys are folded as well since the clients stop folding on
+// indentation once the server gives folding ranges
+func FoldingRanges(params protocol.FoldingRangeParams, cache *utils.Cache, context *utils.LsContext) ([]protocol.FoldingRange, error) {
+	doc, err := yamlparser.ParseFromUriWithCache(params.TextDocument.URI, cache, context)
+	if err != nil {
+		return nil, err
+	}
+
+	// The widest range starting on each line, the clients fold a single region
+	// per line
+	rangesByLine := map[uint32]protocol.FoldingRange{}
+	add := func(rng protocol.Range) {
+		endLine := rng.End.Line
+		// The nodes including the trailing line break end on the next line
+		if rng.End.Character == 0 && endLine > rng.Start.Line {
+			endLine--
+		}
+		if endLine <= rng.Start.Line {
+			return
+		}
+
+		if existing, ok := rangesByLine[rng.Start.Line]; !ok || existing.EndLine < endLine {
+			rangesByLine[rng.Start.Line] = protocol.FoldingRange{StartLine: rng.Start.Line, EndLine: endLine}
+		}
+	}
+
+	// The ranges of the sections only hold their value, the top level keys are
+	// taken from the tree
+	if blockMapping := yamlparser.GetBlockMappingNode(doc.RootNode); blockMapping != nil {
+		for i := 0; i < int(blockMapping.NamedChildCount()); i++ {
+			if child := blockMapping.NamedChild(i); child.Type() == "block_mapping_pair" {
+				add(doc.NodeToRange(child))
+			}
+		}
+	}
+
+	addEntities := func(commands map[string]ast.Command, jobs map[string]ast.Job, executors map[string]ast.Executor) {
+		for _, command := range commands {
+			add(command.Range)
+			addStepsFoldingRanges(doc, command.Steps, add)
+		}
+		for _, job := range jobs {
+			add(job.Range)
+			addStepsFoldingRanges(doc, job.Steps, add)
+		}
+		for _, executor := range executors {
+			add(executor.GetRange())
+		}
+	}
+	addEntities(doc.Commands, doc.Jobs, doc.Executors)
+	for _, orbInfo := range doc.LocalOrbInfo {
+		addEntities(orbInfo.Commands, orbInfo.Jobs, orbInfo.Executors)
+	}
+
+	for _, workflow := range doc.Workflows {
+		add(workflow.Range)
+		for _, jobInvocation := range workflow.JobInvocations {
+			add(jobInvocation.JobInvocationRange)
+			addStepsFoldingRanges(doc, jobInvocation.PreSteps, add)
+			addStepsFoldingRanges(doc, jobInvocation.PostSteps, add)
+		}
+	}
+	for _, jobGroup := range doc.JobGroups {
+		add(jobGroup.Range)
+		for _, jobInvocation := range jobGroup.JobInvocations {
+			add(jobInvocation.JobInvocationRange)
+		}
+	}
+
+	yamlparser.ExecQuery(doc.RootNode, "(block_scalar) @query", func(match *sitter.QueryMatch) {
+		for _, capture := range match.Captures {
+			add(doc.NodeToRange(capture.Node))
+		}
+	})
+
+	res := []protocol.FoldingRange{}
+	for _, line := range slices.Sorted(maps.Keys(rangesByLine)) {
+		res = append(res, rangesByLine[line])
+	}
+	return res, nil
+}
+
+// The ranges of the steps only hold their name, the items of the list of
+// steps are folded instead. The steps of the `when` and `unless` steps are
+// flattened by the parser
+func addStepsFoldingRanges(doc yamlparser.YamlDocument, steps []ast.Step, add func(protocol.Range)) {
+	for _, step := range steps {
+		start := step.GetRange().Start
+		point := sitter.Point{Row: start.Line, Column: start.Character}
+		node := doc.RootNode.NamedDescendantForPointRange(point, point)
+		for node != nil && node.Type() != "block_sequence_item" {
+			node = node.Parent()
+		}
+		if node != nil {
+			add(doc.NodeToRange(node))
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package languageservice
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestFoldingRanges(t *testing.T) {
+	cache, configURI := cacheWithFiles(t, editorFeaturesFile), uri.File(editorFeaturesFile)
+
+	ranges, err := FoldingRanges(protocol.FoldingRangeParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: configURI},
+		},
+	}, cache, testHelpers.GetDefaultLsContext())
+	assert.Nil(t, err)
+
+	expected := []protocol.FoldingRange{
+		{StartLine: 2, EndLine: 5},   // parameters
+		{StartLine: 7, EndLine: 9},   // defaults
+		{StartLine: 11, EndLine: 23}, // commands
+		{StartLine: 12, EndLine: 23}, // greet
+		{StartLine: 19, EndLine: 23}, // run step
+		{StartLine: 21, EndLine: 23}, // block scalar
+		{StartLine: 25, EndLine: 35}, // jobs
+		{StartLine: 26, EndLine: 31}, // build
+		{StartLine: 30, EndLine: 31}, // greet step
+		{StartLine: 32, EndLine: 35}, // test
+		{StartLine: 37, EndLine: 43}, // workflows
+		{StartLine: 38, EndLine: 43}, // main
+		{StartLine: 41, EndLine: 43}, // test invocation
+	}
+	assert.Equal(t, expected, ranges)
+}
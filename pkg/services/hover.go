@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/services/hover"
 	utils "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
 	sitter "github.com/smacker/go-tree-sitter"
 	"go.lsp.dev/protocol"
@@ -24,6 +25,63 @@ func Hover(params protocol.HoverParams, cache *utils.Cache, context *utils.LsCon
 		}, nil
 	}
 
+	if content := hover.HoverAttachWorkspace(doc, params.Position); content != "" {
+		return protocol.Hover{
+			Contents: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: content,
+			},
+		}, nil
+	}
+
+	if content := hover.HoverEnvVariable(doc, params.Position, cache); content != "" {
+		return protocol.Hover{
+			Contents: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: content,
+			},
+		}, nil
+	}
+
+	if content := hover.HoverOrbInclude(doc, params.Position); content != "" {
+		return protocol.Hover{
+			Contents: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: content,
+			},
+		}, nil
+	}
+
+	if content := hover.HoverOrbSourceEntity(doc, params.Position); content != "" {
+		return protocol.Hover{
+			Contents: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: content,
+			},
+		}, nil
+	}
+
+	if content := hover.HoverFileReference(doc, params.Position); content != "" {
+		return protocol.Hover{
+			Contents: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: content,
+			},
+		}, nil
+	}
+
+	if _, visitedNodes, err := utils.NodeAtPos(doc.RootNode, params.Position); err == nil {
+		path := GetPathFromVisitedNodes(visitedNodes, doc)
+		if content := hover.HoverAtPath(doc, path, cache); content != "" {
+			return protocol.Hover{
+				Contents: protocol.MarkupContent{
+					Kind:  protocol.Markdown,
+					Value: content,
+				},
+			}, nil
+		}
+	}
+
 	return protocol.Hover{}, fmt.Errorf("No hover")
 }
 
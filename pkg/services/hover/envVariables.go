@@ -0,0 +1,92 @@
+package hover
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// HoverEnvVariable lists where the environment variable used at the given
+// position of a run command is set from
+func HoverEnvVariable(doc yamlparser.YamlDocument, position protocol.Position, cache *utils.Cache) string {
+	for _, job := range doc.Jobs {
+		if run, usage, ok := findEnvVariableUsage(job.Steps, position); ok {
+			return describeEnvVariable(usage, run, doc.GetJobEnvVariableScope(job, cache))
+		}
+	}
+
+	for name, command := range doc.Commands {
+		if run, usage, ok := findEnvVariableUsage(command.Steps, position); ok {
+			scope, _ := doc.GetCommandEnvVariableScope(name, cache)
+			return describeEnvVariable(usage, run, scope)
+		}
+	}
+
+	return ""
+}
+
+func findEnvVariableUsage(steps []ast.Step, position protocol.Position) (ast.Run, yamlparser.EnvVariableUsage, bool) {
+	for _, step := range steps {
+		run, ok := step.(ast.Run)
+		if !ok || !utils.PosInRange(run.CommandRange, position) {
+			continue
+		}
+
+		for _, usage := range yamlparser.GetEnvVariableUsages(run) {
+			if utils.PosInRange(usage.Range, position) {
+				return run, usage, true
+			}
+		}
+	}
+
+	return ast.Run{}, yamlparser.EnvVariableUsage{}, false
+}
+
+func describeEnvVariable(usage yamlparser.EnvVariableUsage, run ast.Run, scope yamlparser.EnvVariableScope) string {
+	sources := scope.GetEnvVariableSources(usage.Name, run)
+	if len(sources) == 0 {
+		if scope.Complete {
+			return fmt.Sprintf("`%s` is not defined", usage.Name)
+		}
+		return fmt.Sprintf("`%s` is not defined in the configuration, it may be set by an orb, a context or the project settings", usage.Name)
+	}
+
+	lines := []string{}
+	for _, source := range sources {
+		lines = append(lines, "- "+describeEnvVariableSource(usage.Name, source))
+	}
+
+	return fmt.Sprintf("`%s` is set by:\n\n%s", usage.Name, strings.Join(lines, "\n"))
+}
+
+func describeEnvVariableSource(name string, source yamlparser.EnvVariableSource) string {
+	switch source.Kind {
+	case yamlparser.EnvVariableFromStep:
+		return "the `environment` of the step"
+	case yamlparser.EnvVariableFromJob:
+		return fmt.Sprintf("the `environment` of the job `%s`", source.Name)
+	case yamlparser.EnvVariableFromExecutor:
+		return fmt.Sprintf("the executor `%s`", source.Name)
+	case yamlparser.EnvVariableFromDockerImage:
+		return fmt.Sprintf("the Docker image `%s`", source.Name)
+	case yamlparser.EnvVariableFromContext:
+		return fmt.Sprintf("the context `%s`", source.Name)
+	case yamlparser.EnvVariableFromProject:
+		return fmt.Sprintf("the settings of the project `%s`", source.Name)
+	case yamlparser.EnvVariableFromScript:
+		return fmt.Sprintf("a script of the job `%s`", source.Name)
+	case yamlparser.EnvVariableFromShell:
+		return "the shell"
+	case yamlparser.EnvVariableFromBuiltIn:
+		if env, ok := utils.GetBuiltInEnvVariable(name); ok {
+			return "CircleCI: " + env.Description
+		}
+		return "CircleCI"
+	}
+
+	return string(source.Kind)
+}
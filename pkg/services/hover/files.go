@@ -0,0 +1,87 @@
+package hover
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"go.lsp.dev/protocol"
+)
+
+const fileHoverLines = 10
+
+// HoverFileReference shows the first lines of the repository file referenced
+// at the given position by a step
+func HoverFileReference(doc yamlparser.YamlDocument, position protocol.Position) string {
+	ref, ok := doc.GetFileReferenceAt(position)
+	if !ok {
+		return ""
+	}
+
+	path, ok := doc.ResolveFileReference(ref)
+	if !ok {
+		return ""
+	}
+
+	return hoverFile(ref.Path, path)
+}
+
+// Shows the first lines of a file, displayed with the given name
+func hoverFile(name string, path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return fmt.Sprintf("Directory `%s`", name)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(file)
+	for len(lines) < fileHoverLines && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	language := strings.TrimPrefix(filepath.Ext(path), ".")
+	if len(lines) > 0 {
+		if interpreter := getShebangInterpreter(lines[0]); interpreter != "" {
+			language = interpreter
+		}
+	}
+
+	content := fmt.Sprintf("`%s`\n\n```%s\n%s\n```", name, language, strings.Join(lines, "\n"))
+	if scanner.Scan() {
+		content += "\n\n..."
+	}
+
+	return content
+}
+
+// Returns the interpreter of a shebang line: `#!/usr/bin/env python3` and
+// `#!/usr/bin/python3` both give `python3`
+func getShebangInterpreter(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" && len(fields) > 1 {
+		interpreter = fields[1]
+	}
+
+	return interpreter
+}
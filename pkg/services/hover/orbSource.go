@@ -0,0 +1,131 @@
+package hover
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// HoverOrbInclude shows the first lines of the file included at the given
+// position
+func HoverOrbInclude(doc yamlparser.YamlDocument, position protocol.Position) string {
+	include, ok := doc.GetOrbIncludeAt(position)
+	if !ok {
+		return ""
+	}
+
+	path, ok := doc.ResolveOrbInclude(include)
+	if !ok {
+		return ""
+	}
+
+	return hoverFile(include.Path, path)
+}
+
+// HoverOrbSourceEntity describes the command, job or executor referenced at
+// the given position when it is defined in another file of the source
+// directory of the orb
+func HoverOrbSourceEntity(doc yamlparser.YamlDocument, position protocol.Position) string {
+	if doc.OrbSource == nil {
+		return ""
+	}
+
+	kind, name := getOrbSourceReferenceAt(doc, position)
+	if name == "" {
+		return ""
+	}
+
+	definitionDoc, ok := doc.OrbSource.GetDefinitionDocument(kind, name)
+	if !ok || definitionDoc.URI == doc.URI {
+		return ""
+	}
+
+	var description string
+	var parameters map[string]ast.Parameter
+	switch kind {
+	case yamlparser.OrbSourceCommands:
+		description = definitionDoc.Commands[name].Description
+		parameters = definitionDoc.Commands[name].Parameters
+	case yamlparser.OrbSourceJobs:
+		description = definitionDoc.Jobs[name].Description
+		parameters = definitionDoc.Jobs[name].Parameters
+	case yamlparser.OrbSourceExecutors:
+		parameters = definitionDoc.Executors[name].GetParameters()
+	}
+
+	path, err := filepath.Rel(doc.OrbSource.Dir, definitionDoc.URI.Filename())
+	if err != nil {
+		path = filepath.Base(definitionDoc.URI.Filename())
+	}
+
+	content := fmt.Sprintf("`%s` - %s defined in `%s`", name, strings.TrimSuffix(kind, "s"), filepath.ToSlash(path))
+	if description != "" {
+		content += "\n\n" + description
+	}
+
+	if len(parameters) > 0 {
+		names := make([]string, 0, len(parameters))
+		for paramName := range parameters {
+			names = append(names, paramName)
+		}
+		sort.Strings(names)
+
+		content += "\n\nParameters:\n"
+		for _, paramName := range names {
+			param := parameters[paramName]
+			content += fmt.Sprintf("\n- `%s` (%s", paramName, param.GetType())
+			if !param.IsOptional() {
+				content += ", required"
+			}
+			content += ")"
+			if param.GetDescription() != "" {
+				content += ": " + param.GetDescription()
+			}
+		}
+	}
+
+	return content
+}
+
+// Returns the kind and name of the entity referenced at the given position by
+// a step or the executor of a job
+func getOrbSourceReferenceAt(doc yamlparser.YamlDocument, position protocol.Position) (string, string) {
+	findStep := func(steps []ast.Step) (string, string) {
+		for _, step := range steps {
+			named, ok := step.(ast.NamedStep)
+			if !ok || !utils.PosInRange(named.Range, position) {
+				continue
+			}
+
+			if _, ok := doc.OrbSource.Jobs[named.Name]; ok {
+				return yamlparser.OrbSourceJobs, named.Name
+			}
+			return yamlparser.OrbSourceCommands, named.Name
+		}
+		return "", ""
+	}
+
+	for _, job := range doc.Jobs {
+		if utils.PosInRange(job.ExecutorRange, position) {
+			return yamlparser.OrbSourceExecutors, job.Executor
+		}
+
+		if kind, name := findStep(job.Steps); name != "" {
+			return kind, name
+		}
+	}
+
+	for _, command := range doc.Commands {
+		if kind, name := findStep(command.Steps); name != "" {
+			return kind, name
+		}
+	}
+
+	return "", ""
+}
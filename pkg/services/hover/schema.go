@@ -0,0 +1,269 @@
+package hover
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	schema "github.com/CircleCI-Public/circleci-yaml-language-server"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/segmentio/encoding/json"
+)
+
+const configurationReferenceURL = "https://circleci.com/docs/reference/configuration-reference/"
+
+var (
+	embeddedSchema     map[string]any
+	embeddedSchemaOnce sync.Once
+)
+
+func getEmbeddedSchema() map[string]any {
+	embeddedSchemaOnce.Do(func() {
+		if err := json.Unmarshal(schema.EmbeddedSchemaJSON, &embeddedSchema); err != nil {
+			utils.Logger.Error("unable to parse the embedded schema", "error", err)
+		}
+	})
+	return embeddedSchema
+}
+
+// HoverAtPath returns the documentation of the key at the end of the path,
+// from the sections having hovers of their own or from the JSON schema
+func HoverAtPath(doc yamlparser.YamlDocument, path []string, cache *utils.Cache) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	content := ""
+	switch path[0] {
+	case "jobs":
+		content = HoverInJobs(doc, path[1:], cache)
+	case "commands":
+		content = HoverInCommands(doc, path[1:])
+	case "workflows":
+		content = HoverInWorkflows(doc, path[1:])
+	case "orbs":
+		content = HoverInOrbs(doc, path[1:], cache)
+	}
+
+	if content != "" {
+		return content
+	}
+	return HoverSchema(getEmbeddedSchema(), path)
+}
+
+// HoverSchema returns the description, type, allowed values and
+// documentation link of the key at the end of the path, as described by the
+// JSON schema. The names given by the users, such as the names of the jobs,
+// are only documented when the schema describes them
+func HoverSchema(root map[string]any, path []string) string {
+	if root == nil || len(path) == 0 {
+		return ""
+	}
+
+	// The schemas of the key, and the ones in which the next key is searched:
+	// the items of the arrays are only documented by their own keys
+	schemas := expandSchema(root, root, false, 0)
+	parents := expandSchema(root, root, true, 0)
+	isProperty := false
+	for _, key := range path {
+		schemas = []map[string]any{}
+		children := []map[string]any{}
+		isProperty = false
+		for _, s := range parents {
+			child, byName := childSchema(s, key)
+			if child == nil {
+				continue
+			}
+			isProperty = isProperty || byName
+			schemas = append(schemas, expandSchema(root, child, false, 0)...)
+			children = append(children, expandSchema(root, child, true, 0)...)
+		}
+
+		if len(children) == 0 {
+			return ""
+		}
+		parents = children
+	}
+
+	description := ""
+	types := []string{}
+	values := []string{}
+	defaultValue := ""
+	pattern := ""
+	for _, s := range schemas {
+		if description == "" {
+			description, _ = s["markdownDescription"].(string)
+		}
+		if description == "" {
+			description, _ = s["description"].(string)
+		}
+
+		if pattern, ok := s["pattern"].(string); ok && strings.Contains(pattern, "<<") {
+			// The alternative allowing the parameters everywhere
+			continue
+		}
+
+		switch t := s["type"].(type) {
+		case string:
+			types = appendUnique(types, t)
+		case []any:
+			for _, t := range t {
+				if t, ok := t.(string); ok {
+					types = appendUnique(types, t)
+				}
+			}
+		}
+
+		if enum, ok := s["enum"].([]any); ok {
+			for _, value := range enum {
+				values = appendUnique(values, fmt.Sprint(value))
+			}
+		}
+		if value, ok := s["const"]; ok {
+			values = appendUnique(values, fmt.Sprint(value))
+		}
+		if value, ok := s["default"]; ok && defaultValue == "" {
+			defaultValue = fmt.Sprint(value)
+		}
+		if value, ok := s["pattern"].(string); ok && pattern == "" {
+			pattern = value
+		}
+	}
+
+	if description == "" && !isProperty {
+		return ""
+	}
+
+	key := path[len(path)-1]
+	lines := []string{fmt.Sprintf("`%s`", key)}
+	if description != "" {
+		lines = append(lines, description)
+	}
+
+	details := []string{}
+	if len(types) > 0 {
+		details = append(details, "Type: "+codeList(types, " | "))
+	}
+	if len(values) > 0 {
+		details = append(details, "Allowed values: "+codeList(values, ", "))
+	} else if pattern != "" {
+		details = append(details, "Pattern: `"+pattern+"`")
+	}
+	if defaultValue != "" {
+		details = append(details, "Default: `"+defaultValue+"`")
+	}
+	if len(details) > 0 {
+		lines = append(lines, "- "+strings.Join(details, "\n- "))
+	}
+
+	if !strings.Contains(description, "circleci.com/docs") {
+		lines = append(lines, fmt.Sprintf("[Configuration reference](%s#%s)", configurationReferenceURL, docsAnchor(key)))
+	}
+
+	return strings.Join(lines, "\n\n")
+}
+
+// Returns the schema of the key in an object schema, and whether the key is
+// one of its properties rather than a name given by the user
+func childSchema(s map[string]any, key string) (map[string]any, bool) {
+	if properties, ok := s["properties"].(map[string]any); ok {
+		if child, ok := properties[key].(map[string]any); ok {
+			return child, true
+		}
+	}
+
+	if patternProperties, ok := s["patternProperties"].(map[string]any); ok {
+		for pattern, child := range patternProperties {
+			if matched, err := regexp.MatchString(pattern, key); err == nil && matched {
+				if child, ok := child.(map[string]any); ok {
+					return child, false
+				}
+			}
+		}
+	}
+
+	if child, ok := s["additionalProperties"].(map[string]any); ok {
+		return child, false
+	}
+
+	return nil, false
+}
+
+// Returns the schema with the schemas it refers to or is made of: references,
+// alternatives, conditions and, when withItems is set, the items of the arrays
+func expandSchema(root map[string]any, s map[string]any, withItems bool, depth int) []map[string]any {
+	// The definitions of the steps refer to themselves
+	if depth > 16 {
+		return nil
+	}
+
+	res := []map[string]any{s}
+
+	if ref, ok := s["$ref"].(string); ok {
+		if target := resolveReference(root, ref); target != nil {
+			res = append(res, expandSchema(root, target, withItems, depth+1)...)
+		}
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		if alternatives, ok := s[keyword].([]any); ok {
+			for _, alternative := range alternatives {
+				if alternative, ok := alternative.(map[string]any); ok {
+					res = append(res, expandSchema(root, alternative, withItems, depth+1)...)
+				}
+			}
+		}
+	}
+
+	keywords := []string{"then", "else"}
+	if withItems {
+		keywords = append(keywords, "items")
+	}
+	for _, keyword := range keywords {
+		if child, ok := s[keyword].(map[string]any); ok {
+			res = append(res, expandSchema(root, child, withItems, depth+1)...)
+		}
+	}
+
+	return res
+}
+
+// Resolves the references to the definitions of the schema, `#/definitions/x`
+func resolveReference(root map[string]any, ref string) map[string]any {
+	parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	current := root
+	for _, part := range parts {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+var nonAnchorCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// The anchors of the configuration reference are the keys without their
+// underscores
+func docsAnchor(key string) string {
+	return nonAnchorCharacters.ReplaceAllString(strings.ToLower(key), "")
+}
+
+func codeList(values []string, separator string) string {
+	res := make([]string, len(values))
+	for i, value := range values {
+		res[i] = "`" + value + "`"
+	}
+	return strings.Join(res, separator)
+}
+
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,60 @@
+package hover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoverSchema(t *testing.T) {
+	root := getEmbeddedSchema()
+
+	assert.Equal(t, "`no_output_timeout`\n\n"+
+		"Elapsed time the command can run without output. The string is a decimal with unit suffix, such as \"20m\", \"1.25h\", \"5s\" (default: 10 minutes)\n\n"+
+		"- Type: `string`\n- Pattern: `\\d+(\\.\\d+)?[mhs]`\n- Default: `10m`\n\n"+
+		"[Configuration reference](https://circleci.com/docs/reference/configuration-reference/#nooutputtimeout)",
+		HoverSchema(root, []string{"jobs", "build", "steps", "run", "no_output_timeout"}))
+
+	// The description links to the documentation already
+	content := HoverSchema(root, []string{"jobs", "build", "machine", "docker_layer_caching"})
+	assert.Contains(t, content, "[Docker Layer Caching](https://circleci.com/docs/docker-layer-caching)")
+	assert.NotContains(t, content, "[Configuration reference]")
+	assert.NotContains(t, content, "parameters")
+
+	// Without description
+	assert.Equal(t, "`caches`\n\n- Type: `string`\n- Pattern: `^([1-9]|1[0-5])d$`\n\n"+
+		"[Configuration reference](https://circleci.com/docs/reference/configuration-reference/#caches)",
+		HoverSchema(root, []string{"jobs", "build", "retention", "caches"}))
+
+	assert.Contains(t, HoverSchema(root, []string{"version"}), "- Allowed values: `2.1`")
+
+	// Unknown keys
+	assert.Equal(t, "", HoverSchema(root, []string{"jobs", "build", "unknown"}))
+	assert.Equal(t, "", HoverSchema(root, []string{}))
+}
+
+func TestHoverSchemaNames(t *testing.T) {
+	root := map[string]any{
+		"definitions": map[string]any{
+			"item": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"mode": map[string]any{"enum": []any{"fast", "slow"}}},
+			},
+		},
+		"properties": map[string]any{
+			"entries": map[string]any{
+				"type": "object",
+				"additionalProperties": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"$ref": "#/definitions/item"},
+				},
+			},
+		},
+	}
+
+	// The names given by the users are not documented by the schema
+	assert.Equal(t, "", HoverSchema(root, []string{"entries", "name"}))
+	assert.Equal(t, "`mode`\n\n- Allowed values: `fast`, `slow`\n\n"+
+		"[Configuration reference](https://circleci.com/docs/reference/configuration-reference/#mode)",
+		HoverSchema(root, []string{"entries", "name", "mode"}))
+}
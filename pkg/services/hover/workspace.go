@@ -0,0 +1,97 @@
+package hover
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// HoverAttachWorkspace lists, for every workflow invoking a job that attaches
+// the workspace at the given position, the upstream jobs contributing to the
+// workspace and the paths they persist
+func HoverAttachWorkspace(doc yamlparser.YamlDocument, position protocol.Position) string {
+	sections := []string{}
+
+	workflowNames := make([]string, 0, len(doc.Workflows))
+	for name := range doc.Workflows {
+		workflowNames = append(workflowNames, name)
+	}
+	sort.Strings(workflowNames)
+
+	for _, workflowName := range workflowNames {
+		workflow := doc.Workflows[workflowName]
+
+		for _, invocation := range workflow.JobInvocations {
+			usage := doc.GetWorkspaceUsage(invocation)
+
+			attachedAt := ""
+			found := false
+			for _, attach := range usage.Attaches {
+				if utils.PosInRange(attach.Range, position) {
+					attachedAt = attach.At
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				continue
+			}
+
+			sections = append(sections, workspaceContributions(doc, workflowName, invocation.StepName, attachedAt))
+		}
+	}
+
+	return strings.Join(sections, "\n\n---\n\n")
+}
+
+func workspaceContributions(doc yamlparser.YamlDocument, workflowName string, stepName string, attachedAt string) string {
+	workflow := doc.Workflows[workflowName]
+
+	header := fmt.Sprintf("Workspace of `%s` in workflow `%s`", stepName, workflowName)
+	if attachedAt != "" {
+		header += fmt.Sprintf(", attached at `%s`", attachedAt)
+	}
+
+	contributions := []string{}
+	unknown := []string{}
+
+	for _, upstream := range yamlparser.GetUpstreamInvocations(workflow.JobInvocations, stepName) {
+		usage := doc.GetWorkspaceUsage(upstream)
+
+		if !usage.Complete {
+			unknown = append(unknown, fmt.Sprintf("`%s`", upstream.StepName))
+		}
+
+		paths := []string{}
+		for _, persist := range usage.Persists {
+			for _, persistedPath := range yamlparser.GetPersistedPaths(persist) {
+				paths = append(paths, fmt.Sprintf("`%s`", persistedPath))
+			}
+		}
+
+		if len(paths) > 0 {
+			contributions = append(contributions, fmt.Sprintf("- `%s`: %s", upstream.StepName, strings.Join(paths, ", ")))
+		}
+	}
+
+	sort.Strings(contributions)
+	sort.Strings(unknown)
+
+	res := header + "\n\n"
+	if len(contributions) == 0 {
+		res += "No upstream job persists to the workspace."
+	} else {
+		res += strings.Join(contributions, "\n")
+	}
+
+	if len(unknown) > 0 {
+		res += "\n\nUpstream jobs that could not be inspected: " + strings.Join(unknown, ", ")
+	}
+
+	return res
+}
@@ -0,0 +1,66 @@
+package hover
+
+import (
+	"testing"
+
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestHoverAttachWorkspace(t *testing.T) {
+	content := `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - persist_to_workspace:
+          root: .
+          paths:
+            - dist
+  lint:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - attach_workspace:
+          at: /tmp/workspace
+
+workflows:
+  main:
+    jobs:
+      - build
+      - lint
+      - deploy:
+          requires:
+            - build
+            - lint
+  release:
+    jobs:
+      - lint
+      - deploy:
+          requires:
+            - lint
+`
+	doc, err := yamlparser.ParseFromContent([]byte(content), testHelpers.GetDefaultLsContext(), uri.File("config.yml"), protocol.Position{})
+	assert.Nil(t, err)
+
+	assert.Equal(t,
+		"Workspace of `deploy` in workflow `main`, attached at `/tmp/workspace`\n\n"+
+			"- `build`: `dist`"+
+			"\n\n---\n\n"+
+			"Workspace of `deploy` in workflow `release`, attached at `/tmp/workspace`\n\n"+
+			"No upstream job persists to the workspace.",
+		HoverAttachWorkspace(doc, protocol.Position{Line: 20, Character: 10}))
+
+	// Outside of an attach_workspace step
+	assert.Equal(t, "", HoverAttachWorkspace(doc, protocol.Position{Line: 15, Character: 10}))
+}
@@ -0,0 +1,290 @@
+package refactor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+// The keys of a job that can be moved to an executor
+var executorKeys = []string{"docker", "machine", "macos", "windows", "resource_class", "shell", "working_directory", "environment"}
+
+// The keys of the executor types, one of them is required by an executor
+var executorTypeKeys = []string{"docker", "machine", "macos", "windows"}
+
+// The keys of an executor that are not copied into a job
+var executorOnlyKeys = []string{"parameters", "description"}
+
+// The executor configuration written in a job
+type jobExecutor struct {
+	Job string
+	// Pairs of the configuration, including the ones merged from an anchor
+	// with `<<: *defaults`
+	Pairs []*sitter.Node
+	// Pairs of the job replaced by the reference to the executor, the merge
+	// key included
+	Replaced []*sitter.Node
+	// Text of the configuration regardless of the order of the keys and of the
+	// comments, to find the jobs with the same one
+	Fingerprint string
+}
+
+// Returns the jobs with an executor configuration of their own. The
+// configuration can come from an anchor merged into the job, as long as the
+// anchor holds nothing else
+func jobExecutors(doc *parser.YamlDocument) []jobExecutor {
+	res := []jobExecutor{}
+
+	forEachDefinition(doc, "jobs", func(name string, _ *sitter.Node, definition *sitter.Node) {
+		executor := jobExecutor{Job: name}
+		hasType := false
+		hasReference := false
+		fingerprints := []string{}
+
+		add := func(key string, pair *sitter.Node) {
+			executor.Pairs = append(executor.Pairs, pair)
+			hasType = hasType || slices.Contains(executorTypeKeys, key)
+
+			texts := []string{}
+			for _, token := range tokens(doc, pair, false) {
+				texts = append(texts, token.Text)
+			}
+			fingerprints = append(fingerprints, strings.Join(texts, "\x00"))
+		}
+
+		// The keys of the job override the merged ones
+		ownKeys := map[string]bool{}
+		forEachPair(doc, definition, func(key string, _ *sitter.Node, _ *sitter.Node) {
+			ownKeys[key] = true
+		})
+
+		forEachPair(doc, definition, func(key string, pair *sitter.Node, _ *sitter.Node) {
+			switch {
+			case key == "executor":
+				hasReference = true
+
+			case key == mergeKey:
+				merged := mergedMapping(doc, pair)
+				if merged == nil {
+					hasReference = true
+					return
+				}
+
+				mergedPairs := 0
+				forEachPair(doc, merged, func(key string, pair *sitter.Node, _ *sitter.Node) {
+					switch {
+					case ownKeys[key]:
+					case slices.Contains(executorKeys, key):
+						add(key, pair)
+						mergedPairs++
+					default:
+						// Removing the merge key would remove this key
+						// from the job, it can't be replaced by the executor
+						hasReference = true
+					}
+				})
+				if mergedPairs > 0 {
+					executor.Replaced = append(executor.Replaced, pair)
+				}
+
+			case slices.Contains(executorKeys, key):
+				add(key, pair)
+				executor.Replaced = append(executor.Replaced, pair)
+			}
+		})
+
+		if hasReference || !hasType {
+			return
+		}
+
+		sort.Strings(fingerprints)
+		executor.Fingerprint = strings.Join(fingerprints, "\x01")
+		res = append(res, executor)
+	})
+
+	return res
+}
+
+// ExtractExecutor returns the code action moving the executor configuration
+// of the job at the range (docker, resource class, environment...) to a new
+// executor. The jobs with the same configuration are changed to use it too
+func ExtractExecutor(doc *parser.YamlDocument, rng protocol.Range) []protocol.CodeAction {
+	if doc.Version < 2.1 || doc.OrbSourceFile != nil {
+		return nil
+	}
+
+	executors := jobExecutors(doc)
+	start, end := rangeToBytes(doc, rng)
+
+	var selected *jobExecutor
+	for i, executor := range executors {
+		for _, pair := range executor.Replaced {
+			if intersects(pair, start, end) {
+				selected = &executors[i]
+			}
+		}
+	}
+	if selected == nil {
+		return nil
+	}
+
+	job := doc.Jobs[selected.Job]
+	name := uniqueName(doc, executorName(job), nil)
+	unit := indentUnit(doc)
+
+	definition, ok := addDefinition(doc, "executors", doc.ExecutorsRange, executorDefinition(doc, *selected, name, unit))
+	if !ok {
+		return nil
+	}
+
+	edits := []protocol.TextEdit{definition}
+	others := 0
+	for _, executor := range executors {
+		if executor.Fingerprint != selected.Fingerprint {
+			continue
+		}
+		if executor.Job != selected.Job {
+			others++
+		}
+		edits = append(edits, useExecutor(doc, executor, name)...)
+	}
+
+	title := "Extract executor"
+	switch others {
+	case 0:
+	case 1:
+		title = "Extract executor and use it in the other job with the same configuration"
+	default:
+		title = fmt.Sprintf("Extract executor and use it in the %d other jobs with the same configuration", others)
+	}
+
+	return []protocol.CodeAction{createCodeAction(title, protocol.RefactorExtract, doc, edits)}
+}
+
+// Returns the definition of an executor with the configuration of the job
+func executorDefinition(doc *parser.YamlDocument, executor jobExecutor, name string, unit int) string {
+	lines := []string{indent(unit) + name + ":"}
+	for _, pair := range executor.Pairs {
+		text := reindentText(doc.Content, pair.StartByte(), nodeEnd(doc, pair), nil, int(pair.StartPoint().Column), 2*unit)
+		lines = append(lines, indent(2*unit)+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Replaces the executor configuration of the job by a reference to the
+// executor
+func useExecutor(doc *parser.YamlDocument, executor jobExecutor, name string) []protocol.TextEdit {
+	edits := []protocol.TextEdit{{Range: nodeRange(doc, executor.Replaced[0]), NewText: "executor: " + name}}
+	for _, pair := range executor.Replaced[1:] {
+		edits = append(edits, deleteLines(doc, pair))
+	}
+	return edits
+}
+
+// Names the executor after the image of the job
+func executorName(job ast.Job) string {
+	switch {
+	case len(job.Docker.Image) > 0:
+		name := strings.ToLower(job.Docker.Image[0].Image.Name)
+		name = strings.Trim(invalidParameterCharacters.ReplaceAllString(name, "-"), "_-")
+		if name == "" || parameterPlaceholder.MatchString(job.Docker.Image[0].Image.Name) {
+			return "docker"
+		}
+		return name
+	case !utils.IsDefaultRange(job.MachineRange):
+		return "machine"
+	case !utils.IsDefaultRange(job.MacOSRange):
+		return "macos"
+	default:
+		return "default"
+	}
+}
+
+// InlineExecutor returns the code action replacing the `executor` of the job
+// at the range by the configuration of the executor, with its parameters
+// substituted
+func InlineExecutor(doc *parser.YamlDocument, rng protocol.Range) []protocol.CodeAction {
+	if doc.OrbSourceFile != nil {
+		return nil
+	}
+
+	start, end := rangeToBytes(doc, rng)
+
+	var reference *sitter.Node
+	var job ast.Job
+	jobKeys := []string{}
+	forEachDefinition(doc, "jobs", func(name string, _ *sitter.Node, definition *sitter.Node) {
+		if reference != nil {
+			return
+		}
+
+		keys := []string{}
+		forEachPair(doc, definition, func(key string, pair *sitter.Node, _ *sitter.Node) {
+			keys = append(keys, key)
+			if key == "executor" && intersects(pair, start, end) {
+				reference = pair
+				job = doc.Jobs[name]
+			}
+		})
+
+		if reference != nil {
+			jobKeys = keys
+		}
+	})
+	if reference == nil {
+		return nil
+	}
+
+	executor, ok := doc.Executors[job.Executor]
+	if !ok {
+		return nil
+	}
+
+	var executorPairs []*sitter.Node
+	forEachDefinition(doc, "executors", func(name string, _ *sitter.Node, definition *sitter.Node) {
+		if name != job.Executor {
+			return
+		}
+
+		forEachPair(doc, definition, func(key string, pair *sitter.Node, _ *sitter.Node) {
+			if !slices.Contains(executorOnlyKeys, key) {
+				executorPairs = append(executorPairs, pair)
+			}
+		})
+	})
+	if len(executorPairs) == 0 {
+		return nil
+	}
+
+	column := int(reference.StartPoint().Column)
+	lines := []string{}
+	for _, pair := range executorPairs {
+		key := doc.GetNodeText(pair.ChildByFieldName("key"))
+		if slices.Contains(jobKeys, key) {
+			// The job would have the key twice
+			return nil
+		}
+
+		replacements, ok := substituteParameters(doc, pair.StartByte(), nodeEnd(doc, pair), executor.GetParameters(), job.ExecutorParameters)
+		if !ok {
+			return nil
+		}
+		lines = append(lines, reindentText(doc.Content, pair.StartByte(), nodeEnd(doc, pair), replacements, int(pair.StartPoint().Column), column))
+	}
+
+	edit := protocol.TextEdit{
+		Range:   nodeRange(doc, reference),
+		NewText: strings.Join(lines, "\n"+indent(column)),
+	}
+
+	return []protocol.CodeAction{
+		createCodeAction("Inline executor", protocol.RefactorInline, doc, []protocol.TextEdit{edit}),
+	}
+}
@@ -0,0 +1,128 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+func TestExtractExecutor(t *testing.T) {
+	doc := parseDocument(t, `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/node:20.1
+    resource_class: large
+    environment:
+      NODE_ENV: test
+    steps:
+      - checkout
+  test:
+    # Same configuration, in another order
+    resource_class: large
+    docker:
+      - image: cimg/node:20.1
+    environment:
+      NODE_ENV: test
+    steps:
+      - checkout
+  deploy:
+    docker:
+      - image: cimg/node:20.1
+    steps:
+      - checkout
+`)
+
+	// Cursor on the docker key of the job build
+	rng := protocol.Range{Start: protocol.Position{Line: 4, Character: 6}, End: protocol.Position{Line: 4, Character: 6}}
+	codeActions := ExtractExecutor(&doc, rng)
+	assert.Len(t, codeActions, 1)
+	assert.Equal(t, "Extract executor and use it in the other job with the same configuration", codeActions[0].Title)
+	assert.Equal(t, protocol.RefactorExtract, codeActions[0].Kind)
+	assert.Equal(t, `version: 2.1
+
+executors:
+  node:
+    docker:
+      - image: cimg/node:20.1
+    resource_class: large
+    environment:
+      NODE_ENV: test
+
+jobs:
+  build:
+    executor: node
+    steps:
+      - checkout
+  test:
+    # Same configuration, in another order
+    executor: node
+    steps:
+      - checkout
+  deploy:
+    docker:
+      - image: cimg/node:20.1
+    steps:
+      - checkout
+`, applyCodeAction(doc, codeActions[0]))
+
+	// Not on the executor of a job
+	rng = protocol.Range{Start: protocol.Position{Line: 10, Character: 10}, End: protocol.Position{Line: 10, Character: 10}}
+	assert.Empty(t, ExtractExecutor(&doc, rng))
+}
+
+func TestInlineExecutor(t *testing.T) {
+	doc := parseDocument(t, `version: 2.1
+
+executors:
+  node:
+    description: Node.js image
+    parameters:
+      tag:
+        type: string
+        default: "20.1"
+      size:
+        type: string
+    docker:
+      - image: cimg/node:<< parameters.tag >>
+    resource_class: << parameters.size >>
+
+jobs:
+  build:
+    executor:
+      name: node
+      size: large
+    steps:
+      - checkout
+`)
+
+	rng := protocol.Range{Start: protocol.Position{Line: 17, Character: 6}, End: protocol.Position{Line: 17, Character: 6}}
+	codeActions := InlineExecutor(&doc, rng)
+	assert.Len(t, codeActions, 1)
+	assert.Equal(t, protocol.RefactorInline, codeActions[0].Kind)
+	assert.Equal(t, `version: 2.1
+
+executors:
+  node:
+    description: Node.js image
+    parameters:
+      tag:
+        type: string
+        default: "20.1"
+      size:
+        type: string
+    docker:
+      - image: cimg/node:<< parameters.tag >>
+    resource_class: << parameters.size >>
+
+jobs:
+  build:
+    docker:
+      - image: cimg/node:20.1
+    resource_class: large
+    steps:
+      - checkout
+`, applyCodeAction(doc, codeActions[0]))
+}
@@ -0,0 +1,397 @@
+package refactor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+const extractedCommandName = "extracted-command"
+
+// ExtractCommand returns the code actions moving the steps selected in a job
+// or in a command to a new command, the selection being replaced by an
+// invocation of the command.
+//
+// When the same steps are found elsewhere in the document, a second action
+// also replaces them. The values differing between the copies become
+// parameters of the command.
+//
+// The parameters of the job or of the command used by the steps, as
+// `<< parameters.x >>`, are declared by the new command too and passed on by
+// the invocation
+func ExtractCommand(doc *parser.YamlDocument, rng protocol.Range) []protocol.CodeAction {
+	if doc.Version < 2.1 || doc.OrbSourceFile != nil || rng.Start == rng.End {
+		return nil
+	}
+
+	lists := stepLists(doc)
+	start, end := rangeToBytes(doc, rng)
+	listIndex, first, last := selectedSteps(lists, start, end)
+	if listIndex < 0 {
+		return nil
+	}
+
+	selection := stepsCopy{Items: lists[listIndex].Items[first : last+1], Parameters: lists[listIndex].Parameters}
+	selection.Tokens = tokensOfNodes(doc, selection.Items)
+	copies := findCopies(doc, lists, listIndex, first, selection)
+
+	name := uniqueName(doc, extractedCommandName, nil)
+	unit := indentUnit(doc)
+
+	extraction := newExtraction(doc, name, unit, selection, nil)
+	edits, ok := extraction.edits()
+	if !ok {
+		return nil
+	}
+
+	res := []protocol.CodeAction{
+		createCodeAction("Extract steps into a new command", protocol.RefactorExtract, doc, edits),
+	}
+
+	if len(copies) > 0 {
+		extraction = newExtraction(doc, name, unit, selection, copies)
+		edits, _ = extraction.edits()
+
+		title := "Extract steps into a new command and replace the similar occurrence"
+		if len(copies) > 1 {
+			title = fmt.Sprintf("Extract steps into a new command and replace the %d similar occurrences", len(copies))
+		}
+		res = append(res, createCodeAction(title, protocol.RefactorExtract, doc, edits))
+	}
+
+	return res
+}
+
+// Consecutive steps of a step list
+type stepsCopy struct {
+	Items  []*sitter.Node
+	Tokens []token
+	// Indexes of the tokens differing from the selection
+	Diff []int
+	// Parameters of the job or of the command holding the steps
+	Parameters *sitter.Node
+}
+
+// Returns the step list intersecting the byte range, with the indexes of the
+// first and last steps selected. The selection must be inside a single list
+func selectedSteps(lists []stepList, start uint32, end uint32) (int, int, int) {
+	listIndex, first, last := -1, -1, -1
+
+	for i, list := range lists {
+		for j, item := range list.Items {
+			if item.StartByte() >= end || start >= item.EndByte() {
+				continue
+			}
+
+			if listIndex >= 0 && listIndex != i {
+				return -1, -1, -1
+			}
+
+			if first < 0 {
+				first = j
+			}
+			listIndex, last = i, j
+		}
+	}
+
+	return listIndex, first, last
+}
+
+// Returns the sequences of steps similar to the selection, see similarTokens.
+// They do not overlap each other nor the selection, and their job or command
+// declares the parameters the steps have in common with the selection
+func findCopies(doc *parser.YamlDocument, lists []stepList, listIndex int, first int, selection stepsCopy) []stepsCopy {
+	copies := []stepsCopy{}
+	size := len(selection.Items)
+
+	for i, list := range lists {
+		for j := 0; j+size <= len(list.Items); {
+			if i == listIndex && j < first+size && first < j+size {
+				j++
+				continue
+			}
+
+			candidate := stepsCopy{Items: list.Items[j : j+size], Parameters: list.Parameters}
+			candidate.Tokens = tokensOfNodes(doc, candidate.Items)
+
+			diff, ok := similarTokens(selection.Tokens, candidate.Tokens)
+			if ok {
+				_, ok = forwardedParameters(doc, candidate, skipped(diff))
+			}
+			if !ok {
+				j++
+				continue
+			}
+
+			candidate.Diff = diff
+			copies = append(copies, candidate)
+			j += size
+		}
+	}
+
+	return copies
+}
+
+type extractedParameter struct {
+	Name string
+	Type string
+	// Index of the token replaced by the parameter
+	Token int
+}
+
+// A parameter of the job or of the command holding the steps, used by the
+// steps
+type forwardedParameter struct {
+	Name string
+	// Pair of the definition of the parameter
+	Pair *sitter.Node
+}
+
+type extraction struct {
+	doc        *parser.YamlDocument
+	name       string
+	unit       int
+	selection  stepsCopy
+	copies     []stepsCopy
+	parameters []extractedParameter
+	forwarded  []forwardedParameter
+	// Whether the steps use parameters their job or command doesn't declare
+	undeclared bool
+}
+
+func newExtraction(doc *parser.YamlDocument, name string, unit int, selection stepsCopy, copies []stepsCopy) extraction {
+	extraction := extraction{
+		doc:       doc,
+		name:      name,
+		unit:      unit,
+		selection: selection,
+		copies:    copies,
+	}
+
+	differing := map[int]bool{}
+	for _, stepsCopy := range copies {
+		for _, index := range stepsCopy.Diff {
+			differing[index] = true
+		}
+	}
+
+	indexes := []int{}
+	for index := range differing {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	forwarded, ok := forwardedParameters(doc, selection, differing)
+	extraction.forwarded, extraction.undeclared = forwarded, !ok
+
+	usedNames := map[string]bool{}
+	for _, parameter := range forwarded {
+		usedNames[parameter.Name] = true
+	}
+	for _, index := range indexes {
+		values := []token{selection.Tokens[index]}
+		for _, stepsCopy := range copies {
+			values = append(values, stepsCopy.Tokens[index])
+		}
+
+		name := parameterName(doc, selection.Tokens[index].Node)
+		for i := 2; usedNames[name]; i++ {
+			name = parameterName(doc, selection.Tokens[index].Node) + "-" + strconv.Itoa(i)
+		}
+		usedNames[name] = true
+
+		extraction.parameters = append(extraction.parameters, extractedParameter{
+			Name:  name,
+			Type:  parameterType(values),
+			Token: index,
+		})
+	}
+
+	return extraction
+}
+
+func (extraction extraction) edits() ([]protocol.TextEdit, bool) {
+	if extraction.undeclared {
+		return nil, false
+	}
+
+	definition, ok := addDefinition(extraction.doc, "commands", extraction.doc.CommandsRange, extraction.definition())
+	if !ok {
+		return nil, false
+	}
+
+	edits := []protocol.TextEdit{definition, extraction.invocationEdit(extraction.selection)}
+	for _, stepsCopy := range extraction.copies {
+		edits = append(edits, extraction.invocationEdit(stepsCopy))
+	}
+
+	return edits, true
+}
+
+// Returns the definition of the command, without final line break
+func (extraction extraction) definition() string {
+	unit := extraction.unit
+	lines := []string{indent(unit) + extraction.name + ":"}
+
+	replacements := map[*sitter.Node]string{}
+	if len(extraction.forwarded)+len(extraction.parameters) > 0 {
+		lines = append(lines, indent(2*unit)+"parameters:")
+		for _, parameter := range extraction.forwarded {
+			pair := parameter.Pair
+			lines = append(lines, indent(3*unit)+reindentText(
+				extraction.doc.Content,
+				pair.StartByte(),
+				nodeEnd(extraction.doc, pair),
+				nil,
+				int(pair.StartPoint().Column),
+				3*unit,
+			))
+		}
+		for _, parameter := range extraction.parameters {
+			lines = append(lines,
+				indent(3*unit)+parameter.Name+":",
+				indent(4*unit)+"type: "+parameter.Type,
+			)
+			replacements[extraction.selection.Tokens[parameter.Token].Node] = "<< parameters." + parameter.Name + " >>"
+		}
+	}
+
+	items := extraction.selection.Items
+	steps := reindentText(
+		extraction.doc.Content,
+		items[0].StartByte(),
+		nodeEnd(extraction.doc, items[len(items)-1]),
+		replacements,
+		int(items[0].StartPoint().Column),
+		3*unit,
+	)
+	lines = append(lines, indent(2*unit)+"steps:", indent(3*unit)+steps)
+
+	return strings.Join(lines, "\n")
+}
+
+// Replaces the steps by an invocation of the command, with their values of the
+// parameters
+func (extraction extraction) invocationEdit(steps stepsCopy) protocol.TextEdit {
+	invocation := "- " + extraction.name
+	if len(extraction.forwarded)+len(extraction.parameters) > 0 {
+		invocation += ":"
+		column := int(steps.Items[0].StartPoint().Column) + 2 + extraction.unit
+		for _, parameter := range extraction.forwarded {
+			invocation += "\n" + indent(column) + parameter.Name + ": << parameters." + parameter.Name + " >>"
+		}
+		for _, parameter := range extraction.parameters {
+			invocation += "\n" + indent(column) + parameter.Name + ": " + steps.Tokens[parameter.Token].Text
+		}
+	}
+
+	first, last := steps.Items[0], steps.Items[len(steps.Items)-1]
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: nodeRange(extraction.doc, first).Start,
+			End:   nodeRange(extraction.doc, last).End,
+		},
+		NewText: invocation,
+	}
+}
+
+// Returns the parameters used by the tokens of the steps, skipping the tokens
+// replaced by the parameters of the new command, in their order of use. False
+// when one of them is not declared by the job or the command of the steps
+func forwardedParameters(doc *parser.YamlDocument, steps stepsCopy, skip map[int]bool) ([]forwardedParameter, bool) {
+	res := []forwardedParameter{}
+	seen := map[string]bool{}
+
+	for i, token := range steps.Tokens {
+		if skip[i] {
+			continue
+		}
+
+		for _, match := range parameterReference.FindAllStringSubmatch(token.Text, -1) {
+			name := match[1]
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			var definition *sitter.Node
+			forEachPair(doc, steps.Parameters, func(key string, pair *sitter.Node, _ *sitter.Node) {
+				if key == name {
+					definition = pair
+				}
+			})
+			if definition == nil {
+				return nil, false
+			}
+			res = append(res, forwardedParameter{Name: name, Pair: definition})
+		}
+	}
+
+	return res, true
+}
+
+func skipped(indexes []int) map[int]bool {
+	res := map[int]bool{}
+	for _, index := range indexes {
+		res[index] = true
+	}
+	return res
+}
+
+var invalidParameterCharacters = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// Names the parameter after the key holding the value, `command` for the
+// value of a `run` step
+func parameterName(doc *parser.YamlDocument, node *sitter.Node) string {
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if parent.Type() != "block_mapping_pair" && parent.Type() != "flow_pair" {
+			continue
+		}
+
+		key := doc.GetNodeText(parent.ChildByFieldName("key"))
+		if key == "run" {
+			return "command"
+		}
+
+		name := strings.Trim(invalidParameterCharacters.ReplaceAllString(strings.ToLower(key), "_"), "_-")
+		if name != "" {
+			return name
+		}
+		break
+	}
+
+	return "value"
+}
+
+// Returns the most specific type matching all the values
+func parameterType(values []token) string {
+	isBoolean, isInteger := true, true
+	for _, value := range values {
+		plain := value.Node.Type() == "plain_scalar"
+		if !plain || (value.Text != "true" && value.Text != "false") {
+			isBoolean = false
+		}
+		if _, err := strconv.Atoi(value.Text); !plain || err != nil {
+			isInteger = false
+		}
+	}
+
+	switch {
+	case isBoolean:
+		return "boolean"
+	case isInteger:
+		return "integer"
+	default:
+		return "string"
+	}
+}
+
+func indent(width int) string {
+	return strings.Repeat(" ", width)
+}
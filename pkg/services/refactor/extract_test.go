@@ -0,0 +1,368 @@
+package refactor
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func parseDocument(t *testing.T, content string) parser.YamlDocument {
+	doc, err := parser.ParseFromContent([]byte(content), &utils.LsContext{}, uri.File("config.yml"), protocol.Position{})
+	assert.NoError(t, err)
+	return doc
+}
+
+// Returns the content of the document once the edits of the code action
+// applied
+func applyCodeAction(doc parser.YamlDocument, codeAction protocol.CodeAction) string {
+	edits := append([]protocol.TextEdit{}, codeAction.Edit.Changes[doc.URI]...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		return utils.PosToIndex(edits[i].Range.Start, doc.Content) > utils.PosToIndex(edits[j].Range.Start, doc.Content)
+	})
+
+	content := string(doc.Content)
+	for _, edit := range edits {
+		start := utils.PosToIndex(edit.Range.Start, doc.Content)
+		end := utils.PosToIndex(edit.Range.End, doc.Content)
+		content = content[:start] + edit.NewText + content[end:]
+	}
+	return content
+}
+
+const extractConfig = `version: 2.1
+
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - checkout
+      - run: go build ./...
+      - run:
+          name: Test
+          command: go test ./pkg
+      - store_test_results:
+          path: results
+
+  lint:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - checkout
+      - run: go build ./...
+      - run:
+          name: Test
+          command: go test ./cmd
+
+workflows:
+  main:
+    jobs:
+      - build
+      - lint
+`
+
+func TestExtractCommand(t *testing.T) {
+	doc := parseDocument(t, extractConfig)
+
+	// From `- checkout` to the `run` step of the job build
+	rng := protocol.Range{
+		Start: protocol.Position{Line: 7, Character: 8},
+		End:   protocol.Position{Line: 11, Character: 10},
+	}
+	codeActions := ExtractCommand(&doc, rng)
+	assert.Len(t, codeActions, 2)
+
+	assert.Equal(t, "Extract steps into a new command", codeActions[0].Title)
+	assert.Equal(t, protocol.RefactorExtract, codeActions[0].Kind)
+	assert.Equal(t, `version: 2.1
+
+commands:
+  extracted-command:
+    steps:
+      - checkout
+      - run: go build ./...
+      - run:
+          name: Test
+          command: go test ./pkg
+
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - extracted-command
+      - store_test_results:
+          path: results
+
+  lint:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - checkout
+      - run: go build ./...
+      - run:
+          name: Test
+          command: go test ./cmd
+
+workflows:
+  main:
+    jobs:
+      - build
+      - lint
+`, applyCodeAction(doc, codeActions[0]))
+
+	assert.Equal(t, "Extract steps into a new command and replace the similar occurrence", codeActions[1].Title)
+	assert.Equal(t, `version: 2.1
+
+commands:
+  extracted-command:
+    parameters:
+      command:
+        type: string
+    steps:
+      - checkout
+      - run: go build ./...
+      - run:
+          name: Test
+          command: << parameters.command >>
+
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - extracted-command:
+          command: go test ./pkg
+      - store_test_results:
+          path: results
+
+  lint:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - extracted-command:
+          command: go test ./cmd
+
+workflows:
+  main:
+    jobs:
+      - build
+      - lint
+`, applyCodeAction(doc, codeActions[1]))
+}
+
+func TestExtractCommandWithCommands(t *testing.T) {
+	doc := parseDocument(t, `version: 2.1
+
+commands:
+  extracted-command:
+    steps:
+      - checkout
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:current
+    steps:
+      - run: make
+      - run: make test
+  deploy:
+    docker:
+      - image: cimg/base:current
+    steps:
+      - run: make
+      - run: make test
+`)
+
+	// Only the second step of the job build
+	rng := protocol.Range{
+		Start: protocol.Position{Line: 13, Character: 8},
+		End:   protocol.Position{Line: 13, Character: 12},
+	}
+	codeActions := ExtractCommand(&doc, rng)
+	assert.Len(t, codeActions, 2)
+	assert.Equal(t, `version: 2.1
+
+commands:
+  extracted-command:
+    steps:
+      - checkout
+  extracted-command-2:
+    steps:
+      - run: make test
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:current
+    steps:
+      - run: make
+      - extracted-command-2
+  deploy:
+    docker:
+      - image: cimg/base:current
+    steps:
+      - run: make
+      - extracted-command-2
+`, applyCodeAction(doc, codeActions[1]))
+}
+
+func TestExtractCommandWithParameters(t *testing.T) {
+	doc := parseDocument(t, `version: 2.1
+
+jobs:
+  test:
+    parameters:
+      version:
+        type: string
+        default: "18"
+    docker:
+      - image: cimg/node:current
+    steps:
+      - checkout
+      - run: nvm use << parameters.version >>
+      - run: npm test
+  lint:
+    parameters:
+      version:
+        type: string
+    docker:
+      - image: cimg/node:current
+    steps:
+      - run: nvm use << parameters.version >>
+      - run: npm run lint
+`)
+
+	// The two last steps of the job test
+	rng := protocol.Range{
+		Start: protocol.Position{Line: 12, Character: 8},
+		End:   protocol.Position{Line: 13, Character: 20},
+	}
+	codeActions := ExtractCommand(&doc, rng)
+	assert.Len(t, codeActions, 2)
+	assert.Equal(t, `version: 2.1
+
+commands:
+  extracted-command:
+    parameters:
+      version:
+        type: string
+        default: "18"
+    steps:
+      - run: nvm use << parameters.version >>
+      - run: npm test
+
+jobs:
+  test:
+    parameters:
+      version:
+        type: string
+        default: "18"
+    docker:
+      - image: cimg/node:current
+    steps:
+      - checkout
+      - extracted-command:
+          version: << parameters.version >>
+  lint:
+    parameters:
+      version:
+        type: string
+    docker:
+      - image: cimg/node:current
+    steps:
+      - run: nvm use << parameters.version >>
+      - run: npm run lint
+`, applyCodeAction(doc, codeActions[0]))
+
+	// The command of the second step differs, the copy of the job lint
+	// passes its own parameter
+	assert.Equal(t, `version: 2.1
+
+commands:
+  extracted-command:
+    parameters:
+      version:
+        type: string
+        default: "18"
+      command:
+        type: string
+    steps:
+      - run: nvm use << parameters.version >>
+      - run: << parameters.command >>
+
+jobs:
+  test:
+    parameters:
+      version:
+        type: string
+        default: "18"
+    docker:
+      - image: cimg/node:current
+    steps:
+      - checkout
+      - extracted-command:
+          version: << parameters.version >>
+          command: npm test
+  lint:
+    parameters:
+      version:
+        type: string
+    docker:
+      - image: cimg/node:current
+    steps:
+      - extracted-command:
+          version: << parameters.version >>
+          command: npm run lint
+`, applyCodeAction(doc, codeActions[1]))
+
+	// The parameter is not declared by the job
+	doc = parseDocument(t, `version: 2.1
+
+jobs:
+  test:
+    docker:
+      - image: cimg/node:current
+    steps:
+      - run: nvm use << parameters.version >>
+`)
+	rng = protocol.Range{
+		Start: protocol.Position{Line: 7, Character: 8},
+		End:   protocol.Position{Line: 7, Character: 20},
+	}
+	assert.Empty(t, ExtractCommand(&doc, rng))
+}
+
+func TestExtractCommandUnavailable(t *testing.T) {
+	doc := parseDocument(t, extractConfig)
+
+	// Empty selection
+	rng := protocol.Range{Start: protocol.Position{Line: 7, Character: 8}, End: protocol.Position{Line: 7, Character: 8}}
+	assert.Empty(t, ExtractCommand(&doc, rng))
+
+	// Across two jobs
+	rng = protocol.Range{Start: protocol.Position{Line: 13, Character: 8}, End: protocol.Position{Line: 20, Character: 10}}
+	assert.Empty(t, ExtractCommand(&doc, rng))
+
+	// Outside of the steps
+	rng = protocol.Range{Start: protocol.Position{Line: 5, Character: 8}, End: protocol.Position{Line: 5, Character: 20}}
+	assert.Empty(t, ExtractCommand(&doc, rng))
+
+	// Commands are not available before 2.1
+	doc = parseDocument(t, "version: 2\n"+extractConfig[len("version: 2.1\n"):])
+	rng = protocol.Range{Start: protocol.Position{Line: 7, Character: 8}, End: protocol.Position{Line: 11, Character: 10}}
+	assert.Empty(t, ExtractCommand(&doc, rng))
+}
+
+func TestIsKindRequested(t *testing.T) {
+	assert.True(t, IsKindRequested(nil, protocol.RefactorExtract))
+	assert.True(t, IsKindRequested([]protocol.CodeActionKind{protocol.Refactor}, protocol.RefactorExtract))
+	assert.True(t, IsKindRequested([]protocol.CodeActionKind{protocol.RefactorExtract}, protocol.RefactorExtract))
+	assert.False(t, IsKindRequested([]protocol.CodeActionKind{protocol.QuickFix}, protocol.RefactorExtract))
+	assert.False(t, IsKindRequested([]protocol.CodeActionKind{"refactor.ex"}, protocol.RefactorExtract))
+}
@@ -0,0 +1,129 @@
+package refactor
+
+import (
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+// InlineCommand returns the code action replacing the invocation of a command
+// of the document at the range by the steps of the command, with its
+// parameters substituted
+func InlineCommand(doc *parser.YamlDocument, rng protocol.Range) []protocol.CodeAction {
+	if doc.OrbSourceFile != nil {
+		return nil
+	}
+
+	start, end := rangeToBytes(doc, rng)
+
+	var invocation *sitter.Node
+	for _, list := range stepLists(doc) {
+		for _, item := range list.Items {
+			if intersects(item, start, end) && invocation == nil {
+				invocation = item
+			}
+		}
+	}
+	if invocation == nil {
+		return nil
+	}
+
+	name := invokedName(doc, invocation)
+	command, ok := doc.Commands[name]
+	if !ok {
+		return nil
+	}
+
+	for _, parameter := range command.Parameters {
+		if parameter.GetType() == "steps" {
+			// The steps given to the command would have to be inlined too
+			return nil
+		}
+	}
+
+	step, _ := findNamedStep(doc, allSteps(doc), name, invocation)
+
+	var sequence *sitter.Node
+	forEachDefinition(doc, "commands", func(commandName string, _ *sitter.Node, definition *sitter.Node) {
+		if commandName != name {
+			return
+		}
+
+		forEachPair(doc, definition, func(key string, _ *sitter.Node, value *sitter.Node) {
+			if key == "steps" {
+				sequence = parser.GetChildOfType(value, "block_sequence")
+			}
+		})
+	})
+	if sequence == nil || sequence.NamedChildCount() == 0 {
+		return nil
+	}
+
+	first, last := sequence.NamedChild(0), sequence.NamedChild(int(sequence.NamedChildCount())-1)
+	replacements, ok := substituteParameters(doc, first.StartByte(), nodeEnd(doc, last), command.Parameters, step.Parameters)
+	if !ok {
+		return nil
+	}
+	steps := reindentText(doc.Content, first.StartByte(), nodeEnd(doc, last), replacements, int(first.StartPoint().Column), int(invocation.StartPoint().Column))
+
+	edit := protocol.TextEdit{
+		Range:   nodeRange(doc, invocation),
+		NewText: steps,
+	}
+
+	return []protocol.CodeAction{
+		createCodeAction("Inline command", protocol.RefactorInline, doc, []protocol.TextEdit{edit}),
+	}
+}
+
+// Returns the name of the command or orb command invoked by the step
+func invokedName(doc *parser.YamlDocument, item *sitter.Node) string {
+	value := item.NamedChild(0)
+	if value == nil {
+		return ""
+	}
+
+	if value.Type() == "flow_node" {
+		return doc.GetNodeText(value)
+	}
+
+	mapping := parser.GetChildMapping(value)
+	if mapping == nil || mapping.NamedChildCount() != 1 {
+		return ""
+	}
+	return doc.GetNodeText(mapping.NamedChild(0).ChildByFieldName("key"))
+}
+
+// Returns the steps of the jobs and of the commands
+func allSteps(doc *parser.YamlDocument) []ast.Step {
+	steps := []ast.Step{}
+	for _, job := range doc.Jobs {
+		steps = append(steps, job.Steps...)
+	}
+	for _, command := range doc.Commands {
+		steps = append(steps, command.Steps...)
+	}
+	return steps
+}
+
+// Returns the invocation of the command parsed from the node
+func findNamedStep(doc *parser.YamlDocument, steps []ast.Step, name string, node *sitter.Node) (ast.NamedStep, bool) {
+	for _, step := range steps {
+		switch step := step.(type) {
+		case ast.NamedStep:
+			start := uint32(utils.PosToIndex(step.Range.Start, doc.Content))
+			if step.Name == name && node.StartByte() <= start && start < node.EndByte() {
+				return step, true
+			}
+
+		case ast.Steps:
+			if res, ok := findNamedStep(doc, step.Steps, name, node); ok {
+				return res, true
+			}
+		}
+	}
+
+	return ast.NamedStep{}, false
+}
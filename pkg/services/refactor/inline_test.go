@@ -0,0 +1,237 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInlineCommand(t *testing.T) {
+	doc := parseDocument(t, `version: 2.1
+
+commands:
+  install:
+    parameters:
+      directory:
+        type: string
+        default: "."
+      cache:
+        type: boolean
+    steps:
+      - restore_cache:
+          key: deps-{{ checksum "<< parameters.directory >>/package-lock.json" }}
+      - run:
+          name: Install
+          command: npm ci --prefix << parameters.directory >>
+
+jobs:
+  build:
+    docker:
+      - image: cimg/node:20.1
+    steps:
+      - checkout
+      - install:
+          directory: app
+      - run: npm test
+`)
+
+	rng := protocol.Range{Start: protocol.Position{Line: 23, Character: 10}, End: protocol.Position{Line: 23, Character: 10}}
+	codeActions := InlineCommand(&doc, rng)
+	assert.Len(t, codeActions, 1)
+	assert.Equal(t, "Inline command", codeActions[0].Title)
+	assert.Equal(t, protocol.RefactorInline, codeActions[0].Kind)
+	assert.Equal(t, `version: 2.1
+
+commands:
+  install:
+    parameters:
+      directory:
+        type: string
+        default: "."
+      cache:
+        type: boolean
+    steps:
+      - restore_cache:
+          key: deps-{{ checksum "<< parameters.directory >>/package-lock.json" }}
+      - run:
+          name: Install
+          command: npm ci --prefix << parameters.directory >>
+
+jobs:
+  build:
+    docker:
+      - image: cimg/node:20.1
+    steps:
+      - checkout
+      - restore_cache:
+          key: deps-{{ checksum "app/package-lock.json" }}
+      - run:
+          name: Install
+          command: npm ci --prefix app
+      - run: npm test
+`, applyCodeAction(doc, codeActions[0]))
+
+	// Not a command of the document
+	rng = protocol.Range{Start: protocol.Position{Line: 22, Character: 10}, End: protocol.Position{Line: 22, Character: 10}}
+	assert.Empty(t, InlineCommand(&doc, rng))
+}
+
+func TestInlineCommandUnsubstitutedParameters(t *testing.T) {
+	testCases := []struct {
+		name    string
+		command string
+		step    string
+	}{
+		{
+			name: "Required parameter without value",
+			command: `    parameters:
+      directory:
+        type: string
+    steps:
+      - run: npm ci --prefix << parameters.directory >>`,
+			step: `      - install`,
+		},
+		{
+			name: "Multi-line value",
+			command: `    parameters:
+      script:
+        type: string
+    steps:
+      - run: << parameters.script >>`,
+			step: `      - install:
+          script: |
+            npm ci
+            npm test`,
+		},
+		{
+			name: "Conditional section",
+			command: `    parameters:
+      cache:
+        type: boolean
+        default: true
+    steps:
+      - run: npm ci <<# parameters.cache >>--prefer-offline<</ parameters.cache >>`,
+			step: `      - install`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, `version: 2.1
+
+commands:
+  install:
+`+tt.command+`
+
+jobs:
+  build:
+    docker:
+      - image: cimg/node:20.1
+    steps:
+`+tt.step+`
+`)
+
+			pos := utils.IndexToPos(strings.Index(string(doc.Content), tt.step)+len("      - "), doc.Content)
+			assert.Empty(t, InlineCommand(&doc, protocol.Range{Start: pos, End: pos}))
+		})
+	}
+}
+
+func TestInlineCommandQuotedValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		run      string
+		value    string
+		expected string
+	}{
+		{
+			name:     "Value with a colon in a plain scalar",
+			run:      `- run: echo << parameters.msg >>`,
+			value:    `"a: b"`,
+			expected: `- run: "echo a: b"`,
+		},
+		{
+			name:     "Value with a comment in a plain scalar",
+			run:      `- run: echo << parameters.msg >>`,
+			value:    `"a #b"`,
+			expected: `- run: "echo a #b"`,
+		},
+		{
+			name:     "Value starting a plain scalar with an alias",
+			run:      `- run: << parameters.msg >> is removed`,
+			value:    `"*.log"`,
+			expected: `- run: "*.log is removed"`,
+		},
+		{
+			name:     "Value keeping a plain scalar",
+			run:      `- run: echo << parameters.msg >>`,
+			value:    `'hello world'`,
+			expected: `- run: echo hello world`,
+		},
+		{
+			name:     "Value in a double-quoted scalar",
+			run:      `- run: "echo << parameters.msg >>"`,
+			value:    `'say "hi"'`,
+			expected: `- run: "echo say \"hi\""`,
+		},
+		{
+			name:     "Value in a single-quoted scalar",
+			run:      `- run: 'echo << parameters.msg >>'`,
+			value:    `"it's"`,
+			expected: `- run: 'echo it''s'`,
+		},
+		{
+			name:     "Value as the whole scalar",
+			run:      `- run: << parameters.msg >>`,
+			value:    `"a: b"`,
+			expected: `- run: "a: b"`,
+		},
+		{
+			name: "Value in a block scalar",
+			run: `- run:
+          command: |
+            echo << parameters.msg >>`,
+			value: `"a: b"`,
+			expected: `- run:
+          command: |
+            echo a: b`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseDocument(t, `version: 2.1
+
+commands:
+  say:
+    parameters:
+      msg:
+        type: string
+    steps:
+      `+tt.run+`
+
+jobs:
+  build:
+    docker:
+      - image: cimg/base:2024.01
+    steps:
+      - say:
+          msg: `+tt.value+`
+`)
+
+			pos := utils.IndexToPos(strings.Index(string(doc.Content), "- say:")+len("- "), doc.Content)
+			codeActions := InlineCommand(&doc, protocol.Range{Start: pos, End: pos})
+			assert.Len(t, codeActions, 1)
+
+			inlined := applyCodeAction(doc, codeActions[0])
+			assert.Contains(t, inlined, "    steps:\n      "+tt.expected+"\n")
+
+			// The inlined step is valid YAML
+			assert.NoError(t, yaml.Unmarshal([]byte(inlined), &map[string]any{}))
+		})
+	}
+}
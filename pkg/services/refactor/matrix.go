@@ -0,0 +1,373 @@
+package refactor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+// The keys of a job invocation which are not parameters of the job
+var invocationKeys = []string{"name", "requires", "context", "filters", "type", "matrix", "pre-steps", "post-steps", "serial-group", "override-with"}
+
+// A job of the `jobs` list of a workflow
+type workflowJob struct {
+	Item    *sitter.Node
+	JobName string
+	// Name of the job in the workflow, the job name unless `name` is given
+	Name  string
+	Pairs map[string]*sitter.Node
+	Keys  []string
+}
+
+// Returns the jobs of every workflow
+func workflowJobs(doc *parser.YamlDocument) [][]workflowJob {
+	res := [][]workflowJob{}
+
+	forEachDefinition(doc, "workflows", func(_ string, _ *sitter.Node, definition *sitter.Node) {
+		forEachPair(doc, definition, func(key string, _ *sitter.Node, value *sitter.Node) {
+			sequence := parser.GetChildOfType(value, "block_sequence")
+			if key != "jobs" || sequence == nil {
+				return
+			}
+
+			jobs := []workflowJob{}
+			for i := 0; i < int(sequence.NamedChildCount()); i++ {
+				if item := sequence.NamedChild(i); item.Type() == "block_sequence_item" {
+					jobs = append(jobs, parseWorkflowJob(doc, item))
+				}
+			}
+			res = append(res, jobs)
+		})
+	})
+
+	return res
+}
+
+func parseWorkflowJob(doc *parser.YamlDocument, item *sitter.Node) workflowJob {
+	job := workflowJob{Item: item, Pairs: map[string]*sitter.Node{}}
+
+	value := item.NamedChild(0)
+	if value != nil && value.Type() == "flow_node" {
+		job.JobName = doc.GetNodeText(value)
+		job.Name = job.JobName
+		return job
+	}
+
+	mapping := parser.GetChildMapping(value)
+	if mapping == nil || mapping.NamedChildCount() == 0 {
+		return job
+	}
+
+	pair := mapping.NamedChild(0)
+	job.JobName = doc.GetNodeText(pair.ChildByFieldName("key"))
+	job.Name = job.JobName
+
+	forEachPair(doc, parser.GetChildMapping(pair.ChildByFieldName("value")), func(key string, pair *sitter.Node, value *sitter.Node) {
+		job.Pairs[key] = pair
+		job.Keys = append(job.Keys, key)
+		if key == "name" {
+			job.Name = doc.GetNodeText(value)
+		}
+	})
+
+	return job
+}
+
+// An entry of the `requires` of a job
+type requiredJob struct {
+	Entry *sitter.Node
+	// The scalar of the name, the key of the `- job: success` form
+	Name *sitter.Node
+}
+
+// Returns the sequence of the `requires` of the job with its entries
+func requiredJobs(job workflowJob) (*sitter.Node, []requiredJob) {
+	pair, ok := job.Pairs["requires"]
+	if !ok {
+		return nil, nil
+	}
+
+	value := pair.ChildByFieldName("value")
+	sequence := parser.GetChildSequence(value)
+	if sequence == nil {
+		sequence = parser.GetChildSequence(parser.GetFirstChild(value))
+	}
+	if sequence == nil {
+		return nil, nil
+	}
+
+	res := []requiredJob{}
+	for i := 0; i < int(sequence.NamedChildCount()); i++ {
+		entry := sequence.NamedChild(i)
+		name := entry
+		if entry.Type() == "block_sequence_item" {
+			name = entry.NamedChild(0)
+		}
+		if name == nil || name.Type() == "comment" {
+			continue
+		}
+
+		if mapping := parser.GetChildMapping(name); mapping != nil && mapping.NamedChildCount() > 0 {
+			name = mapping.NamedChild(0).ChildByFieldName("key")
+		}
+		res = append(res, requiredJob{Entry: entry, Name: name})
+	}
+
+	return sequence, res
+}
+
+// ConvertToMatrix returns the code action replacing the invocations of the
+// same job in a workflow, differing only by their parameters, by a single
+// invocation with a matrix. The `requires` of the other jobs of the workflow
+// are changed to the names of the matrix jobs
+func ConvertToMatrix(doc *parser.YamlDocument, rng protocol.Range) []protocol.CodeAction {
+	if doc.Version < 2.1 || doc.OrbSourceFile != nil {
+		return nil
+	}
+
+	start, end := rangeToBytes(doc, rng)
+
+	for _, jobs := range workflowJobs(doc) {
+		for _, job := range jobs {
+			if !intersects(job.Item, start, end) {
+				continue
+			}
+
+			if edits, count, ok := convertToMatrix(doc, jobs, job.JobName); ok {
+				title := fmt.Sprintf("Convert the %d invocations of %s to a matrix", count, job.JobName)
+				return []protocol.CodeAction{createCodeAction(title, protocol.RefactorRewrite, doc, edits)}
+			}
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func convertToMatrix(doc *parser.YamlDocument, jobs []workflowJob, jobName string) ([]protocol.TextEdit, int, bool) {
+	group := []workflowJob{}
+	others := []workflowJob{}
+	for _, job := range jobs {
+		if job.JobName == jobName {
+			group = append(group, job)
+		} else {
+			others = append(others, job)
+		}
+	}
+	if len(group) < 2 {
+		return nil, 0, false
+	}
+
+	names := []string{}
+	for _, job := range group {
+		if _, ok := job.Pairs["matrix"]; ok {
+			return nil, 0, false
+		}
+		names = append(names, job.Name)
+	}
+
+	keysOf := func(job workflowJob) []string {
+		keys := slices.DeleteFunc(slices.Clone(job.Keys), func(key string) bool { return key == "name" })
+		sort.Strings(keys)
+		return keys
+	}
+
+	// Every invocation must have the same keys, with the same values but for
+	// the parameters
+	keys := keysOf(group[0])
+	values := map[string][]string{}
+	for _, job := range group {
+		if !slices.Equal(keys, keysOf(job)) {
+			return nil, 0, false
+		}
+
+		if _, required := requiredJobs(job); slices.ContainsFunc(required, func(required requiredJob) bool {
+			return slices.Contains(names, doc.GetNodeText(required.Name))
+		}) {
+			// The jobs of a matrix can't require each other
+			return nil, 0, false
+		}
+
+		for _, key := range keys {
+			if slices.Contains(invocationKeys, key) {
+				if !sameTokens(doc, group[0].Pairs[key], job.Pairs[key]) {
+					return nil, 0, false
+				}
+				continue
+			}
+
+			value, ok := scalarValue(doc, job.Pairs[key])
+			if !ok {
+				return nil, 0, false
+			}
+			values[key] = append(values[key], value)
+		}
+	}
+
+	// The invocations must be all the combinations of the differing values,
+	// a matrix would run more jobs otherwise
+	matrixKeys := []string{}
+	distinct := map[string][]string{}
+	combinations := 1
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if !slices.Contains(distinct[key], value) {
+				distinct[key] = append(distinct[key], value)
+			}
+		}
+
+		if len(distinct[key]) > 1 {
+			matrixKeys = append(matrixKeys, key)
+			combinations *= len(distinct[key])
+		}
+	}
+	if len(matrixKeys) == 0 || combinations != len(group) {
+		return nil, 0, false
+	}
+
+	generatedNames := map[string]string{}
+	seen := map[string]bool{}
+	for i, job := range group {
+		parts := []string{jobName}
+		for _, key := range matrixKeys {
+			parts = append(parts, strings.Trim(values[key][i], `"'`))
+		}
+
+		generated := strings.Join(parts, "-")
+		if seen[generated] {
+			return nil, 0, false
+		}
+		seen[generated] = true
+		generatedNames[job.Name] = generated
+	}
+
+	edits := []protocol.TextEdit{
+		{Range: nodeRange(doc, group[0].Item), NewText: matrixInvocation(doc, group[0], matrixKeys, distinct)},
+	}
+	for _, job := range group[1:] {
+		edits = append(edits, deleteLines(doc, job.Item))
+	}
+
+	for _, job := range others {
+		edits = append(edits, renameRequires(doc, job, jobName, generatedNames)...)
+	}
+
+	return edits, len(group), true
+}
+
+// Returns the invocation of the job with the matrix, the other keys being the
+// ones of the first invocation
+func matrixInvocation(doc *parser.YamlDocument, job workflowJob, matrixKeys []string, distinct map[string][]string) string {
+	unit := indentUnit(doc)
+	column := int(job.Pairs[job.Keys[0]].StartPoint().Column)
+
+	lines := []string{"- " + job.JobName + ":"}
+	for _, key := range job.Keys {
+		if key == "name" || slices.Contains(matrixKeys, key) {
+			continue
+		}
+
+		pair := job.Pairs[key]
+		lines = append(lines, indent(column)+reindentText(doc.Content, pair.StartByte(), nodeEnd(doc, pair), nil, int(pair.StartPoint().Column), column))
+	}
+
+	lines = append(lines, indent(column)+"matrix:", indent(column+unit)+"parameters:")
+	for _, key := range matrixKeys {
+		lines = append(lines, indent(column+2*unit)+key+": ["+strings.Join(distinct[key], ", ")+"]")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Renames the required jobs that are now part of the matrix. When all of them
+// are required, the name of the job is enough
+func renameRequires(doc *parser.YamlDocument, job workflowJob, jobName string, generatedNames map[string]string) []protocol.TextEdit {
+	sequence, entries := requiredJobs(job)
+
+	isRenamed := func(entry requiredJob) bool {
+		_, ok := generatedNames[doc.GetNodeText(entry.Name)]
+		return ok
+	}
+	renamed := slices.DeleteFunc(slices.Clone(entries), func(entry requiredJob) bool { return !isRenamed(entry) })
+	if len(renamed) == 0 {
+		return nil
+	}
+
+	requiresAll := len(renamed) == len(generatedNames)
+	newName := func(entry requiredJob) string {
+		if requiresAll {
+			return jobName
+		}
+		return generatedNames[doc.GetNodeText(entry.Name)]
+	}
+
+	if sequence.Type() == "flow_sequence" {
+		texts := []string{}
+		for _, entry := range entries {
+			if !isRenamed(entry) {
+				texts = append(texts, doc.GetRawNodeText(entry.Entry))
+				continue
+			}
+
+			start, end := entry.Entry.StartByte(), entry.Entry.EndByte()
+			text := string(doc.Content[start:entry.Name.StartByte()]) + newName(entry) + string(doc.Content[entry.Name.EndByte():end])
+			if !slices.Contains(texts, text) {
+				texts = append(texts, text)
+			}
+		}
+
+		return []protocol.TextEdit{{Range: nodeRange(doc, sequence), NewText: "[" + strings.Join(texts, ", ") + "]"}}
+	}
+
+	edits := []protocol.TextEdit{}
+	for i, entry := range renamed {
+		if requiresAll && i > 0 {
+			edits = append(edits, deleteLines(doc, entry.Entry))
+			continue
+		}
+		edits = append(edits, protocol.TextEdit{Range: nodeRange(doc, entry.Name), NewText: newName(entry)})
+	}
+
+	return edits
+}
+
+// Returns the value of the pair when it is a scalar on a single line, quoted
+// when it could not be written in a flow sequence
+func scalarValue(doc *parser.YamlDocument, pair *sitter.Node) (string, bool) {
+	value := pair.ChildByFieldName("value")
+	if value == nil || value.Type() != "flow_node" || value.StartPoint().Row != value.EndPoint().Row {
+		return "", false
+	}
+
+	scalar := parser.GetFirstChild(value)
+	switch {
+	case scalar == nil:
+		return "", false
+	case scalar.Type() == "plain_scalar" && strings.ContainsAny(doc.GetRawNodeText(scalar), ",[]{}#"):
+		return strconv.Quote(doc.GetRawNodeText(scalar)), true
+	case scalar.Type() == "plain_scalar", scalar.Type() == "double_quote_scalar", scalar.Type() == "single_quote_scalar":
+		return doc.GetRawNodeText(scalar), true
+	default:
+		return "", false
+	}
+}
+
+func sameTokens(doc *parser.YamlDocument, a *sitter.Node, b *sitter.Node) bool {
+	tokensA, tokensB := tokens(doc, a, false), tokens(doc, b, false)
+	if len(tokensA) != len(tokensB) {
+		return false
+	}
+
+	for i := range tokensA {
+		if tokensA[i].Text != tokensB[i].Text {
+			return false
+		}
+	}
+	return true
+}
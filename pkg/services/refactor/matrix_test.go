@@ -0,0 +1,145 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+const matrixConfig = `version: 2.1
+
+jobs:
+  test:
+    parameters:
+      node:
+        type: string
+      os:
+        type: string
+    docker:
+      - image: cimg/node:<< parameters.node >>
+    steps:
+      - checkout
+  deploy:
+    docker:
+      - image: cimg/base:current
+    steps:
+      - checkout
+
+workflows:
+  main:
+    jobs:
+      - test:
+          name: test-18
+          node: "18"
+          os: linux
+          context: org
+      - test:
+          name: test-20
+          node: "20"
+          os: linux
+          context: org
+      - deploy:
+          requires:
+            - test-18
+            - test-20
+  nightly:
+    jobs:
+      - test:
+          name: test-18
+          node: "18"
+          os: linux
+      - test:
+          name: test-20
+          node: "20"
+          os: mac
+`
+
+func TestConvertToMatrix(t *testing.T) {
+	doc := parseDocument(t, matrixConfig)
+
+	rng := protocol.Range{Start: protocol.Position{Line: 22, Character: 8}, End: protocol.Position{Line: 22, Character: 8}}
+	codeActions := ConvertToMatrix(&doc, rng)
+	assert.Len(t, codeActions, 1)
+	assert.Equal(t, "Convert the 2 invocations of test to a matrix", codeActions[0].Title)
+	assert.Equal(t, protocol.RefactorRewrite, codeActions[0].Kind)
+	assert.Equal(t, `version: 2.1
+
+jobs:
+  test:
+    parameters:
+      node:
+        type: string
+      os:
+        type: string
+    docker:
+      - image: cimg/node:<< parameters.node >>
+    steps:
+      - checkout
+  deploy:
+    docker:
+      - image: cimg/base:current
+    steps:
+      - checkout
+
+workflows:
+  main:
+    jobs:
+      - test:
+          os: linux
+          context: org
+          matrix:
+            parameters:
+              node: ["18", "20"]
+      - deploy:
+          requires:
+            - test
+  nightly:
+    jobs:
+      - test:
+          name: test-18
+          node: "18"
+          os: linux
+      - test:
+          name: test-20
+          node: "20"
+          os: mac
+`, applyCodeAction(doc, codeActions[0]))
+
+	// Both parameters differ, a matrix would run 4 jobs instead of 2
+	rng = protocol.Range{Start: protocol.Position{Line: 45, Character: 8}, End: protocol.Position{Line: 45, Character: 8}}
+	assert.Empty(t, ConvertToMatrix(&doc, rng))
+}
+
+func TestConvertToMatrixRequiresOne(t *testing.T) {
+	doc := parseDocument(t, `version: 2.1
+
+workflows:
+  main:
+    jobs:
+      - test:
+          name: test-18
+          node: "18"
+      - test:
+          name: test-20
+          node: "20"
+      - deploy:
+          requires: [lint, test-20]
+`)
+
+	rng := protocol.Range{Start: protocol.Position{Line: 6, Character: 10}, End: protocol.Position{Line: 6, Character: 10}}
+	codeActions := ConvertToMatrix(&doc, rng)
+	assert.Len(t, codeActions, 1)
+	assert.Equal(t, `version: 2.1
+
+workflows:
+  main:
+    jobs:
+      - test:
+          matrix:
+            parameters:
+              node: ["18", "20"]
+      - deploy:
+          requires: [lint, test-20]
+`, applyCodeAction(doc, codeActions[0]))
+}
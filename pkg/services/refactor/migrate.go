@@ -0,0 +1,174 @@
+package refactor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+// MigrateTo21 returns the edit rewriting a 2.0 config into a 2.1 one:
+//   - the steps reused through YAML anchors become commands
+//   - the executor configurations shared by several jobs become executors
+//   - the `version` of the workflows is removed
+//
+// It returns false when the document is not a 2.0 config
+func MigrateTo21(doc *parser.YamlDocument) (protocol.WorkspaceEdit, bool) {
+	versionPair := rootPair(doc, "version")
+	if doc.Version == 0 || doc.Version >= 2.1 || doc.IsOrb || versionPair == nil {
+		return protocol.WorkspaceEdit{}, false
+	}
+
+	edits := []protocol.TextEdit{
+		{Range: nodeRange(doc, versionPair.ChildByFieldName("value")), NewText: "2.1"},
+	}
+
+	if workflows := rootPair(doc, "workflows"); workflows != nil {
+		forEachPair(doc, parser.GetChildMapping(workflows.ChildByFieldName("value")), func(key string, pair *sitter.Node, _ *sitter.Node) {
+			if key == "version" {
+				edits = append(edits, deleteLines(doc, pair))
+			}
+		})
+	}
+
+	unit := indentUnit(doc)
+	taken := map[string]bool{}
+	definitions := []protocol.TextEdit{}
+
+	executors, executorEdits := sharedExecutors(doc, unit, taken)
+	edits = append(edits, executorEdits...)
+	if len(executors) > 0 {
+		definition, ok := addDefinition(doc, "executors", doc.ExecutorsRange, strings.Join(executors, "\n"))
+		if ok {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	commands, commandEdits := anchorsToCommands(doc, unit, taken)
+	edits = append(edits, commandEdits...)
+	if len(commands) > 0 {
+		definition, ok := addDefinition(doc, "commands", doc.CommandsRange, strings.Join(commands, "\n"))
+		if ok {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	// Both sections are created at the same place when there are none
+	if len(definitions) == 2 && definitions[0].Range == definitions[1].Range {
+		definitions[0].NewText += definitions[1].NewText
+		definitions = definitions[:1]
+	}
+
+	return protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			doc.URI: append(definitions, edits...),
+		},
+	}, true
+}
+
+// Returns the definitions of the executors shared by several jobs, with the
+// edits of the jobs using them
+func sharedExecutors(doc *parser.YamlDocument, unit int, taken map[string]bool) ([]string, []protocol.TextEdit) {
+	groups := map[string][]jobExecutor{}
+	fingerprints := []string{}
+	for _, executor := range jobExecutors(doc) {
+		if _, ok := groups[executor.Fingerprint]; !ok {
+			fingerprints = append(fingerprints, executor.Fingerprint)
+		}
+		groups[executor.Fingerprint] = append(groups[executor.Fingerprint], executor)
+	}
+
+	definitions := []string{}
+	edits := []protocol.TextEdit{}
+	for _, fingerprint := range fingerprints {
+		group := groups[fingerprint]
+		if len(group) < 2 {
+			continue
+		}
+
+		name := uniqueName(doc, executorName(doc.Jobs[group[0].Job]), taken)
+		taken[name] = true
+
+		definitions = append(definitions, executorDefinition(doc, group[0], name, unit))
+		for _, executor := range group {
+			edits = append(edits, useExecutor(doc, executor, name)...)
+		}
+	}
+
+	return definitions, edits
+}
+
+// Returns the commands made of the steps reused through aliases, with the
+// edits replacing the aliases by invocations of the commands. The anchors
+// defined on a step are replaced too, their definition outside of the steps
+// are kept
+func anchorsToCommands(doc *parser.YamlDocument, unit int, taken map[string]bool) ([]string, []protocol.TextEdit) {
+	items := map[uint32]bool{}
+	aliases := map[string][]*sitter.Node{}
+	for _, list := range stepLists(doc) {
+		for _, item := range list.Items {
+			items[item.StartByte()] = true
+
+			value := item.NamedChild(0)
+			alias := parser.GetFirstChild(value)
+			if value == nil || value.Type() != "flow_node" || alias == nil || alias.Type() != "alias" {
+				continue
+			}
+
+			name := doc.GetNodeText(alias)[1:]
+			aliases[name] = append(aliases[name], alias)
+		}
+	}
+
+	names := []string{}
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definitions := []string{}
+	edits := []protocol.TextEdit{}
+	for _, anchorName := range names {
+		anchor, ok := doc.YamlAnchors[anchorName]
+		if !ok || anchor.ValueNode == nil {
+			continue
+		}
+
+		anchorNode := parser.GetChildOfType(anchor.ValueNode, "anchor")
+		if anchorNode == nil {
+			continue
+		}
+
+		step := anchorNode.NextNamedSibling()
+		for step != nil && step.Type() == "comment" {
+			step = step.NextNamedSibling()
+		}
+		if step == nil || step.Type() == "block_sequence" || step.Type() == "flow_sequence" {
+			// A list of steps can't be used as a single step
+			continue
+		}
+
+		name := strings.Trim(invalidParameterCharacters.ReplaceAllString(strings.ToLower(anchorName), "-"), "_-")
+		name = uniqueName(doc, name, taken)
+		taken[name] = true
+
+		text := reindentText(doc.Content, step.StartByte(), nodeEnd(doc, anchor.ValueNode), nil, int(step.StartPoint().Column), 3*unit+2)
+		definitions = append(definitions, strings.Join([]string{
+			indent(unit) + name + ":",
+			indent(2*unit) + "steps:",
+			indent(3*unit) + "- " + text,
+		}, "\n"))
+
+		for _, alias := range aliases[anchorName] {
+			edits = append(edits, protocol.TextEdit{Range: nodeRange(doc, alias), NewText: name})
+		}
+
+		if parent := anchor.ValueNode.Parent(); parent != nil && items[parent.StartByte()] {
+			edits = append(edits, protocol.TextEdit{Range: nodeRange(doc, anchor.ValueNode), NewText: name})
+		}
+	}
+
+	return definitions, edits
+}
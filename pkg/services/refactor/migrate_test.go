@@ -0,0 +1,228 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+func TestMigrateTo21(t *testing.T) {
+	doc := parseDocument(t, `version: 2
+
+defaults: &defaults
+  docker:
+    - image: cimg/node:20.1
+  working_directory: ~/app
+
+restore: &restore_deps
+  restore_cache:
+    key: deps-{{ checksum "package-lock.json" }}
+
+jobs:
+  build:
+    <<: *defaults
+    steps:
+      - checkout
+      - *restore_deps
+      - run: npm ci
+  test:
+    docker:
+      - image: cimg/node:20.1
+    working_directory: ~/app
+    steps:
+      - checkout
+      - *restore_deps
+      - run: npm test
+
+workflows:
+  version: 2
+  main:
+    jobs:
+      - build
+      - test:
+          requires:
+            - build
+`)
+
+	edit, ok := MigrateTo21(&doc)
+	assert.True(t, ok)
+	assert.Equal(t, `version: 2.1
+
+defaults: &defaults
+  docker:
+    - image: cimg/node:20.1
+  working_directory: ~/app
+
+restore: &restore_deps
+  restore_cache:
+    key: deps-{{ checksum "package-lock.json" }}
+
+executors:
+  node:
+    docker:
+      - image: cimg/node:20.1
+    working_directory: ~/app
+
+commands:
+  restore_deps:
+    steps:
+      - restore_cache:
+          key: deps-{{ checksum "package-lock.json" }}
+
+jobs:
+  build:
+    executor: node
+    steps:
+      - checkout
+      - restore_deps
+      - run: npm ci
+  test:
+    executor: node
+    steps:
+      - checkout
+      - restore_deps
+      - run: npm test
+
+workflows:
+  main:
+    jobs:
+      - build
+      - test:
+          requires:
+            - build
+`, applyCodeAction(doc, protocol.CodeAction{Edit: &edit}))
+}
+
+func TestMigrateTo21SharedExecutors(t *testing.T) {
+	doc := parseDocument(t, `version: 2.0
+
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - checkout
+  test:
+    docker:
+      - image: cimg/go:1.22
+    steps:
+      - checkout
+`)
+
+	edit, ok := MigrateTo21(&doc)
+	assert.True(t, ok)
+	assert.Equal(t, `version: 2.1
+
+executors:
+  go:
+    docker:
+      - image: cimg/go:1.22
+
+jobs:
+  build:
+    executor: go
+    steps:
+      - checkout
+  test:
+    executor: go
+    steps:
+      - checkout
+`, applyCodeAction(doc, protocol.CodeAction{Edit: &edit}))
+}
+
+func TestMigrateTo21MergedExecutors(t *testing.T) {
+	doc := parseDocument(t, `version: 2
+
+defaults: &defaults
+  docker:
+    - image: cimg/python:3.12
+  resource_class: large
+
+settings: &settings
+  docker:
+    - image: cimg/python:3.12
+  parallelism: 4
+
+jobs:
+  build:
+    <<: *defaults
+    steps:
+      - checkout
+  test:
+    resource_class: large
+    <<: *defaults
+    steps:
+      - checkout
+  small:
+    <<: *defaults
+    resource_class: small
+    steps:
+      - checkout
+  lint:
+    <<: *settings
+    steps:
+      - checkout
+  check:
+    <<: *settings
+    steps:
+      - checkout
+`)
+
+	// The keys of the job override the merged ones, the anchors holding other
+	// keys than the executor configuration are kept
+	edit, ok := MigrateTo21(&doc)
+	assert.True(t, ok)
+	assert.Equal(t, `version: 2.1
+
+defaults: &defaults
+  docker:
+    - image: cimg/python:3.12
+  resource_class: large
+
+settings: &settings
+  docker:
+    - image: cimg/python:3.12
+  parallelism: 4
+
+executors:
+  python:
+    docker:
+      - image: cimg/python:3.12
+    resource_class: large
+
+jobs:
+  build:
+    executor: python
+    steps:
+      - checkout
+  test:
+    executor: python
+    steps:
+      - checkout
+  small:
+    <<: *defaults
+    resource_class: small
+    steps:
+      - checkout
+  lint:
+    <<: *settings
+    steps:
+      - checkout
+  check:
+    <<: *settings
+    steps:
+      - checkout
+`, applyCodeAction(doc, protocol.CodeAction{Edit: &edit}))
+}
+
+func TestMigrateTo21Unavailable(t *testing.T) {
+	for _, content := range []string{
+		"version: 2.1\n\njobs: {}\n",
+		"jobs: {}\n",
+	} {
+		doc := parseDocument(t, content)
+		_, ok := MigrateTo21(&doc)
+		assert.False(t, ok, content)
+	}
+}
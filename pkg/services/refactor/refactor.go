@@ -0,0 +1,556 @@
+package refactor
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+// The refactorings are code actions computed from the range selected in the
+// editor, unlike the quick fixes which are attached to the diagnostics. They
+// only work on the tree-sitter nodes of the document: the ast is used to find
+// the entities but the edits keep the text of the user (comments, quotes,
+// anchors) as it is.
+
+// Returns true when the kind is asked by the client, see
+// CodeActionContext.Only
+func IsKindRequested(only []protocol.CodeActionKind, kind protocol.CodeActionKind) bool {
+	if len(only) == 0 {
+		return true
+	}
+
+	for _, requested := range only {
+		if kind == requested || strings.HasPrefix(string(kind), string(requested)+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func createCodeAction(title string, kind protocol.CodeActionKind, doc *parser.YamlDocument, edits []protocol.TextEdit) protocol.CodeAction {
+	codeAction := utils.CreateCodeActionTextEdit(title, doc.URI, edits, false)
+	codeAction.Kind = kind
+	return codeAction
+}
+
+// Calls fn on every pair of a block mapping, with the text of its key
+func forEachPair(doc *parser.YamlDocument, mapping *sitter.Node, fn func(key string, pair *sitter.Node, value *sitter.Node)) {
+	if mapping == nil {
+		return
+	}
+
+	for i := 0; i < int(mapping.NamedChildCount()); i++ {
+		pair := mapping.NamedChild(i)
+		if pair.Type() != "block_mapping_pair" {
+			continue
+		}
+
+		keyNode := pair.ChildByFieldName("key")
+		valueNode := pair.ChildByFieldName("value")
+		fn(doc.GetNodeText(keyNode), pair, valueNode)
+	}
+}
+
+const mergeKey = "<<"
+
+// Returns the mapping of the anchor merged by the pair, such as
+// `<<: *defaults`, nil when the value is not the alias of a mapping
+func mergedMapping(doc *parser.YamlDocument, pair *sitter.Node) *sitter.Node {
+	alias := parser.GetChildOfType(pair.ChildByFieldName("value"), "alias")
+	if alias == nil {
+		return nil
+	}
+
+	anchor, ok := doc.YamlAnchors[strings.TrimPrefix(doc.GetNodeText(alias), "*")]
+	if !ok || anchor.ValueNode == nil {
+		return nil
+	}
+	return parser.GetChildOfType(anchor.ValueNode, "block_mapping")
+}
+
+// Returns the pair of a root key of the document, such as `commands`
+func rootPair(doc *parser.YamlDocument, key string) *sitter.Node {
+	var res *sitter.Node
+	forEachPair(doc, parser.GetBlockMappingNode(doc.RootNode), func(name string, pair *sitter.Node, _ *sitter.Node) {
+		if name == key && res == nil {
+			res = pair
+		}
+	})
+	return res
+}
+
+// Adds the definition of an entity at the end of its root section, such as
+// `commands`, creating the section before `jobs` when there is none. The
+// definition is indented and has no final line break
+func addDefinition(doc *parser.YamlDocument, section string, sectionRange protocol.Range, definition string) (protocol.TextEdit, bool) {
+	insert := func(pos protocol.Position, text string) (protocol.TextEdit, bool) {
+		return protocol.TextEdit{Range: protocol.Range{Start: pos, End: pos}, NewText: text}, true
+	}
+
+	if pair := rootPair(doc, section); pair != nil && !utils.IsDefaultRange(sectionRange) {
+		if value := pair.ChildByFieldName("value"); value != nil && parser.GetChildOfType(value, "block_mapping") == nil {
+			// Flow mapping or alias, the definition can't be added to it
+			return protocol.TextEdit{}, false
+		}
+
+		pos, ok := lineAfter(doc, utils.PosToIndex(sectionRange.End, doc.Content))
+		if !ok {
+			return insert(pos, "\n"+definition)
+		}
+		return insert(pos, definition+"\n")
+	}
+
+	definition = section + ":\n" + definition + "\n"
+	if jobs := rootPair(doc, "jobs"); jobs != nil {
+		return insert(protocol.Position{Line: jobs.StartPoint().Row}, definition+"\n")
+	}
+
+	pos, ok := lineAfter(doc, len(doc.Content))
+	if !ok && len(doc.Content) > 0 {
+		definition = "\n" + definition
+	}
+	return insert(pos, definition)
+}
+
+// The steps of a job or of a command, as block_sequence_item nodes
+type stepList struct {
+	Name  string
+	Items []*sitter.Node
+	// Mapping of the parameters of the job or of the command, nil without
+	// parameters
+	Parameters *sitter.Node
+}
+
+// Calls fn on every entry of a root section, such as the jobs, with the
+// mapping of its definition
+func forEachDefinition(doc *parser.YamlDocument, section string, fn func(name string, pair *sitter.Node, definition *sitter.Node)) {
+	sectionPair := rootPair(doc, section)
+	if sectionPair == nil {
+		return
+	}
+
+	forEachPair(doc, parser.GetChildMapping(sectionPair.ChildByFieldName("value")), func(name string, pair *sitter.Node, value *sitter.Node) {
+		fn(name, pair, parser.GetChildMapping(value))
+	})
+}
+
+// Returns the step lists of the jobs, then of the commands
+func stepLists(doc *parser.YamlDocument) []stepList {
+	lists := []stepList{}
+
+	for _, section := range []string{"jobs", "commands"} {
+		forEachDefinition(doc, section, func(name string, _ *sitter.Node, definition *sitter.Node) {
+			var parameters *sitter.Node
+			forEachPair(doc, definition, func(key string, _ *sitter.Node, value *sitter.Node) {
+				if key == "parameters" {
+					parameters = parser.GetChildMapping(value)
+				}
+			})
+
+			forEachPair(doc, definition, func(key string, _ *sitter.Node, steps *sitter.Node) {
+				if key != "steps" {
+					return
+				}
+
+				sequence := parser.GetChildOfType(steps, "block_sequence")
+				if sequence == nil {
+					return
+				}
+
+				list := stepList{Name: name, Parameters: parameters}
+				for i := 0; i < int(sequence.NamedChildCount()); i++ {
+					if item := sequence.NamedChild(i); item.Type() == "block_sequence_item" {
+						list.Items = append(list.Items, item)
+					}
+				}
+				lists = append(lists, list)
+			})
+		})
+	}
+
+	return lists
+}
+
+// The leaves of a subtree, used to compare two subtrees while ignoring their
+// position and their comments. The scalars are kept whole
+type token struct {
+	Node *sitter.Node
+	Text string
+	// Whether the token is a value that can be replaced by a parameter: a
+	// scalar on a single line which is not a key
+	IsValue bool
+}
+
+func tokens(doc *parser.YamlDocument, node *sitter.Node, isKey bool) []token {
+	res := []token{}
+
+	switch node.Type() {
+	case "comment":
+		return res
+
+	case "plain_scalar", "double_quote_scalar", "single_quote_scalar", "block_scalar":
+		return append(res, token{
+			Node:    node,
+			Text:    doc.GetRawNodeText(node),
+			IsValue: !isKey && node.Type() != "block_scalar" && node.StartPoint().Row == node.EndPoint().Row,
+		})
+	}
+
+	if node.ChildCount() == 0 {
+		return append(res, token{Node: node, Text: doc.GetRawNodeText(node)})
+	}
+
+	for i := 0; i < int(node.ChildCount()); i++ {
+		childIsKey := isKey || node.FieldNameForChild(i) == "key"
+		res = append(res, tokens(doc, node.Child(i), childIsKey)...)
+	}
+
+	return res
+}
+
+func tokensOfNodes(doc *parser.YamlDocument, nodes []*sitter.Node) []token {
+	res := []token{}
+	for _, node := range nodes {
+		res = append(res, tokens(doc, node, false)...)
+	}
+	return res
+}
+
+// Compares two lists of tokens. They are similar when they only differ by
+// values and share at least one of them, so that two unrelated `run` steps are
+// not taken for copies of each other. Returns the indexes of the differing
+// values
+func similarTokens(a []token, b []token) ([]int, bool) {
+	if len(a) != len(b) {
+		return nil, false
+	}
+
+	diff := []int{}
+	values := 0
+	for i := range a {
+		if a[i].IsValue {
+			values++
+		}
+
+		if a[i].Text == b[i].Text {
+			continue
+		}
+
+		if !a[i].IsValue || !b[i].IsValue {
+			return nil, false
+		}
+		diff = append(diff, i)
+	}
+
+	if values > 0 && len(diff) == values {
+		return nil, false
+	}
+
+	return diff, true
+}
+
+// Returns the indentation unit of the document, from its first nested
+// mapping, 2 by default
+func indentUnit(doc *parser.YamlDocument) int {
+	unit := 0
+	forEachPair(doc, parser.GetBlockMappingNode(doc.RootNode), func(_ string, _ *sitter.Node, value *sitter.Node) {
+		if mapping := parser.GetChildOfType(value, "block_mapping"); unit == 0 && mapping != nil {
+			unit = int(mapping.StartPoint().Column)
+		}
+	})
+
+	if unit == 0 {
+		return 2
+	}
+	return unit
+}
+
+// Returns the text between start and end, with the nodes of replacements
+// replaced by their new text, and each line after the first one moved from the
+// column `from` to the column `to`. Blank lines are kept empty
+func reindentText(content []byte, start uint32, end uint32, replacements map[*sitter.Node]string, from int, to int) string {
+	text := []byte{}
+	cursor := start
+
+	nodes := make([]*sitter.Node, 0, len(replacements))
+	for node := range replacements {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].StartByte() < nodes[j].StartByte() })
+
+	for _, node := range nodes {
+		if node.StartByte() < cursor || node.EndByte() > end {
+			continue
+		}
+		text = append(text, content[cursor:node.StartByte()]...)
+		text = append(text, replacements[node]...)
+		cursor = node.EndByte()
+	}
+	text = append(text, content[cursor:end]...)
+
+	lines := strings.Split(string(text), "\n")
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			lines[i] = ""
+			continue
+		}
+
+		indentation := len(line) - len(trimmed)
+		if indentation >= from {
+			trimmed = line[from:]
+		}
+		lines[i] = strings.Repeat(" ", to) + trimmed
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Returns the position of the line following the byte offset, false when the
+// offset is on the last line of the document and the end of the document is
+// returned instead
+func lineAfter(doc *parser.YamlDocument, offset int) (protocol.Position, bool) {
+	if offset > 0 && doc.Content[offset-1] == '\n' {
+		return utils.IndexToPos(offset, doc.Content), true
+	}
+
+	newLine := bytes.IndexByte(doc.Content[offset:], '\n')
+	if newLine < 0 {
+		return utils.IndexToPos(len(doc.Content), doc.Content), false
+	}
+
+	return utils.IndexToPos(offset+newLine+1, doc.Content), true
+}
+
+// Returns true when the node and the byte range intersect, an empty range
+// being a cursor touching the node
+func intersects(node *sitter.Node, start uint32, end uint32) bool {
+	if start == end {
+		return node.StartByte() <= start && start <= node.EndByte()
+	}
+	return node.StartByte() < end && start < node.EndByte()
+}
+
+// Removes the lines of the node
+func deleteLines(doc *parser.YamlDocument, node *sitter.Node) protocol.TextEdit {
+	end, _ := lineAfter(doc, int(nodeEnd(doc, node)))
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: node.StartPoint().Row},
+			End:   end,
+		},
+	}
+}
+
+// Returns the end of the node without the trailing line breaks tree-sitter
+// gives to the last node of a document
+func nodeEnd(doc *parser.YamlDocument, node *sitter.Node) uint32 {
+	end := node.EndByte()
+	for end > node.StartByte() && (doc.Content[end-1] == '\n' || doc.Content[end-1] == '\r') {
+		end--
+	}
+	return end
+}
+
+func nodeRange(doc *parser.YamlDocument, node *sitter.Node) protocol.Range {
+	return protocol.Range{
+		Start: utils.IndexToPos(int(node.StartByte()), doc.Content),
+		End:   utils.IndexToPos(int(nodeEnd(doc, node)), doc.Content),
+	}
+}
+
+// Returns the byte offsets of the range in the document
+func rangeToBytes(doc *parser.YamlDocument, rng protocol.Range) (uint32, uint32) {
+	return uint32(utils.PosToIndex(rng.Start, doc.Content)), uint32(utils.PosToIndex(rng.End, doc.Content))
+}
+
+// Returns a name not used by a command, a job or an executor of the document,
+// nor by the names taken by the other definitions being added
+func uniqueName(doc *parser.YamlDocument, base string, taken map[string]bool) string {
+	name := base
+	for i := 2; ; i++ {
+		_, isCommand := doc.Commands[name]
+		_, isJob := doc.Jobs[name]
+		_, isExecutor := doc.Executors[name]
+		if !isCommand && !isJob && !isExecutor && !taken[name] {
+			return name
+		}
+		name = base + "-" + strconv.Itoa(i)
+	}
+}
+
+var parameterPlaceholder = regexp.MustCompile(`<<\s*parameters\.([A-Za-z0-9_-]+)\s*>>`)
+
+// The placeholders and the tags of the conditional sections, such as
+// `<<# parameters.x >>`
+var parameterReference = regexp.MustCompile(`<<[#^/]?\s*parameters\.([A-Za-z0-9_-]+)\s*>>`)
+
+// Returns the replacements, see reindentText, of the scalars between start
+// and end using `<< parameters.x >>`: their text with the values given to the
+// entity, or the default values of its parameters. False when a parameter
+// can't be substituted: unknown, required without value, or with a multi-line
+// value, or used by a conditional section such as `<<# parameters.x >>`
+func substituteParameters(doc *parser.YamlDocument, start uint32, end uint32, parameters map[string]ast.Parameter, values map[string]ast.ParameterValue) (map[*sitter.Node]string, bool) {
+	text := doc.Content[start:end]
+	if len(parameterPlaceholder.FindAllIndex(text, -1)) != len(parameterReference.FindAllIndex(text, -1)) {
+		return nil, false
+	}
+
+	replacements := map[*sitter.Node]string{}
+	for _, match := range parameterPlaceholder.FindAllIndex(text, -1) {
+		scalar := scalarAt(doc, start+uint32(match[0]))
+		if scalar == nil || scalar.StartByte() < start || scalar.EndByte() > end {
+			return nil, false
+		}
+		if _, ok := replacements[scalar]; ok {
+			continue
+		}
+
+		replacement, ok := substituteScalarParameters(doc, scalar, parameters, values)
+		if !ok {
+			return nil, false
+		}
+		replacements[scalar] = replacement
+	}
+
+	return replacements, true
+}
+
+// Returns the scalar, or the comment, holding the byte offset
+func scalarAt(doc *parser.YamlDocument, offset uint32) *sitter.Node {
+	point := sitter.Point{
+		Row:    uint32(bytes.Count(doc.Content[:offset], []byte{'\n'})),
+		Column: offset - uint32(bytes.LastIndexByte(doc.Content[:offset], '\n')+1),
+	}
+	node := doc.RootNode.NamedDescendantForPointRange(point, point)
+	for node != nil {
+		switch node.Type() {
+		case "plain_scalar", "double_quote_scalar", "single_quote_scalar", "block_scalar", "comment":
+			return node
+		}
+		node = node.Parent()
+	}
+
+	return nil
+}
+
+// Returns the text of the scalar with its placeholders substituted. The values
+// are escaped as the scalar is quoted, and a plain scalar which can't hold its
+// new text is quoted
+func substituteScalarParameters(doc *parser.YamlDocument, scalar *sitter.Node, parameters map[string]ast.Parameter, values map[string]ast.ParameterValue) (string, bool) {
+	text := doc.GetRawNodeText(scalar)
+	res := strings.Builder{}
+	cursor := 0
+
+	for _, match := range parameterPlaceholder.FindAllStringSubmatchIndex(text, -1) {
+		value, ok := parameterValueText(doc, text[match[2]:match[3]], parameters, values)
+		if !ok || strings.Contains(value, "\n") {
+			// Multi-line values such as steps would need to be indented
+			return "", false
+		}
+
+		// The value is kept as written when it is the whole scalar
+		if scalar.Type() == "plain_scalar" && match[0] == 0 && match[1] == len(text) {
+			return value, true
+		}
+
+		value = unquoteScalar(value)
+		switch scalar.Type() {
+		case "double_quote_scalar":
+			quoted := strconv.Quote(value)
+			value = quoted[1 : len(quoted)-1]
+		case "single_quote_scalar":
+			value = strings.ReplaceAll(value, "'", "''")
+		}
+
+		res.WriteString(text[cursor:match[0]])
+		res.WriteString(value)
+		cursor = match[1]
+	}
+	res.WriteString(text[cursor:])
+
+	if scalar.Type() != "plain_scalar" || isPlainScalar(res.String()) {
+		return res.String(), true
+	}
+	if strings.Contains(text, "\n") {
+		// The lines of the scalar are folded, the quoted text would not be
+		return "", false
+	}
+	return strconv.Quote(res.String()), true
+}
+
+// Returns the value of a single-line scalar from its text
+func unquoteScalar(text string) string {
+	if len(text) < 2 {
+		return text
+	}
+
+	switch {
+	case text[0] == '"' && text[len(text)-1] == '"':
+		if value, err := strconv.Unquote(text); err == nil {
+			return value
+		}
+		return text[1 : len(text)-1]
+	case text[0] == '\'' && text[len(text)-1] == '\'':
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'")
+	}
+
+	return text
+}
+
+// Whether the text keeps its meaning as a plain scalar of a block collection,
+// it would otherwise be read as a mapping, an alias, a tag, a comment...
+func isPlainScalar(text string) bool {
+	if text == "" || text != strings.TrimSpace(text) {
+		return false
+	}
+
+	// "-", "?" and ":" only start something else when followed by a space
+	if strings.ContainsAny(text[:1], "-?:") {
+		if len(text) == 1 || text[1] == ' ' {
+			return false
+		}
+	} else if strings.ContainsAny(text[:1], ",[]{}#&*!|>'\"%@`") {
+		return false
+	}
+
+	return !strings.Contains(text, ": ") && !strings.HasSuffix(text, ":") && !strings.Contains(text, " #")
+}
+
+func parameterValueText(doc *parser.YamlDocument, name string, parameters map[string]ast.Parameter, values map[string]ast.ParameterValue) (string, bool) {
+	if value, ok := values[name]; ok {
+		return rangeText(doc, value.ValueRange), true
+	}
+
+	if parameter, ok := parameters[name]; ok && parameter.IsOptional() {
+		// The default range is the one of the `default` pair
+		start := parameter.GetDefaultRange().Start
+		node := doc.RootNode.NamedDescendantForPointRange(
+			sitter.Point{Row: start.Line, Column: start.Character},
+			sitter.Point{Row: start.Line, Column: start.Character},
+		)
+		for node != nil && node.Type() != "block_mapping_pair" {
+			node = node.Parent()
+		}
+		if node != nil {
+			return strings.TrimSpace(doc.GetRawNodeText(node.ChildByFieldName("value"))), true
+		}
+	}
+
+	return "", false
+}
+
+func rangeText(doc *parser.YamlDocument, rng protocol.Range) string {
+	start, end := rangeToBytes(doc, rng)
+	return strings.TrimSpace(string(doc.Content[start:end]))
+}
@@ -2,6 +2,8 @@ package languageservice
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
@@ -110,9 +112,15 @@ func (ref ReferenceHandler) GetReferences() ([]protocol.Location, error) {
 		return paramRefs, nil
 	}
 
+	// The files of an orb source directory define a single entity
+	if cmdName == "" && ref.Doc.OrbSourceFile != nil {
+		cmdName = ref.Doc.OrbSourceFile.Name
+	}
+
 	ref.getStepsOfWorkflows()
 	ref.getStepsOfJobs()
 	ref.getStepsOfCommands()
+	ref.getStepsOfOrbSource()
 
 	return ref.getReferenceFromSteps(cmdName, isOrb)
 }
@@ -120,6 +128,9 @@ func (ref ReferenceHandler) GetReferences() ([]protocol.Location, error) {
 type StepRangeAndName struct {
 	protocol.Range
 	Name string
+
+	// URI is set for the steps of the other files of an orb source directory
+	URI protocol.URI
 }
 
 func (ref ReferenceHandler) getStepsOfWorkflows() {
@@ -132,17 +143,46 @@ func (ref ReferenceHandler) getStepsOfWorkflows() {
 
 func (ref ReferenceHandler) getStepsOfJobs() {
 	for _, job := range ref.Doc.Jobs {
-		*ref.FoundSteps = append(*ref.FoundSteps, getStepsOfCommandOrJob(job.Steps)...)
+		*ref.FoundSteps = append(*ref.FoundSteps, getStepsOfCommandOrJob(job.Steps, stepsParameterChecker(ref.Doc, ref.Cache))...)
 	}
 }
 
 func (ref ReferenceHandler) getStepsOfCommands() {
 	for _, job := range ref.Doc.Commands {
-		*ref.FoundSteps = append(*ref.FoundSteps, getStepsOfCommandOrJob(job.Steps)...)
+		*ref.FoundSteps = append(*ref.FoundSteps, getStepsOfCommandOrJob(job.Steps, stepsParameterChecker(ref.Doc, ref.Cache))...)
 	}
 }
 
-func getStepsOfCommandOrJob(steps []ast.Step) []StepRangeAndName {
+func (ref ReferenceHandler) getStepsOfOrbSource() {
+	if ref.Doc.OrbSource == nil {
+		return
+	}
+
+	for _, doc := range ref.Doc.OrbSource.Documents() {
+		if doc.URI == ref.Doc.URI {
+			continue
+		}
+
+		steps := []StepRangeAndName{}
+		for _, job := range doc.Jobs {
+			steps = append(steps, getStepsOfCommandOrJob(job.Steps, stepsParameterChecker(ref.Doc, ref.Cache))...)
+		}
+		for _, command := range doc.Commands {
+			steps = append(steps, getStepsOfCommandOrJob(command.Steps, stepsParameterChecker(ref.Doc, ref.Cache))...)
+		}
+
+		for _, step := range steps {
+			step.URI = doc.URI
+			*ref.FoundSteps = append(*ref.FoundSteps, step)
+		}
+	}
+}
+
+// Returns the named steps, including the ones given to the `steps` parameters
+// of the commands and jobs invoked. The bare names given to these parameters
+// are only known to be steps from the type of the parameters, found by
+// isStepsParameter when not nil
+func getStepsOfCommandOrJob(steps []ast.Step, isStepsParameter func(stepName string, parameterName string) bool) []StepRangeAndName {
 	res := []StepRangeAndName{}
 
 	for _, step := range steps {
@@ -150,12 +190,57 @@ func getStepsOfCommandOrJob(steps []ast.Step) []StepRangeAndName {
 		case ast.NamedStep:
 			res = append(res, StepRangeAndName{Name: step.Name, Range: step.Range})
 
+			for _, name := range slices.Sorted(maps.Keys(step.Parameters)) {
+				withNames := isStepsParameter != nil && isStepsParameter(step.Name, name)
+				res = append(res, getStepsOfCommandOrJob(getStepsOfParameterValue(step.Parameters[name], withNames), isStepsParameter)...)
+			}
 		}
 	}
 
 	return res
 }
 
+// Returns the steps given as value of a `steps` parameter, the bare names
+// being included when withNames is set
+func getStepsOfParameterValue(value ast.ParameterValue, withNames bool) []ast.Step {
+	values, ok := value.Value.([]ast.ParameterValue)
+	if !ok {
+		return nil
+	}
+
+	res := []ast.Step{}
+	for _, value := range values {
+		if steps, ok := value.Value.([]ast.Step); ok && value.Type == "steps" {
+			res = append(res, steps...)
+		} else if name, ok := value.Value.(string); ok && withNames && value.Type == "string" {
+			res = append(res, ast.NamedStep{Name: strings.TrimSpace(name), Range: value.ValueRange})
+		}
+	}
+	return res
+}
+
+// Returns whether the parameter of the command or job of the document or of
+// an orb is a `steps` parameter
+func stepsParameterChecker(doc yamlparser.YamlDocument, cache *utils.Cache) func(string, string) bool {
+	return func(stepName string, parameterName string) bool {
+		var parameters map[string]ast.Parameter
+		if command, ok := doc.GetCommand(stepName); ok {
+			parameters = command.Parameters
+		} else if job, ok := doc.GetJob(stepName); ok {
+			parameters = job.Parameters
+		} else if orbInfo, entityName, ok := orbInfoOf(doc, stepName, cache); ok {
+			if command, ok := orbInfo.Commands[entityName]; ok {
+				parameters = command.Parameters
+			} else if job, ok := orbInfo.Jobs[entityName]; ok {
+				parameters = job.Parameters
+			}
+		}
+
+		_, ok := parameters[parameterName].(ast.StepsParameter)
+		return ok
+	}
+}
+
 func (ref ReferenceHandler) searchInOrbs() string {
 	for _, orb := range ref.Doc.Orbs {
 		if utils.PosInRange(orb.NameRange, ref.Params.Position) {
@@ -201,8 +286,13 @@ func (ref ReferenceHandler) getReferenceFromSteps(nameOfStep string, isOrb bool)
 
 	for _, step := range *ref.FoundSteps {
 		if step.Name == nameOfStep || (isOrb && strings.HasPrefix(step.Name, nameOfStep+"/")) {
+			uri := ref.Params.TextDocument.URI
+			if step.URI != "" {
+				uri = step.URI
+			}
+
 			locations = append(locations, protocol.Location{
-				URI:   ref.Params.TextDocument.URI,
+				URI:   uri,
 				Range: step.Range,
 			})
 		}
@@ -229,6 +319,23 @@ func (ref ReferenceHandler) getExecutorReferences() ([]protocol.Location, string
 		}
 	}
 
+	if ref.Doc.OrbSource != nil {
+		for _, doc := range ref.Doc.OrbSource.Documents() {
+			if doc.URI == ref.Doc.URI {
+				continue
+			}
+
+			for _, job := range doc.Jobs {
+				if job.Executor == executor.GetName() {
+					locations = append(locations, protocol.Location{
+						URI:   doc.URI,
+						Range: job.ExecutorRange,
+					})
+				}
+			}
+		}
+	}
+
 	return locations, executorName
 }
 
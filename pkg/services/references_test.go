@@ -252,6 +252,31 @@ func TestReferences(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Reference for command given to a steps parameter",
+			args: args{
+				filePath: "./testdata/callHierarchy.yml",
+				position: protocol.Position{
+					Line:      13,
+					Character: 3,
+				},
+			},
+			want: []protocol.Location{
+				{
+					URI: uri.File("./testdata/callHierarchy.yml"),
+					Range: protocol.Range{
+						Start: protocol.Position{
+							Line:      38,
+							Character: 14,
+						},
+						End: protocol.Position{
+							Line:      38,
+							Character: 21,
+						},
+					},
+				},
+			},
+		},
 	}
 	context := testHelpers.GetDefaultLsContext()
 	for _, tt := range tests {
@@ -0,0 +1,61 @@
+package languageservice
+
+import (
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	sitter "github.com/smacker/go-tree-sitter"
+	"go.lsp.dev/protocol"
+)
+
+// SelectionRange is not part of go.lsp.dev/protocol, each range is contained
+// by its parent
+type SelectionRange struct {
+	Range  protocol.Range  `json:"range"`
+	Parent *SelectionRange `json:"parent,omitempty"`
+}
+
+// SelectionRanges returns, for each position, the ranges to select when
+// expanding the selection: the scalar, the key/value pair, the step, the steps
+// of the job, the job, the jobs and the whole document
+func SelectionRanges(documentURI protocol.DocumentURI, positions []protocol.Position, cache *utils.Cache, context *utils.LsContext) ([]SelectionRange, error) {
+	doc, err := yamlparser.ParseFromUriWithCache(documentURI, cache, context)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]SelectionRange, 0, len(positions))
+	for _, pos := range positions {
+		res = append(res, selectionRangeAt(doc, pos))
+	}
+	return res, nil
+}
+
+func selectionRangeAt(doc yamlparser.YamlDocument, pos protocol.Position) SelectionRange {
+	point := sitter.Point{Row: pos.Line, Column: pos.Character}
+	node := doc.RootNode.NamedDescendantForPointRange(point, point)
+
+	// The ranges from the innermost node, each range strictly containing the
+	// previous one
+	ranges := []protocol.Range{}
+	for ; node != nil; node = node.Parent() {
+		if node.Type() == "comment" {
+			continue
+		}
+
+		rng := doc.NodeToRange(node)
+		if len(ranges) > 0 && ranges[len(ranges)-1] == rng {
+			continue
+		}
+		ranges = append(ranges, rng)
+	}
+
+	if len(ranges) == 0 {
+		return SelectionRange{Range: protocol.Range{Start: pos, End: pos}}
+	}
+
+	var parent *SelectionRange
+	for i := len(ranges) - 1; i > 0; i-- {
+		parent = &SelectionRange{Range: ranges[i], Parent: parent}
+	}
+	return SelectionRange{Range: ranges[0], Parent: parent}
+}
@@ -0,0 +1,55 @@
+package languageservice
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func selectionRangesOf(selectionRange SelectionRange) []protocol.Range {
+	ranges := []protocol.Range{}
+	for current := &selectionRange; current != nil; current = current.Parent {
+		ranges = append(ranges, current.Range)
+	}
+	return ranges
+}
+
+func TestSelectionRanges(t *testing.T) {
+	cache, configURI := cacheWithFiles(t, editorFeaturesFile), uri.File(editorFeaturesFile)
+
+	selectionRanges, err := SelectionRanges(configURI, []protocol.Position{
+		{Line: 31, Character: 16},
+		{Line: 18, Character: 15},
+	}, cache, testHelpers.GetDefaultLsContext())
+	assert.Nil(t, err)
+	assert.Len(t, selectionRanges, 2)
+
+	assert.Equal(t, []protocol.Range{
+		rangeOf(31, 15, 31, 17), // me
+		rangeOf(31, 10, 31, 17), // who: me
+		rangeOf(30, 8, 31, 17),  // greet: ...
+		rangeOf(30, 6, 31, 17),  // - greet: ...
+		rangeOf(29, 6, 31, 17),  // the steps
+		rangeOf(28, 4, 31, 17),  // steps: ...
+		rangeOf(27, 4, 31, 17),  // the keys of the job
+		rangeOf(26, 2, 31, 17),  // build: ...
+		rangeOf(26, 2, 35, 16),  // the jobs
+		rangeOf(25, 0, 35, 16),  // jobs: ...
+		rangeOf(0, 0, 44, 0),    // the document
+	}, selectionRangesOf(selectionRanges[0]))
+
+	assert.Equal(t, []protocol.Range{
+		rangeOf(18, 13, 18, 38), // echo << parameters.who >>
+		rangeOf(18, 8, 18, 38),  // run: ...
+		rangeOf(18, 6, 18, 38),  // - run: ...
+		rangeOf(18, 6, 23, 46),  // the steps
+		rangeOf(17, 4, 23, 46),  // steps: ...
+		rangeOf(13, 4, 23, 46),  // the keys of the command
+		rangeOf(12, 2, 23, 46),  // greet: ...
+		rangeOf(11, 0, 23, 46),  // commands: ...
+		rangeOf(0, 0, 44, 0),    // the document
+	}, selectionRangesOf(selectionRanges[1]))
+}
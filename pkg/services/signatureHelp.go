@@ -0,0 +1,330 @@
+package languageservice
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+)
+
+// go.lsp.dev/protocol only knows about the string labels of the parameters,
+// which the clients search in the label of the signature: the label of
+// `version` would be found in the one of `node-version`. The offsets of the
+// labels are given instead
+type SignatureHelp struct {
+	Signatures      []SignatureInformation `json:"signatures"`
+	ActiveSignature uint32                 `json:"activeSignature"`
+	ActiveParameter uint32                 `json:"activeParameter"`
+}
+
+type SignatureInformation struct {
+	Label         string                  `json:"label"`
+	Documentation *protocol.MarkupContent `json:"documentation,omitempty"`
+	Parameters    []ParameterInformation  `json:"parameters"`
+}
+
+type ParameterInformation struct {
+	// Start and end offsets of the parameter in the label of the signature
+	Label         [2]uint32               `json:"label"`
+	Documentation *protocol.MarkupContent `json:"documentation,omitempty"`
+}
+
+// An invocation of a command, a job or an executor with its parameters
+type invocation struct {
+	Name        string
+	Description string
+	Parameters  map[string]ast.Parameter
+	// Lines of the invocation, the line of its name being the first one
+	Range protocol.Range
+}
+
+func SignatureHelpFromParams(params protocol.SignatureHelpParams, cache *utils.Cache, context *utils.LsContext) (*SignatureHelp, error) {
+	doc, err := yamlparser.ParseFromUriWithCache(params.TextDocument.URI, cache, context)
+	if err != nil {
+		return nil, err
+	}
+
+	return SignatureHelpAt(doc, params.Position, cache), nil
+}
+
+// SignatureHelpAt returns the parameters of the command, job or executor
+// invoked at the position, the parameter being written at the position being
+// the active one. It returns nil out of an invocation
+func SignatureHelpAt(doc yamlparser.YamlDocument, position protocol.Position, cache *utils.Cache) *SignatureHelp {
+	if doc.Version < 2.1 {
+		return nil
+	}
+
+	invocation, ok := invocationAt(doc, position, cache)
+	if !ok || len(invocation.Parameters) == 0 || position.Line == invocation.Range.Start.Line {
+		return nil
+	}
+
+	parameters := sortedParameters(invocation.Parameters)
+
+	signature := SignatureInformation{Label: invocation.Name + "("}
+	if invocation.Description != "" {
+		signature.Documentation = &protocol.MarkupContent{Kind: protocol.Markdown, Value: invocation.Description}
+	}
+
+	activeParameter := uint32(len(parameters))
+	key, isKeyComplete := keyAt(doc, position)
+	for i, parameter := range parameters {
+		if i > 0 {
+			signature.Label += ", "
+		}
+
+		start := uint32(len(signature.Label))
+		signature.Label += parameterLabel(parameter)
+		signature.Parameters = append(signature.Parameters, ParameterInformation{
+			Label: [2]uint32{start, uint32(len(signature.Label))},
+			Documentation: &protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: parameterDocumentation(parameter),
+			},
+		})
+
+		name := parameter.GetName()
+		if key != "" && activeParameter == uint32(len(parameters)) &&
+			(name == key || (!isKeyComplete && strings.HasPrefix(name, key))) {
+			activeParameter = uint32(i)
+		}
+	}
+	signature.Label += ")"
+
+	return &SignatureHelp{
+		Signatures:      []SignatureInformation{signature},
+		ActiveParameter: activeParameter,
+	}
+}
+
+func invocationAt(doc yamlparser.YamlDocument, position protocol.Position, cache *utils.Cache) (invocation, bool) {
+	steps := []ast.Step{}
+	for _, job := range doc.Jobs {
+		steps = append(steps, job.Steps...)
+
+		if utils.PosInRange(job.ExecutorRange, position) {
+			return executorInvocation(doc, job, cache)
+		}
+	}
+	for _, command := range doc.Commands {
+		steps = append(steps, command.Steps...)
+	}
+
+	for _, workflow := range doc.Workflows {
+		for _, jobInvocation := range workflow.JobInvocations {
+			steps = append(steps, jobInvocation.PreSteps...)
+			steps = append(steps, jobInvocation.PostSteps...)
+		}
+	}
+
+	if step, ok := namedStepAt(steps, position); ok {
+		return definedInvocation(doc, step.Name, protocol.Range{Start: step.Range.Start, End: step.ParametersRange.End}, cache)
+	}
+
+	for _, workflow := range doc.Workflows {
+		for _, jobInvocation := range workflow.JobInvocations {
+			if utils.PosInRange(jobInvocation.PreStepsRange, position) || utils.PosInRange(jobInvocation.PostStepsRange, position) {
+				return invocation{}, false
+			}
+			if utils.PosInRange(jobInvocation.JobInvocationRange, position) {
+				return definedInvocation(doc, jobInvocation.JobName, jobInvocation.JobInvocationRange, cache)
+			}
+		}
+	}
+
+	return invocation{}, false
+}
+
+// Returns the invocation of a command or of a job with parameters, `when` and
+// `unless` steps included
+func namedStepAt(steps []ast.Step, position protocol.Position) (ast.NamedStep, bool) {
+	for _, step := range steps {
+		switch step := step.(type) {
+		case ast.NamedStep:
+			rng := protocol.Range{Start: step.Range.Start, End: step.ParametersRange.End}
+			if !utils.IsDefaultRange(step.ParametersRange) && utils.PosInRange(rng, position) {
+				return step, true
+			}
+		case ast.Steps:
+			if res, ok := namedStepAt(step.Steps, position); ok {
+				return res, true
+			}
+		}
+	}
+
+	return ast.NamedStep{}, false
+}
+
+// Returns the command or the job of the document or of an orb
+func definedInvocation(doc yamlparser.YamlDocument, name string, rng protocol.Range, cache *utils.Cache) (invocation, bool) {
+	if command, ok := doc.GetCommand(name); ok {
+		return invocation{Name: name, Description: command.Description, Parameters: command.Parameters, Range: rng}, true
+	}
+	if job, ok := doc.GetJob(name); ok {
+		return invocation{Name: name, Description: job.Description, Parameters: job.Parameters, Range: rng}, true
+	}
+
+	orbInfo, entityName, ok := orbInfoOf(doc, name, cache)
+	if !ok {
+		return invocation{}, false
+	}
+	if command, ok := orbInfo.Commands[entityName]; ok {
+		return invocation{Name: name, Description: command.Description, Parameters: command.Parameters, Range: rng}, true
+	}
+	if job, ok := orbInfo.Jobs[entityName]; ok {
+		return invocation{Name: name, Description: job.Description, Parameters: job.Parameters, Range: rng}, true
+	}
+
+	return invocation{}, false
+}
+
+// Returns the executor of the job, of the document or of an orb
+func executorInvocation(doc yamlparser.YamlDocument, job ast.Job, cache *utils.Cache) (invocation, bool) {
+	executor, ok := doc.GetExecutor(job.Executor)
+	if !ok {
+		orbInfo, entityName, isOrb := orbInfoOf(doc, job.Executor, cache)
+		if !isOrb {
+			return invocation{}, false
+		}
+		if executor, ok = orbInfo.Executors[entityName]; !ok {
+			return invocation{}, false
+		}
+	}
+
+	return invocation{
+		Name:        job.Executor,
+		Description: executorDescription(executor),
+		Parameters:  executor.GetParameters(),
+		Range:       job.ExecutorRange,
+	}, true
+}
+
+func orbInfoOf(doc yamlparser.YamlDocument, name string, cache *utils.Cache) (*ast.OrbInfo, string, bool) {
+	orbName, entityName, ok := strings.Cut(name, "/")
+	if !ok {
+		return nil, "", false
+	}
+
+	orbInfo, err := doc.GetOrbInfoFromName(orbName, cache)
+	if err != nil || orbInfo == nil {
+		return nil, "", false
+	}
+
+	return orbInfo, entityName, true
+}
+
+func executorDescription(executor ast.Executor) string {
+	switch executor := executor.(type) {
+	case ast.DockerExecutor:
+		return executor.BuiltInParameters.Description
+	case ast.MachineExecutor:
+		return executor.BuiltInParameters.Description
+	case ast.MacOSExecutor:
+		return executor.BuiltInParameters.Description
+	default:
+		return ""
+	}
+}
+
+// Returns the required parameters first, as in the signature of a function,
+// then the optional ones, each sorted by name
+func sortedParameters(parametersByName map[string]ast.Parameter) []ast.Parameter {
+	parameters := make([]ast.Parameter, 0, len(parametersByName))
+	for _, parameter := range parametersByName {
+		parameters = append(parameters, parameter)
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		if parameters[i].IsOptional() != parameters[j].IsOptional() {
+			return !parameters[i].IsOptional()
+		}
+		return parameters[i].GetName() < parameters[j].GetName()
+	})
+	return parameters
+}
+
+var keyBeforeCursor = regexp.MustCompile(`^\s*(?:-\s+)?([A-Za-z0-9_-]+)(:?)`)
+
+// Returns the key of the line of the position and whether it is followed by a
+// colon, in which case the key is not being written anymore
+func keyAt(doc yamlparser.YamlDocument, position protocol.Position) (string, bool) {
+	lineStart := utils.PosToIndex(protocol.Position{Line: position.Line}, doc.Content)
+	end := utils.PosToIndex(position, doc.Content)
+	if lineStart < 0 || end > len(doc.Content) || lineStart > end {
+		return "", false
+	}
+
+	match := keyBeforeCursor.FindStringSubmatch(string(doc.Content[lineStart:end]))
+	if match == nil {
+		return "", false
+	}
+	return match[1], match[2] == ":"
+}
+
+// Returns `name: type`, followed by the default value of the optional
+// parameters
+func parameterLabel(parameter ast.Parameter) string {
+	label := parameter.GetName() + ": " + parameter.GetType()
+	if !parameter.IsOptional() {
+		return label
+	}
+
+	if value, ok := defaultValue(parameter); ok {
+		return label + " = " + value
+	}
+	return parameter.GetName() + "?: " + parameter.GetType()
+}
+
+func parameterDocumentation(parameter ast.Parameter) string {
+	lines := []string{}
+	if parameter.IsOptional() {
+		if value, ok := defaultValue(parameter); ok {
+			lines = append(lines, fmt.Sprintf("Default: `%s`", value))
+		} else {
+			lines = append(lines, "Optional")
+		}
+	} else {
+		lines = append(lines, "**Required**")
+	}
+
+	if enum, ok := parameter.(ast.EnumParameter); ok && len(enum.Enum) > 0 {
+		values := make([]string, len(enum.Enum))
+		for i, value := range enum.Enum {
+			values[i] = "`" + value + "`"
+		}
+		lines = append(lines, "Values: "+strings.Join(values, ", "))
+	}
+
+	if description := parameter.GetDescription(); description != "" {
+		lines = append(lines, description)
+	}
+
+	return strings.Join(lines, "\n\n")
+}
+
+// Returns the default value of the parameter as written in YAML, the steps
+// are not shown
+func defaultValue(parameter ast.Parameter) (string, bool) {
+	switch parameter := parameter.(type) {
+	case ast.StringParameter:
+		return strconv.Quote(parameter.Default), true
+	case ast.EnumParameter:
+		return parameter.Default, true
+	case ast.ExecutorParameter:
+		return parameter.Default, true
+	case ast.EnvVariableParameter:
+		return parameter.Default, true
+	case ast.BooleanParameter:
+		return strconv.FormatBool(parameter.Default), true
+	case ast.IntegerParameter:
+		return strconv.Itoa(parameter.Default), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,137 @@
+package languageservice
+
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+const signatureHelpConfig = `version: 2.1
+
+orbs:
+  tools:
+    commands:
+      lint:
+        parameters:
+          strict:
+            type: boolean
+            default: false
+        steps:
+          - run: lint
+
+executors:
+  node:
+    description: Node.js image
+    parameters:
+      tag:
+        type: string
+        default: lts
+    docker:
+      - image: cimg/node:<< parameters.tag >>
+
+commands:
+  install:
+    description: Installs the dependencies
+    parameters:
+      node-version:
+        type: string
+      version:
+        type: enum
+        enum: [v1, v2]
+        default: v1
+        description: Version of the lock file
+    steps:
+      - run: npm ci
+
+jobs:
+  build:
+    executor:
+      name: node
+      tag: "20.1"
+    steps:
+      - install:
+          node-version: "20"
+          version:
+      - tools/lint:
+          strict: true
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    parameters:
+      env:
+        type: string
+    steps:
+      - checkout
+
+workflows:
+  main:
+    jobs:
+      - build
+      - deploy:
+          env: production
+`
+
+func signatureHelpAt(t *testing.T, line uint32, character uint32) *SignatureHelp {
+	doc, err := parser.ParseFromContent([]byte(signatureHelpConfig), testHelpers.GetDefaultLsContext(), uri.File("config.yml"), protocol.Position{})
+	assert.NoError(t, err)
+	return SignatureHelpAt(doc, protocol.Position{Line: line, Character: character}, utils.CreateCache())
+}
+
+func TestSignatureHelpCommand(t *testing.T) {
+	// After the key being written
+	help := signatureHelpAt(t, 45, 18)
+	assert.NotNil(t, help)
+	assert.Len(t, help.Signatures, 1)
+
+	signature := help.Signatures[0]
+	assert.Equal(t, `install(node-version: string, version: enum = v1)`, signature.Label)
+	assert.Equal(t, "Installs the dependencies", signature.Documentation.Value)
+	assert.Equal(t, [2]uint32{8, 28}, signature.Parameters[0].Label)
+	assert.Equal(t, [2]uint32{30, 48}, signature.Parameters[1].Label)
+	assert.Equal(t, "**Required**", signature.Parameters[0].Documentation.Value)
+	assert.Equal(t, "Default: `v1`\n\nValues: `v1`, `v2`\n\nVersion of the lock file", signature.Parameters[1].Documentation.Value)
+	assert.Equal(t, uint32(1), help.ActiveParameter)
+
+	// On the value of a parameter
+	help = signatureHelpAt(t, 44, 27)
+	assert.NotNil(t, help)
+	assert.Equal(t, uint32(0), help.ActiveParameter)
+
+	// On the name of the command
+	assert.Nil(t, signatureHelpAt(t, 43, 10))
+}
+
+func TestSignatureHelpOrbCommand(t *testing.T) {
+	help := signatureHelpAt(t, 47, 14)
+	assert.NotNil(t, help)
+	assert.Equal(t, "tools/lint(strict: boolean = false)", help.Signatures[0].Label)
+	assert.Equal(t, uint32(0), help.ActiveParameter)
+}
+
+func TestSignatureHelpExecutor(t *testing.T) {
+	help := signatureHelpAt(t, 41, 8)
+	assert.NotNil(t, help)
+	assert.Equal(t, `node(tag: string = "lts")`, help.Signatures[0].Label)
+	assert.Equal(t, "Node.js image", help.Signatures[0].Documentation.Value)
+	assert.Equal(t, uint32(0), help.ActiveParameter)
+
+	// The name of the executor is not a parameter
+	help = signatureHelpAt(t, 40, 8)
+	assert.NotNil(t, help)
+	assert.Equal(t, uint32(1), help.ActiveParameter)
+}
+
+func TestSignatureHelpJobInvocation(t *testing.T) {
+	help := signatureHelpAt(t, 62, 12)
+	assert.NotNil(t, help)
+	assert.Equal(t, "deploy(env: string)", help.Signatures[0].Label)
+	assert.Equal(t, uint32(0), help.ActiveParameter)
+
+	// Out of any invocation
+	assert.Nil(t, signatureHelpAt(t, 55, 8))
+}
@@ -0,0 +1,231 @@
+package languageservice
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	yamlparser "github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// A symbol matching the query, with the score used to sort the results
+type workspaceSymbol struct {
+	Symbol protocol.SymbolInformation
+	Score  int
+}
+
+type workspaceSymbolsHandler struct {
+	Query   string
+	Symbols []workspaceSymbol
+	// Orbs of the cache already listed, by orb ID
+	SeenOrbs map[string]bool
+}
+
+// WorkspaceSymbols returns the jobs, commands, executors, workflows, job
+// groups and pipeline parameters of the documents, and the commands, jobs and
+// executors of their orbs, fuzzily matching the query. The orbs are only taken
+// from the cache, none is fetched
+func WorkspaceSymbols(ctx context.Context, params protocol.WorkspaceSymbolParams, documents []protocol.DocumentURI, cache *utils.Cache, lsContext *utils.LsContext) ([]protocol.SymbolInformation, error) {
+	wsh := workspaceSymbolsHandler{
+		Query:    params.Query,
+		Symbols:  []workspaceSymbol{},
+		SeenOrbs: map[string]bool{},
+	}
+
+	for _, documentURI := range documents {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		doc, err := yamlparser.ParseFromUriWithCache(documentURI, cache, lsContext)
+		if errors.Is(err, yamlparser.CacheMissingError) {
+			doc, err = yamlparser.ParseFromURI(documentURI, lsContext)
+		}
+		if err != nil {
+			continue
+		}
+
+		wsh.addDocumentSymbols(doc)
+		wsh.addDocumentOrbsSymbols(doc, cache)
+	}
+
+	// The orbs of the cache used by documents that are not indexed, qualified
+	// with their name
+	orbs := cache.OrbCache.GetOrbs()
+	for _, orbID := range slices.Sorted(maps.Keys(orbs)) {
+		if !wsh.SeenOrbs[orbID] {
+			wsh.addOrbSymbols(orbShortName(orbID), orbs[orbID], "")
+		}
+	}
+
+	slices.SortStableFunc(wsh.Symbols, func(a, b workspaceSymbol) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+		if c := strings.Compare(a.Symbol.Name, b.Symbol.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Symbol.Location.URI), string(b.Symbol.Location.URI))
+	})
+
+	res := make([]protocol.SymbolInformation, 0, len(wsh.Symbols))
+	for _, symbol := range wsh.Symbols {
+		res = append(res, symbol.Symbol)
+	}
+	return res, nil
+}
+
+func (wsh *workspaceSymbolsHandler) addDocumentSymbols(doc yamlparser.YamlDocument) {
+	for name, job := range doc.Jobs {
+		wsh.add(name, protocol.SymbolKindClass, "jobs", doc.URI, job.Range)
+	}
+	for name, command := range doc.Commands {
+		wsh.add(name, protocol.SymbolKindFunction, "commands", doc.URI, command.Range)
+	}
+	for name, executor := range doc.Executors {
+		wsh.add(name, protocol.SymbolKindStruct, "executors", doc.URI, executor.GetRange())
+	}
+	for name, workflow := range doc.Workflows {
+		wsh.add(name, protocol.SymbolKindModule, "workflows", doc.URI, workflow.Range)
+	}
+	for name, jobGroup := range doc.JobGroups {
+		wsh.add(name, protocol.SymbolKindNamespace, "job-groups", doc.URI, jobGroup.Range)
+	}
+	for name, parameter := range doc.PipelineParameters {
+		wsh.add(name, protocol.SymbolKindProperty, "parameters", doc.URI, parameter.GetRange())
+	}
+}
+
+// Adds the symbols of the local orbs and of the orbs of the cache imported by
+// the document, qualified with the name they are imported under
+func (wsh *workspaceSymbolsHandler) addDocumentOrbsSymbols(doc yamlparser.YamlDocument, cache *utils.Cache) {
+	for _, name := range slices.Sorted(maps.Keys(doc.LocalOrbInfo)) {
+		wsh.addOrbSymbols(name, doc.LocalOrbInfo[name], doc.URI)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(doc.Orbs)) {
+		orb := doc.Orbs[name]
+		if orb.Url.IsLocal {
+			continue
+		}
+
+		orbID := orb.Url.GetOrbID()
+		orbInfo := cache.OrbCache.GetOrb(orbID)
+		if orbInfo == nil {
+			continue
+		}
+
+		wsh.SeenOrbs[orbID] = true
+		wsh.addOrbSymbols(name, orbInfo, "")
+	}
+}
+
+// Adds the commands, jobs and executors of the orb, located in the given
+// document or, when empty, in the file the orb has been downloaded to
+func (wsh *workspaceSymbolsHandler) addOrbSymbols(orbName string, orbInfo *ast.OrbInfo, documentURI protocol.DocumentURI) {
+	if documentURI == "" {
+		if orbInfo.RemoteInfo.FilePath == "" {
+			return
+		}
+		documentURI = uri.New(orbInfo.RemoteInfo.FilePath)
+	}
+
+	for name, job := range orbInfo.Jobs {
+		wsh.add(orbName+"/"+name, protocol.SymbolKindClass, "orb jobs", documentURI, job.Range)
+	}
+	for name, command := range orbInfo.Commands {
+		wsh.add(orbName+"/"+name, protocol.SymbolKindFunction, "orb commands", documentURI, command.Range)
+	}
+	for name, executor := range orbInfo.Executors {
+		wsh.add(orbName+"/"+name, protocol.SymbolKindStruct, "orb executors", documentURI, executor.GetRange())
+	}
+}
+
+func (wsh *workspaceSymbolsHandler) add(name string, kind protocol.SymbolKind, containerName string, documentURI protocol.DocumentURI, rng protocol.Range) {
+	score, ok := fuzzyMatch(wsh.Query, name)
+	if !ok {
+		return
+	}
+
+	wsh.Symbols = append(wsh.Symbols, workspaceSymbol{
+		Symbol: protocol.SymbolInformation{
+			Name:          name,
+			Kind:          kind,
+			ContainerName: containerName,
+			Location: protocol.Location{
+				URI:   documentURI,
+				Range: rng,
+			},
+		},
+		Score: score,
+	})
+}
+
+// Returns the name of an orb from its ID: `circleci/node@5.0.0` is `node`
+func orbShortName(orbID string) string {
+	name, _, _ := strings.Cut(orbID, "@")
+	if index := strings.LastIndex(name, "/"); index >= 0 {
+		name = name[index+1:]
+	}
+	return name
+}
+
+// Returns whether the characters of the query appear in order in the name,
+// ignoring the case, and a score favouring the consecutive characters, the
+// characters starting a word and the shortest names. Every name matches an
+// empty query
+func fuzzyMatch(query string, name string) (int, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0, true
+	}
+
+	lowerName := strings.ToLower(name)
+	score := 0
+	previousMatchEnd := -1
+	previousRune := utf8.RuneError
+	nameIndex := 0
+
+	for _, queryRune := range query {
+		matched := false
+		for nameIndex < len(lowerName) {
+			nameRune, size := utf8.DecodeRuneInString(lowerName[nameIndex:])
+			index := nameIndex
+			runeBefore := previousRune
+			previousRune = nameRune
+			nameIndex += size
+
+			if nameRune != queryRune {
+				continue
+			}
+
+			score++
+			if index == previousMatchEnd {
+				score += 5
+			}
+			if index == 0 || isWordSeparator(runeBefore) {
+				score += 3
+			}
+			previousMatchEnd = nameIndex
+			matched = true
+			break
+		}
+
+		if !matched {
+			return 0, false
+		}
+	}
+
+	return score*10 - utf8.RuneCountInString(name), true
+}
+
+func isWordSeparator(r rune) bool {
+	return r == '/' || r == '-' || r == '_' || r == '.' || r == ' '
+}
@@ -0,0 +1,133 @@
+package languageservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+var workspaceSymbolsFiles = []string{"./testdata/workspaceSymbols.yml", "./testdata/workspaceSymbolsOther.yml"}
+
+func workspaceSymbolsCache(t *testing.T) (*utils.Cache, []protocol.DocumentURI) {
+	t.Helper()
+	cache := cacheWithFiles(t, workspaceSymbolsFiles...)
+
+	cache.OrbCache.SetOrb(&ast.OrbInfo{
+		OrbParsedAttributes: ast.OrbParsedAttributes{
+			Commands: map[string]ast.Command{"install-packages": {Name: "install-packages"}},
+			Jobs:     map[string]ast.Job{"test": {Name: "test"}},
+		},
+		RemoteInfo: ast.RemoteOrbInfo{FilePath: "/cache/circleci/node@5.0.0.yml"},
+	}, "circleci/node@5.0.0")
+	cache.OrbCache.SetOrb(&ast.OrbInfo{
+		OrbParsedAttributes: ast.OrbParsedAttributes{
+			Commands: map[string]ast.Command{"install": {Name: "install"}},
+		},
+		RemoteInfo: ast.RemoteOrbInfo{FilePath: "/cache/circleci/python@2.1.0.yml"},
+	}, "circleci/python@2.1.0")
+
+	documents := []protocol.DocumentURI{}
+	for _, filePath := range workspaceSymbolsFiles {
+		documents = append(documents, uri.File(filePath))
+	}
+	return cache, documents
+}
+
+func TestWorkspaceSymbols(t *testing.T) {
+	cache, documents := workspaceSymbolsCache(t)
+	symbols, err := WorkspaceSymbols(context.Background(), protocol.WorkspaceSymbolParams{}, documents, cache, testHelpers.GetDefaultLsContext())
+	assert.Nil(t, err)
+
+	byName := map[string]protocol.SymbolInformation{}
+	for _, symbol := range symbols {
+		byName[symbol.Name] = symbol
+	}
+
+	tests := []struct {
+		name      string
+		kind      protocol.SymbolKind
+		uri       protocol.DocumentURI
+		container string
+	}{
+		{name: "build", kind: protocol.SymbolKindClass, uri: uri.File(workspaceSymbolsFiles[0]), container: "jobs"},
+		{name: "deploy", kind: protocol.SymbolKindClass, uri: uri.File(workspaceSymbolsFiles[1]), container: "jobs"},
+		{name: "install-deps", kind: protocol.SymbolKindFunction, uri: uri.File(workspaceSymbolsFiles[0]), container: "commands"},
+		{name: "base", kind: protocol.SymbolKindStruct, uri: uri.File(workspaceSymbolsFiles[0]), container: "executors"},
+		{name: "main", kind: protocol.SymbolKindModule, uri: uri.File(workspaceSymbolsFiles[0]), container: "workflows"},
+		{name: "deploy-env", kind: protocol.SymbolKindProperty, uri: uri.File(workspaceSymbolsFiles[0]), container: "parameters"},
+		{name: "tools/lint", kind: protocol.SymbolKindFunction, uri: uri.File(workspaceSymbolsFiles[0])},
+		{name: "node/install-packages", kind: protocol.SymbolKindFunction, uri: uri.File("/cache/circleci/node@5.0.0.yml"), container: "orb commands"},
+		{name: "node/test", kind: protocol.SymbolKindClass, uri: uri.File("/cache/circleci/node@5.0.0.yml")},
+		{name: "python/install", kind: protocol.SymbolKindFunction, uri: uri.File("/cache/circleci/python@2.1.0.yml")},
+	}
+
+	assert.Len(t, symbols, len(tests))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol, ok := byName[tt.name]
+			assert.True(t, ok)
+			assert.Equal(t, tt.kind, symbol.Kind)
+			assert.Equal(t, tt.uri, symbol.Location.URI)
+			if tt.container != "" {
+				assert.Equal(t, tt.container, symbol.ContainerName)
+			}
+		})
+	}
+}
+
+func TestWorkspaceSymbolsFuzzyMatching(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "inst", want: []string{"install-deps", "python/install", "node/install-packages"}},
+		{query: "nip", want: []string{"node/install-packages"}},
+		{query: "DEPLOY", want: []string{"deploy", "deploy-env"}},
+		{query: "zzz", want: []string{}},
+	}
+
+	cache, documents := workspaceSymbolsCache(t)
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			symbols, err := WorkspaceSymbols(context.Background(), protocol.WorkspaceSymbolParams{Query: tt.query}, documents, cache, testHelpers.GetDefaultLsContext())
+			assert.Nil(t, err)
+
+			names := []string{}
+			for _, symbol := range symbols {
+				names = append(names, symbol.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		match bool
+	}{
+		{name: "install-packages", query: "", match: true},
+		{name: "install-packages", query: "ip", match: true},
+		{name: "install-packages", query: "Packages", match: true},
+		{name: "install-packages", query: "pi", match: false},
+		{name: "build", query: "builds", match: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name+"/"+tt.query, func(t *testing.T) {
+			_, ok := fuzzyMatch(tt.query, tt.name)
+			assert.Equal(t, tt.match, ok)
+		})
+	}
+
+	prefix, _ := fuzzyMatch("bu", "build")
+	scattered, _ := fuzzyMatch("bu", "bump-utils")
+	assert.Greater(t, prefix, scattered)
+}
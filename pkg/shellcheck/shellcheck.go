@@ -0,0 +1,245 @@
+package shellcheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/encoding/json"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityStyle   Severity = "style"
+)
+
+// A Finding is a problem found in a script. Lines and columns are 1-based,
+// columns are counted in bytes and the end column is exclusive
+type Finding struct {
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+type ShellLinter interface {
+	Lint(script string, shell string) []Finding
+}
+
+type shellLinter struct {
+	shellcheckPath string
+
+	mu    sync.Mutex
+	cache map[string][]Finding
+}
+
+// Above this number of scripts the cache is reset, it only has to hold the
+// scripts of the files being edited
+const maxCachedScripts = 2000
+
+const shellcheckTimeout = 5 * time.Second
+
+// NewLinter returns a linter parsing the scripts with an embedded shell
+// parser, and running them through `shellcheck` when it is found on the PATH
+func NewLinter() ShellLinter {
+	shellcheckPath, err := exec.LookPath("shellcheck")
+	if err != nil {
+		shellcheckPath = ""
+	}
+
+	return &shellLinter{
+		shellcheckPath: shellcheckPath,
+		cache:          map[string][]Finding{},
+	}
+}
+
+// GetDialect returns the shell dialect used to lint the scripts of a step
+// using the given `shell`; ok is false when the shell is not a POSIX-like
+// shell (PowerShell, cmd, python, ...)
+func GetDialect(shell string) (dialect string, ok bool) {
+	fields := strings.Fields(shell)
+	if len(fields) == 0 {
+		// Default shell of CircleCI: /bin/bash -eo pipefail
+		return "bash", true
+	}
+
+	switch path.Base(fields[0]) {
+	case "bash":
+		return "bash", true
+	case "sh", "dash":
+		return "sh", true
+	case "ksh", "mksh":
+		return "ksh", true
+	default:
+		return "", false
+	}
+}
+
+func (linter *shellLinter) Lint(script string, shell string) []Finding {
+	dialect, ok := GetDialect(shell)
+	if !ok || strings.TrimSpace(script) == "" {
+		return []Finding{}
+	}
+
+	key := dialect + "\x00" + script
+
+	linter.mu.Lock()
+	cached, ok := linter.cache[key]
+	linter.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	findings := parseScript(script, dialect)
+	if len(findings) == 0 && linter.shellcheckPath != "" {
+		findings = linter.runShellcheck(script, dialect)
+	}
+
+	linter.mu.Lock()
+	if len(linter.cache) >= maxCachedScripts {
+		linter.cache = map[string][]Finding{}
+	}
+	linter.cache[key] = findings
+	linter.mu.Unlock()
+
+	return findings
+}
+
+func parseScript(script string, dialect string) []Finding {
+	variant := syntax.LangBash
+	switch dialect {
+	case "sh":
+		variant = syntax.LangPOSIX
+	case "ksh":
+		variant = syntax.LangMirBSDKorn
+	}
+
+	parser := syntax.NewParser(syntax.Variant(variant))
+	_, err := parser.Parse(strings.NewReader(script), "")
+	if err == nil {
+		return []Finding{}
+	}
+
+	var parseError syntax.ParseError
+	if errors.As(err, &parseError) {
+		return []Finding{syntaxFinding(parseError.Pos, parseError.Text)}
+	}
+
+	var langError syntax.LangError
+	if errors.As(err, &langError) {
+		return []Finding{syntaxFinding(
+			langError.Pos,
+			fmt.Sprintf("%s is not supported by %s", langError.Feature, dialect),
+		)}
+	}
+
+	return []Finding{}
+}
+
+func syntaxFinding(pos syntax.Pos, message string) Finding {
+	line, column := int(pos.Line()), int(pos.Col())
+	if line == 0 {
+		line, column = 1, 1
+	}
+
+	return Finding{
+		Line:      line,
+		Column:    column,
+		EndLine:   line,
+		EndColumn: column + 1,
+		Severity:  SeverityError,
+		Message:   "Shell syntax error: " + message,
+	}
+}
+
+// The `json` format counts the columns with tab stops of 8, as the positions
+// shown by shellcheck, unlike `json1`
+type shellcheckComment struct {
+	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine"`
+	Column    int    `json:"column"`
+	EndColumn int    `json:"endColumn"`
+	Level     string `json:"level"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+}
+
+const shellcheckTabWidth = 8
+
+func (linter *shellLinter) runShellcheck(script string, dialect string) []Finding {
+	ctx, cancel := context.WithTimeout(context.Background(), shellcheckTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, linter.shellcheckPath, "--format=json", "--shell="+dialect, "-")
+	cmd.Stdin = strings.NewReader(script)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	// shellcheck exits with 1 when it finds something
+	if err := cmd.Run(); err != nil && stdout.Len() == 0 {
+		return []Finding{}
+	}
+
+	comments := []shellcheckComment{}
+	if err := json.Unmarshal(stdout.Bytes(), &comments); err != nil {
+		return []Finding{}
+	}
+
+	return commentsToFindings(script, comments)
+}
+
+func commentsToFindings(script string, comments []shellcheckComment) []Finding {
+	lines := strings.Split(script, "\n")
+	findings := []Finding{}
+	for _, comment := range comments {
+		findings = append(findings, Finding{
+			Line:      comment.Line,
+			Column:    virtualColumnToByteColumn(lines, comment.Line, comment.Column),
+			EndLine:   comment.EndLine,
+			EndColumn: virtualColumnToByteColumn(lines, comment.EndLine, comment.EndColumn),
+			Severity:  Severity(comment.Level),
+			Code:      fmt.Sprintf("SC%d", comment.Code),
+			Message:   comment.Message,
+		})
+	}
+
+	return findings
+}
+
+// shellcheck counts columns in characters, a tab moving to the next tab stop,
+// while findings are in bytes. A column within the width of a tab is the
+// column of the tab
+func virtualColumnToByteColumn(lines []string, line int, column int) int {
+	if line < 1 || line > len(lines) {
+		return column
+	}
+
+	virtualColumn := 1
+	for index, r := range lines[line-1] {
+		next := virtualColumn + 1
+		if r == '\t' {
+			next = ((virtualColumn-1)/shellcheckTabWidth+1)*shellcheckTabWidth + 1
+		}
+		if column < next {
+			return index + 1
+		}
+		virtualColumn = next
+	}
+
+	return len(lines[line-1]) + 1
+}
@@ -0,0 +1,39 @@
+package shellcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentsToFindings(t *testing.T) {
+	script := "if true; then\n\techo $x\n  \tcd é $y\nfi"
+
+	findings := commentsToFindings(script, []shellcheckComment{
+		// `$x` after a tab: virtual column 9, byte column 7
+		{Line: 2, Column: 14, EndLine: 2, EndColumn: 16, Level: "info", Code: 2086, Message: "Double quote"},
+		// `$y` after spaces, a tab and a multi-byte character
+		{Line: 3, Column: 14, EndLine: 3, EndColumn: 16, Level: "info", Code: 2086, Message: "Double quote"},
+	})
+
+	assert.Equal(t, []Finding{
+		{Line: 2, Column: 7, EndLine: 2, EndColumn: 9, Severity: SeverityInfo, Code: "SC2086", Message: "Double quote"},
+		{Line: 3, Column: 10, EndLine: 3, EndColumn: 12, Severity: SeverityInfo, Code: "SC2086", Message: "Double quote"},
+	}, findings)
+}
+
+func TestVirtualColumnToByteColumn(t *testing.T) {
+	lines := []string{"\tab", "a\tb"}
+
+	// Within the tab
+	assert.Equal(t, 1, virtualColumnToByteColumn(lines, 1, 1))
+	assert.Equal(t, 1, virtualColumnToByteColumn(lines, 1, 5))
+	assert.Equal(t, 2, virtualColumnToByteColumn(lines, 1, 9))
+	assert.Equal(t, 3, virtualColumnToByteColumn(lines, 1, 10))
+	// The tab stops do not depend on the start of the tab
+	assert.Equal(t, 3, virtualColumnToByteColumn(lines, 2, 9))
+	// After the end of the line
+	assert.Equal(t, 4, virtualColumnToByteColumn(lines, 1, 20))
+	// Unknown line
+	assert.Equal(t, 5, virtualColumnToByteColumn(lines, 3, 5))
+}
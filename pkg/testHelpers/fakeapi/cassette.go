@@ -0,0 +1,153 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+)
+
+// A Cassette holds the interactions with the real APIs recorded by a Recorder.
+// Replayed by the server, they take precedence over its data.
+//
+// To record one, run the session with the Recorder as transport then save it:
+//
+//	recorder := fakeapi.NewRecorder(http.DefaultTransport)
+//	restore := utils.SetHTTPTransport(recorder)
+//	...
+//	restore()
+//	recorder.Cassette().Save("testdata/session.json")
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	RequestBody string `json:"requestBody,omitempty"`
+
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	ResponseBody string      `json:"responseBody"`
+}
+
+// The headers of the responses worth replaying
+var recordedHeaders = []string{"Content-Type"}
+
+func LoadCassette(path string) (*Cassette, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{}
+	err = json.Unmarshal(content, cassette)
+	return cassette, err
+}
+
+func (c *Cassette) Save(path string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
+
+// Returns the first interaction made with the same request, the scheme of
+// the URL is ignored as the server only sees the host requested
+func (c *Cassette) find(method, host, requestURI, body string) (Interaction, bool) {
+	if c == nil {
+		return Interaction{}, false
+	}
+
+	for _, interaction := range c.Interactions {
+		u, err := url.Parse(interaction.URL)
+		if err == nil &&
+			interaction.Method == method &&
+			u.Host == host &&
+			u.RequestURI() == requestURI &&
+			interaction.RequestBody == body {
+			return interaction, true
+		}
+	}
+
+	return Interaction{}, false
+}
+
+// Replay makes the server answer with the interactions of the cassette
+func (s *Server) Replay(cassette *Cassette) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cassette = cassette
+}
+
+// A Recorder is a transport recording the interactions made through it
+type Recorder struct {
+	upstream http.RoundTripper
+
+	mutex    sync.Mutex
+	cassette Cassette
+}
+
+func NewRecorder(upstream http.RoundTripper) *Recorder {
+	return &Recorder{upstream: upstream}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := Interaction{
+		Method: req.Method,
+		URL:    req.URL.String(),
+	}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		interaction.RequestBody = string(body)
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	res, err := r.upstream.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction.Status = res.StatusCode
+	interaction.ResponseBody = string(body)
+	for _, key := range recordedHeaders {
+		if value := res.Header.Get(key); value != "" {
+			if interaction.Header == nil {
+				interaction.Header = http.Header{}
+			}
+			interaction.Header.Set(key, value)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+
+	return res, nil
+}
+
+// Cassette returns the interactions recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return &Cassette{Interactions: slices.Clone(r.cassette.Interactions)}
+}
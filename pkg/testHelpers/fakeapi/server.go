@@ -0,0 +1,490 @@
+// Package fakeapi serves, on a local HTTP server, the parts of the CircleCI,
+// orb registry and Docker Hub APIs the language server uses, so that whole
+// sessions can be tested without the network.
+//
+// The server answers from the data added to it, or from the interactions of a
+// cassette recorded against the real APIs, see cassette.go. Install routes
+// all the requests of the language server to it, whatever their host, which
+// also allows testing a self-hosted instance by changing the HostUrl of the
+// context.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"golang.org/x/mod/semver"
+)
+
+const DockerHubHost = "hub.docker.com"
+
+type Project struct {
+	Slug           string
+	Id             string
+	OrganizationId string
+	EnvVariables   []string
+}
+
+type Server struct {
+	*httptest.Server
+
+	mutex sync.Mutex
+
+	// Orb name -> version -> source
+	orbs               map[string]map[string]string
+	registryNamespaces map[string]bool
+	// Organization id -> contexts
+	contexts        map[string][]utils.ContextResponse
+	projects        map[string]Project
+	offerings       *utils.Offerings
+	resourceClasses map[string][]string
+	// Namespace/image -> tags
+	dockerImages map[string][]string
+
+	cassette *Cassette
+	requests []Request
+}
+
+// A Request is a request received by the server
+type Request struct {
+	Method string
+	Host   string
+	Path   string
+	Query  url.Values
+	Body   string
+}
+
+// New starts a server with no data, to be closed with Close
+func New() *Server {
+	s := &Server{
+		orbs:               make(map[string]map[string]string),
+		registryNamespaces: make(map[string]bool),
+		contexts:           make(map[string][]utils.ContextResponse),
+		projects:           make(map[string]Project),
+		resourceClasses:    make(map[string][]string),
+		dockerImages:       make(map[string][]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Install starts a server and routes all the requests of the language server
+// to it until the end of the test
+func Install(t testing.TB) *Server {
+	s := New()
+	restore := utils.SetHTTPTransport(s.Transport())
+	t.Cleanup(func() {
+		restore()
+		s.Close()
+	})
+
+	return s
+}
+
+// LsContext returns a context using the server as the CircleCI instance
+func (s *Server) LsContext() *utils.LsContext {
+	return &utils.LsContext{
+		Api: utils.ApiContext{
+			Token:   "XXXXXXXXXXXX",
+			HostUrl: s.URL,
+		},
+	}
+}
+
+// Transport returns a transport sending the requests to the server, the host
+// they were made to is kept in their Host header
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.URL)
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Host = req.URL.Host
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+
+		return s.Client().Transport.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// AddOrb adds a version of an orb, its namespace is registered with it
+func (s *Server) AddOrb(name, version, source string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.orbs[name] == nil {
+		s.orbs[name] = make(map[string]string)
+	}
+	s.orbs[name][version] = source
+	s.registryNamespaces[strings.Split(name, "/")[0]] = true
+}
+
+func (s *Server) AddRegistryNamespace(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.registryNamespaces[name] = true
+}
+
+func (s *Server) AddContext(organizationId, name string, envVariables ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	context := utils.ContextResponse{
+		Name:                 name,
+		ID:                   fmt.Sprintf("%s-%s", organizationId, name),
+		CreatedAt:            time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EnvironmentVariables: []utils.EnvVar{},
+	}
+	for _, envVariable := range envVariables {
+		context.EnvironmentVariables = append(context.EnvironmentVariables, utils.EnvVar{Variable: envVariable})
+	}
+
+	s.contexts[organizationId] = append(s.contexts[organizationId], context)
+}
+
+func (s *Server) AddProject(project Project) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.projects[project.Slug] = project
+}
+
+func (s *Server) SetOfferings(offerings utils.Offerings) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.offerings = &offerings
+}
+
+func (s *Server) AddResourceClass(namespace, resourceClass string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resourceClasses[namespace] = append(s.resourceClasses[namespace], resourceClass)
+}
+
+// AddDockerImage adds an image of Docker Hub, images of the official library
+// use the "library" namespace
+func (s *Server) AddDockerImage(namespace, image string, tags ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	key := namespace + "/" + image
+	s.dockerImages[key] = append(s.dockerImages[key], tags...)
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Host:   r.Host,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Body:   string(body),
+	})
+
+	if interaction, ok := s.cassette.find(r.Method, r.Host, r.URL.RequestURI(), string(body)); ok {
+		for key, values := range interaction.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(interaction.Status)
+		w.Write([]byte(interaction.ResponseBody))
+		return
+	}
+
+	if r.Host == DockerHubHost {
+		s.serveDockerHub(w, r)
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodPost && path == "/graphql-unstable":
+		s.serveGraphQL(w, body)
+
+	case path == "/api/v2/me":
+		writeJSON(w, http.StatusOK, utils.MeRes{Id: "fake-user", Login: "fake-user", Name: "Fake User"})
+
+	case path == "/api/v2/context":
+		s.serveContexts(w, r)
+
+	case strings.HasPrefix(path, "/api/v2/project/") && strings.HasSuffix(path, "/envvar"):
+		slug := strings.TrimSuffix(strings.TrimPrefix(path, "/api/v2/project/"), "/envvar")
+		s.serveProjectEnvVariables(w, slug)
+
+	case strings.HasPrefix(path, "/api/v2/project/"):
+		project, ok := s.projects[strings.TrimPrefix(path, "/api/v2/project/")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, utils.Project{
+			Slug:           project.Slug,
+			Id:             project.Id,
+			OrganizationId: project.OrganizationId,
+		})
+
+	case path == "/api/v3/catalog/offerings":
+		if s.offerings == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{"attributes": s.offerings},
+		})
+
+	case path == "/api/v3/runner/resource":
+		items := []map[string]string{}
+		for _, resourceClass := range s.resourceClasses[r.URL.Query().Get("namespace")] {
+			items = append(items, map[string]string{"resource_class": resourceClass})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not found"})
+	}
+}
+
+func (s *Server) serveContexts(w http.ResponseWriter, r *http.Request) {
+	contexts := slices.Clone(s.contexts[r.URL.Query().Get("owner-id")])
+
+	if r.URL.Query().Get("include-env-vars") != "true" {
+		for i := range contexts {
+			contexts[i].EnvironmentVariables = nil
+		}
+	}
+
+	writeJSON(w, http.StatusOK, utils.GetAllContextRes{Items: contexts})
+}
+
+func (s *Server) serveProjectEnvVariables(w http.ResponseWriter, slug string) {
+	project, ok := s.projects[slug]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+
+	res := utils.ProjectEnvVariableRes{}
+	for _, envVariable := range project.EnvVariables {
+		res.Items = append(res.Items, struct {
+			Name  string
+			Value string
+		}{Name: envVariable, Value: "xxxx"})
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type orbVersion struct {
+	Version string `json:"version"`
+}
+
+func (s *Server) serveGraphQL(w http.ResponseWriter, body []byte) {
+	var req graphQLRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []map[string]string{{"message": err.Error()}},
+		})
+		return
+	}
+
+	name, _ := req.Variables["name"].(string)
+	var data map[string]any
+
+	switch {
+	case strings.Contains(req.Query, "orbVersion("):
+		ref, _ := req.Variables["orbVersionRef"].(string)
+		data = map[string]any{"orbVersion": s.getOrbVersion(ref)}
+
+	case strings.Contains(req.Query, "orb("):
+		if orbName, ok := req.Variables["orbName"].(string); ok {
+			name = orbName
+		}
+		data = map[string]any{"orb": s.getOrb(name)}
+
+	case strings.Contains(req.Query, "registryNamespace("):
+		data = map[string]any{"registryNamespace": s.getRegistryNamespace(name, strings.Contains(req.Query, "orbs("))}
+
+	default:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"errors": []map[string]string{{"message": "fakeapi: unknown query"}},
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"data": data})
+}
+
+// The versions of the orb, the most recent first
+func (s *Server) getOrbVersions(name string) []orbVersion {
+	versions := []string{}
+	for version := range s.orbs[name] {
+		versions = append(versions, version)
+	}
+	slices.SortFunc(versions, func(a, b string) int {
+		return semver.Compare("v"+b, "v"+a)
+	})
+
+	res := []orbVersion{}
+	for _, version := range versions {
+		res = append(res, orbVersion{Version: version})
+	}
+	return res
+}
+
+func (s *Server) getOrb(name string) any {
+	if _, ok := s.orbs[name]; !ok {
+		return nil
+	}
+
+	return map[string]any{
+		"id":       name,
+		"name":     name,
+		"versions": s.getOrbVersions(name),
+	}
+}
+
+// Resolves the references made of the name of the orb and of a version, a
+// partial version or "volatile"
+func (s *Server) getOrbVersion(ref string) any {
+	name, wanted, _ := strings.Cut(ref, "@")
+	versions := s.getOrbVersions(name)
+
+	for _, version := range versions {
+		if wanted == "volatile" || version.Version == wanted || strings.HasPrefix(version.Version, wanted+".") {
+			return map[string]any{
+				"id":      ref,
+				"version": version.Version,
+				"orb": map[string]any{
+					"id":       name,
+					"versions": versions,
+				},
+				"source": s.orbs[name][version.Version],
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) getRegistryNamespace(name string, withOrbs bool) any {
+	if !s.registryNamespaces[name] {
+		return nil
+	}
+	if !withOrbs {
+		return map[string]any{"name": name}
+	}
+
+	edges := []map[string]any{}
+	orbNames := []string{}
+	for orbName := range s.orbs {
+		if strings.HasPrefix(orbName, name+"/") {
+			orbNames = append(orbNames, orbName)
+		}
+	}
+	slices.Sort(orbNames)
+	for _, orbName := range orbNames {
+		edges = append(edges, map[string]any{"cursor": orbName, "node": s.getOrb(orbName)})
+	}
+
+	return map[string]any{
+		"id":   name,
+		"name": name,
+		"orbs": map[string]any{
+			"edges":      edges,
+			"totalCount": len(edges),
+		},
+	}
+}
+
+type dockerHubTag struct {
+	Name      string `json:"name"`
+	TagStatus string `json:"tag_status"`
+}
+
+// Serves /v2/namespaces/{namespace}/repositories[/{image}[/tags[/{tag}]]]
+func (s *Server) serveDockerHub(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	notFound := map[string]string{"message": "object not found"}
+
+	if len(parts) < 4 || parts[0] != "v2" || parts[1] != "namespaces" || parts[3] != "repositories" {
+		writeJSON(w, http.StatusNotFound, notFound)
+		return
+	}
+	namespace := parts[2]
+
+	if len(parts) == 4 {
+		results := []map[string]any{}
+		images := []string{}
+		for key := range s.dockerImages {
+			if image, ok := strings.CutPrefix(key, namespace+"/"); ok {
+				images = append(images, image)
+			}
+		}
+		slices.Sort(images)
+		for _, image := range images {
+			results = append(results, map[string]any{"name": image, "namespace": namespace})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(results), "results": results})
+		return
+	}
+
+	tags, ok := s.dockerImages[namespace+"/"+parts[4]]
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusNotFound, notFound)
+
+	case len(parts) == 5:
+		writeJSON(w, http.StatusOK, map[string]any{"name": parts[4], "namespace": namespace})
+
+	case len(parts) == 6 && parts[5] == "tags":
+		name := r.URL.Query().Get("name")
+		results := []dockerHubTag{}
+		for _, tag := range tags {
+			if strings.Contains(tag, name) {
+				results = append(results, dockerHubTag{Name: tag, TagStatus: "active"})
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"count": len(results), "results": results})
+
+	case len(parts) == 7 && parts[5] == "tags" && slices.Contains(tags, parts[6]):
+		writeJSON(w, http.StatusOK, dockerHubTag{Name: parts[6], TagStatus: "active"})
+
+	default:
+		writeJSON(w, http.StatusNotFound, notFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
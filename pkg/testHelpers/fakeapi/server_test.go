@@ -0,0 +1,110 @@
+package fakeapi_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/dockerhub"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/parser"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/testHelpers/fakeapi"
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const nodeOrb = `version: 2.1
+commands:
+  install:
+    steps:
+      - run: npm ci
+`
+
+func TestServer(t *testing.T) {
+	server := fakeapi.Install(t)
+	lsContext := server.LsContext()
+
+	server.AddOrb("circleci/node", "5.1.0", nodeOrb)
+	server.AddOrb("circleci/node", "5.0.0", "version: 2.1\n")
+	server.AddContext("org-id", "deploy", "AWS_KEY")
+	server.AddProject(fakeapi.Project{Slug: "gh/org/repo", Id: "project-id", OrganizationId: "org-id", EnvVariables: []string{"NPM_TOKEN"}})
+	server.SetOfferings(utils.Offerings{Linux: map[string][]string{"medium": {"ubuntu-2404:current"}}})
+	server.AddDockerImage("cimg", "node", "20.11", "22.1")
+
+	orb, err := parser.GetRemoteOrb(context.Background(), "circleci/node@5", lsContext.Api.Token, lsContext.Api.HostUrl, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.1.0", orb.Version)
+	assert.Equal(t, nodeOrb, orb.Source)
+	assert.Len(t, orb.Orb.Versions, 2)
+
+	_, err = parser.GetRemoteOrb(context.Background(), "circleci/unknown@1.0.0", lsContext.Api.Token, lsContext.Api.HostUrl, "")
+	assert.Error(t, err)
+
+	orbData, err := parser.GetOrbByName("circleci/node", lsContext)
+	assert.NoError(t, err)
+	assert.Equal(t, "circleci/node", orbData.Name)
+
+	cache := utils.CreateCache()
+	assert.NoError(t, utils.GetAllContextWithEnvVars(t.Context(), lsContext, "org-id", cache))
+	envVariables := utils.GetAllContextEnvVariables(cache, "org-id", []string{"deploy"})
+	assert.Equal(t, []utils.ContextEnvVariable{{Name: "AWS_KEY", AssociatedContext: "deploy"}}, envVariables)
+
+	project, err := utils.GetProjectId(t.Context(), "gh/org/repo", lsContext)
+	assert.NoError(t, err)
+	assert.Equal(t, "org-id", project.OrganizationId)
+
+	file := cache.FileCache.SetFile(utils.CachedFile{Project: project})
+	utils.GetAllProjectEnvVariables(t.Context(), lsContext, cache, &file)
+	assert.Equal(t, []string{"NPM_TOKEN"}, cache.FileCache.GetFile(file.TextDocument.URI).EnvVariables)
+
+	assert.Equal(t, []string{"medium"}, utils.MachineResourceClasses(lsContext, cache))
+
+	// Docker Hub is reached at its own host
+	api := dockerhub.NewAPI()
+	assert.True(t, api.DoesImageExist("cimg", "node"))
+	assert.False(t, api.DoesImageExist("cimg", "unknown"))
+	assert.True(t, api.ImageHasTag("cimg", "node", "22.1"))
+	assert.False(t, api.ImageHasTag("cimg", "node", "18.0"))
+	tags, err := api.GetImageTags("cimg", "node")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"20.11", "22.1"}, tags)
+
+	hosts := map[string]bool{}
+	for _, request := range server.Requests() {
+		hosts[request.Host] = true
+	}
+	assert.True(t, hosts[fakeapi.DockerHubHost])
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	upstream := fakeapi.New()
+	defer upstream.Close()
+	upstream.AddOrb("circleci/node", "5.1.0", nodeOrb)
+	upstream.AddDockerImage("cimg", "node", "22.1")
+
+	// Record the interactions with the real APIs, here stood in for by another
+	// server
+	recorder := fakeapi.NewRecorder(upstream.Transport())
+	restore := utils.SetHTTPTransport(recorder)
+	orb, err := parser.GetRemoteOrb(context.Background(), "circleci/node@5.1.0", "token", "https://circleci.com", "")
+	assert.NoError(t, err)
+	assert.True(t, dockerhub.NewAPI().ImageHasTag("cimg", "node", "22.1"))
+	restore()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	assert.NoError(t, recorder.Cassette().Save(path))
+	cassette, err := fakeapi.LoadCassette(path)
+	assert.NoError(t, err)
+	assert.Len(t, cassette.Interactions, 2)
+	assert.Equal(t, http.StatusOK, cassette.Interactions[1].Status)
+
+	// Replayed by a server without data
+	server := fakeapi.Install(t)
+	server.Replay(cassette)
+
+	replayed, err := parser.GetRemoteOrb(context.Background(), "circleci/node@5.1.0", "token", "https://circleci.com", "")
+	assert.NoError(t, err)
+	assert.Equal(t, orb, replayed)
+	assert.True(t, dockerhub.NewAPI().ImageHasTag("cimg", "node", "22.1"))
+	assert.False(t, dockerhub.NewAPI().ImageHasTag("cimg", "node", "18.0"))
+}
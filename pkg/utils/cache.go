@@ -1,12 +1,16 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"maps"
 	"os"
 	"path"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
 	"github.com/adrg/xdg"
@@ -21,16 +25,48 @@ type Cache struct {
 	ResourceClassCache    ResourceClassCache
 	ContextCache          ContextCache
 	MachineOfferingsCache MachineOfferingsCache
+	WorkspaceCache        WorkspaceCache
+
+	// The data kept across sessions, nil when it is not, see diskCache.go
+	DiskCache *DiskCache
+
+	generation *cacheGeneration
+}
+
+// The diagnostics of a document depend on its content and on the data fetched
+// or found for it: the orbs, Docker images, contexts, project environment
+// variables and files of the workspace. The generation counts the changes of
+// this data
+type cacheGeneration struct {
+	value    atomic.Uint64
+	listener atomic.Pointer[func()]
+}
+
+func (g *cacheGeneration) bump() {
+	if g == nil {
+		return
+	}
+
+	g.value.Add(1)
+	if listener := g.listener.Load(); listener != nil {
+		(*listener)()
+	}
 }
 
 type DockerCache struct {
-	cacheMutex  *sync.Mutex
-	dockerCache map[string]*CachedDockerImage
+	cacheMutex   *sync.Mutex
+	dockerCache  map[string]*CachedDockerImage
+	revalidating map[string]bool
+	disk         *DiskCache
+	generation   *cacheGeneration
 }
 
 type CachedDockerImage struct {
 	Checked bool
 	Exists  bool
+
+	// Read from the disk past its TTL, see DockerCache.Revalidate
+	Stale bool
 }
 
 type CachedDockerTags struct {
@@ -39,27 +75,39 @@ type CachedDockerTags struct {
 
 	// The key of the map are the tag checked and the value is whether this tag exists or not
 	CheckedTags map[string]bool
+
+	// Read from the disk past its TTL, see DockerTagsCache.Revalidate
+	Stale bool `json:"-"`
 }
 
 type DockerTagsCache struct {
-	cacheMutex *sync.Mutex
-	tagsCache  map[string]CachedDockerTags
+	cacheMutex   *sync.Mutex
+	tagsCache    map[string]CachedDockerTags
+	revalidating map[string]bool
+	disk         *DiskCache
+	generation   *cacheGeneration
 }
 
 type CachedFile struct {
 	TextDocument protocol.TextDocumentItem
 	Project      Project
 	EnvVariables []string
+
+	// Hash of the text of the document, it identifies the diagnostics
+	// computed for this content
+	ContentHash string
 }
 
 type FileCache struct {
 	cacheMutex *sync.Mutex
 	fileCache  map[protocol.URI]*CachedFile
+	generation *cacheGeneration
 }
 
 type OrbCache struct {
 	cacheMutex *sync.Mutex
 	orbsCache  map[string]*ast.OrbInfo
+	generation *cacheGeneration
 }
 
 type ContextCache struct {
@@ -67,6 +115,7 @@ type ContextCache struct {
 	contextCache        map[string]map[string]*Context
 	ambiguousShortNames map[string]map[string]struct{}
 	listLoadedOrgs      map[string]bool
+	generation          *cacheGeneration
 }
 
 type ResourceClassCache struct {
@@ -75,17 +124,31 @@ type ResourceClassCache struct {
 }
 
 func (c *Cache) init() {
+	c.generation = &cacheGeneration{}
+	c.FileCache.generation = c.generation
+	c.WorkspaceCache.generation = c.generation
+	c.OrbCache.generation = c.generation
+	c.DockerCache.generation = c.generation
+	c.DockerTagsCache.generation = c.generation
+	c.ContextCache.generation = c.generation
+	c.MachineOfferingsCache.generation = c.generation
+
 	c.FileCache.fileCache = make(map[protocol.URI]*CachedFile)
 	c.FileCache.cacheMutex = &sync.Mutex{}
 
+	c.WorkspaceCache.folders = make(map[protocol.URI]*WorkspaceFolder)
+	c.WorkspaceCache.cacheMutex = &sync.Mutex{}
+
 	c.OrbCache.orbsCache = make(map[string]*ast.OrbInfo)
 	c.OrbCache.cacheMutex = &sync.Mutex{}
 
 	c.DockerCache.cacheMutex = &sync.Mutex{}
 	c.DockerCache.dockerCache = make(map[string]*CachedDockerImage)
+	c.DockerCache.revalidating = make(map[string]bool)
 
 	c.DockerTagsCache.cacheMutex = &sync.Mutex{}
 	c.DockerTagsCache.tagsCache = make(map[string]CachedDockerTags)
+	c.DockerTagsCache.revalidating = make(map[string]bool)
 
 	c.ContextCache.cacheMutex = &sync.Mutex{}
 	c.ContextCache.contextCache = make(map[string]map[string]*Context)
@@ -101,6 +164,7 @@ func (c *Cache) init() {
 func (c *FileCache) SetFile(cachedFile CachedFile) CachedFile {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
+	cachedFile.ContentHash = HashContent(cachedFile.TextDocument.Text)
 	c.fileCache[cachedFile.TextDocument.URI] = &cachedFile
 	return cachedFile
 }
@@ -127,32 +191,71 @@ func (c *FileCache) AddEnvVariableToProjectLinkedToFile(uri protocol.URI, envVar
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 	project := c.fileCache[uri]
+	if project == nil {
+		return
+	}
 
 	if !slices.Contains(project.EnvVariables, envVariable) {
 		project.EnvVariables = append(project.EnvVariables, envVariable)
+		c.generation.bump()
 	}
 	c.fileCache[uri] = project
 }
 
+// SetProjectEnvVariables replaces the environment variables of the project of
+// the file, such as once fetched again
+func (c *FileCache) SetProjectEnvVariables(uri protocol.URI, envVariables []string) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	file := c.fileCache[uri]
+	if file == nil {
+		return
+	}
+
+	envVariables = slices.Compact(slices.Sorted(slices.Values(envVariables)))
+	if !slices.Equal(file.EnvVariables, envVariables) {
+		file.EnvVariables = envVariables
+		c.generation.bump()
+	}
+}
+
 func (c *FileCache) AddProjectSlugToFile(uri protocol.URI, project Project) {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 	file := c.fileCache[uri]
 
-	file.Project = project
+	if file.Project != project {
+		file.Project = project
+		c.generation.bump()
+	}
 
 	c.fileCache[uri] = file
 }
 
+func (c *FileCache) clearEnvVariables() {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	for _, file := range c.fileCache {
+		file.EnvVariables = []string{}
+	}
+	c.generation.bump()
+}
+
 func (c *FileCache) UpdateTextDocument(uri protocol.URI, textDocument protocol.TextDocumentItem) {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 	file := c.fileCache[uri]
 	file.TextDocument = textDocument
+	file.ContentHash = HashContent(textDocument.Text)
 
 	c.fileCache[uri] = file
 }
 
+func HashContent(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
 // ORBS
 
 func (c *OrbCache) HasOrb(orbID string) bool {
@@ -164,9 +267,17 @@ func (c *OrbCache) HasOrb(orbID string) bool {
 	return ok
 }
 
+// SetOrb adds or replaces an orb. The orbs of the disk are set again on each
+// parsing, they only count as a change when their source or versions differ
 func (c *OrbCache) SetOrb(orb *ast.OrbInfo, orbID string) ast.OrbInfo {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
+
+	previous, ok := c.orbsCache[orbID]
+	if !ok || previous.Source != orb.Source || previous.RemoteInfo != orb.RemoteInfo {
+		c.generation.bump()
+	}
+
 	c.orbsCache[orbID] = orb
 	return *orb
 }
@@ -175,6 +286,7 @@ func (c *OrbCache) UpdateOrbParsedAttributes(orbID string, parsedOrbAttributes a
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 	c.orbsCache[orbID].OrbParsedAttributes = parsedOrbAttributes
+	c.generation.bump()
 	return parsedOrbAttributes
 }
 
@@ -184,15 +296,28 @@ func (c *OrbCache) GetOrb(orbID string) *ast.OrbInfo {
 	return c.orbsCache[orbID]
 }
 
+// GetOrbs returns a copy of the cached orbs, by orb ID
+func (c *OrbCache) GetOrbs() map[string]*ast.OrbInfo {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	return maps.Clone(c.orbsCache)
+}
+
 func (c *OrbCache) RemoveOrb(orbID string) {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
-	delete(c.orbsCache, orbID)
+	if _, ok := c.orbsCache[orbID]; ok {
+		delete(c.orbsCache, orbID)
+		c.generation.bump()
+	}
 }
 
 func (c *OrbCache) RemoveOrbs() {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
+	if len(c.orbsCache) > 0 {
+		c.generation.bump()
+	}
 	for k := range c.orbsCache {
 		delete(c.orbsCache, k)
 	}
@@ -213,14 +338,24 @@ func (c *Cache) RemoveOrbFiles() {
 
 // Docker images cache
 
+// Only the existing images are kept on the disk: an image is also reported as
+// missing when Docker Hub can not be reached
 func (c *DockerCache) Add(name string, exists bool) *CachedDockerImage {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 
-	c.dockerCache[name] = &CachedDockerImage{
+	image := &CachedDockerImage{
 		Checked: true,
 		Exists:  exists,
 	}
+	if previous, ok := c.dockerCache[name]; !ok || *previous != *image {
+		c.generation.bump()
+	}
+	c.dockerCache[name] = image
+
+	if exists {
+		c.disk.Set(DiskCacheDockerImages, name, true)
+	}
 
 	return c.dockerCache[name]
 }
@@ -229,6 +364,19 @@ func (c *DockerCache) Get(name string) *CachedDockerImage {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 
+	if image, ok := c.dockerCache[name]; ok {
+		return image
+	}
+
+	exists := false
+	if found, fresh := c.disk.Get(DiskCacheDockerImages, name, &exists); found {
+		c.dockerCache[name] = &CachedDockerImage{
+			Checked: true,
+			Exists:  exists,
+			Stale:   !fresh,
+		}
+	}
+
 	return c.dockerCache[name]
 }
 
@@ -236,7 +384,42 @@ func (c *DockerCache) Remove(name string) {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 
-	delete(c.dockerCache, name)
+	if _, ok := c.dockerCache[name]; ok {
+		delete(c.dockerCache, name)
+		c.generation.bump()
+	}
+}
+
+// Revalidate checks again, in the background, a stale image. The image is
+// forgotten when it is not found, to be checked again on its next use
+func (c *DockerCache) Revalidate(name string, exists func() bool) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	if c.revalidating[name] {
+		return
+	}
+	c.revalidating[name] = true
+
+	go func() {
+		if exists() {
+			c.Add(name, true)
+		} else {
+			c.Remove(name)
+			c.disk.Remove(DiskCacheDockerImages, name)
+		}
+
+		c.cacheMutex.Lock()
+		defer c.cacheMutex.Unlock()
+		delete(c.revalidating, name)
+	}()
+}
+
+func (c *DockerCache) clear() {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	c.dockerCache = make(map[string]*CachedDockerImage)
+	c.generation.bump()
 }
 
 // Docker tags cache
@@ -245,20 +428,78 @@ func (c *DockerTagsCache) Add(namespace, image string, value CachedDockerTags) {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 
-	c.tagsCache[fmt.Sprintf("%s/%s", namespace, image)] = value
+	key := fmt.Sprintf("%s/%s", namespace, image)
+	if previous, ok := c.tagsCache[key]; !ok ||
+		previous.Recommended != value.Recommended ||
+		previous.Stale != value.Stale ||
+		!maps.Equal(previous.CheckedTags, value.CheckedTags) {
+		c.generation.bump()
+	}
+	c.tagsCache[key] = value
+
+	// As for the images, the missing tags may come from a network error
+	stored := CachedDockerTags{Recommended: value.Recommended, CheckedTags: map[string]bool{}}
+	for tag, exists := range value.CheckedTags {
+		if exists {
+			stored.CheckedTags[tag] = true
+		}
+	}
+	c.disk.Set(DiskCacheDockerTags, key, stored)
 }
 
 func (c *DockerTagsCache) Get(namespace, image string) *CachedDockerTags {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 
-	tags, ok := c.tagsCache[fmt.Sprintf("%s/%s", namespace, image)]
-	if !ok {
+	key := fmt.Sprintf("%s/%s", namespace, image)
+	tags, ok := c.tagsCache[key]
+	if ok {
+		return &tags
+	}
+
+	found, fresh := c.disk.Get(DiskCacheDockerTags, key, &tags)
+	if !found {
 		return nil
 	}
+	if tags.CheckedTags == nil {
+		tags.CheckedTags = map[string]bool{}
+	}
+	tags.Stale = !fresh
+	c.tagsCache[key] = tags
+
 	return &tags
 }
 
+// Revalidate fetches again, in the background, the stale tags of an image.
+// The stale tags are kept when they can not be fetched
+func (c *DockerTagsCache) Revalidate(namespace, image string, fetch func() *CachedDockerTags) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	key := fmt.Sprintf("%s/%s", namespace, image)
+	if c.revalidating[key] {
+		return
+	}
+	c.revalidating[key] = true
+
+	go func() {
+		if tags := fetch(); tags != nil {
+			c.Add(namespace, image, *tags)
+		}
+
+		c.cacheMutex.Lock()
+		defer c.cacheMutex.Unlock()
+		delete(c.revalidating, key)
+	}()
+}
+
+func (c *DockerTagsCache) clear() {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	c.tagsCache = make(map[string]CachedDockerTags)
+	c.generation.bump()
+}
+
 // Cache
 
 func CreateCache() *Cache {
@@ -267,6 +508,50 @@ func CreateCache() *Cache {
 	return &cache
 }
 
+// Generation changes whenever the data the diagnostics depend on, besides the
+// content of the documents, changes
+func (cache *Cache) Generation() uint64 {
+	return cache.generation.value.Load()
+}
+
+// OnChange sets the function called on each change of the generation. It is
+// called with the lock of the changed cache held and must not block
+func (cache *Cache) OnChange(listener func()) {
+	cache.generation.listener.Store(&listener)
+}
+
+// SetDiskCache makes the cache read and keep the data fetched from CircleCI
+// and Docker Hub on the disk
+func (cache *Cache) SetDiskCache(disk *DiskCache) {
+	cache.DiskCache = disk
+	cache.DockerCache.disk = disk
+	cache.DockerTagsCache.disk = disk
+}
+
+// Invalidate forgets the data of the given kinds fetched from CircleCI and
+// Docker Hub, or all of it when none is given
+func (cache *Cache) Invalidate(kinds ...DiskCacheKind) {
+	if len(kinds) == 0 {
+		kinds = DiskCacheKinds
+	}
+	cache.DiskCache.Invalidate(kinds...)
+
+	for _, kind := range kinds {
+		switch kind {
+		case DiskCacheDockerImages:
+			cache.DockerCache.clear()
+		case DiskCacheDockerTags:
+			cache.DockerTagsCache.clear()
+		case DiskCacheMachineOfferings:
+			cache.MachineOfferingsCache.clear()
+		case DiskCacheContexts:
+			cache.clearContextCache()
+		case DiskCacheProjectEnvVariables:
+			cache.FileCache.clearEnvVariables()
+		}
+	}
+}
+
 func GetOrbCacheFSPath(orbYaml string) string {
 	file := path.Join("cci", "orbs", ".circleci", orbYaml+".yml")
 	filePath, err := xdg.CacheFile(file)
@@ -281,6 +566,7 @@ func (cache *Cache) ClearHostData() {
 	cache.RemoveOrbFiles()
 	cache.OrbCache.RemoveOrbs()
 	cache.clearContextCache()
+	cache.WorkspaceCache.clearProjects()
 }
 
 func (cache *Cache) clearContextCache() {
@@ -289,6 +575,7 @@ func (cache *Cache) clearContextCache() {
 	cache.ContextCache.contextCache = make(map[string]map[string]*Context)
 	cache.ContextCache.ambiguousShortNames = make(map[string]map[string]struct{})
 	cache.ContextCache.listLoadedOrgs = make(map[string]bool)
+	cache.generation.bump()
 }
 
 func (c *ContextCache) MarkOrganizationContextListLoaded(organizationId string) {
@@ -297,7 +584,10 @@ func (c *ContextCache) MarkOrganizationContextListLoaded(organizationId string)
 	if c.listLoadedOrgs == nil {
 		c.listLoadedOrgs = make(map[string]bool)
 	}
-	c.listLoadedOrgs[organizationId] = true
+	if !c.listLoadedOrgs[organizationId] {
+		c.listLoadedOrgs[organizationId] = true
+		c.generation.bump()
+	}
 }
 
 func (c *ContextCache) IsOrganizationContextListLoaded(organizationId string) bool {
@@ -312,6 +602,7 @@ func (c *ContextCache) ClearOrganizationContextList(organizationId string) {
 	delete(c.contextCache, organizationId)
 	delete(c.ambiguousShortNames, organizationId)
 	delete(c.listLoadedOrgs, organizationId)
+	c.generation.bump()
 }
 
 func (c *ContextCache) SetOrganizationContext(organizationId string, ctx *Context) *Context {
@@ -320,6 +611,34 @@ func (c *ContextCache) SetOrganizationContext(organizationId string, ctx *Contex
 	if c.contextCache[organizationId] == nil {
 		c.contextCache[organizationId] = make(map[string]*Context)
 	}
+	orgMap := c.contextCache[organizationId]
+	if previous, ok := orgMap[ctx.Name]; !ok || !previous.equal(ctx) {
+		c.generation.bump()
+	}
+	c.setOrganizationContext(organizationId, ctx)
+	return ctx
+}
+
+// SetOrganizationContexts replaces the contexts of the organization, such as
+// once fetched again, the contexts removed meanwhile being forgotten
+func (c *ContextCache) SetOrganizationContexts(organizationId string, contexts []*Context) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	previous := c.contextCache[organizationId]
+	c.contextCache[organizationId] = make(map[string]*Context)
+	delete(c.ambiguousShortNames, organizationId)
+	for _, ctx := range contexts {
+		c.setOrganizationContext(organizationId, ctx)
+	}
+
+	current := c.contextCache[organizationId]
+	if !maps.EqualFunc(previous, current, (*Context).equal) {
+		c.generation.bump()
+	}
+}
+
+func (c *ContextCache) setOrganizationContext(organizationId string, ctx *Context) {
 	orgMap := c.contextCache[organizationId]
 	orgMap[ctx.Name] = ctx
 	// CircleCI configs often use the short context name for the project's org; the API may
@@ -327,21 +646,19 @@ func (c *ContextCache) SetOrganizationContext(organizationId string, ctx *Contex
 	if i := strings.LastIndex(ctx.Name, "/"); i >= 0 {
 		short := ctx.Name[i+1:]
 		if short == "" {
-			return ctx
+			return
 		}
 		if c.isAmbiguousShortName(organizationId, short) {
-			return ctx
+			return
 		}
-		if existing, exists := orgMap[short]; exists {
-			if existing != ctx {
-				delete(orgMap, short)
-				c.markAmbiguousShortName(organizationId, short)
-			}
-			return ctx
+		// A context set again replaces its previous version
+		if existing, exists := orgMap[short]; exists && existing.Name != ctx.Name {
+			delete(orgMap, short)
+			c.markAmbiguousShortName(organizationId, short)
+			return
 		}
 		orgMap[short] = ctx
 	}
-	return ctx
 }
 
 func (c *ContextCache) isAmbiguousShortName(organizationId, short string) bool {
@@ -398,7 +715,10 @@ func (c *ContextCache) RemoveOrganizationContext(organizationId string, name str
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
 	org := c.contextCache[organizationId]
-	delete(org, name)
+	if _, ok := org[name]; ok {
+		delete(org, name)
+		c.generation.bump()
+	}
 }
 
 func (c *ContextCache) AddEnvVariableToOrganizationContext(organizationId string, name string, envVariable string) {
@@ -408,6 +728,7 @@ func (c *ContextCache) AddEnvVariableToOrganizationContext(organizationId string
 
 	if !slices.Contains(ctx.envVariables, envVariable) {
 		ctx.envVariables = append(ctx.envVariables, envVariable)
+		c.generation.bump()
 	}
 	c.contextCache[organizationId][name] = ctx
 }
@@ -1,6 +1,10 @@
 package utils
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/CircleCI-Public/circleci-yaml-language-server/pkg/ast"
+)
 
 func testContext(name string) *Context {
 	return &Context{Name: name}
@@ -98,3 +102,40 @@ func TestContextCache_listLoadedTracking(t *testing.T) {
 		t.Fatal("expected list loaded after mark")
 	}
 }
+
+func TestCache_generation(t *testing.T) {
+	cache := CreateCache()
+	changes := 0
+	cache.OnChange(func() { changes++ })
+
+	cache.OrbCache.SetOrb(&ast.OrbInfo{Source: "version: 2.1\n"}, "circleci/node@5.0.0")
+	cache.DockerCache.Add("cimg/base", true)
+	cache.ContextCache.SetOrganizationContext("org-uuid", testContext("deploy"))
+	cache.MachineOfferingsCache.Set(&Offerings{Linux: map[string][]string{"medium": {CurrentLinuxImage}}})
+	if changes != 4 || cache.Generation() != 4 {
+		t.Fatalf("expected 4 changes, got %d (generation %d)", changes, cache.Generation())
+	}
+
+	// The same data set again is not a change
+	cache.OrbCache.SetOrb(&ast.OrbInfo{Source: "version: 2.1\n"}, "circleci/node@5.0.0")
+	cache.DockerCache.Add("cimg/base", true)
+	cache.ContextCache.SetOrganizationContext("org-uuid", testContext("deploy"))
+	cache.MachineOfferingsCache.Set(&Offerings{Linux: map[string][]string{"medium": {CurrentLinuxImage}}})
+	cache.OrbCache.RemoveOrb("circleci/python@2.1.0")
+	if changes != 4 {
+		t.Fatalf("expected no new change, got %d", changes-4)
+	}
+
+	cache.OrbCache.SetOrb(&ast.OrbInfo{Source: "version: 2.1\ndescription: new\n"}, "circleci/node@5.0.0")
+	cache.DockerCache.Add("cimg/base", false)
+	cache.MachineOfferingsCache.Set(&Offerings{Linux: map[string][]string{"large": {CurrentLinuxImage}}})
+	if changes != 7 {
+		t.Fatalf("expected 3 new changes, got %d", changes-4)
+	}
+
+	// The offerings fetched again once invalidated
+	cache.Invalidate(DiskCacheMachineOfferings)
+	if changes != 8 {
+		t.Fatalf("expected the invalidation to be a change, got %d", changes-7)
+	}
+}
@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -15,6 +18,13 @@ type Context struct {
 	envVariables []string
 }
 
+func (c *Context) equal(other *Context) bool {
+	return c.Id == other.Id &&
+		c.Name == other.Name &&
+		c.CreatedAt == other.CreatedAt &&
+		slices.Equal(c.envVariables, other.envVariables)
+}
+
 type ContextEnvVariable struct {
 	Name              string
 	AssociatedContext string
@@ -58,22 +68,30 @@ type GetAllContextRes struct {
 	NextPageToken *string           `json:"next_page_token"`
 }
 
-func GetAllContext(lsContext *LsContext, orgID string, cache *Cache) error {
+// GetAllContext replaces the contexts of the organization in the cache. The
+// environment variables known for a context are kept until fetched again, see
+// GetAllContextWithEnvVars
+func GetAllContext(ctx context.Context, lsContext *LsContext, orgID string, cache *Cache) error {
 	pageToken := ""
+	contexts := []*Context{}
 
 	for {
-		res, err := getContext(lsContext, orgID, pageToken, false)
+		res, err := getContext(ctx, lsContext, orgID, pageToken, false)
 		if err != nil {
 			return err
 		}
 
 		for _, c := range res.Items {
-			cache.ContextCache.SetOrganizationContext(orgID, &Context{
+			context := &Context{
 				Id:           c.ID,
 				Name:         c.Name,
 				CreatedAt:    c.CreatedAt.String(),
 				envVariables: envVarNames(c.EnvironmentVariables),
-			})
+			}
+			if existing := cache.ContextCache.GetOrganizationContext(orgID, c.Name); existing != nil && existing.Id == c.ID && context.envVariables == nil {
+				context.envVariables = existing.envVariables
+			}
+			contexts = append(contexts, context)
 		}
 
 		if res.NextPageToken == nil {
@@ -83,26 +101,22 @@ func GetAllContext(lsContext *LsContext, orgID string, cache *Cache) error {
 		pageToken = *res.NextPageToken
 	}
 
+	cache.ContextCache.SetOrganizationContexts(orgID, contexts)
 	return nil
 }
 
 // GetAllContextWithEnvVars loads contexts including environment variable names when the token
 // has permission. Used for completion; callers should prefer GetAllContext for validation.
-func GetAllContextWithEnvVars(lsContext *LsContext, orgID string, cache *Cache) error {
+func GetAllContextWithEnvVars(ctx context.Context, lsContext *LsContext, orgID string, cache *Cache) error {
 	pageToken := ""
 
 	for {
-		res, err := getContext(lsContext, orgID, pageToken, true)
+		res, err := getContext(ctx, lsContext, orgID, pageToken, true)
 		if err != nil {
 			return err
 		}
 
 		for _, c := range res.Items {
-			existing := cache.ContextCache.GetOrganizationContext(orgID, c.Name)
-			if existing != nil {
-				existing.envVariables = envVarNames(c.EnvironmentVariables)
-				continue
-			}
 			cache.ContextCache.SetOrganizationContext(orgID, &Context{
 				Id:           c.ID,
 				Name:         c.Name,
@@ -121,14 +135,14 @@ func GetAllContextWithEnvVars(lsContext *LsContext, orgID string, cache *Cache)
 	return nil
 }
 
-func getContext(lsContext *LsContext, orgID string, nextPageToken string, includeEnvVars bool) (*GetAllContextRes, error) {
+func getContext(ctx context.Context, lsContext *LsContext, orgID string, nextPageToken string, includeEnvVars bool) (*GetAllContextRes, error) {
 	url := fmt.Sprintf("%s/api/v2/context?owner-id=%s&page-token=%s", lsContext.Api.HostUrl, orgID, nextPageToken)
 	if includeEnvVars {
 		// Requires permission to read context environment variables; many users can list
 		// contexts but receive HTTP 403 when env vars are included (private contexts).
 		url = fmt.Sprintf("%s/api/v2/context?owner-id=%s&include-env-vars=true&page-token=%s", lsContext.Api.HostUrl, orgID, nextPageToken)
 	}
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +150,7 @@ func getContext(lsContext *LsContext, orgID string, nextPageToken string, includ
 	req.Header.Add("Circle-Token", lsContext.Api.Token)
 	req.Header.Set("User-Agent", UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := HTTPClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -166,3 +180,61 @@ func envVarNames(resources []EnvVar) []string {
 	}
 	return envVariables
 }
+
+// The contexts as kept on the disk, see diskCache.go
+type storedContext struct {
+	Id           string
+	Name         string
+	CreatedAt    string
+	EnvVariables []string
+}
+
+// LoadStoredContexts fills the cache with the contexts of the organization
+// kept on the disk. It returns whether there were some and whether they are
+// still fresh, stale ones should be fetched again
+func LoadStoredContexts(lsContext *LsContext, orgID string, cache *Cache) (found bool, fresh bool) {
+	stored := []storedContext{}
+	found, fresh = cache.DiskCache.Get(DiskCacheContexts, apiDiskCacheKey(lsContext, orgID), &stored)
+	if !found {
+		return false, false
+	}
+
+	contexts := make([]*Context, 0, len(stored))
+	for _, c := range stored {
+		contexts = append(contexts, &Context{
+			Id:           c.Id,
+			Name:         c.Name,
+			CreatedAt:    c.CreatedAt,
+			envVariables: c.EnvVariables,
+		})
+	}
+	cache.ContextCache.SetOrganizationContexts(orgID, contexts)
+
+	return found, fresh
+}
+
+// StoreContexts keeps the contexts of the organization on the disk
+func StoreContexts(lsContext *LsContext, orgID string, cache *Cache) {
+	stored := []storedContext{}
+	seen := map[*Context]bool{}
+
+	// The contexts are also indexed by their short names
+	for _, c := range cache.ContextCache.GetAllContextOfOrganization(orgID) {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		stored = append(stored, storedContext{
+			Id:           c.Id,
+			Name:         c.Name,
+			CreatedAt:    c.CreatedAt,
+			EnvVariables: c.envVariables,
+		})
+	}
+	slices.SortFunc(stored, func(a, b storedContext) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	cache.DiskCache.Set(DiskCacheContexts, apiDiskCacheKey(lsContext, orgID), stored)
+}
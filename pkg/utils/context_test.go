@@ -71,7 +71,7 @@ func Test_getContext(t *testing.T) {
 				},
 			}
 
-			got, err := getContext(lsContext, orgID, "", false)
+			got, err := getContext(t.Context(), lsContext, orgID, "", false)
 			if tt.wantErr != "" {
 				if err == nil {
 					t.Fatalf("getContext() error = nil, want error containing %q", tt.wantErr)
@@ -109,7 +109,7 @@ func Test_getContext_withoutEnvVarsQueryParam(t *testing.T) {
 	defer server.Close()
 
 	lsContext := &LsContext{Api: ApiContext{Token: "test-token", HostUrl: server.URL}}
-	if _, err := getContext(lsContext, orgID, "", false); err != nil {
+	if _, err := getContext(t.Context(), lsContext, orgID, "", false); err != nil {
 		t.Fatalf("getContext() error = %v", err)
 	}
 }
@@ -126,7 +126,7 @@ func Test_getContext_withEnvVarsQueryParam(t *testing.T) {
 	defer server.Close()
 
 	lsContext := &LsContext{Api: ApiContext{Token: "test-token", HostUrl: server.URL}}
-	if _, err := getContext(lsContext, orgID, "", true); err != nil {
+	if _, err := getContext(t.Context(), lsContext, orgID, "", true); err != nil {
 		t.Fatalf("getContext() error = %v", err)
 	}
 }
@@ -147,10 +147,10 @@ func Test_GetAllContextWithEnvVars_mergesEnvVarNames(t *testing.T) {
 
 	cache := CreateCache()
 	lsContext := &LsContext{Api: ApiContext{Token: "test-token", HostUrl: server.URL}}
-	if err := GetAllContext(lsContext, orgID, cache); err != nil {
+	if err := GetAllContext(t.Context(), lsContext, orgID, cache); err != nil {
 		t.Fatalf("GetAllContext() error = %v", err)
 	}
-	if err := GetAllContextWithEnvVars(lsContext, orgID, cache); err != nil {
+	if err := GetAllContextWithEnvVars(t.Context(), lsContext, orgID, cache); err != nil {
 		t.Fatalf("GetAllContextWithEnvVars() error = %v", err)
 	}
 	ctx := cache.ContextCache.GetOrganizationContext(orgID, "my-org/deploy")
@@ -164,3 +164,54 @@ func Test_GetAllContextWithEnvVars_mergesEnvVarNames(t *testing.T) {
 		t.Fatalf("requests = %d, want 2", requests)
 	}
 }
+
+func Test_LoadStoredContexts_replacesContexts(t *testing.T) {
+	orgID := "11111111-2222-3333-4444-555555555555"
+	lsContext := &LsContext{Api: ApiContext{Token: "test-token", HostUrl: "https://circleci.invalid"}}
+	key := apiDiskCacheKey(lsContext, orgID)
+
+	cache := CreateCache()
+	cache.SetDiskCache(NewDiskCache(t.TempDir()))
+	cache.DiskCache.Set(DiskCacheContexts, key, []storedContext{
+		{Id: "deploy-id", Name: "my-org/deploy", EnvVariables: []string{"SECRET"}},
+		{Id: "staging-id", Name: "my-org/staging"},
+	})
+	LoadStoredContexts(lsContext, orgID, cache)
+
+	// Revalidated, the contexts removed meanwhile are forgotten
+	cache.DiskCache.Set(DiskCacheContexts, key, []storedContext{
+		{Id: "deploy-id", Name: "my-org/deploy", EnvVariables: []string{"TOKEN"}},
+	})
+	LoadStoredContexts(lsContext, orgID, cache)
+
+	if got := cache.ContextCache.GetOrganizationContext(orgID, "my-org/staging"); got != nil {
+		t.Fatalf("removed context should be forgotten, got %q", got.Name)
+	}
+	ctx := cache.ContextCache.GetOrganizationContext(orgID, "deploy")
+	if ctx == nil {
+		t.Fatal("short name should still resolve once the context is set again")
+	}
+	if len(ctx.envVariables) != 1 || ctx.envVariables[0] != "TOKEN" {
+		t.Fatalf("envVariables = %#v, want [TOKEN]", ctx.envVariables)
+	}
+}
+
+func Test_getContext_tracedToTheClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"items":[]}`))
+	}))
+	defer server.Close()
+
+	client := &logClientMock{}
+	clientLog := NewClientLog(client)
+	clientLog.SetTrace("messages")
+	ctx := WithClientLog(t.Context(), clientLog)
+
+	lsContext := &LsContext{Api: ApiContext{Token: "test-token", HostUrl: server.URL}}
+	if _, err := getContext(ctx, lsContext, "org-id", "", false); err != nil {
+		t.Fatalf("getContext() error = %v", err)
+	}
+	if len(client.traces) != 1 || client.traces[0][0] != "http request" {
+		t.Fatalf("expected the request to be traced to the client, got %v", client.traces)
+	}
+}
@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// The data fetched from CircleCI and Docker Hub is kept on the disk across
+// sessions so that the diagnostics of the first files opened do not wait for
+// the network, and still work offline.
+//
+// Each kind of data has its own file and time to live. Past its TTL an entry
+// is stale: it is still returned, flagged as such, for the caller to use it
+// while fetching it again in the background.
+//
+// The changes are written in the background, a while after the first one, so
+// that the validation of a document writes each file at most once. Several
+// servers may share the files: before writing a file, the entries written by
+// the others meanwhile are merged, the most recent entry of each key winning.
+
+// The version of the format of the files, files of another version are ignored
+// and overwritten
+const DiskCacheVersion = 1
+
+type DiskCacheKind string
+
+const (
+	DiskCacheDockerImages        DiskCacheKind = "docker-images"
+	DiskCacheDockerTags          DiskCacheKind = "docker-tags"
+	DiskCacheMachineOfferings    DiskCacheKind = "machine-offerings"
+	DiskCacheContexts            DiskCacheKind = "contexts"
+	DiskCacheProjectEnvVariables DiskCacheKind = "project-env-variables"
+)
+
+var DiskCacheKinds = []DiskCacheKind{
+	DiskCacheDockerImages,
+	DiskCacheDockerTags,
+	DiskCacheMachineOfferings,
+	DiskCacheContexts,
+	DiskCacheProjectEnvVariables,
+}
+
+var DiskCacheTTLs = map[DiskCacheKind]time.Duration{
+	DiskCacheDockerImages:        7 * 24 * time.Hour,
+	DiskCacheDockerTags:          24 * time.Hour,
+	DiskCacheMachineOfferings:    24 * time.Hour,
+	DiskCacheContexts:            time.Hour,
+	DiskCacheProjectEnvVariables: time.Hour,
+}
+
+type DiskCacheEntry struct {
+	Value     json.RawMessage `json:"value"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+type diskCacheFile struct {
+	Version int                       `json:"version"`
+	Entries map[string]DiskCacheEntry `json:"entries"`
+}
+
+// Delay between the first change of a file and its writing
+const DiskCacheWriteDelay = 2 * time.Second
+
+// A DiskCache is safe to use when nil, nothing is then stored
+type DiskCache struct {
+	mutex sync.Mutex
+	dir   string
+	files map[DiskCacheKind]*diskCacheFile
+	now   func() time.Time
+
+	// The keys changed and removed since the files were last written
+	changed map[DiskCacheKind]map[string]bool
+	removed map[DiskCacheKind]map[string]bool
+	flush   *time.Timer
+}
+
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{
+		dir:     dir,
+		files:   make(map[DiskCacheKind]*diskCacheFile),
+		now:     time.Now,
+		changed: make(map[DiskCacheKind]map[string]bool),
+		removed: make(map[DiskCacheKind]map[string]bool),
+	}
+}
+
+func GetDiskCacheFSPath() string {
+	return path.Join(xdg.CacheHome, "cci", "cache")
+}
+
+// Get unmarshals the entry of the given key into value. It returns whether the
+// entry was found and whether it is still within its TTL
+func (c *DiskCache) Get(kind DiskCacheKind, key string, value any) (found bool, fresh bool) {
+	if c == nil {
+		return false, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.getFile(kind).Entries[key]
+	if !ok || json.Unmarshal(entry.Value, value) != nil {
+		return false, false
+	}
+
+	return true, c.now().Sub(entry.UpdatedAt) < DiskCacheTTLs[kind]
+}
+
+func (c *DiskCache) Set(kind DiskCacheKind, key string, value any) {
+	if c == nil {
+		return
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	file := c.getFile(kind)
+	file.Entries[key] = DiskCacheEntry{Value: raw, UpdatedAt: c.now()}
+	c.markChanged(kind, key, false)
+}
+
+func (c *DiskCache) Remove(kind DiskCacheKind, key string) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	file := c.getFile(kind)
+	if _, ok := file.Entries[key]; ok {
+		delete(file.Entries, key)
+		c.markChanged(kind, key, true)
+	}
+}
+
+// Flush writes the pending changes at once, such as when the server stops
+func (c *DiskCache) Flush() {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.flush != nil {
+		c.flush.Stop()
+		c.flush = nil
+	}
+
+	for _, kind := range DiskCacheKinds {
+		if len(c.changed[kind]) > 0 || len(c.removed[kind]) > 0 {
+			c.writeFile(kind)
+		}
+	}
+}
+
+func (c *DiskCache) markChanged(kind DiskCacheKind, key string, removed bool) {
+	pending, other := c.changed, c.removed
+	if removed {
+		pending, other = c.removed, c.changed
+	}
+	if pending[kind] == nil {
+		pending[kind] = map[string]bool{}
+	}
+	pending[kind][key] = true
+	delete(other[kind], key)
+
+	if c.flush == nil {
+		c.flush = time.AfterFunc(DiskCacheWriteDelay, c.Flush)
+	}
+}
+
+// Invalidate removes the data of the given kinds, or all of it when none is
+// given
+func (c *DiskCache) Invalidate(kinds ...DiskCacheKind) {
+	if c == nil {
+		return
+	}
+	if len(kinds) == 0 {
+		kinds = DiskCacheKinds
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, kind := range kinds {
+		delete(c.files, kind)
+		delete(c.changed, kind)
+		delete(c.removed, kind)
+		os.Remove(c.getFilePath(kind))
+	}
+}
+
+func (c *DiskCache) getFilePath(kind DiskCacheKind) string {
+	return filepath.Join(c.dir, string(kind)+".json")
+}
+
+// Files are read on their first use
+func (c *DiskCache) getFile(kind DiskCacheKind) *diskCacheFile {
+	if file, ok := c.files[kind]; ok {
+		return file
+	}
+
+	file := c.readFile(kind)
+	c.files[kind] = file
+	return file
+}
+
+func (c *DiskCache) readFile(kind DiskCacheKind) *diskCacheFile {
+	file := &diskCacheFile{}
+	content, err := os.ReadFile(c.getFilePath(kind))
+	if err != nil || json.Unmarshal(content, file) != nil || file.Version != DiskCacheVersion || file.Entries == nil {
+		file = &diskCacheFile{Version: DiskCacheVersion, Entries: map[string]DiskCacheEntry{}}
+	}
+
+	return file
+}
+
+// Writes the changes of the file on top of the file on disk, which may have
+// been written by another server since it was read. An entry changed here
+// replaces the one on disk unless the latter is more recent
+func (c *DiskCache) writeFile(kind DiskCacheKind) {
+	current := c.getFile(kind)
+	file := c.readFile(kind)
+
+	for key := range c.changed[kind] {
+		entry, ok := current.Entries[key]
+		if onDisk, found := file.Entries[key]; ok && (!found || !entry.UpdatedAt.Before(onDisk.UpdatedAt)) {
+			file.Entries[key] = entry
+		}
+	}
+	for key := range c.removed[kind] {
+		delete(file.Entries, key)
+	}
+
+	c.files[kind] = file
+	delete(c.changed, kind)
+	delete(c.removed, kind)
+
+	// The file is replaced at once so that a server reading it meanwhile
+	// never sees it partially written
+	content, err := json.Marshal(file)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.dir, string(kind)+"-*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err != nil || closeErr != nil {
+		return
+	}
+
+	os.Rename(tmp.Name(), c.getFilePath(kind))
+}
+
+// The data fetched from CircleCI depends on the instance and on the user
+func apiDiskCacheKey(lsContext *LsContext, parts ...string) string {
+	user := ""
+	if lsContext.Api.Token != "" {
+		user = HashContent(lsContext.Api.Token)[:16]
+	}
+
+	return strings.Join(append([]string{lsContext.Api.HostUrl, user}, parts...), "|")
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+func newTestDiskCache(t *testing.T, now *time.Time) *DiskCache {
+	disk := NewDiskCache(t.TempDir())
+	disk.now = func() time.Time { return *now }
+	return disk
+}
+
+func TestDiskCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	disk := newTestDiskCache(t, &now)
+
+	disk.Set(DiskCacheContexts, "org", []string{"deploy"})
+
+	// The changes are written in the background
+	_, err := os.Stat(filepath.Join(disk.dir, string(DiskCacheContexts)+".json"))
+	assert.True(t, os.IsNotExist(err))
+	disk.Flush()
+
+	// Entries are read back by another server, until their TTL they are fresh
+	other := NewDiskCache(disk.dir)
+	other.now = disk.now
+	value := []string{}
+	found, fresh := other.Get(DiskCacheContexts, "org", &value)
+	assert.True(t, found)
+	assert.True(t, fresh)
+	assert.Equal(t, []string{"deploy"}, value)
+
+	now = now.Add(DiskCacheTTLs[DiskCacheContexts])
+	found, fresh = other.Get(DiskCacheContexts, "org", &value)
+	assert.True(t, found)
+	assert.False(t, fresh)
+
+	found, _ = other.Get(DiskCacheContexts, "other-org", &value)
+	assert.False(t, found)
+
+	// The files of another version are ignored
+	path := filepath.Join(disk.dir, string(DiskCacheContexts)+".json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"version":0,"entries":{"org":{"value":["old"]}}}`), 0o644))
+	found, _ = NewDiskCache(disk.dir).Get(DiskCacheContexts, "org", &value)
+	assert.False(t, found)
+
+	disk.Set(DiskCacheDockerImages, "cimg/base", true)
+	disk.Flush()
+	disk.Remove(DiskCacheDockerImages, "cimg/base")
+	disk.Flush()
+	found, _ = NewDiskCache(disk.dir).Get(DiskCacheDockerImages, "cimg/base", &value)
+	assert.False(t, found)
+
+	disk.Set(DiskCacheDockerTags, "cimg/base", CachedDockerTags{})
+	disk.Invalidate()
+	disk.Flush()
+	found, _ = disk.Get(DiskCacheDockerTags, "cimg/base", &CachedDockerTags{})
+	assert.False(t, found)
+	_, err = os.Stat(filepath.Join(disk.dir, string(DiskCacheDockerTags)+".json"))
+	assert.True(t, os.IsNotExist(err))
+
+	// A nil cache stores nothing
+	var none *DiskCache
+	none.Set(DiskCacheContexts, "org", []string{"deploy"})
+	found, _ = none.Get(DiskCacheContexts, "org", &value)
+	assert.False(t, found)
+}
+
+func TestDiskCacheSharedBetweenServers(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := newTestDiskCache(t, &now)
+	second := NewDiskCache(first.dir)
+	second.now = first.now
+
+	first.Set(DiskCacheDockerImages, "cimg/base", true)
+	first.Set(DiskCacheDockerImages, "cimg/go", true)
+	first.Flush()
+
+	// The second server read the file before the first one changed it
+	value := false
+	found, _ := second.Get(DiskCacheDockerImages, "cimg/base", &value)
+	assert.True(t, found)
+
+	now = now.Add(time.Minute)
+	first.Set(DiskCacheDockerImages, "cimg/node", true)
+	first.Remove(DiskCacheDockerImages, "cimg/go")
+	first.Flush()
+
+	now = now.Add(time.Minute)
+	second.Set(DiskCacheDockerImages, "cimg/python", true)
+	second.Flush()
+
+	// The changes of both servers are kept
+	for image, expected := range map[string]bool{
+		"cimg/base":   true,
+		"cimg/go":     false,
+		"cimg/node":   true,
+		"cimg/python": true,
+	} {
+		found, _ := NewDiskCache(first.dir).Get(DiskCacheDockerImages, image, &value)
+		assert.Equal(t, expected, found, image)
+	}
+
+	// And seen by the second server once it wrote the file
+	found, _ = second.Get(DiskCacheDockerImages, "cimg/node", &value)
+	assert.True(t, found)
+}
+
+func TestDockerCacheStaleWhileRevalidate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	disk := newTestDiskCache(t, &now)
+
+	previous := CreateCache()
+	previous.SetDiskCache(disk)
+	previous.DockerCache.Add("cimg/base", true)
+	previous.DockerCache.Add("cimg/missing", false)
+	previous.DockerTagsCache.Add("cimg", "base", CachedDockerTags{
+		Recommended: "2024.01",
+		CheckedTags: map[string]bool{"2024.01": true, "unknown": false},
+	})
+
+	// A new session starts from the data of the previous one, the missing
+	// images and tags are checked again
+	cache := CreateCache()
+	cache.SetDiskCache(disk)
+	assert.Equal(t, &CachedDockerImage{Checked: true, Exists: true}, cache.DockerCache.Get("cimg/base"))
+	assert.Nil(t, cache.DockerCache.Get("cimg/missing"))
+	assert.Equal(t, &CachedDockerTags{
+		Recommended: "2024.01",
+		CheckedTags: map[string]bool{"2024.01": true},
+	}, cache.DockerTagsCache.Get("cimg", "base"))
+
+	now = now.Add(DiskCacheTTLs[DiskCacheDockerImages])
+	cache = CreateCache()
+	cache.SetDiskCache(disk)
+	image := cache.DockerCache.Get("cimg/base")
+	assert.True(t, image.Exists)
+	assert.True(t, image.Stale)
+
+	done := make(chan bool)
+	cache.DockerCache.Revalidate("cimg/base", func() bool {
+		defer close(done)
+		return false
+	})
+	<-done
+	assert.Eventually(t, func() bool {
+		return cache.DockerCache.Get("cimg/base") == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// Invalidating the data forgets it in memory too
+	cache.DockerTagsCache.Get("cimg", "base")
+	cache.Invalidate(DiskCacheDockerTags)
+	assert.Nil(t, cache.DockerTagsCache.Get("cimg", "base"))
+}
+
+func TestMachineOfferingsFromDisk(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	disk := newTestDiskCache(t, &now)
+	lsContext := &LsContext{Api: ApiContext{HostUrl: "https://circleci.invalid"}}
+
+	offerings := &Offerings{Linux: map[string][]string{"medium": {"ubuntu-2404:current"}}}
+	disk.Set(DiskCacheMachineOfferings, lsContext.Api.HostUrl, offerings)
+
+	cache := CreateCache()
+	cache.SetDiskCache(disk)
+	assert.Equal(t, []string{"medium"}, MachineResourceClasses(lsContext, cache))
+}
+
+func TestProjectEnvVariablesFromDisk(t *testing.T) {
+	lsContext := &LsContext{Api: ApiContext{Token: "test-token", HostUrl: "https://circleci.invalid"}}
+	cache := CreateCache()
+	cache.SetDiskCache(NewDiskCache(t.TempDir()))
+
+	file := cache.FileCache.SetFile(CachedFile{
+		TextDocument: protocol.TextDocumentItem{URI: "file:///project/.circleci/config.yml"},
+		Project:      Project{Slug: "gh/org/project"},
+	})
+	key := apiDiskCacheKey(lsContext, "gh/org/project")
+
+	cache.DiskCache.Set(DiskCacheProjectEnvVariables, key, []string{"TOKEN", "SECRET"})
+	GetAllProjectEnvVariables(t.Context(), lsContext, cache, &file)
+	assert.Equal(t, []string{"SECRET", "TOKEN"}, cache.FileCache.GetFile(file.TextDocument.URI).EnvVariables)
+
+	// The variables removed from the project are forgotten
+	cache.DiskCache.Set(DiskCacheProjectEnvVariables, key, []string{"TOKEN"})
+	GetAllProjectEnvVariables(t.Context(), lsContext, cache, &file)
+	assert.Equal(t, []string{"TOKEN"}, cache.FileCache.GetFile(file.TextDocument.URI).EnvVariables)
+}
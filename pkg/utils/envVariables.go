@@ -0,0 +1,56 @@
+package utils
+
+type BuiltInEnvVariable struct {
+	Name        string
+	Description string
+}
+
+// Environment variables set by CircleCI in every job, see
+// https://circleci.com/docs/variables/#built-in-environment-variables
+var BuiltInEnvVariables = []BuiltInEnvVariable{
+	{"CI", "Represents whether the current environment is a CI environment. Always `true`."},
+	{"CIRCLECI", "Represents whether the current environment is a CircleCI environment. Always `true`."},
+	{"CIRCLE_BRANCH", "The name of the Git branch currently being built."},
+	{"CIRCLE_BUILD_NUM", "The number of the current job. Job numbers are unique for each job."},
+	{"CIRCLE_BUILD_URL", "The URL for the current job on CircleCI."},
+	{"CIRCLE_JOB", "The name of the current job."},
+	{"CIRCLE_NODE_INDEX", "For jobs that run with parallelism enabled, this is the index of the current parallel run. The value ranges from 0 to (`CIRCLE_NODE_TOTAL` - 1)."},
+	{"CIRCLE_NODE_TOTAL", "For jobs that run with parallelism enabled, this is the number of parallel runs. This is equivalent to the value of `parallelism` in the job."},
+	{"CIRCLE_OIDC_TOKEN", "An OpenID Connect token signed by CircleCI which includes details about the current job."},
+	{"CIRCLE_OIDC_TOKEN_V2", "An OpenID Connect token signed by CircleCI which includes details about the current job, with a `sub` claim including the pipeline trigger type."},
+	{"CIRCLE_PR_NUMBER", "The number of the associated GitHub or Bitbucket pull request. Only available on forked PRs."},
+	{"CIRCLE_PR_REPONAME", "The name of the GitHub or Bitbucket repository where the pull request was created. Only available on forked PRs."},
+	{"CIRCLE_PR_USERNAME", "The GitHub or Bitbucket username of the user who created the pull request. Only available on forked PRs."},
+	{"CIRCLE_PREVIOUS_BUILD_NUM", "The largest job number in a given branch that is less than the current job number."},
+	{"CIRCLE_PROJECT_REPONAME", "The name of the repository of the current project."},
+	{"CIRCLE_PROJECT_USERNAME", "The GitHub or Bitbucket username of the current project."},
+	{"CIRCLE_PULL_REQUEST", "The URL of the associated pull request. If there are multiple associated pull requests, one URL is randomly chosen."},
+	{"CIRCLE_PULL_REQUESTS", "Comma-separated list of URLs of the current build's associated pull requests."},
+	{"CIRCLE_REPOSITORY_URL", "The URL of your GitHub or Bitbucket repository."},
+	{"CIRCLE_SHA1", "The SHA1 hash of the last commit of the current build."},
+	{"CIRCLE_TAG", "The name of the git tag, if the current build is tagged."},
+	{"CIRCLE_USERNAME", "The GitHub or Bitbucket username of the user who triggered the pipeline."},
+	{"CIRCLE_WORKFLOW_ID", "A unique identifier for the workflow instance of the current job."},
+	{"CIRCLE_WORKFLOW_JOB_ID", "A unique identifier for the current job."},
+	{"CIRCLE_WORKFLOW_WORKSPACE_ID", "An identifier for the workspace of the current job. This identifier is the same for every job in a given workflow."},
+	{"CIRCLE_WORKING_DIRECTORY", "The value of the `working_directory` key of the current job."},
+	{"CIRCLE_INTERNAL_TASK_DATA", "The directory where test timing data is saved."},
+}
+
+// Variables set by the shell or the operating system of the executors
+var ShellEnvVariables = []string{
+	"BASH", "BASH_ENV", "BASH_REMATCH", "BASH_SOURCE", "BASH_VERSION", "BASHPID",
+	"COLUMNS", "EUID", "FUNCNAME", "GROUPS", "HOME", "HOSTNAME", "HOSTTYPE", "IFS",
+	"LANG", "LC_ALL", "LINENO", "LINES", "LOGNAME", "MACHTYPE", "OLDPWD", "OPTARG",
+	"OPTIND", "OSTYPE", "PATH", "PIPESTATUS", "PPID", "PS1", "PS4", "PWD", "RANDOM",
+	"REPLY", "SECONDS", "SHELL", "SHELLOPTS", "SHLVL", "TERM", "TMPDIR", "UID", "USER",
+}
+
+func GetBuiltInEnvVariable(name string) (BuiltInEnvVariable, bool) {
+	for _, env := range BuiltInEnvVariables {
+		if env.Name == name {
+			return env, true
+		}
+	}
+	return BuiltInEnvVariable{}, false
+}
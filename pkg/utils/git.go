@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
@@ -18,6 +21,21 @@ func GetProjectSlug(configPath string) string {
 		return ""
 	}
 
+	return getRepositoryProjectSlug(repo)
+}
+
+// GetFolderProjectSlug returns the project slug of the git repository
+// containing the folder, which can be any of its subdirectories
+func GetFolderProjectSlug(dir string) string {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+
+	return getRepositoryProjectSlug(repo)
+}
+
+func getRepositoryProjectSlug(repo *git.Repository) string {
 	remotes, err := repo.Remotes()
 	if err != nil || len(remotes) == 0 {
 		return ""
@@ -80,10 +98,10 @@ func GetProjectOrg(projectSlug string) string {
 	return splitted[1]
 }
 
-func GetProjectId(projectSlug string, lsContext *LsContext) (Project, error) {
+func GetProjectId(ctx context.Context, projectSlug string, lsContext *LsContext) (Project, error) {
 	url := fmt.Sprintf("%s/api/v2/project/%s", lsContext.Api.HostUrl, projectSlug)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return Project{}, err
 	}
@@ -91,7 +109,7 @@ func GetProjectId(projectSlug string, lsContext *LsContext) (Project, error) {
 	req.Header.Add("Circle-Token", lsContext.Api.Token)
 	req.Header.Set("User-Agent", UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := HTTPClient().Do(req)
 
 	if err != nil {
 		return Project{}, err
@@ -115,3 +133,30 @@ func GetProjectId(projectSlug string, lsContext *LsContext) (Project, error) {
 
 	return projectIdRes, nil
 }
+
+// GetRepositoryRoot returns the directory the steps of the given config file
+// are run from once the project is checked out: the parent of the `.circleci`
+// folder or, for files outside of it, the root of the git repository.
+// Returns an empty string when it can not be found
+func GetRepositoryRoot(configPath string) string {
+	if configPath == "" {
+		return ""
+	}
+
+	if idx := strings.LastIndex(configPath, string(filepath.Separator)+".circleci"+string(filepath.Separator)); idx != -1 {
+		return configPath[:idx]
+	}
+
+	dir := filepath.Dir(configPath)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -6,12 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 )
@@ -25,18 +23,6 @@ type Client struct {
 	httpClient *http.Client
 }
 
-func GetClient() *http.Client {
-	return &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			ExpectContinueTimeout: 1 * time.Second,
-			IdleConnTimeout:       90 * time.Second,
-			MaxIdleConns:          10,
-			TLSHandshakeTimeout:   10 * time.Second,
-		},
-	}
-}
-
 // NewClient returns a reference to a Client.
 func NewClient(host, endpoint, token string, debug bool) *Client {
 	return &Client{
@@ -196,7 +182,7 @@ func prepareRequest(ctx context.Context, address string, request *Request) (*htt
 	if err != nil {
 		return nil, err
 	}
-	r, err := http.NewRequest(http.MethodPost, address, &requestBody)
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, address, &requestBody)
 	if err != nil {
 		return nil, err
 	}
@@ -209,17 +195,18 @@ func prepareRequest(ctx context.Context, address string, request *Request) (*htt
 		}
 	}
 
-	r = r.WithContext(ctx)
 	return r, nil
 }
 
 // Run sends an HTTP request to the GraphQL server and deserializes the response or returns an error.
-// TODO(zzak): This function is fairly complex, we should refactor it
-// nolint: gocyclo
 func (cl *Client) Run(request *Request, resp interface{}) error {
-	l := log.New(os.Stderr, "", 0)
-	ctx := context.Background()
+	return cl.RunWithContext(context.Background(), request, resp)
+}
 
+// RunWithContext is like Run but the request is aborted when ctx is done.
+// TODO(zzak): This function is fairly complex, we should refactor it
+// nolint: gocyclo
+func (cl *Client) RunWithContext(ctx context.Context, request *Request, resp interface{}) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -237,8 +224,7 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 	}
 
 	if cl.Debug {
-		l.Printf(">> variables: %v", request.Variables)
-		l.Printf(">> query: %s", request.Query)
+		Logger.Debug("graphql request", "variables", request.Variables, "query", request.Query)
 	}
 
 	res, err := cl.httpClient.Do(req)
@@ -249,13 +235,12 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 	defer func() {
 		responseBodyCloseErr := res.Body.Close()
 		if responseBodyCloseErr != nil {
-			l.Printf("%s", responseBodyCloseErr.Error())
+			Logger.Warn("could not close the graphql response", "error", responseBodyCloseErr)
 		}
 	}()
 
 	if cl.Debug {
-		l.Printf("<< request id: %s", res.Header.Get("X-Request-Id"))
-		l.Printf("<< result status: %s", res.Status)
+		Logger.Debug("graphql response", "requestId", res.Header.Get("X-Request-Id"), "status", res.Status)
 	}
 
 	if res.StatusCode != http.StatusOK {
@@ -271,7 +256,7 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 				return errors.Wrap(err, "reading response")
 			}
 
-			l.Printf("<< %s", string(bodyBytes))
+			Logger.Debug("graphql response body", "body", string(bodyBytes))
 
 			// Restore the io.ReadCloser to its original state
 			res.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
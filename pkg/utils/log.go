@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.lsp.dev/protocol"
+)
+
+// The logs of the server are leveled and structured. They are written to
+// stderr, or to the file given with --log-file, and sent to the client: the
+// records of level info and above as window/logMessage and, once the client
+// enabled the traces with $/setTrace, all of them as $/logTrace.
+//
+// A server listening on a socket has several clients, the records are only
+// sent to the client of the connection found in the context of the record
+// (see WithClientLog), the records logged without context are only written.
+//
+// The timings (requests, parsing, validators, network calls) are logged at
+// the debug level, so they are only traced or written to the log file.
+
+// LogClient sends the logs to the client, see methods/logging.go
+type LogClient interface {
+	LogMessage(messageType protocol.MessageType, message string)
+	LogTrace(message string, verbose string)
+}
+
+type logState struct {
+	mutex       sync.RWMutex
+	output      slog.Handler
+	outputLevel slog.Level
+}
+
+var logs = &logState{
+	output:      slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	outputLevel: slog.LevelInfo,
+}
+
+var Logger = slog.New(&logHandler{state: logs})
+
+// SetLogFile writes the logs, all levels included, to the file as JSON instead
+// of stderr
+func SetLogFile(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	setLogOutput(slog.NewJSONHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}), slog.LevelDebug)
+	return nil
+}
+
+func setLogOutput(output slog.Handler, level slog.Level) {
+	logs.mutex.Lock()
+	defer logs.mutex.Unlock()
+	logs.output = output
+	logs.outputLevel = level
+}
+
+// ClientLog sends the logs of a connection to its client, with the traces
+// the client wants
+type ClientLog struct {
+	mutex  sync.RWMutex
+	client LogClient
+	trace  protocol.TraceValue
+}
+
+func NewClientLog(client LogClient) *ClientLog {
+	return &ClientLog{client: client, trace: protocol.TraceOff}
+}
+
+// SetTrace sets the traces the client wants, as given to initialize and
+// $/setTrace
+func (log *ClientLog) SetTrace(trace protocol.TraceValue) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	switch trace {
+	// go.lsp.dev/protocol misspells the value of the specification
+	case protocol.TraceMessage, "messages":
+		log.trace = protocol.TraceMessage
+	case protocol.TraceVerbose:
+		log.trace = protocol.TraceVerbose
+	default:
+		log.trace = protocol.TraceOff
+	}
+}
+
+func (log *ClientLog) getTrace() protocol.TraceValue {
+	log.mutex.RLock()
+	defer log.mutex.RUnlock()
+	return log.trace
+}
+
+type clientLogKey struct{}
+
+// WithClientLog returns a context whose records are sent to the client of log
+func WithClientLog(ctx context.Context, log *ClientLog) context.Context {
+	return context.WithValue(ctx, clientLogKey{}, log)
+}
+
+func clientLogFrom(ctx context.Context) *ClientLog {
+	if ctx == nil {
+		return nil
+	}
+	log, _ := ctx.Value(clientLogKey{}).(*ClientLog)
+	return log
+}
+
+// LogDuration logs, at the debug level, the time elapsed since start
+func LogDuration(message string, start time.Time, attrs ...any) {
+	LogDurationContext(context.Background(), message, start, attrs...)
+}
+
+// LogDurationContext is LogDuration sending the record to the client of ctx
+func LogDurationContext(ctx context.Context, message string, start time.Time, attrs ...any) {
+	Logger.DebugContext(ctx, message, append(attrs, "duration", time.Since(start))...)
+}
+
+type logHandler struct {
+	state *logState
+	attrs []slog.Attr
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	h.state.mutex.RLock()
+	outputLevel := h.state.outputLevel
+	h.state.mutex.RUnlock()
+
+	if level >= outputLevel {
+		return true
+	}
+	log := clientLogFrom(ctx)
+	return log != nil && (level >= slog.LevelInfo || log.getTrace() != protocol.TraceOff)
+}
+
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.state.mutex.RLock()
+	output, outputLevel := h.state.output, h.state.outputLevel
+	h.state.mutex.RUnlock()
+
+	var err error
+	if record.Level >= outputLevel {
+		err = output.WithAttrs(h.attrs).Handle(ctx, record)
+	}
+
+	log := clientLogFrom(ctx)
+	if log == nil {
+		return err
+	}
+	client, trace := log.client, log.getTrace()
+
+	attrs := h.formatAttrs(record)
+	if record.Level >= slog.LevelInfo {
+		message := record.Message
+		if attrs != "" {
+			message += " " + attrs
+		}
+		client.LogMessage(messageType(record.Level), message)
+	}
+
+	switch trace {
+	case protocol.TraceMessage:
+		client.LogTrace(record.Message, "")
+	case protocol.TraceVerbose:
+		client.LogTrace(record.Message, attrs)
+	}
+
+	return err
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{
+		state: h.state,
+		attrs: append(append([]slog.Attr{}, h.attrs...), attrs...),
+	}
+}
+
+// The groups are not used by the server, their attributes are kept flat
+func (h *logHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
+
+// Returns the attributes of the record as key=value pairs
+func (h *logHandler) formatAttrs(record slog.Record) string {
+	pairs := []string{}
+	add := func(attr slog.Attr) bool {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", attr.Key, attr.Value))
+		return true
+	}
+
+	for _, attr := range h.attrs {
+		add(attr)
+	}
+	record.Attrs(add)
+
+	return strings.Join(pairs, " ")
+}
+
+func messageType(level slog.Level) protocol.MessageType {
+	switch {
+	case level >= slog.LevelError:
+		return protocol.MessageTypeError
+	case level >= slog.LevelWarn:
+		return protocol.MessageTypeWarning
+	default:
+		return protocol.MessageTypeInfo
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+type logClientMock struct {
+	mutex    sync.Mutex
+	messages []string
+	traces   [][2]string
+}
+
+func (c *logClientMock) LogMessage(messageType protocol.MessageType, message string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.messages = append(c.messages, messageType.String()+": "+message)
+}
+
+func (c *logClientMock) LogTrace(message string, verbose string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.traces = append(c.traces, [2]string{message, verbose})
+}
+
+func TestLogger(t *testing.T) {
+	defer setLogOutput(logs.output, logs.outputLevel)
+	var output bytes.Buffer
+	setLogOutput(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}), slog.LevelInfo)
+
+	// Without client the logs are only written
+	Logger.Debug("request handled", "method", "textDocument/hover")
+	Logger.Warn("could not get the contexts")
+	assert.NotContains(t, output.String(), "request handled")
+	assert.Contains(t, output.String(), "could not get the contexts")
+
+	client := &logClientMock{}
+	ctx := WithClientLog(context.Background(), NewClientLog(client))
+	otherClient := &logClientMock{}
+	otherCtx := WithClientLog(context.Background(), NewClientLog(otherClient))
+
+	Logger.DebugContext(ctx, "request handled", "method", "textDocument/hover")
+	Logger.ErrorContext(ctx, "could not get the contexts", "organization", "org-id")
+	Logger.Error("could not close the graphql response")
+	assert.Equal(t, []string{"error: could not get the contexts organization=org-id"}, client.messages)
+	assert.Empty(t, client.traces)
+
+	clientLogFrom(ctx).SetTrace("messages")
+	LogDurationContext(ctx, "request handled", time.Now(), "method", "textDocument/hover")
+	assert.Equal(t, [][2]string{{"request handled", ""}}, client.traces)
+
+	clientLogFrom(ctx).SetTrace(protocol.TraceVerbose)
+	Logger.With("uri", "file:///config.yml").DebugContext(ctx, "validator run", "validator", "ValidateJobs")
+	assert.Equal(t, [2]string{"validator run", "uri=file:///config.yml validator=ValidateJobs"}, client.traces[1])
+
+	clientLogFrom(ctx).SetTrace(protocol.TraceOff)
+	Logger.DebugContext(ctx, "request handled")
+	assert.Len(t, client.traces, 2)
+	assert.Len(t, client.messages, 1)
+
+	// The records of a connection are not sent to the other clients
+	assert.Empty(t, otherClient.messages)
+	assert.Empty(t, otherClient.traces)
+	Logger.InfoContext(otherCtx, "new client connection")
+	assert.Equal(t, []string{"info: new client connection"}, otherClient.messages)
+	assert.Len(t, client.messages, 1)
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +12,14 @@ type LsContext struct {
 	Api                ApiContext
 	UserIdForTelemetry string
 	IsCciExtension     bool
+
+	// The client pulls the diagnostics itself instead of waiting for them to be
+	// published, and may be asked to pull them again on a configuration change
+	UsePullDiagnostics    bool
+	CanRefreshDiagnostics bool
+
+	// The client reports the changes of the files on disk once asked to
+	CanWatchFiles bool
 }
 
 type ApiContext struct {
@@ -33,17 +42,17 @@ type MeRes struct {
 	Name  string
 }
 
-func (apiContext ApiContext) GetUserId() string {
+func (apiContext ApiContext) GetUserId(ctx context.Context) string {
 	if apiContext.userId != "" {
 		return apiContext.userId
 	}
 
 	url := fmt.Sprintf("%s/api/v2/me", apiContext.HostUrl)
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	req.Header.Add("Circle-Token", apiContext.Token)
 	req.Header.Set("User-Agent", UserAgent)
-	res, err := http.DefaultClient.Do(req)
+	res, err := HTTPClient().Do(req)
 	if err != nil {
 		return ""
 	}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"maps"
 	"net/http"
+	"reflect"
 	"slices"
 	"strings"
 	"sync"
@@ -32,25 +33,61 @@ type MachineOfferingsCache struct {
 	cacheMutex sync.Mutex
 	offerings  *Offerings
 	attempted  bool
+	generation *cacheGeneration
 }
 
 func (c *MachineOfferingsCache) Set(offerings *Offerings) {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
+	if !reflect.DeepEqual(c.offerings, offerings) {
+		c.generation.bump()
+	}
 	c.offerings = offerings
 	c.attempted = true
 }
 
+func (c *MachineOfferingsCache) clear() {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	if c.attempted {
+		c.generation.bump()
+	}
+	c.offerings = nil
+	c.attempted = false
+}
+
 // machineOfferings fetches the catalog once, holding the lock across the fetch so concurrent
 // callers wait for the result instead of racing to a nil. Returns nil on failure, so callers
-// skip validation rather than flag valid config.
+// skip validation rather than flag valid config. A catalog kept on the disk is used as is,
+// and fetched again in the background once stale.
 func machineOfferings(lsContext *LsContext, cache *Cache) *Offerings {
 	c := &cache.MachineOfferingsCache
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
-	if !c.attempted {
-		c.attempted = true
+	if c.attempted {
+		return c.offerings
+	}
+	c.attempted = true
+
+	stored := &Offerings{}
+	found, fresh := cache.DiskCache.Get(DiskCacheMachineOfferings, lsContext.Api.HostUrl, stored)
+	if !found {
 		c.offerings = fetchOfferings(lsContext)
+		if c.offerings != nil {
+			c.generation.bump()
+			cache.DiskCache.Set(DiskCacheMachineOfferings, lsContext.Api.HostUrl, c.offerings)
+		}
+		return c.offerings
+	}
+
+	c.offerings = stored
+	if !fresh {
+		go func() {
+			if offerings := fetchOfferings(lsContext); offerings != nil {
+				c.Set(offerings)
+				cache.DiskCache.Set(DiskCacheMachineOfferings, lsContext.Api.HostUrl, offerings)
+			}
+		}()
 	}
 	return c.offerings
 }
@@ -67,7 +104,7 @@ func fetchOfferings(lsContext *LsContext) *Offerings {
 	req.Header.Set("User-Agent", UserAgent)
 	req.Header.Add("Circle-Token", lsContext.Api.Token)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := HTTPClient().Do(req)
 	if err != nil {
 		return nil
 	}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,17 +16,39 @@ type ProjectEnvVariableRes struct {
 	NextPageToken string `json:"next_page_token,omitempty"`
 }
 
-func GetAllProjectEnvVariables(lsContext *LsContext, cache *Cache, cachedFile *CachedFile) {
-	var projectEnvVariables []string
+// GetAllProjectEnvVariables sets the environment variables of the project of
+// the file. The variables kept on the disk are used at once, and replaced by
+// the ones fetched again in the background once stale
+func GetAllProjectEnvVariables(ctx context.Context, lsContext *LsContext, cache *Cache, cachedFile *CachedFile) {
+	key := apiDiskCacheKey(lsContext, cachedFile.Project.Slug)
+	uri := cachedFile.TextDocument.URI
 
-	fetchAllProjectEnvVariables(lsContext, cachedFile.Project.Slug, "", cache, &projectEnvVariables)
+	var stored []string
+	found, fresh := cache.DiskCache.Get(DiskCacheProjectEnvVariables, key, &stored)
+	if found {
+		cache.FileCache.SetProjectEnvVariables(uri, stored)
+	}
+
+	fetch := func() {
+		var projectEnvVariables []string
+		err := fetchAllProjectEnvVariables(ctx, lsContext, cachedFile.Project.Slug, "", cache, &projectEnvVariables)
+		if err != nil {
+			return
+		}
+		cache.DiskCache.Set(DiskCacheProjectEnvVariables, key, projectEnvVariables)
+		cache.FileCache.SetProjectEnvVariables(uri, projectEnvVariables)
+	}
 
-	for _, projectEnvVariable := range projectEnvVariables {
-		cache.FileCache.AddEnvVariableToProjectLinkedToFile(cachedFile.TextDocument.URI, projectEnvVariable)
+	switch {
+	case found && fresh:
+	case found:
+		go fetch()
+	default:
+		fetch()
 	}
 }
 
-func fetchAllProjectEnvVariables(lsContext *LsContext, projectSlug string, nextPageToken string, cache *Cache, projectEnvVariables *[]string) error {
+func fetchAllProjectEnvVariables(ctx context.Context, lsContext *LsContext, projectSlug string, nextPageToken string, cache *Cache, projectEnvVariables *[]string) error {
 	var nextPageQuery string
 
 	if nextPageToken != "" {
@@ -34,12 +57,12 @@ func fetchAllProjectEnvVariables(lsContext *LsContext, projectSlug string, nextP
 		nextPageQuery = ""
 	}
 	url := fmt.Sprintf("%s/api/v2/project/%s/envvar%s", lsContext.Api.HostUrl, projectSlug, nextPageQuery)
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	req.Header.Add("Circle-Token", lsContext.Api.Token)
 	req.Header.Set("User-Agent", UserAgent)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := HTTPClient().Do(req)
 
 	if err != nil {
 		return err
@@ -51,6 +74,10 @@ func fetchAllProjectEnvVariables(lsContext *LsContext, projectSlug string, nextP
 		return err
 	}
 
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("list environment variables of %s: HTTP %d", projectSlug, res.StatusCode)
+	}
+
 	var projectRes ProjectEnvVariableRes
 	err = json.Unmarshal(body, &projectRes)
 	if err != nil {
@@ -62,7 +89,7 @@ func fetchAllProjectEnvVariables(lsContext *LsContext, projectSlug string, nextP
 	}
 
 	if projectRes.NextPageToken != "" {
-		return fetchAllProjectEnvVariables(lsContext, projectSlug, projectRes.NextPageToken, cache, projectEnvVariables)
+		return fetchAllProjectEnvVariables(ctx, lsContext, projectSlug, projectRes.NextPageToken, cache, projectEnvVariables)
 	}
 
 	return nil
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// All the requests made to CircleCI and Docker Hub go through the transport
+// below. It is replaced in the tests to serve them locally, see
+// pkg/testHelpers/fakeapi
+
+var transportMutex sync.RWMutex
+var transport http.RoundTripper
+
+// SetHTTPTransport makes the requests go through the given transport, nil
+// restores the default one. It returns a function restoring the previous
+// transport
+func SetHTTPTransport(t http.RoundTripper) (restore func()) {
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+
+	previous := transport
+	transport = t
+
+	return func() {
+		transportMutex.Lock()
+		defer transportMutex.Unlock()
+		transport = previous
+	}
+}
+
+func getHTTPTransport() http.RoundTripper {
+	transportMutex.RLock()
+	defer transportMutex.RUnlock()
+	return transport
+}
+
+// HTTPClient returns the client to use for the REST requests
+func HTTPClient() *http.Client {
+	t := getHTTPTransport()
+	if t == nil {
+		t = http.DefaultTransport
+	}
+
+	return &http.Client{Transport: loggingTransport{next: t}}
+}
+
+// GetClient returns the client to use for the GraphQL requests
+func GetClient() *http.Client {
+	var t http.RoundTripper = &http.Transport{
+		ExpectContinueTimeout: 1 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          10,
+		TLSHandshakeTimeout:   10 * time.Second,
+	}
+	if custom := getHTTPTransport(); custom != nil {
+		t = custom
+	}
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: loggingTransport{next: t},
+	}
+}
+
+// Logs the duration of the requests, their query is left out as it may hold
+// user data
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+
+	attrs := []any{"method", req.Method, "host", req.URL.Host, "path", req.URL.Path}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	} else {
+		attrs = append(attrs, "status", res.StatusCode)
+	}
+	LogDurationContext(req.Context(), "http request", start, attrs...)
+
+	return res, err
+}
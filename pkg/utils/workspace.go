@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// A WorkspaceFolder is a folder opened in the editor, with the CircleCI files
+// found in it and the CircleCI project it belongs to. A monorepo has a single
+// project whatever the number of `.circleci` directories in it
+type WorkspaceFolder struct {
+	protocol.WorkspaceFolder
+
+	// Built from the git remote of the repository containing the folder, empty
+	// when there is none
+	ProjectSlug string
+
+	// Fetched from the API once the user is logged in, nil until then
+	Project *Project
+
+	// The CircleCI files found in the folder
+	files map[protocol.URI]bool
+}
+
+type WorkspaceCache struct {
+	cacheMutex *sync.Mutex
+	folders    map[protocol.URI]*WorkspaceFolder
+	generation *cacheGeneration
+}
+
+func (folder WorkspaceFolder) Dir() string {
+	return uri.URI(folder.URI).Filename()
+}
+
+func (folder *WorkspaceFolder) getFiles() []protocol.URI {
+	files := make([]protocol.URI, 0, len(folder.files))
+	for file := range folder.files {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	return files
+}
+
+// SetFolder adds a folder to the workspace, keeping its index if it is
+// already there
+func (c *WorkspaceCache) SetFolder(folder protocol.WorkspaceFolder) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	if _, ok := c.folders[protocol.URI(folder.URI)]; ok {
+		return
+	}
+	c.folders[protocol.URI(folder.URI)] = &WorkspaceFolder{
+		WorkspaceFolder: folder,
+		files:           map[protocol.URI]bool{},
+	}
+	c.generation.bump()
+}
+
+func (c *WorkspaceCache) RemoveFolder(uri protocol.URI) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	if _, ok := c.folders[uri]; ok {
+		delete(c.folders, uri)
+		c.generation.bump()
+	}
+}
+
+func (c *WorkspaceCache) GetFolders() []protocol.WorkspaceFolder {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	folders := make([]protocol.WorkspaceFolder, 0, len(c.folders))
+	for _, folder := range c.folders {
+		folders = append(folders, folder.WorkspaceFolder)
+	}
+	slices.SortFunc(folders, func(a, b protocol.WorkspaceFolder) int {
+		return strings.Compare(a.URI, b.URI)
+	})
+
+	return folders
+}
+
+// GetFolderOfFile returns the innermost folder containing the file
+func (c *WorkspaceCache) GetFolderOfFile(uri protocol.URI) (WorkspaceFolder, bool) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	folder := c.getFolderOfFile(uri)
+	if folder == nil {
+		return WorkspaceFolder{}, false
+	}
+
+	// The index is only read under the lock of the cache
+	res := *folder
+	res.files = nil
+	return res, true
+}
+
+func (c *WorkspaceCache) getFolderOfFile(uri protocol.URI) *WorkspaceFolder {
+	filename := uri.Filename()
+
+	var res *WorkspaceFolder
+	for _, folder := range c.folders {
+		dir := folder.Dir()
+		if filename != dir && !strings.HasPrefix(filename, dir+string(filepath.Separator)) {
+			continue
+		}
+
+		if res == nil || len(dir) > len(res.Dir()) {
+			res = folder
+		}
+	}
+
+	return res
+}
+
+// SetFolderFiles replaces the index of the folder
+func (c *WorkspaceCache) SetFolderFiles(folderURI protocol.URI, files []protocol.URI) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	folder, ok := c.folders[folderURI]
+	if !ok {
+		return
+	}
+
+	folder.files = map[protocol.URI]bool{}
+	for _, file := range files {
+		folder.files[file] = true
+	}
+	c.generation.bump()
+}
+
+// IndexFile adds the file to the index of its folder, it is ignored when
+// outside of the workspace. The file is also indexed again when it changed on
+// disk, which changes the diagnostics of the files using it
+func (c *WorkspaceCache) IndexFile(uri protocol.URI) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	if folder := c.getFolderOfFile(uri); folder != nil {
+		folder.files[uri] = true
+		c.generation.bump()
+	}
+}
+
+func (c *WorkspaceCache) UnindexFile(uri protocol.URI) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	if folder := c.getFolderOfFile(uri); folder != nil {
+		delete(folder.files, uri)
+		c.generation.bump()
+	}
+}
+
+// GetIndexedFiles returns the CircleCI files of every folder
+func (c *WorkspaceCache) GetIndexedFiles() []protocol.URI {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	files := []protocol.URI{}
+	for _, folder := range c.folders {
+		files = append(files, folder.getFiles()...)
+	}
+	slices.Sort(files)
+
+	return slices.Compact(files)
+}
+
+// SetFolderProjectSlug sets the slug of the project of the folder, forgetting
+// the project fetched for the previous one
+func (c *WorkspaceCache) SetFolderProjectSlug(folderURI protocol.URI, projectSlug string) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	folder, ok := c.folders[folderURI]
+	if !ok || folder.ProjectSlug == projectSlug {
+		return
+	}
+
+	folder.ProjectSlug = projectSlug
+	folder.Project = nil
+	c.generation.bump()
+}
+
+func (c *WorkspaceCache) SetFolderProject(folderURI protocol.URI, project Project) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	if folder, ok := c.folders[folderURI]; ok {
+		folder.Project = &project
+		c.generation.bump()
+	}
+}
+
+// The projects depend on the instance of CircleCI and on the user
+func (c *WorkspaceCache) clearProjects() {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	for _, folder := range c.folders {
+		folder.Project = nil
+	}
+	c.generation.bump()
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestWorkspaceCache(t *testing.T) {
+	cache := CreateCache()
+	dir := t.TempDir()
+	root := protocol.WorkspaceFolder{URI: string(uri.File(dir)), Name: "monorepo"}
+	service := protocol.WorkspaceFolder{URI: string(uri.File(filepath.Join(dir, "service"))), Name: "service"}
+
+	cache.WorkspaceCache.SetFolder(root)
+	cache.WorkspaceCache.SetFolder(service)
+	assert.Equal(t, []protocol.WorkspaceFolder{root, service}, cache.WorkspaceCache.GetFolders())
+
+	// Files belong to the innermost folder
+	serviceConfig := uri.File(filepath.Join(dir, "service", ".circleci", "config.yml"))
+	folder, ok := cache.WorkspaceCache.GetFolderOfFile(serviceConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "service", folder.Name)
+
+	folder, ok = cache.WorkspaceCache.GetFolderOfFile(uri.File(filepath.Join(dir+"-other", "config.yml")))
+	assert.False(t, ok)
+
+	rootConfig := uri.File(filepath.Join(dir, ".circleci", "config.yml"))
+	cache.WorkspaceCache.SetFolderFiles(protocol.URI(root.URI), []protocol.URI{rootConfig})
+	cache.WorkspaceCache.IndexFile(serviceConfig)
+	cache.WorkspaceCache.IndexFile(uri.File(filepath.Join(t.TempDir(), "config.yml")))
+	assert.Equal(t, []protocol.URI{rootConfig, serviceConfig}, cache.WorkspaceCache.GetIndexedFiles())
+
+	cache.WorkspaceCache.UnindexFile(rootConfig)
+	assert.Equal(t, []protocol.URI{serviceConfig}, cache.WorkspaceCache.GetIndexedFiles())
+
+	// A new slug forgets the project of the previous one
+	cache.WorkspaceCache.SetFolderProjectSlug(protocol.URI(root.URI), "gh/org/monorepo")
+	cache.WorkspaceCache.SetFolderProject(protocol.URI(root.URI), Project{Slug: "gh/org/monorepo"})
+	folder, _ = cache.WorkspaceCache.GetFolderOfFile(rootConfig)
+	assert.Equal(t, "gh/org/monorepo", folder.Project.Slug)
+
+	cache.WorkspaceCache.SetFolderProjectSlug(protocol.URI(root.URI), "gh/org/renamed")
+	folder, _ = cache.WorkspaceCache.GetFolderOfFile(rootConfig)
+	assert.Equal(t, "gh/org/renamed", folder.ProjectSlug)
+	assert.Nil(t, folder.Project)
+
+	cache.WorkspaceCache.RemoveFolder(protocol.URI(service.URI))
+	assert.Empty(t, cache.WorkspaceCache.GetIndexedFiles())
+}
+
+func TestGetFolderProjectSlug(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{"git@github.com:CircleCI-Public/circleci-yaml-language-server.git"},
+	})
+	assert.NoError(t, err)
+
+	subdir := filepath.Join(dir, "services", "api")
+	assert.NoError(t, os.MkdirAll(subdir, 0o755))
+
+	assert.Equal(t, "gh/CircleCI-Public/circleci-yaml-language-server", GetFolderProjectSlug(subdir))
+	assert.Equal(t, "", GetFolderProjectSlug(t.TempDir()))
+}